@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 
 	"github.com/canonical/lxd/lxd/response"
@@ -11,9 +13,11 @@ import (
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/ceph"
 	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
 	"github.com/canonical/microceph/microceph/interfaces"
 	"github.com/canonical/microcluster/v2/rest"
 	"github.com/canonical/microcluster/v2/state"
+	"github.com/gorilla/mux"
 )
 
 var clusterCmd = rest.Endpoint{
@@ -72,3 +76,375 @@ func cmdClusterGet(s state.State, r *http.Request) response.Response {
 
 	return response.SyncResponse(true, data)
 }
+
+var clusterLostCmd = rest.Endpoint{
+	Path: "cluster/lost/{name}",
+
+	Get:    rest.EndpointAction{Handler: cmdClusterLostGet, ProxyTarget: false},
+	Delete: rest.EndpointAction{Handler: cmdClusterLostDelete, ProxyTarget: false},
+}
+
+// cmdClusterLostGet reports what removing a lost member takes away from the cluster.
+func cmdClusterLostGet(s state.State, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	lost, err := ceph.GetLostMember(r.Context(), interfaces.CephState{State: s}, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, lost)
+}
+
+// cmdClusterLostDelete purges the OSDs, monitor and records of a permanently lost member.
+func cmdClusterLostDelete(s state.State, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.RemoveLostMember(r.Context(), interfaces.CephState{State: s}, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+var clusterLocationsCmd = rest.Endpoint{
+	Path: "cluster/locations",
+
+	Get: rest.EndpointAction{Handler: cmdClusterLocationsGet, ProxyTarget: false},
+}
+
+// cmdClusterLocationsGet returns the recorded crush locations of members, keyed by member name.
+func cmdClusterLocationsGet(s state.State, r *http.Request) response.Response {
+	locations, err := database.CrushLocationQuery.List(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, locations)
+}
+
+var clusterLocationCmd = rest.Endpoint{
+	Path: "cluster/locations/{name}",
+
+	Put: rest.EndpointAction{Handler: cmdClusterLocationPut, ProxyTarget: true},
+}
+
+// cmdClusterLocationPut sets the crush location of a member. It has to run on the member itself
+// as its host bucket is named after the local hostname.
+func cmdClusterLocationPut(s state.State, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if name != s.Name() {
+		return response.BadRequest(fmt.Errorf("crush location of %s must be set on that member", name))
+	}
+
+	var req types.CrushLocationPut
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	result, err := ceph.SetCrushLocation(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+var clusterStretchCmd = rest.Endpoint{
+	Path: "cluster/stretch",
+
+	Get:  rest.EndpointAction{Handler: cmdClusterStretchGet, ProxyTarget: false},
+	Post: rest.EndpointAction{Handler: cmdClusterStretchPost, ProxyTarget: false},
+}
+
+// cmdClusterStretchGet returns the stretch mode of the cluster.
+func cmdClusterStretchGet(s state.State, r *http.Request) response.Response {
+	stretch, err := ceph.GetStretchMode(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, stretch)
+}
+
+// cmdClusterStretchPost enables stretch mode.
+func cmdClusterStretchPost(s state.State, r *http.Request) response.Response {
+	var req types.StretchModePost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.EnableStretchMode(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// /1.0/cluster/restart restarts a service across the cluster, one host at a time.
+var clusterRestartCmd = rest.Endpoint{
+	Path: "cluster/restart",
+
+	Post: rest.EndpointAction{Handler: cmdClusterRestartPost, ProxyTarget: false},
+}
+
+// cmdClusterRestartPost starts a rolling restart of a service.
+func cmdClusterRestartPost(s state.State, r *http.Request) response.Response {
+	var req types.RollingRestartPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	cs := interfaces.CephState{State: s}
+	op, err := ceph.StartAsyncOperation(r.Context(), cs, ceph.OperationTypeRollingRestart, func(ctx context.Context, op *ceph.AsyncOperation) (string, error) {
+		results, err := ceph.RollingRestart(ctx, cs, req.Service, op)
+
+		data, jsonErr := json.Marshal(results)
+		if jsonErr != nil {
+			logger.Errorf("Failed to encode rolling restart results: %v", jsonErr)
+		}
+
+		return string(data), err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
+
+// /1.0/version returns the Ceph version installed on a member.
+var versionCmd = rest.Endpoint{
+	Path: "version",
+
+	Get: rest.EndpointAction{Handler: cmdVersionGet, ProxyTarget: true},
+}
+
+func cmdVersionGet(s state.State, r *http.Request) response.Response {
+	version, err := ceph.GetMemberVersion(s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, version)
+}
+
+// /1.0/cluster/upgrade upgrades the cluster to the Ceph version installed on its members.
+var clusterUpgradeCmd = rest.Endpoint{
+	Path: "cluster/upgrade",
+
+	Get:  rest.EndpointAction{Handler: cmdClusterUpgradeGet, ProxyTarget: false},
+	Post: rest.EndpointAction{Handler: cmdClusterUpgradePost, ProxyTarget: false},
+}
+
+// cmdClusterUpgradeGet returns the state of the cluster upgrade.
+func cmdClusterUpgradeGet(s state.State, r *http.Request) response.Response {
+	status, err := ceph.GetUpgradeStatus(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, status)
+}
+
+// cmdClusterUpgradePost starts, pauses or resumes the cluster upgrade.
+func cmdClusterUpgradePost(s state.State, r *http.Request) response.Response {
+	var req types.UpgradePost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	cs := interfaces.CephState{State: s}
+	switch req.Action {
+	case types.UpgradeActionPause:
+		err = ceph.PauseUpgrade(r.Context(), cs)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	case types.UpgradeActionStart, types.UpgradeActionResume:
+		op, err := ceph.StartUpgrade(r.Context(), cs, req.Action == types.UpgradeActionResume)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return operationResponse(op)
+	}
+
+	return response.BadRequest(fmt.Errorf("unknown upgrade action %q", req.Action))
+}
+
+// /1.0/cluster/upgrade/check runs the checks ahead of an upgrade.
+var clusterUpgradeCheckCmd = rest.Endpoint{
+	Path: "cluster/upgrade/check",
+
+	Get: rest.EndpointAction{Handler: cmdClusterUpgradeCheckGet, ProxyTarget: false},
+}
+
+// cmdClusterUpgradeCheckGet returns the report of the upgrade checks.
+func cmdClusterUpgradeCheckGet(s state.State, r *http.Request) response.Response {
+	results := ceph.UpgradeCheck(ceph.ClusterOps{State: s, Context: r.Context()})
+
+	return response.SyncResponse(true, results)
+}
+
+// /1.0/cluster/backup writes an archive of the control plane of the member.
+var clusterBackupCmd = rest.Endpoint{
+	Path: "cluster/backup",
+
+	Post: rest.EndpointAction{Handler: cmdClusterBackupPost, ProxyTarget: false},
+}
+
+// cmdClusterBackupPost backs up the control plane of the member and returns the manifest of the archive.
+func cmdClusterBackupPost(s state.State, r *http.Request) response.Response {
+	var req types.BackupPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	manifest, err := ceph.BackupMember(r.Context(), interfaces.CephState{State: s}, req.Path)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, manifest)
+}
+
+// /1.0/cluster/restore rebuilds the control plane of the member from a backup archive.
+var clusterRestoreCmd = rest.Endpoint{
+	Path: "cluster/restore",
+
+	Post: rest.EndpointAction{Handler: cmdClusterRestorePost, ProxyTarget: false},
+}
+
+// cmdClusterRestorePost restores the control plane of the member from a backup archive.
+func cmdClusterRestorePost(s state.State, r *http.Request) response.Response {
+	var req types.RestorePost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	result, err := ceph.RestoreMember(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// /1.0/cluster/shutdown shuts the cluster down ahead of a power-down.
+var clusterShutdownCmd = rest.Endpoint{
+	Path: "cluster/shutdown",
+
+	Post: rest.EndpointAction{Handler: cmdClusterShutdownPost, ProxyTarget: false},
+}
+
+// cmdClusterShutdownPost starts the shutdown of the cluster.
+func cmdClusterShutdownPost(s state.State, r *http.Request) response.Response {
+	var req types.ShutdownPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	op, err := ceph.StartShutdown(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
+
+// /1.0/cluster/startup brings the cluster back after a shutdown.
+var clusterStartupCmd = rest.Endpoint{
+	Path: "cluster/startup",
+
+	Post: rest.EndpointAction{Handler: cmdClusterStartupPost, ProxyTarget: false},
+}
+
+// cmdClusterStartupPost starts bringing the cluster back.
+func cmdClusterStartupPost(s state.State, r *http.Request) response.Response {
+	var req types.ShutdownPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	op, err := ceph.StartStartup(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
+
+// /1.0/cluster/network/address returns the address of a member on a network.
+var clusterNetworkAddressCmd = rest.Endpoint{
+	Path: "cluster/network/address",
+
+	Post: rest.EndpointAction{Handler: cmdClusterNetworkAddressPost, ProxyTarget: true},
+}
+
+// cmdClusterNetworkAddressPost returns the address of this member on the requested network.
+func cmdClusterNetworkAddressPost(s state.State, r *http.Request) response.Response {
+	var req types.NetworkAddressPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	address, err := ceph.GetNetworkAddress(req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, address)
+}
+
+// /1.0/cluster/network/migrate moves the cluster to a new public network.
+var clusterNetworkMigrateCmd = rest.Endpoint{
+	Path: "cluster/network/migrate",
+
+	Post: rest.EndpointAction{Handler: cmdClusterNetworkMigratePost, ProxyTarget: false},
+}
+
+// cmdClusterNetworkMigratePost starts the migration to a new public network.
+func cmdClusterNetworkMigratePost(s state.State, r *http.Request) response.Response {
+	var req types.NetworkMigratePost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	op, err := ceph.StartNetworkMigration(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
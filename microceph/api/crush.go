@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+)
+
+// /1.0/crush/rules endpoint.
+var crushRulesCmd = rest.Endpoint{
+	Path: "crush/rules",
+
+	Get:  rest.EndpointAction{Handler: cmdCrushRulesGet, ProxyTarget: false},
+	Post: rest.EndpointAction{Handler: cmdCrushRulesPost, ProxyTarget: false},
+}
+
+// /1.0/crush/rules/{name} endpoint.
+var crushRuleCmd = rest.Endpoint{
+	Path: "crush/rules/{name}",
+
+	Delete: rest.EndpointAction{Handler: cmdCrushRuleDelete, ProxyTarget: false},
+}
+
+func cmdCrushRulesGet(s state.State, r *http.Request) response.Response {
+	rules, err := ceph.ListCrushRules()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, rules)
+}
+
+func cmdCrushRulesPost(s state.State, r *http.Request) response.Response {
+	var req types.CrushRulePost
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.CreateCrushRule(req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func cmdCrushRuleDelete(s state.State, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.DeleteCrushRule(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
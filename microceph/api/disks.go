@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/canonical/microceph/microceph/interfaces"
 
@@ -32,6 +34,40 @@ var disksCmd = rest.Endpoint{
 	Post: rest.EndpointAction{Handler: cmdDisksPost, ProxyTarget: true},
 }
 
+// /1.0/disks/encrypt endpoint.
+// Registered ahead of /1.0/disks/{osdid}, which would match it otherwise.
+var disksEncryptCmd = rest.Endpoint{
+	Path: "disks/encrypt",
+
+	Get:  rest.EndpointAction{Handler: cmdDisksEncryptGet, ProxyTarget: true},
+	Post: rest.EndpointAction{Handler: cmdDisksEncryptPost, ProxyTarget: true},
+}
+
+// /1.0/disks/drain endpoint.
+// Registered ahead of /1.0/disks/{osdid}, which would match it otherwise.
+var disksDrainCmd = rest.Endpoint{
+	Path: "disks/drain",
+
+	Post: rest.EndpointAction{Handler: cmdDisksDrainPost, ProxyTarget: true},
+}
+
+// /1.0/disks/status endpoint.
+// Registered ahead of /1.0/disks/{osdid}, which would match it otherwise.
+var disksStatusCmd = rest.Endpoint{
+	Path: "disks/status",
+
+	Get: rest.EndpointAction{Handler: cmdDisksStatusGet, ProxyTarget: true},
+}
+
+// /1.0/disks/adopt endpoint.
+// Registered ahead of /1.0/disks/{osdid}, which would match it otherwise.
+var disksAdoptCmd = rest.Endpoint{
+	Path: "disks/adopt",
+
+	Get:  rest.EndpointAction{Handler: cmdDisksAdoptGet, ProxyTarget: true},
+	Post: rest.EndpointAction{Handler: cmdDisksAdoptPost, ProxyTarget: true},
+}
+
 // /1.0/disks/{osdid} endpoint.
 var disksDelCmd = rest.Endpoint{
 	Path: "disks/{osdid}",
@@ -39,6 +75,27 @@ var disksDelCmd = rest.Endpoint{
 	Delete: rest.EndpointAction{Handler: cmdDisksDelete, ProxyTarget: true},
 }
 
+// /1.0/disks/{osdid}/unlock endpoint.
+var disksUnlockCmd = rest.Endpoint{
+	Path: "disks/{osdid}/unlock",
+
+	Post: rest.EndpointAction{Handler: cmdDisksUnlock, ProxyTarget: true},
+}
+
+// /1.0/disks/{osdid}/rekey endpoint.
+var disksRekeyCmd = rest.Endpoint{
+	Path: "disks/{osdid}/rekey",
+
+	Post: rest.EndpointAction{Handler: cmdDisksRekey, ProxyTarget: true},
+}
+
+// /1.0/disks/{osdid}/resize endpoint.
+var disksResizeCmd = rest.Endpoint{
+	Path: "disks/{osdid}/resize",
+
+	Post: rest.EndpointAction{Handler: cmdDisksResize, ProxyTarget: true},
+}
+
 var mu sync.Mutex
 
 func cmdDisksGet(s state.State, r *http.Request) response.Response {
@@ -73,10 +130,11 @@ func cmdDisksPost(s state.State, r *http.Request) response.Response {
 	disks = make([]types.DiskParameter, len(req.Path))
 	for i, diskPath := range req.Path {
 		disks[i] = types.DiskParameter{
-			Path:     diskPath,
-			Encrypt:  req.Encrypt,
-			Wipe:     req.Wipe,
-			LoopSize: 0,
+			Path:        diskPath,
+			Encrypt:     req.Encrypt,
+			Wipe:        req.Wipe,
+			LoopSize:    0,
+			DeviceClass: req.DeviceClass,
 		}
 	}
 
@@ -137,12 +195,35 @@ func cmdDisksDelete(s state.State, r *http.Request) response.Response {
 		}
 	}
 
-	err = ceph.RemoveOSD(r.Context(), cs, osdid, req.BypassSafety, req.Timeout)
+	// Callers without the async_operations extension expect the plain response.
+	if !req.Async {
+		err = ceph.RemoveOSD(r.Context(), cs, osdid, req.BypassSafety, req.Timeout)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	op, err := ceph.StartAsyncOperation(r.Context(), cs, ceph.OperationTypeDiskRemove, func(ctx context.Context, op *ceph.AsyncOperation) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		op.SetProgress(fmt.Sprintf("removing osd.%d", osdid))
+
+		if req.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Second)
+			defer cancel()
+		}
+
+		return "", ceph.RemoveOSD(ctx, cs, osdid, req.BypassSafety, req.Timeout)
+	})
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	return response.EmptySyncResponse
+	return operationResponse(op)
 }
 
 // parseAndPatchDiskPostParams parses/patches Disk add command parameters
@@ -180,3 +261,161 @@ func parseAndPatchDiskPostParams(rb io.ReadCloser) (types.DisksPost, error) {
 
 	return output, nil
 }
+
+// cmdDisksUnlock is the handler for POST /1.0/disks/{osdid}/unlock.
+func cmdDisksUnlock(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOSDParam(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.UnlockOSD(r.Context(), interfaces.CephState{State: s}, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksRekey is the handler for POST /1.0/disks/{osdid}/rekey.
+func cmdDisksRekey(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOSDParam(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.RekeyOSD(r.Context(), interfaces.CephState{State: s}, osdid)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksResize is the handler for POST /1.0/disks/{osdid}/resize.
+func cmdDisksResize(s state.State, r *http.Request) response.Response {
+	osdid, err := parseOSDParam(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var req types.DisksResizePost
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.ResizeOSD(r.Context(), interfaces.CephState{State: s}, osdid, req.Size)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksEncryptGet is the handler for GET /1.0/disks/encrypt.
+func cmdDisksEncryptGet(s state.State, r *http.Request) response.Response {
+	encryptions, err := ceph.ListDiskEncryptions(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.SyncResponse(true, encryptions)
+}
+
+// cmdDisksEncryptPost is the handler for POST /1.0/disks/encrypt.
+func cmdDisksEncryptPost(s state.State, r *http.Request) response.Response {
+	var req types.DisksEncryptPost
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.EncryptOSDs(r.Context(), interfaces.CephState{State: s}, req.OSDs)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksDrainPost is the handler for POST /1.0/disks/drain.
+func cmdDisksDrainPost(s state.State, r *http.Request) response.Response {
+	var req types.DisksDrainPost
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.DrainOSDs(r.Context(), interfaces.CephState{State: s}, req.OSDs)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// cmdDisksStatusGet is the handler for GET /1.0/disks/status.
+func cmdDisksStatusGet(s state.State, r *http.Request) response.Response {
+	status, err := ceph.GetDiskStatus(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, status)
+}
+
+// cmdDisksAdoptGet is the handler for GET /1.0/disks/adopt, listing existing OSDs found on this host.
+func cmdDisksAdoptGet(s state.State, r *http.Request) response.Response {
+	disks, err := ceph.ListAdoptableDisks(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, disks)
+}
+
+// cmdDisksAdoptPost is the handler for POST /1.0/disks/adopt.
+func cmdDisksAdoptPost(s state.State, r *http.Request) response.Response {
+	var req types.DisksAdoptPost
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	disks, err := ceph.AdoptOSDs(r.Context(), interfaces.CephState{State: s}, req.OSDs)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, disks)
+}
+
+// parseOSDParam parses the {osdid} path parameter of a request.
+func parseOSDParam(r *http.Request) (int64, error) {
+	osd, err := url.PathUnescape(mux.Vars(r)["osdid"])
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(osd, 10, 64)
+}
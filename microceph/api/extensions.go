@@ -0,0 +1,14 @@
+package api
+
+// extensions is the list of API extensions microceph adds to the microcluster API.
+var extensions = []string{
+	// async_operations: PUT /1.0/services/{name} and DELETE /1.0/disks/{osdid} run as an
+	// operation and return it when the request sets "async", as do PUT /1.0/ops/replication/{wl}
+	// and PUT /1.0/ops/replication/{wl}/{name} with the "async" query parameter.
+	"async_operations",
+}
+
+// Extensions returns the list of API extensions to register with microcluster.
+func Extensions() []string {
+	return extensions
+}
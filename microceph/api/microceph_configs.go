@@ -2,12 +2,15 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/lxd/shared/logger"
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microcluster/v2/client"
 	"github.com/canonical/microcluster/v2/rest"
 	"github.com/canonical/microcluster/v2/state"
 )
@@ -28,6 +31,18 @@ var logLevelCmd = rest.Endpoint{
 	Get:  rest.EndpointAction{Handler: logLevelGet, ProxyTarget: true},
 }
 
+var keyStoreCmd = rest.Endpoint{
+	Path: "microceph/configs/keystore",
+	Put:  rest.EndpointAction{Handler: keyStorePut, ProxyTarget: true},
+	Get:  rest.EndpointAction{Handler: keyStoreGet, ProxyTarget: true},
+}
+
+var keyStoreTokenCmd = rest.Endpoint{
+	Path: "microceph/configs/keystore/token",
+	Put:  rest.EndpointAction{Handler: keyStoreTokenPut, ProxyTarget: true},
+	Get:  rest.EndpointAction{Handler: keyStoreTokenGet, ProxyTarget: true},
+}
+
 func logLevelPut(s state.State, r *http.Request) response.Response {
 	var req types.LogLevelPut
 
@@ -49,3 +64,72 @@ func logLevelPut(s state.State, r *http.Request) response.Response {
 func logLevelGet(s state.State, r *http.Request) response.Response {
 	return response.SyncResponse(true, ceph.GetLogLevel())
 }
+
+// keyStoreGet is the handler for GET /1.0/microceph/configs/keystore.
+func keyStoreGet(s state.State, r *http.Request) response.Response {
+	config, err := ceph.GetKeyStoreConfig(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	// Never hand out the key store credentials.
+	config.Token = ""
+
+	return response.SyncResponse(true, config)
+}
+
+// keyStorePut is the handler for PUT /1.0/microceph/configs/keystore.
+func keyStorePut(s state.State, r *http.Request) response.Response {
+	var req types.KeyStoreConfig
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err = ceph.SetKeyStore(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// keyStoreTokenPut is the handler for PUT /1.0/microceph/configs/keystore/token.
+func keyStoreTokenPut(s state.State, r *http.Request) response.Response {
+	if !client.IsNotification(r) {
+		return response.Forbidden(fmt.Errorf("the key store token is only handed over by cluster members"))
+	}
+
+	var req types.KeyStoreToken
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.WriteKeyStoreToken(req.Token)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// keyStoreTokenGet is the handler for GET /1.0/microceph/configs/keystore/token. The token is
+// only handed out to other cluster members.
+func keyStoreTokenGet(s state.State, r *http.Request) response.Response {
+	if !client.IsNotification(r) {
+		return response.Forbidden(fmt.Errorf("the key store token is only handed out to cluster members"))
+	}
+
+	token, err := ceph.ReadKeyStoreToken()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.SyncResponse(true, types.KeyStoreToken{Token: token})
+}
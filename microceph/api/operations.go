@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/gorilla/mux"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// /1.0/operations endpoint.
+var operationsCmd = rest.Endpoint{
+	Path: "operations",
+
+	Get: rest.EndpointAction{Handler: cmdOperationsGet, ProxyTarget: true},
+}
+
+// /1.0/operations/{id} endpoint.
+var operationCmd = rest.Endpoint{
+	Path: "operations/{id}",
+
+	Get:    rest.EndpointAction{Handler: cmdOperationGet, ProxyTarget: true},
+	Delete: rest.EndpointAction{Handler: cmdOperationDelete, ProxyTarget: true},
+}
+
+// cmdOperationsGet lists the operations of all cluster members.
+func cmdOperationsGet(s state.State, r *http.Request) response.Response {
+	ops, err := ceph.ListAsyncOperations(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, ops)
+}
+
+// cmdOperationGet returns a single operation.
+func cmdOperationGet(s state.State, r *http.Request) response.Response {
+	id, err := url.PathUnescape(mux.Vars(r)["id"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	op, err := ceph.GetAsyncOperation(r.Context(), interfaces.CephState{State: s}, id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, op)
+}
+
+// cmdOperationDelete cancels a running operation.
+func cmdOperationDelete(s state.State, r *http.Request) response.Response {
+	id, err := url.PathUnescape(mux.Vars(r)["id"])
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.CancelAsyncOperation(r.Context(), interfaces.CephState{State: s}, id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// operationResponse returns a started operation, pointing at the endpoint to follow it.
+func operationResponse(op types.Operation) response.Response {
+	location := api.NewURL().Path(string(types.ExtendedPathPrefix), "operations", op.ID).String()
+
+	return response.SyncResponseLocation(true, op, location)
+}
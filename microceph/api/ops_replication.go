@@ -8,6 +8,7 @@ import (
 	"net/url"
 
 	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/logger"
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/ceph"
@@ -51,7 +52,7 @@ func getOpsReplicationWorkload(s state.State, r *http.Request) response.Response
 // putOpsReplicationWorkload handles site level (promote/demote) operation
 func putOpsReplicationWorkload(s state.State, r *http.Request) response.Response {
 	// either promote or demote (already encoded in request)
-	return cmdOpsReplication(s, r, types.WorkloadReplicationRequest)
+	return cmdOpsReplicationPut(s, r, types.WorkloadReplicationRequest)
 }
 
 // getOpsReplicationResource handles status operation for a certain resource.
@@ -66,7 +67,7 @@ func postOpsReplicationResource(s state.State, r *http.Request) response.Respons
 
 // putOpsReplicationResource handles configuration of the requested resource
 func putOpsReplicationResource(s state.State, r *http.Request) response.Response {
-	return cmdOpsReplication(s, r, types.ConfigureReplicationRequest)
+	return cmdOpsReplicationPut(s, r, types.ConfigureReplicationRequest)
 }
 
 // deleteOpsReplicationResource handles rep disablement for the requested resource
@@ -76,18 +77,64 @@ func deleteOpsReplicationResource(s state.State, r *http.Request) response.Respo
 
 // cmdOpsReplication is the common handler for all requests on replication endpoint.
 func cmdOpsReplication(s state.State, r *http.Request, patchRequest types.ReplicationRequestType) response.Response {
+	req, err := parseReplicationRequest(r, patchRequest)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp, err := handleReplicationRequest(s, r.Context(), req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, resp)
+}
+
+// cmdOpsReplicationPut is the common handler for long-running requests on replication endpoint,
+// which run as an operation when the request asks for it with the async query parameter.
+func cmdOpsReplicationPut(s state.State, r *http.Request, patchRequest types.ReplicationRequestType) response.Response {
+	// Callers without the async_operations extension expect the plain response.
+	if !shared.IsTrue(r.URL.Query().Get("async")) {
+		return cmdOpsReplication(s, r, patchRequest)
+	}
+
+	return cmdOpsReplicationAsync(s, r, patchRequest)
+}
+
+// cmdOpsReplicationAsync is the common handler for long-running requests on replication endpoint,
+// it returns an operation tracking the request.
+func cmdOpsReplicationAsync(s state.State, r *http.Request, patchRequest types.ReplicationRequestType) response.Response {
+	req, err := parseReplicationRequest(r, patchRequest)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	op, err := ceph.StartAsyncOperation(r.Context(), interfaces.CephState{State: s}, ceph.OperationTypeReplication, func(ctx context.Context, op *ceph.AsyncOperation) (string, error) {
+		op.SetProgress(fmt.Sprintf("processing %s request for %s", req.GetWorkloadRequestType(), req.GetWorkloadType()))
+
+		return handleReplicationRequest(s, ctx, req)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
+
+// parseReplicationRequest populates the replication request with the workload and resource of the endpoint.
+func parseReplicationRequest(r *http.Request, patchRequest types.ReplicationRequestType) (types.ReplicationRequest, error) {
 	// Get workload name from API
 	wl, err := url.PathUnescape(mux.Vars(r)["wl"])
 	if err != nil {
 		logger.Errorf("REP: %v", err.Error())
-		return response.InternalError(err)
+		return nil, err
 	}
 
 	// Get resource name from API
 	resource, err := url.PathUnescape(mux.Vars(r)["name"])
 	if err != nil {
 		logger.Errorf("REP: %v", err.Error())
-		return response.InternalError(err)
+		return nil, err
 	}
 
 	// Populate the replication request with necessary information for RESTfullnes
@@ -97,7 +144,7 @@ func cmdOpsReplication(s state.State, r *http.Request, patchRequest types.Replic
 		err := json.NewDecoder(r.Body).Decode(&data)
 		if err != nil {
 			logger.Errorf("REP: failed to decode request data: %v", err.Error())
-			return response.InternalError(err)
+			return nil, err
 		}
 
 		// carry RbdReplicationRequest in interface object.
@@ -109,27 +156,27 @@ func cmdOpsReplication(s state.State, r *http.Request, patchRequest types.Replic
 
 		req = data
 	} else {
-		return response.SmartError(fmt.Errorf("unknown workload %s, resource %s", wl, resource))
+		return nil, fmt.Errorf("unknown workload %s, resource %s", wl, resource)
 	}
 
 	logger.Debugf("REPOPS: %s received for %s: %s", req.GetWorkloadRequestType(), wl, resource)
 
-	return handleReplicationRequest(s, r.Context(), req)
+	return req, nil
 }
 
 // handleReplicationRequest parses the replication request and feeds it to the corresponding state machine.
-func handleReplicationRequest(s state.State, ctx context.Context, req types.ReplicationRequest) response.Response {
+func handleReplicationRequest(s state.State, ctx context.Context, req types.ReplicationRequest) (string, error) {
 	// Fetch replication handler
 	wl := string(req.GetWorkloadType())
 	rh := ceph.GetReplicationHandler(wl)
 	if rh == nil {
-		return response.SmartError(fmt.Errorf("no replication handler for %s workload", wl))
+		return "", fmt.Errorf("no replication handler for %s workload", wl)
 	}
 
 	// Populate resource info
 	err := rh.PreFill(ctx, req)
 	if err != nil {
-		return response.SmartError(err)
+		return "", err
 	}
 
 	// Get FSM
@@ -140,15 +187,10 @@ func handleReplicationRequest(s state.State, ctx context.Context, req types.Repl
 	// Each event is provided with, replication handler, response object and state.
 	err = repFsm.FireCtx(ctx, event, rh, &resp, interfaces.CephState{State: s})
 	if err != nil {
-		return response.SmartError(err)
+		return "", err
 	}
 
 	logger.Debugf("REPFSM: Check FSM response: %s", resp)
 
-	// If non-empty response
-	if len(resp) > 0 {
-		return response.SyncResponse(true, resp)
-	}
-
-	return response.SyncResponse(true, "")
+	return resp, nil
 }
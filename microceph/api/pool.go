@@ -24,6 +24,7 @@ var poolsOpCmd = rest.Endpoint{
 var poolsCmd = rest.Endpoint{
 	Path: "pools",
 	Get:  rest.EndpointAction{Handler: cmdPoolsGet, ProxyTarget: true},
+	Post: rest.EndpointAction{Handler: cmdPoolsPost, ProxyTarget: true},
 }
 
 func cmdPoolsGet(s state.State, r *http.Request) response.Response {
@@ -38,6 +39,23 @@ func cmdPoolsGet(s state.State, r *http.Request) response.Response {
 	return response.SyncResponse(true, pools)
 }
 
+func cmdPoolsPost(s state.State, r *http.Request) response.Response {
+	var req types.PoolPost
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	logger.Debugf("cmdPoolPost: %v", req)
+	err = ceph.CreatePool(req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
 func cmdPoolsPut(s state.State, r *http.Request) response.Response {
 	var req types.PoolPut
 
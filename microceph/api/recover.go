@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/rest"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// /1.0/recover/mon-store rebuilds the mon store from the OSDs once all monitors are lost.
+var recoverMonStoreCmd = rest.Endpoint{
+	Path: "recover/mon-store",
+
+	Post: rest.EndpointAction{Handler: cmdRecoverMonStorePost, ProxyTarget: false},
+}
+
+// cmdRecoverMonStorePost starts the recovery of the mon store on this member.
+func cmdRecoverMonStorePost(s state.State, r *http.Request) response.Response {
+	var req types.RecoverMonStorePost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	op, err := ceph.StartMonStoreRecovery(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return operationResponse(op)
+}
+
+// /1.0/recover/mon-store/collect adds the cluster maps held by the OSDs of a member to a mon store.
+var recoverMonStoreCollectCmd = rest.Endpoint{
+	Path: "recover/mon-store/collect",
+
+	Post: rest.EndpointAction{Handler: cmdRecoverMonStoreCollectPost, ProxyTarget: true},
+}
+
+// cmdRecoverMonStoreCollectPost stops the local OSDs and collects the cluster maps they hold.
+func cmdRecoverMonStoreCollectPost(s state.State, r *http.Request) response.Response {
+	var req types.MonStoreCollectPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	result, err := ceph.CollectMonStore(req, s.Name())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// /1.0/recover/quorum restores quorum after a majority of monitors is lost.
+var recoverQuorumCmd = rest.Endpoint{
+	Path: "recover/quorum",
+
+	Post: rest.EndpointAction{Handler: cmdRecoverQuorumPost, ProxyTarget: false},
+}
+
+// cmdRecoverQuorumPost keeps only the monitor of the requested member.
+func cmdRecoverQuorumPost(s state.State, r *http.Request) response.Response {
+	var req types.RecoverQuorumPost
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	result, err := ceph.RecoverQuorum(r.Context(), interfaces.CephState{State: s}, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/canonical/lxd/lxd/response"
 	"github.com/canonical/microcluster/v2/rest"
 	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/interfaces"
 )
 
 // /1.0/resources endpoint.
@@ -16,6 +19,13 @@ var resourcesCmd = rest.Endpoint{
 	Get: rest.EndpointAction{Handler: cmdResourcesGet, ProxyTarget: true},
 }
 
+// /1.0/resources/health endpoint.
+var resourcesHealthCmd = rest.Endpoint{
+	Path: "resources/health",
+
+	Get: rest.EndpointAction{Handler: cmdResourcesHealthGet, ProxyTarget: true},
+}
+
 func cmdResourcesGet(s state.State, r *http.Request) response.Response {
 	storage, err := resources.GetStorage()
 	if err != nil {
@@ -24,3 +34,13 @@ func cmdResourcesGet(s state.State, r *http.Request) response.Response {
 
 	return response.SyncResponse(true, storage)
 }
+
+// cmdResourcesHealthGet is the handler for GET /1.0/resources/health.
+func cmdResourcesHealthGet(s state.State, r *http.Request) response.Response {
+	health, err := ceph.GetLocalDiskHealth(r.Context(), interfaces.CephState{State: s})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, health)
+}
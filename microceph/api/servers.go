@@ -15,11 +15,22 @@ var Servers = map[string]rest.Server{
 				PathPrefix: types.ExtendedPathPrefix,
 				Endpoints: []rest.Endpoint{
 					disksCmd,
+					disksEncryptCmd,
+					disksDrainCmd,
+					disksStatusCmd,
+					disksAdoptCmd,
 					disksDelCmd,
+					disksUnlockCmd,
+					disksRekeyCmd,
+					disksResizeCmd,
 					resourcesCmd,
+					resourcesHealthCmd,
 					servicesCmd,
+					servicesPolicyCmd,
 					configsCmd,
 					restartServiceCmd,
+					stopServiceCmd,
+					startServiceCmd,
 					mdsServiceCmd,
 					mgrServiceCmd,
 					monServiceCmd,
@@ -27,13 +38,34 @@ var Servers = map[string]rest.Server{
 					rgwServiceCmd,
 					rbdMirroServiceCmd,
 					poolsCmd,
+					crushRulesCmd,
+					crushRuleCmd,
 					clientCmd,
 					clientConfigsCmd,
 					clientConfigsKeyCmd,
 					microcephCmd,
 					microcephConfigsCmd,
 					logLevelCmd,
+					keyStoreCmd,
+					keyStoreTokenCmd,
 					clusterCmd,
+					clusterLostCmd,
+					clusterLocationsCmd,
+					clusterLocationCmd,
+					clusterStretchCmd,
+					clusterRestartCmd,
+					clusterUpgradeCmd,
+					clusterUpgradeCheckCmd,
+					clusterBackupCmd,
+					clusterRestoreCmd,
+					clusterShutdownCmd,
+					clusterStartupCmd,
+					clusterNetworkAddressCmd,
+					clusterNetworkMigrateCmd,
+					recoverMonStoreCmd,
+					recoverMonStoreCollectCmd,
+					recoverQuorumCmd,
+					versionCmd,
 					remoteCmd,
 					remoteNameCmd,
 					opsCmd,
@@ -43,6 +75,9 @@ var Servers = map[string]rest.Server{
 					opsReplicationResourceCmd,
 					// Maintenance APIs
 					opsMaintenanceNodeCmd,
+					// Asynchronous operations APIs
+					operationsCmd,
+					operationCmd,
 				},
 			},
 		},
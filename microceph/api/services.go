@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"github.com/canonical/microceph/microceph/interfaces"
 
 	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/lxd/shared"
 	"github.com/canonical/lxd/shared/logger"
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microcluster/v2/rest"
@@ -33,6 +35,40 @@ func cmdServicesGet(s state.State, r *http.Request) response.Response {
 	return response.SyncResponse(true, services)
 }
 
+// /1.0/services/policy endpoint.
+var servicesPolicyCmd = rest.Endpoint{
+	Path: "services/policy",
+
+	Get: rest.EndpointAction{Handler: cmdServicesPolicyGet, ProxyTarget: false},
+	Put: rest.EndpointAction{Handler: cmdServicesPolicyPut, ProxyTarget: false},
+}
+
+// cmdServicesPolicyGet returns the desired service counts.
+func cmdServicesPolicyGet(s state.State, r *http.Request) response.Response {
+	policy, err := ceph.GetServicePolicy(r.Context(), s)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, policy)
+}
+
+// cmdServicesPolicyPut sets the desired service counts.
+func cmdServicesPolicyPut(s state.State, r *http.Request) response.Response {
+	var policy types.ServicePolicy
+	err := json.NewDecoder(r.Body).Decode(&policy)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = ceph.SetServicePolicy(r.Context(), s, policy)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
 // Service endpoints.
 var monServiceCmd = rest.Endpoint{
 	Path:   "services/mon",
@@ -85,12 +121,30 @@ func cmdEnableServicePut(s state.State, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
-	err = ceph.ServicePlacementHandler(r.Context(), interfaces.CephState{State: s}, payload)
+	// Callers without the async_operations extension expect the plain response.
+	if !payload.Async {
+		err = ceph.ServicePlacementHandler(r.Context(), interfaces.CephState{State: s}, payload)
+		if err != nil {
+			return response.SyncResponse(false, err)
+		}
+
+		return response.SyncResponse(true, nil)
+	}
+
+	// The operation covers the whole enablement, the caller waits for it when it set Wait.
+	placement := payload
+	placement.Wait = true
+
+	op, err := ceph.StartAsyncOperation(r.Context(), interfaces.CephState{State: s}, ceph.OperationTypeServiceEnable, func(ctx context.Context, op *ceph.AsyncOperation) (string, error) {
+		op.SetProgress(fmt.Sprintf("enabling %s", placement.Name))
+
+		return "", ceph.ServicePlacementHandler(ctx, interfaces.CephState{State: s}, placement)
+	})
 	if err != nil {
-		return response.SyncResponse(false, err)
+		return response.SmartError(err)
 	}
 
-	return response.SyncResponse(true, nil)
+	return operationResponse(op)
 }
 
 // Service Reload Endpoint.
@@ -118,7 +172,7 @@ func cmdRestartServicePost(s state.State, r *http.Request) response.Response {
 		}
 	}
 
-	clusterServices, err := ceph.ListServices(r.Context(), s)
+	clusterServices, err := ceph.ListRestartableServices(r.Context(), interfaces.CephState{State: s})
 	if err != nil {
 		logger.Errorf("failed fetching services from db: %v", err)
 		return response.SyncResponse(false, err)
@@ -136,19 +190,67 @@ func cmdRestartServicePost(s state.State, r *http.Request) response.Response {
 	return response.EmptySyncResponse
 }
 
+// /1.0/services/stop stops services on a member, without disabling them.
+var stopServiceCmd = rest.Endpoint{
+	Path: "services/stop",
+	Post: rest.EndpointAction{Handler: cmdStopServicePost, ProxyTarget: true},
+}
+
+func cmdStopServicePost(s state.State, r *http.Request) response.Response {
+	return powerServices(r, ceph.StopServices)
+}
+
+// /1.0/services/start starts services on a member.
+var startServiceCmd = rest.Endpoint{
+	Path: "services/start",
+	Post: rest.EndpointAction{Handler: cmdStartServicePost, ProxyTarget: true},
+}
+
+func cmdStartServicePost(s state.State, r *http.Request) response.Response {
+	return powerServices(r, ceph.StartServices)
+}
+
+// powerServices decodes the services of a stop or start request and hands them over.
+func powerServices(r *http.Request, power func(services []string) error) response.Response {
+	var services types.Services
+	err := json.NewDecoder(r.Body).Decode(&services)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	valid := ceph.GetConfigTableServiceSet()
+	names := []string{}
+	for _, service := range services {
+		if _, ok := valid[service.Service]; !ok {
+			return response.BadRequest(fmt.Errorf("%s is not a valid ceph service", service.Service))
+		}
+
+		names = append(names, service.Service)
+	}
+
+	err = power(names)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
 // cmdDeleteService handles service deletion.
 func cmdDeleteService(s state.State, r *http.Request) response.Response {
 	which := path.Base(r.URL.Path)
-	_, ok := ceph.GetConfigTableServiceSet()[which]
+	_, ok := ceph.GetServicePlacementTable()[which]
 	if !ok {
 		err := fmt.Errorf("%s is not a valid ceph service", which)
 		logger.Errorf("%v", err)
 		return response.InternalError(err)
 	}
 
-	err := ceph.DeleteService(r.Context(), interfaces.CephState{State: s}, which)
+	force := shared.IsTrue(r.URL.Query().Get("force"))
+
+	err := ceph.DeleteService(r.Context(), interfaces.CephState{State: s}, which, force)
 	if err != nil {
-		return response.SyncResponse(false, err)
+		return response.SmartError(err)
 	}
 
 	return response.SyncResponse(true, nil)
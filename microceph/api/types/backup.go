@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+// BackupPost holds the path of the backup archive to create on the member.
+type BackupPost struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+// RestorePost holds the path of the backup archive to restore a member from.
+type RestorePost struct {
+	Path          string `json:"path" yaml:"path"`
+	SkipMonStore  bool   `json:"skip_mon_store" yaml:"skip_mon_store"`
+	Force         bool   `json:"force" yaml:"force"`
+	ClusterConfig bool   `json:"cluster_config" yaml:"cluster_config"`
+	DryRun        bool   `json:"dry_run" yaml:"dry_run"`
+}
+
+// BackupManifest describes the content of a backup archive, along with the checksums of its files.
+type BackupManifest struct {
+	Format    int               `json:"format" yaml:"format"`
+	CreatedAt time.Time         `json:"created_at" yaml:"created_at"`
+	Member    string            `json:"member" yaml:"member"`
+	FSID      string            `json:"fsid" yaml:"fsid"`
+	Version   MemberVersion     `json:"version" yaml:"version"`
+	Files     map[string]string `json:"files" yaml:"files"`
+	Warnings  []string          `json:"warnings" yaml:"warnings"`
+}
+
+// RestoreResult reports what was restored from a backup archive, or what would be on a dry run.
+type RestoreResult struct {
+	Manifest BackupManifest `json:"manifest" yaml:"manifest"`
+	Restored []string       `json:"restored" yaml:"restored"`
+	Warnings []string       `json:"warnings" yaml:"warnings"`
+}
+
+// EncryptionKeyRefs is a slice of references to the keys of encrypted OSD devices.
+type EncryptionKeyRefs []EncryptionKeyRef
+
+// EncryptionKeyRef tells where the key of an encrypted OSD device is stored. It doesn't hold the key.
+type EncryptionKeyRef struct {
+	OSD     int64  `json:"osd" yaml:"osd"`
+	Device  string `json:"device" yaml:"device"`
+	Backend string `json:"backend" yaml:"backend"`
+	URL     string `json:"url" yaml:"url"`
+	Name    string `json:"name" yaml:"name"`
+}
@@ -0,0 +1,50 @@
+package types
+
+// LostMember describes what removing a permanently lost member takes away from the cluster.
+type LostMember struct {
+	Name                   string   `json:"name" yaml:"name"`
+	OSDs                   []int64  `json:"osds" yaml:"osds"`
+	Services               []string `json:"services" yaml:"services"`
+	FailureDomainDowngrade bool     `json:"failure_domain_downgrade" yaml:"failure_domain_downgrade"`
+	// FailureDomain is the failure domain in use and FailureDomainTarget the one it is
+	// downgraded to, if a downgrade is needed.
+	FailureDomain       string `json:"failure_domain" yaml:"failure_domain"`
+	FailureDomainTarget string `json:"failure_domain_target" yaml:"failure_domain_target"`
+}
+
+// CrushLocationPut holds the crush location of a cluster member, e.g. rack=r1,row=a.
+// An empty location places the member directly under the crush root.
+type CrushLocationPut struct {
+	Location string `json:"location" yaml:"location"`
+	// RackFailureDomain switches the automatic crush rules to the rack failure domain.
+	RackFailureDomain bool `json:"rack_failure_domain" yaml:"rack_failure_domain"`
+}
+
+// CrushLocationResult reports the failure domain in use after setting a crush location, and
+// whether the OSDs are spread over enough racks for the rack failure domain.
+type CrushLocationResult struct {
+	FailureDomain          string `json:"failure_domain" yaml:"failure_domain"`
+	RackFailureDomainReady bool   `json:"rack_failure_domain_ready" yaml:"rack_failure_domain_ready"`
+}
+
+// StretchSite is one of the two data sites of a stretch cluster.
+type StretchSite struct {
+	Name    string   `json:"name" yaml:"name"`
+	Members []string `json:"members" yaml:"members"`
+}
+
+// StretchMode describes the stretch mode of the cluster. Site members are the
+// members whose crush location is within the site's datacenter bucket.
+type StretchMode struct {
+	Enabled    bool          `json:"enabled" yaml:"enabled"`
+	Tiebreaker string        `json:"tiebreaker" yaml:"tiebreaker"`
+	Sites      []StretchSite `json:"sites" yaml:"sites"`
+}
+
+// StretchModePost holds the parameters for enabling stretch mode. The members of
+// both sites need to be placed in their datacenter bucket beforehand.
+type StretchModePost struct {
+	SiteA      string `json:"site_a" yaml:"site_a"`
+	SiteB      string `json:"site_b" yaml:"site_b"`
+	Tiebreaker string `json:"tiebreaker" yaml:"tiebreaker"`
+}
@@ -0,0 +1,27 @@
+package types
+
+// CrushRule describes a crush rule and the pools using it.
+type CrushRule struct {
+	ID            int64    `json:"id" yaml:"id"`
+	Name          string   `json:"name" yaml:"name"`
+	Type          string   `json:"type" yaml:"type"`
+	Root          string   `json:"root" yaml:"root"`
+	FailureDomain string   `json:"failure_domain" yaml:"failure_domain"`
+	DeviceClass   string   `json:"device_class" yaml:"device_class"`
+	Pools         []string `json:"pools" yaml:"pools"`
+}
+
+// CrushRules is a slice of crush rules.
+type CrushRules []CrushRule
+
+// CrushRulePost holds the parameters of a new crush rule. K and M are the
+// number of data and coding chunks of erasure rules.
+type CrushRulePost struct {
+	Name          string `json:"name" yaml:"name"`
+	Type          string `json:"type" yaml:"type"`
+	Root          string `json:"root" yaml:"root"`
+	FailureDomain string `json:"failure_domain" yaml:"failure_domain"`
+	DeviceClass   string `json:"device_class" yaml:"device_class"`
+	K             int64  `json:"k" yaml:"k"`
+	M             int64  `json:"m" yaml:"m"`
+}
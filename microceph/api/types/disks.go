@@ -3,15 +3,16 @@ package types
 
 // DisksPost hold a path and a flag for enabling device wiping
 type DisksPost struct {
-	Path       []string `json:"path" yaml:"path"`
-	Wipe       bool     `json:"wipe" yaml:"wipe"`
-	Encrypt    bool     `json:"encrypt" yaml:"encrypt"`
-	WALDev     *string  `json:"waldev" yaml:"waldev"`
-	WALWipe    bool     `json:"walwipe" yaml:"walwipe"`
-	WALEncrypt bool     `json:"walencrypt" yaml:"walencrypt"`
-	DBDev      *string  `json:"dbdev" yaml:"dbdev"`
-	DBWipe     bool     `json:"dbwipe" yaml:"dbwipe"`
-	DBEncrypt  bool     `json:"dbencrypt" yaml:"dbencrypt"`
+	Path        []string `json:"path" yaml:"path"`
+	Wipe        bool     `json:"wipe" yaml:"wipe"`
+	Encrypt     bool     `json:"encrypt" yaml:"encrypt"`
+	WALDev      *string  `json:"waldev" yaml:"waldev"`
+	WALWipe     bool     `json:"walwipe" yaml:"walwipe"`
+	WALEncrypt  bool     `json:"walencrypt" yaml:"walencrypt"`
+	DBDev       *string  `json:"dbdev" yaml:"dbdev"`
+	DBWipe      bool     `json:"dbwipe" yaml:"dbwipe"`
+	DBEncrypt   bool     `json:"dbencrypt" yaml:"dbencrypt"`
+	DeviceClass string   `json:"device_class" yaml:"device_class"`
 }
 
 // DiskAddReport holds report for single disk addition i.e. success/failure and optional error for failures.
@@ -34,6 +35,8 @@ type DisksDelete struct {
 	ConfirmDowngrade       bool  `json:"confirm_downgrade" yaml:"confirm_downgrade"`
 	ProhibitCrushScaledown bool  `json:"prohibit_crush_scaledown" yaml:"prohibit_crush_scaledown"`
 	Timeout                int64 `json:"timeout" yaml:"timeout"`
+	// Async has the removal run as an operation, see the async_operations API extension.
+	Async bool `json:"async" yaml:"async"`
 }
 
 // Disks is a slice of disks
@@ -47,8 +50,99 @@ type Disk struct {
 }
 
 type DiskParameter struct {
-	Path     string
-	Encrypt  bool
-	Wipe     bool
-	LoopSize uint64
+	Path        string
+	Encrypt     bool
+	Wipe        bool
+	LoopSize    uint64
+	LoopDir     string
+	DeviceClass string
 }
+
+// KeyStoreConfig holds the cluster wide key store settings for encrypted OSDs.
+type KeyStoreConfig struct {
+	Backend string `json:"backend" yaml:"backend"`
+	URL     string `json:"url" yaml:"url"`
+	Token   string `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+// KeyStoreToken holds the key store token handed from member to member. It is kept on each
+// member rather than in the cluster database.
+type KeyStoreToken struct {
+	Token string `json:"token" yaml:"token"`
+}
+
+// DisksEncryptPost holds the OSDs to convert into encrypted OSDs.
+type DisksEncryptPost struct {
+	OSDs []int64 `json:"osds" yaml:"osds"`
+}
+
+// DiskEncryptions is a slice of disk encryption conversions.
+type DiskEncryptions []DiskEncryption
+
+// DiskEncryption holds the progress of converting an OSD into an encrypted OSD.
+type DiskEncryption struct {
+	OSD      int64  `json:"osd" yaml:"osd"`
+	Location string `json:"location" yaml:"location"`
+	Status   string `json:"status" yaml:"status"`
+	Error    string `json:"error" yaml:"error"`
+}
+
+// DisksResizePost holds the new size of a loop file backed OSD, e.g. 16G.
+type DisksResizePost struct {
+	Size string `json:"size" yaml:"size"`
+}
+
+// DisksDrainPost holds the OSDs to drain.
+type DisksDrainPost struct {
+	OSDs []int64 `json:"osds" yaml:"osds"`
+}
+
+// DiskStatuses is a slice of disk statuses.
+type DiskStatuses []DiskStatus
+
+// DiskStatus holds the weight, placement group count and drain state of an OSD.
+type DiskStatus struct {
+	OSD           int64   `json:"osd" yaml:"osd"`
+	Location      string  `json:"location" yaml:"location"`
+	Path          string  `json:"path" yaml:"path"`
+	Weight        float64 `json:"weight" yaml:"weight"`
+	PGs           int64   `json:"pgs" yaml:"pgs"`
+	UsedBytes     uint64  `json:"used_bytes" yaml:"used_bytes"`
+	Utilization   float64 `json:"utilization" yaml:"utilization"`
+	DeviceClass   string  `json:"device_class" yaml:"device_class"`
+	Up            bool    `json:"up" yaml:"up"`
+	In            bool    `json:"in" yaml:"in"`
+	SafeToDestroy bool    `json:"safe_to_destroy" yaml:"safe_to_destroy"`
+	Status        string  `json:"status" yaml:"status"`
+}
+
+// DiskHealths is a slice of disk health reports.
+type DiskHealths []DiskHealth
+
+// DiskHealth holds the health of the device backing an OSD, as seen by the member hosting it.
+type DiskHealth struct {
+	OSD     int64  `json:"osd" yaml:"osd"`
+	Path    string `json:"path" yaml:"path"`
+	Health  string `json:"health" yaml:"health"`
+	Source  string `json:"source" yaml:"source"`
+	Details string `json:"details" yaml:"details"`
+}
+
+// DisksAdoptPost holds the ids of existing OSDs to adopt, all adoptable ones if empty.
+type DisksAdoptPost struct {
+	OSDs []int64 `json:"osds" yaml:"osds"`
+}
+
+// AdoptableDisk describes an existing BlueStore OSD found on a host.
+type AdoptableDisk struct {
+	OSD     int64  `json:"osd" yaml:"osd"`
+	FSID    string `json:"fsid" yaml:"fsid"`
+	Path    string `json:"path" yaml:"path"`
+	DBPath  string `json:"db_path" yaml:"db_path"`
+	WALPath string `json:"wal_path" yaml:"wal_path"`
+	Up      bool   `json:"up" yaml:"up"`
+	Adopted bool   `json:"adopted" yaml:"adopted"`
+}
+
+// AdoptableDisks is a slice of adoptable disks
+type AdoptableDisks []AdoptableDisk
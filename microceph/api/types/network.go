@@ -0,0 +1,33 @@
+package types
+
+// NetworkMigratePost holds the public network the cluster moves to, or asks for an unfinished
+// migration to be rolled back.
+type NetworkMigratePost struct {
+	PublicNetwork string `json:"public_network" yaml:"public_network"`
+	Rollback      bool   `json:"rollback" yaml:"rollback"`
+}
+
+// NetworkAddressPost asks a member for its address on a network.
+type NetworkAddressPost struct {
+	Subnet string `json:"subnet" yaml:"subnet"`
+}
+
+// NetworkAddress is the address of a member on a network.
+type NetworkAddress struct {
+	Address string `json:"address" yaml:"address"`
+}
+
+// NetworkMigrateResult reports the addresses the monitors moved to, along with the problems
+// met once the monitors moved.
+type NetworkMigrateResult struct {
+	PublicNetwork string              `json:"public_network" yaml:"public_network"`
+	Monitors      []NetworkMonAddress `json:"monitors" yaml:"monitors"`
+	Warnings      []string            `json:"warnings" yaml:"warnings"`
+}
+
+// NetworkMonAddress holds the previous and new addresses of a monitor.
+type NetworkMonAddress struct {
+	Name     string `json:"name" yaml:"name"`
+	Previous string `json:"previous" yaml:"previous"`
+	Address  string `json:"address" yaml:"address"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+)
+
+// States of an operation.
+const (
+	OperationStatusRunning   = "running"
+	OperationStatusSuccess   = "success"
+	OperationStatusFailure   = "failure"
+	OperationStatusCancelled = "cancelled"
+)
+
+// Operations is a slice of operations.
+type Operations []Operation
+
+// Operation holds the state of a long-running request executed in the background.
+type Operation struct {
+	ID        string    `json:"id" yaml:"id"`
+	Type      string    `json:"type" yaml:"type"`
+	Location  string    `json:"location" yaml:"location"`
+	Status    string    `json:"status" yaml:"status"`
+	Progress  string    `json:"progress" yaml:"progress"`
+	Result    string    `json:"result" yaml:"result"`
+	Error     string    `json:"error" yaml:"error"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
@@ -6,6 +6,14 @@ type PoolPut struct {
 	Size  int64    `json:"size" yaml:"size"`
 }
 
+// PoolPost holds the parameters of a new replicated pool.
+type PoolPost struct {
+	Name        string `json:"name" yaml:"name"`
+	Size        int64  `json:"size" yaml:"size"`
+	DeviceClass string `json:"device_class" yaml:"device_class"`
+	Application string `json:"application" yaml:"application"`
+}
+
 // Pool represents information about an OSD pool.
 type Pool struct {
 	Pool      string `json:"pool" yaml:"pool"`
@@ -0,0 +1,70 @@
+package types
+
+// Preseed describes how "microceph init --preseed" sets up this member: the cluster to bootstrap
+// or join, along with the disks to add and the services to enable.
+type Preseed struct {
+	Bootstrap      bool   `json:"bootstrap" yaml:"bootstrap"`
+	Token          string `json:"token" yaml:"token"`
+	Name           string `json:"name" yaml:"name"`
+	MicroCephIp    string `json:"microceph-ip" yaml:"microceph-ip"`
+	MonIp          string `json:"mon-ip" yaml:"mon-ip"`
+	PublicNetwork  string `json:"public-network" yaml:"public-network"`
+	ClusterNetwork string `json:"cluster-network" yaml:"cluster-network"`
+	CrushLocation  string `json:"crush-location" yaml:"crush-location"`
+
+	// Initial settings of a bootstrapped cluster. Ceph config keys are of the form [<who>/]<key>.
+	Fsid               string            `json:"fsid" yaml:"fsid"`
+	PoolDefaultSize    int               `json:"pool-default-size" yaml:"pool-default-size"`
+	PoolDefaultMinSize int               `json:"pool-default-min-size" yaml:"pool-default-min-size"`
+	FailureDomain      string            `json:"failure-domain" yaml:"failure-domain"`
+	Msgr2Only          bool              `json:"msgr2-only" yaml:"msgr2-only"`
+	SecureMode         bool              `json:"secure-mode" yaml:"secure-mode"`
+	CephConfig         map[string]string `json:"ceph-config" yaml:"ceph-config"`
+	ClientConfig       map[string]string `json:"client-config" yaml:"client-config"`
+
+	// Join tokens are issued for these members once the cluster is bootstrapped.
+	Tokens []string `json:"tokens" yaml:"tokens"`
+
+	Disks    []PreseedDisk    `json:"disks" yaml:"disks"`
+	Services []PreseedService `json:"services" yaml:"services"`
+}
+
+// PreseedDisk is a disk, or a loop spec like loop,4G,3, to add as OSDs.
+type PreseedDisk struct {
+	Path        string `json:"path" yaml:"path"`
+	Wipe        bool   `json:"wipe" yaml:"wipe"`
+	Encrypt     bool   `json:"encrypt" yaml:"encrypt"`
+	WALDevice   string `json:"wal-device" yaml:"wal-device"`
+	WALWipe     bool   `json:"wal-wipe" yaml:"wal-wipe"`
+	WALEncrypt  bool   `json:"wal-encrypt" yaml:"wal-encrypt"`
+	DBDevice    string `json:"db-device" yaml:"db-device"`
+	DBWipe      bool   `json:"db-wipe" yaml:"db-wipe"`
+	DBEncrypt   bool   `json:"db-encrypt" yaml:"db-encrypt"`
+	DeviceClass string `json:"device-class" yaml:"device-class"`
+}
+
+// PreseedService is a service to enable on this member. The ports and SSL material only apply to rgw.
+type PreseedService struct {
+	Name           string `json:"name" yaml:"name"`
+	Port           int    `json:"port" yaml:"port"`
+	SSLPort        int    `json:"ssl-port" yaml:"ssl-port"`
+	SSLCertificate string `json:"ssl-certificate" yaml:"ssl-certificate"`
+	SSLPrivateKey  string `json:"ssl-private-key" yaml:"ssl-private-key"`
+}
+
+// InitResult reports what "microceph init --preseed" did.
+type InitResult struct {
+	Mode     string              `json:"mode" yaml:"mode"`
+	Name     string              `json:"name" yaml:"name"`
+	Address  string              `json:"address" yaml:"address"`
+	Tokens   map[string]string   `json:"tokens" yaml:"tokens"`
+	Disks    []DiskAddReport     `json:"disks" yaml:"disks"`
+	Services []InitServiceResult `json:"services" yaml:"services"`
+	Error    string              `json:"error" yaml:"error"`
+}
+
+// InitServiceResult holds the outcome of enabling a service.
+type InitServiceResult struct {
+	Name  string `json:"name" yaml:"name"`
+	Error string `json:"error" yaml:"error"`
+}
@@ -0,0 +1,40 @@
+package types
+
+// RecoverMonStorePost holds the options of a mon store recovery.
+type RecoverMonStorePost struct {
+	Force bool `json:"force" yaml:"force"`
+}
+
+// MonStoreCollectPost carries the mon store rebuilt so far to a member, as a gzipped tarball.
+type MonStoreCollectPost struct {
+	Store []byte `json:"store" yaml:"store"`
+}
+
+// MonStoreCollectResult holds the mon store with the cluster maps of the OSDs of a member
+// added, along with the keys of the daemons running on the member.
+type MonStoreCollectResult struct {
+	Store []byte            `json:"store" yaml:"store"`
+	Keys  map[string]string `json:"keys" yaml:"keys"`
+}
+
+// RecoverMonStoreResult reports the outcome of a mon store recovery.
+type RecoverMonStoreResult struct {
+	Mon      string   `json:"mon" yaml:"mon"`
+	Members  []string `json:"members" yaml:"members"`
+	Warnings []string `json:"warnings" yaml:"warnings"`
+}
+
+// RecoverQuorumPost holds the member whose monitor is kept when recovering quorum, and the
+// monitors to remove. Monitors of members which aren't online are removed when none are given.
+type RecoverQuorumPost struct {
+	Keep   string   `json:"keep" yaml:"keep"`
+	Remove []string `json:"remove" yaml:"remove"`
+	Force  bool     `json:"force" yaml:"force"`
+}
+
+// RecoverQuorumResult reports the monitor kept and the ones dropped when recovering quorum.
+type RecoverQuorumResult struct {
+	Mon      string   `json:"mon" yaml:"mon"`
+	Removed  []string `json:"removed" yaml:"removed"`
+	Warnings []string `json:"warnings" yaml:"warnings"`
+}
@@ -17,6 +17,8 @@ type EnableService struct {
 	Name    string `json:"name" yaml:"name"`
 	Wait    bool   `json:"bool" yaml:"bool"`
 	Payload string `json:"payload" yaml:"payload"`
+	// Async has the enablement run as an operation, see the async_operations API extension.
+	Async bool `json:"async" yaml:"async"`
 	// Enable Service passes all additional data as a json payload string.
 }
 
@@ -32,3 +34,36 @@ type RGWService struct {
 type MonitorStatus struct {
 	Addresses []string `json:"addresses" yaml:"addresses"`
 }
+
+// ServicePolicy holds the desired number of mon, mgr and mds services in the cluster.
+// A count of 0 leaves the placement of a service alone. Services of offline members are
+// replaced once these have been offline for ReplaceAfter, a duration such as "30m". Without
+// it, they are only replaced once their member is removed or marked lost.
+type ServicePolicy struct {
+	Mon          int    `json:"mon" yaml:"mon"`
+	Mgr          int    `json:"mgr" yaml:"mgr"`
+	Mds          int    `json:"mds" yaml:"mds"`
+	ReplaceAfter string `json:"replace_after" yaml:"replace_after"`
+}
+
+// Outcomes of a rolling restart on a host.
+const (
+	RestartStatusRestarted = "restarted"
+	RestartStatusFailed    = "failed"
+	RestartStatusSkipped   = "skipped"
+)
+
+// RollingRestartPost requests a restart of a service across the cluster, one host at a time.
+type RollingRestartPost struct {
+	Service string `json:"service" yaml:"service"`
+}
+
+// RollingRestartResults holds the outcome of a rolling restart on each host.
+type RollingRestartResults []RollingRestartResult
+
+// RollingRestartResult holds the outcome of a rolling restart on a host.
+type RollingRestartResult struct {
+	Host   string `json:"host" yaml:"host"`
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error" yaml:"error"`
+}
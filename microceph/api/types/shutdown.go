@@ -0,0 +1,40 @@
+package types
+
+// States of a cluster shutdown.
+const (
+	ShutdownStateStopping = "stopping"
+	ShutdownStateShutdown = "shutdown"
+	ShutdownStateStarting = "starting"
+)
+
+// Outcomes of stopping or starting a service on a member.
+const (
+	PowerStatusStopped = "stopped"
+	PowerStatusStarted = "started"
+	PowerStatusFailed  = "failed"
+	PowerStatusSkipped = "skipped"
+)
+
+// ShutdownPost holds the options of a cluster shutdown or startup.
+type ShutdownPost struct {
+	Force bool `json:"force" yaml:"force"`
+}
+
+// ShutdownStatus is the recorded state of a cluster shutdown, along with the OSD flags it set
+// and the member which ran it.
+type ShutdownStatus struct {
+	State  string   `json:"state" yaml:"state"`
+	Flags  []string `json:"flags" yaml:"flags"`
+	Member string   `json:"member" yaml:"member"`
+}
+
+// PowerResults reports, service by service, the members a shutdown or startup went through.
+type PowerResults []PowerResult
+
+// PowerResult holds the outcome of stopping or starting a service on a member.
+type PowerResult struct {
+	Host    string `json:"host" yaml:"host"`
+	Service string `json:"service" yaml:"service"`
+	Status  string `json:"status" yaml:"status"`
+	Error   string `json:"error" yaml:"error"`
+}
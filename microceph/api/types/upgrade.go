@@ -0,0 +1,58 @@
+package types
+
+// States of a cluster upgrade.
+const (
+	UpgradeStateRunning   = "running"
+	UpgradeStatePaused    = "paused"
+	UpgradeStateCompleted = "completed"
+	UpgradeStateFailed    = "failed"
+)
+
+// Actions on a cluster upgrade.
+const (
+	UpgradeActionStart  = "start"
+	UpgradeActionPause  = "pause"
+	UpgradeActionResume = "resume"
+)
+
+// UpgradePost starts, pauses or resumes the upgrade of the cluster.
+type UpgradePost struct {
+	Action string `json:"action" yaml:"action"`
+}
+
+// MemberVersion holds the Ceph version and snap revision installed on a cluster member.
+type MemberVersion struct {
+	Name     string `json:"name" yaml:"name"`
+	Version  string `json:"version" yaml:"version"`
+	Release  string `json:"release" yaml:"release"`
+	Revision string `json:"revision" yaml:"revision"`
+}
+
+// UpgradeStatus describes the upgrade of the cluster: its state, the version upgraded to,
+// the step it got to and the versions installed on each member.
+type UpgradeStatus struct {
+	State   string          `json:"state" yaml:"state"`
+	Target  string          `json:"target" yaml:"target"`
+	Member  string          `json:"member" yaml:"member"`
+	Step    string          `json:"step" yaml:"step"`
+	Error   string          `json:"error" yaml:"error"`
+	Members []MemberVersion `json:"members" yaml:"members"`
+}
+
+// Outcomes of an upgrade check.
+const (
+	CheckStatusPass = "pass"
+	CheckStatusWarn = "warn"
+	CheckStatusFail = "fail"
+)
+
+// UpgradeCheckResults is the report of the checks run ahead of an upgrade.
+type UpgradeCheckResults []UpgradeCheckResult
+
+// UpgradeCheckResult holds the outcome of a check run ahead of an upgrade.
+type UpgradeCheckResult struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Status      string `json:"status" yaml:"status"`
+	Message     string `json:"message" yaml:"message"`
+}
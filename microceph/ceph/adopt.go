@@ -0,0 +1,545 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/lxd/resources"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/revert"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// BlueStore label descriptions of the devices making up an OSD.
+const (
+	bluestoreMain = "main"
+	bluestoreDB   = "bluefs db"
+	bluestoreWAL  = "bluefs wal"
+)
+
+// bluestoreLabel holds the parts of a BlueStore device label needed to adopt an OSD.
+type bluestoreLabel struct {
+	OSDUUID     string `json:"osd_uuid"`
+	CephFSID    string `json:"ceph_fsid"`
+	Description string `json:"description"`
+	Whoami      string `json:"whoami"`
+	OSDKey      string `json:"osd_key"`
+}
+
+// adoptableOSD is an existing OSD found on this host along with its key.
+type adoptableOSD struct {
+	types.AdoptableDisk
+	key string
+}
+
+// readBluestoreLabel reads the BlueStore label of a device, failing if it has none.
+func readBluestoreLabel(device string) (bluestoreLabel, error) {
+	out, err := processExec.RunCommand("ceph-bluestore-tool", "show-label", "--dev", device)
+	if err != nil {
+		return bluestoreLabel{}, err
+	}
+
+	labels := map[string]bluestoreLabel{}
+	err = json.Unmarshal([]byte(out), &labels)
+	if err != nil {
+		return bluestoreLabel{}, fmt.Errorf("failed to parse label of %s: %w", device, err)
+	}
+
+	for _, label := range labels {
+		return label, nil
+	}
+
+	return bluestoreLabel{}, fmt.Errorf("no label found on %s", device)
+}
+
+// adoptCandidates lists the devices of this host which may hold BlueStore OSDs.
+func adoptCandidates(storage *api.ResourcesStorage) ([]string, error) {
+	devices := []string{}
+	for _, disk := range storage.Disks {
+		devices = append(devices, filepath.Join("/dev", disk.ID))
+		for _, part := range disk.Partitions {
+			devices = append(devices, filepath.Join("/dev", part.ID))
+		}
+	}
+
+	// LVM based OSDs, as deployed by ceph-volume lvm.
+	volumes, err := common.ListLogicalVolumes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list logical volumes: %w", err)
+	}
+
+	return append(devices, volumes...), nil
+}
+
+// discoverOSDs assembles the OSDs of the given Ceph cluster from the BlueStore labels of the given devices.
+func discoverOSDs(devices []string, fsid string) []adoptableOSD {
+	byUUID := map[string]*adoptableOSD{}
+
+	for _, device := range devices {
+		label, err := readBluestoreLabel(device)
+		if err != nil {
+			// Not a BlueStore device.
+			continue
+		}
+
+		if label.CephFSID != fsid {
+			logger.Debugf("Skipping %s, it belongs to Ceph cluster %s", device, label.CephFSID)
+			continue
+		}
+
+		osd, ok := byUUID[label.OSDUUID]
+		if !ok {
+			osd = &adoptableOSD{AdoptableDisk: types.AdoptableDisk{FSID: label.OSDUUID}}
+			byUUID[label.OSDUUID] = osd
+		}
+
+		switch label.Description {
+		case bluestoreMain:
+			id, err := strconv.ParseInt(label.Whoami, 10, 64)
+			if err != nil {
+				logger.Warnf("Skipping %s, invalid OSD id %q", device, label.Whoami)
+				continue
+			}
+			osd.OSD = id
+			osd.Path = device
+			osd.key = label.OSDKey
+		case bluestoreDB:
+			osd.DBPath = device
+		case bluestoreWAL:
+			osd.WALPath = device
+		}
+	}
+
+	ret := []adoptableOSD{}
+	for _, osd := range byUUID {
+		// DB or WAL devices whose data device wasn't found can't be adopted.
+		if osd.Path != "" {
+			ret = append(ret, *osd)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].OSD < ret[j].OSD })
+	return ret
+}
+
+// getUpOSDIDs returns the ids of the OSDs Ceph considers up.
+func getUpOSDIDs() (map[int64]bool, error) {
+	out, err := processExec.RunCommand("ceph", "osd", "dump", "-f", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get osd dump: %w", err)
+	}
+
+	ret := map[int64]bool{}
+	for _, id := range gjson.Get(out, "osds.#(up==1)#.osd").Array() {
+		ret[id.Int()] = true
+	}
+
+	return ret, nil
+}
+
+// findAdoptableOSDs discovers the OSDs of the MicroCeph managed Ceph cluster on this host.
+func findAdoptableOSDs(ctx context.Context, s interfaces.StateInterface) ([]adoptableOSD, error) {
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	storage, err := resources.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	devices, err := adoptCandidates(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	osds := discoverOSDs(devices, config["fsid"])
+
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	recorded := map[int64]bool{}
+	for _, disk := range disks {
+		recorded[disk.OSD] = true
+	}
+
+	up, err := getUpOSDIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range osds {
+		osds[i].Adopted = recorded[osds[i].OSD]
+		osds[i].Up = up[osds[i].OSD]
+	}
+
+	return osds, nil
+}
+
+// ListAdoptableDisks lists the existing OSDs of the cluster found on this host.
+func ListAdoptableDisks(ctx context.Context, s interfaces.StateInterface) (types.AdoptableDisks, error) {
+	osds, err := findAdoptableOSDs(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := types.AdoptableDisks{}
+	for _, osd := range osds {
+		ret = append(ret, osd.AdoptableDisk)
+	}
+
+	return ret, nil
+}
+
+// AdoptOSDs brings existing OSDs of this host under MicroCeph management without re-creating them.
+// All OSDs not adopted yet are taken if none are given.
+func AdoptOSDs(ctx context.Context, s interfaces.StateInterface, ids []int64) (types.AdoptableDisks, error) {
+	osds, err := findAdoptableOSDs(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectAdoptableOSDs(osds, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, osd := range selected {
+		isPresent, err := haveOSDInCeph(osd.OSD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd.OSD, err)
+		}
+
+		if !isPresent {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "osd.%d is not present in Ceph", osd.OSD)
+		}
+	}
+
+	storage, err := resources.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list system disks: %w", err)
+	}
+
+	ret := types.AdoptableDisks{}
+	for _, osd := range selected {
+		err = adoptOSD(ctx, s, osd, storage)
+		if err != nil {
+			return ret, fmt.Errorf("failed to adopt osd.%d: %w", osd.OSD, err)
+		}
+
+		osd.Adopted = true
+		ret = append(ret, osd.AdoptableDisk)
+	}
+
+	if len(ret) == 0 {
+		return ret, nil
+	}
+
+	err = snapRestart("osd", true)
+	if err != nil {
+		return ret, fmt.Errorf("failed to start adopted OSDs: %w", err)
+	}
+
+	err = updateFailureDomain(ctx, s.ClusterState())
+	if err != nil {
+		return ret, err
+	}
+
+	return ret, nil
+}
+
+// selectAdoptableOSDs picks the OSDs to adopt, making sure their previous daemons are stopped.
+func selectAdoptableOSDs(osds []adoptableOSD, ids []int64) ([]adoptableOSD, error) {
+	byID := map[int64]adoptableOSD{}
+	for _, osd := range osds {
+		byID[osd.OSD] = osd
+	}
+
+	selected := []adoptableOSD{}
+	if len(ids) == 0 {
+		for _, osd := range osds {
+			if !osd.Adopted {
+				selected = append(selected, osd)
+			}
+		}
+	} else {
+		for _, id := range ids {
+			osd, ok := byID[id]
+			if !ok {
+				return nil, api.StatusErrorf(http.StatusNotFound, "osd.%d not found on this host", id)
+			}
+
+			if osd.Adopted {
+				return nil, api.StatusErrorf(http.StatusBadRequest, "osd.%d is already managed by MicroCeph", id)
+			}
+
+			selected = append(selected, osd)
+		}
+	}
+
+	for _, osd := range selected {
+		if osd.Up {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "osd.%d is still up, stop its current daemon first", osd.OSD)
+		}
+	}
+
+	return selected, nil
+}
+
+// adoptOSD lays out the data directory of an existing OSD the way bootstrapOSD does and records it.
+func adoptOSD(ctx context.Context, s interfaces.StateInterface, osd adoptableOSD, storage *api.ResourcesStorage) error {
+	osdDataPath := getOSDDataPath(osd.OSD)
+
+	_, err := os.Stat(osdDataPath)
+	if err == nil {
+		return fmt.Errorf("data directory %s exists already", osdDataPath)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = os.MkdirAll(osdDataPath, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create OSD directory: %w", err)
+	}
+
+	revert.Add(func() { os.RemoveAll(osdDataPath) })
+
+	data := types.DiskParameter{Path: osd.Path}
+	err = setStablePath(storage, &data)
+	if err != nil {
+		return fmt.Errorf("failed to set stable disk path: %w", err)
+	}
+
+	// Populate the data directory from the BlueStore label: fsid, whoami, type etc.
+	_, err = processExec.RunCommand("ceph-bluestore-tool", "prime-osd-dir", "--dev", data.Path, "--path", osdDataPath, "--no-mon-config")
+	if err != nil {
+		return fmt.Errorf("failed to populate OSD directory: %w", err)
+	}
+
+	links := map[string]string{"block": data.Path}
+	for suffix, path := range map[string]string{"block.db": osd.DBPath, "block.wal": osd.WALPath} {
+		if path == "" {
+			continue
+		}
+
+		param := types.DiskParameter{Path: path}
+		err = setStablePath(storage, &param)
+		if err != nil {
+			return fmt.Errorf("failed to set stable path for %s: %w", suffix, err)
+		}
+
+		links[suffix] = param.Path
+	}
+
+	for name, target := range links {
+		link := filepath.Join(osdDataPath, name)
+		_ = os.Remove(link)
+
+		err = os.Symlink(target, link)
+		if err != nil {
+			return fmt.Errorf("failed to link %s: %w", name, err)
+		}
+	}
+
+	err = writeAdoptedKeyring(osdDataPath, osd)
+	if err != nil {
+		return err
+	}
+
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return database.CreateDiskWithID(ctx, tx, osd.OSD, s.ClusterState().Name(), data.Path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record disk: %w", err)
+	}
+
+	// Write the stamp file, picked up by the OSD service.
+	err = os.WriteFile(filepath.Join(osdDataPath, "ready"), []byte(""), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write stamp file: %w", err)
+	}
+
+	revert.Success()
+	logger.Infof("Adopted osd.%d on %s", osd.OSD, data.Path)
+	return nil
+}
+
+// writeAdoptedKeyring writes the keyring of an adopted OSD, using the key stored in its
+// label if there is one, the one known to the cluster otherwise.
+func writeAdoptedKeyring(osdDataPath string, osd adoptableOSD) error {
+	key := osd.key
+	if key == "" {
+		out, err := cephRun("auth", "print-key", fmt.Sprintf("osd.%d", osd.OSD))
+		if err != nil {
+			return fmt.Errorf("failed to fetch key of osd.%d: %w", osd.OSD, err)
+		}
+
+		key = strings.TrimSpace(out)
+	}
+
+	keyring := NewCephKeyring(osdDataPath, "keyring")
+	err := keyring.WriteConfig(map[string]any{"name": fmt.Sprintf("osd.%d", osd.OSD), "key": key}, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write keyring of osd.%d: %w", osd.OSD, err)
+	}
+
+	return nil
+}
+
+// parseMonDump returns the addresses of the monitors in a 'ceph mon dump' output, keyed by name.
+func parseMonDump(out string) (map[string]string, error) {
+	ret := map[string]string{}
+
+	for _, mon := range gjson.Get(out, "mons").Array() {
+		name := mon.Get("name").String()
+
+		// The legacy address is of the form ip:port/nonce.
+		addr, _, _ := strings.Cut(mon.Get("public_addr").String(), "/")
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q of mon %s: %w", addr, name, err)
+		}
+
+		ret[name] = host
+	}
+
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no monitors found")
+	}
+
+	return ret, nil
+}
+
+// adoptCluster takes over the control of an existing Ceph cluster. Rather than bootstrapping a
+// new monitor, MicroCeph is pointed at the existing ones and records the cluster fsid and admin key.
+// The control plane is moved over by enabling MicroCeph monitors and managers, which join the
+// existing quorum.
+func adoptCluster(ctx context.Context, s interfaces.StateInterface, data common.BootstrapConfig) error {
+	pathConsts := constants.GetPathConst()
+
+	err := prepareCephBootstrapData(s, &data)
+	if err != nil {
+		return err
+	}
+
+	monHosts := []string{}
+	for _, host := range strings.Split(data.AdoptMonHosts, ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			monHosts = append(monHosts, host)
+		}
+	}
+
+	if len(monHosts) == 0 {
+		return fmt.Errorf("no monitors of the cluster to adopt given")
+	}
+
+	conf := NewCephConfig(constants.CephConfFileName)
+	err = conf.WriteConfig(
+		map[string]any{
+			"fsid":     data.AdoptFsid,
+			"runDir":   pathConsts.RunPath,
+			"monitors": strings.Join(formatIPv6(monHosts), ","),
+			"pubNet":   data.PublicNet,
+			"ipv4":     strings.Contains(data.PublicNet, "."),
+			"ipv6":     strings.Contains(data.PublicNet, ":"),
+		},
+		0644,
+	)
+	if err != nil {
+		return err
+	}
+
+	keyring := NewCephKeyring(pathConsts.ConfPath, "ceph.keyring")
+	err = keyring.WriteConfig(map[string]any{"name": "client.admin", "key": data.AdoptAdminKey}, 0640)
+	if err != nil {
+		return fmt.Errorf("couldn't render admin keyring: %w", err)
+	}
+
+	out, err := cephRun("fsid")
+	if err != nil {
+		return fmt.Errorf("failed to reach the cluster to adopt: %w", err)
+	}
+
+	if strings.TrimSpace(out) != data.AdoptFsid {
+		return fmt.Errorf("cluster fsid %s does not match %s", strings.TrimSpace(out), data.AdoptFsid)
+	}
+
+	out, err = cephRun("mon", "dump", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to get monmap: %w", err)
+	}
+
+	mons, err := parseMonDump(out)
+	if err != nil {
+		return err
+	}
+
+	// New OSDs must not reuse the ids of existing ones.
+	out, err = cephRun("osd", "getmaxosd", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to get max osd: %w", err)
+	}
+
+	maxOSD := gjson.Get(out, "max_osd").Int()
+
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items := map[string]string{
+			"fsid":                 data.AdoptFsid,
+			"keyring.client.admin": data.AdoptAdminKey,
+			"public_network":       data.PublicNet,
+		}
+
+		for name, addr := range mons {
+			items[fmt.Sprintf("mon.host.%s", name)] = addr
+		}
+
+		for key, value := range items {
+			_, err := database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: key, Value: value})
+			if err != nil {
+				return fmt.Errorf("failed to record %s: %w", key, err)
+			}
+		}
+
+		if maxOSD > 0 {
+			return database.ReserveDiskIDs(ctx, tx, maxOSD-1)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = ensureCrushRules()
+	if err != nil {
+		return err
+	}
+
+	err = UpdateConfig(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to re-generate the configuration: %w", err)
+	}
+
+	return startOSDs(s, pathConsts.DataPath)
+}
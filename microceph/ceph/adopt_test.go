@@ -0,0 +1,116 @@
+package ceph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type adoptSuite struct {
+	tests.BaseSuite
+}
+
+func TestAdopt(t *testing.T) {
+	suite.Run(t, new(adoptSuite))
+}
+
+func (s *adoptSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const adoptFsid = "c4d2fb02-0f5d-4c1c-9a6d-5e2f7c4b6e10"
+
+// addShowLabelExpectations expects a label read of the given device.
+func addShowLabelExpectations(r *mocks.Runner, device string, fsid string, osdUUID string, description string, whoami string) {
+	label := fmt.Sprintf(`{"%s": {"osd_uuid": "%s", "size": 10737418240, "description": "%s", "ceph_fsid": "%s", "whoami": "%s", "osd_key": "AQBkey=="}}`,
+		device, osdUUID, description, fsid, whoami)
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", device).Return(label, nil).Once()
+}
+
+func (s *adoptSuite) TestDiscoverOSDs() {
+	r := mocks.NewRunner(s.T())
+	// osd.3 with a DB device
+	addShowLabelExpectations(r, "/dev/ceph-a/block-3", adoptFsid, "uuid-3", bluestoreMain, "3")
+	addShowLabelExpectations(r, "/dev/ceph-b/db-3", adoptFsid, "uuid-3", bluestoreDB, "")
+	// raw osd.1
+	addShowLabelExpectations(r, "/dev/sdc", adoptFsid, "uuid-1", bluestoreMain, "1")
+	// another cluster
+	addShowLabelExpectations(r, "/dev/sdd", "other-fsid", "uuid-9", bluestoreMain, "9")
+	// orphaned WAL device
+	addShowLabelExpectations(r, "/dev/sde", adoptFsid, "uuid-7", bluestoreWAL, "")
+	// no BlueStore
+	r.On("RunCommand", "ceph-bluestore-tool", "show-label", "--dev", "/dev/sda").Return("", fmt.Errorf("unable to read label")).Once()
+	processExec = r
+
+	osds := discoverOSDs([]string{"/dev/sda", "/dev/sdc", "/dev/sdd", "/dev/sde", "/dev/ceph-a/block-3", "/dev/ceph-b/db-3"}, adoptFsid)
+	assert.Len(s.T(), osds, 2)
+
+	assert.Equal(s.T(), int64(1), osds[0].OSD)
+	assert.Equal(s.T(), "/dev/sdc", osds[0].Path)
+	assert.Equal(s.T(), "uuid-1", osds[0].FSID)
+	assert.Equal(s.T(), "AQBkey==", osds[0].key)
+
+	assert.Equal(s.T(), int64(3), osds[1].OSD)
+	assert.Equal(s.T(), "/dev/ceph-a/block-3", osds[1].Path)
+	assert.Equal(s.T(), "/dev/ceph-b/db-3", osds[1].DBPath)
+	assert.Equal(s.T(), "", osds[1].WALPath)
+}
+
+func (s *adoptSuite) TestSelectAdoptableOSDs() {
+	osds := []adoptableOSD{
+		{AdoptableDisk: types.AdoptableDisk{OSD: 1}},
+		{AdoptableDisk: types.AdoptableDisk{OSD: 2, Adopted: true}},
+		{AdoptableDisk: types.AdoptableDisk{OSD: 3}},
+	}
+
+	// all not yet adopted
+	selected, err := selectAdoptableOSDs(osds, nil)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), selected, 2)
+
+	selected, err = selectAdoptableOSDs(osds, []int64{3})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(3), selected[0].OSD)
+
+	_, err = selectAdoptableOSDs(osds, []int64{2})
+	assert.ErrorContains(s.T(), err, "already managed by MicroCeph")
+
+	_, err = selectAdoptableOSDs(osds, []int64{5})
+	assert.ErrorContains(s.T(), err, "not found on this host")
+
+	// running daemons must be stopped first
+	osds[0].Up = true
+	_, err = selectAdoptableOSDs(osds, nil)
+	assert.ErrorContains(s.T(), err, "osd.1 is still up")
+}
+
+func (s *adoptSuite) TestGetUpOSDIDs() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "dump", "-f", "json").Return(`{"osds": [{"osd": 0, "up": 1}, {"osd": 1, "up": 0}, {"osd": 4, "up": 1}]}`, nil).Once()
+	processExec = r
+
+	up, err := getUpOSDIDs()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]bool{0: true, 4: true}, up)
+}
+
+func (s *adoptSuite) TestParseMonDump() {
+	out := `{"fsid": "x", "mons": [
+{"rank": 0, "name": "ceph-a", "public_addr": "10.0.0.1:6789/0"},
+{"rank": 1, "name": "ceph-b", "public_addr": "[fd00::2]:6789/0"}
+]}`
+
+	mons, err := parseMonDump(out)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"ceph-a": "10.0.0.1", "ceph-b": "fd00::2"}, mons)
+
+	_, err = parseMonDump(`{"mons": []}`)
+	assert.Error(s.T(), err)
+}
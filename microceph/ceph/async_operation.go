@@ -0,0 +1,266 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/pborman/uuid"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Types of asynchronous operations.
+const (
+	OperationTypeDiskRemove    = "disk-remove"
+	OperationTypeServiceEnable = "service-enable"
+	OperationTypeReplication   = "replication"
+)
+
+// operationRetention is how long the records of finished operations are kept.
+const operationRetention = 24 * time.Hour
+
+// AsyncOperationFunc is the body of an asynchronous operation, returning its result.
+// It is expected to give up once ctx is cancelled.
+type AsyncOperationFunc func(ctx context.Context, op *AsyncOperation) (string, error)
+
+// AsyncOperation is a handle on an operation executed by this member.
+type AsyncOperation struct {
+	id     string
+	s      interfaces.StateInterface
+	cancel context.CancelFunc
+}
+
+// runningOperations holds the operations currently executed by this member, keyed by ID.
+var runningOperations = struct {
+	sync.Mutex
+	ops map[string]*AsyncOperation
+}{ops: map[string]*AsyncOperation{}}
+
+// operationToAPI converts an operation record into its API representation.
+func operationToAPI(record database.Operation) types.Operation {
+	return types.Operation{
+		ID:        record.UUID,
+		Type:      record.Type,
+		Location:  record.Member,
+		Status:    record.Status,
+		Progress:  record.Progress,
+		Result:    record.Result,
+		Error:     record.Error,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}
+}
+
+// operationStatus derives the final state of an operation from the error it returned.
+func operationStatus(err error, cancelled bool) string {
+	if err == nil {
+		return types.OperationStatusSuccess
+	}
+
+	if cancelled {
+		return types.OperationStatusCancelled
+	}
+
+	return types.OperationStatusFailure
+}
+
+// StartAsyncOperation records a new operation and executes fn in the background.
+func StartAsyncOperation(ctx context.Context, s interfaces.StateInterface, opType string, fn AsyncOperationFunc) (types.Operation, error) {
+	now := time.Now().UTC()
+	record := database.Operation{
+		UUID:      uuid.NewRandom().String(),
+		Member:    s.ClusterState().Name(),
+		Type:      opType,
+		Status:    types.OperationStatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// Drop the records of operations that finished a while ago.
+		err := database.DeleteOperationsBefore(ctx, tx, types.OperationStatusRunning, now.Add(-operationRetention))
+		if err != nil {
+			return err
+		}
+
+		_, err = database.CreateOperation(ctx, tx, record)
+		if err != nil {
+			return fmt.Errorf("failed to record operation: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	// The operation outlives the request which started it.
+	opCtx, cancel := context.WithCancel(context.Background())
+	op := &AsyncOperation{id: record.UUID, s: s, cancel: cancel}
+
+	runningOperations.Lock()
+	runningOperations.ops[op.id] = op
+	runningOperations.Unlock()
+
+	go op.run(opCtx, fn)
+
+	return operationToAPI(record), nil
+}
+
+// run executes the operation and records its outcome.
+func (o *AsyncOperation) run(ctx context.Context, fn AsyncOperationFunc) {
+	defer func() {
+		runningOperations.Lock()
+		delete(runningOperations.ops, o.id)
+		runningOperations.Unlock()
+
+		o.cancel()
+	}()
+
+	result, err := fn(ctx, o)
+	status := operationStatus(err, errors.Is(ctx.Err(), context.Canceled))
+
+	logger.Debugf("Operation %s finished with status %s", o.id, status)
+
+	o.update(func(record *database.Operation) {
+		record.Status = status
+		record.Result = result
+		if err != nil {
+			record.Error = err.Error()
+		}
+	})
+}
+
+// SetProgress records a human readable description of how far the operation got.
+func (o *AsyncOperation) SetProgress(progress string) {
+	o.update(func(record *database.Operation) {
+		record.Progress = progress
+	})
+}
+
+// update applies the given change to the record of the operation.
+func (o *AsyncOperation) update(change func(record *database.Operation)) {
+	// The operation context may be cancelled already, use a fresh one.
+	err := o.s.ClusterState().Database().Transaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
+		record, err := database.GetOperation(ctx, tx, o.id)
+		if err != nil {
+			return err
+		}
+
+		change(record)
+		record.UpdatedAt = time.Now().UTC()
+
+		return database.UpdateOperation(ctx, tx, o.id, *record)
+	})
+	if err != nil {
+		logger.Errorf("Failed to update operation %s: %v", o.id, err)
+	}
+}
+
+// ListAsyncOperations returns all operations recorded in the cluster.
+func ListAsyncOperations(ctx context.Context, s interfaces.StateInterface) (types.Operations, error) {
+	ret := types.Operations{}
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		records, err := database.GetOperations(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch operations: %w", err)
+		}
+
+		for _, record := range records {
+			ret = append(ret, operationToAPI(record))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetAsyncOperation returns the operation with the given ID.
+func GetAsyncOperation(ctx context.Context, s interfaces.StateInterface, id string) (types.Operation, error) {
+	var ret types.Operation
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		record, err := database.GetOperation(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		ret = operationToAPI(*record)
+		return nil
+	})
+
+	return ret, err
+}
+
+// CancelAsyncOperation requests a running operation of this member gives up.
+func CancelAsyncOperation(ctx context.Context, s interfaces.StateInterface, id string) error {
+	record, err := GetAsyncOperation(ctx, s, id)
+	if err != nil {
+		return err
+	}
+
+	if record.Status != types.OperationStatusRunning {
+		return api.StatusErrorf(http.StatusBadRequest, "operation %s is not running", id)
+	}
+
+	if record.Location != s.ClusterState().Name() {
+		return api.StatusErrorf(http.StatusBadRequest, "operation %s is running on %s", id, record.Location)
+	}
+
+	runningOperations.Lock()
+	op, ok := runningOperations.ops[id]
+	runningOperations.Unlock()
+
+	if !ok {
+		return api.StatusErrorf(http.StatusBadRequest, "operation %s has already finished", id)
+	}
+
+	op.cancel()
+
+	return nil
+}
+
+// FailInterruptedOperations marks operations of this member that were cut short by a daemon restart as failed.
+func FailInterruptedOperations(ctx context.Context, s interfaces.StateInterface) {
+	member := s.ClusterState().Name()
+	status := types.OperationStatusRunning
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		records, err := database.GetOperations(ctx, tx, database.OperationFilter{Member: &member, Status: &status})
+		if err != nil {
+			return fmt.Errorf("failed to fetch operations: %w", err)
+		}
+
+		for _, record := range records {
+			logger.Warnf("Operation %s (%s) was interrupted", record.UUID, record.Type)
+
+			record.Status = types.OperationStatusFailure
+			record.Error = "interrupted by a restart of the daemon"
+			record.UpdatedAt = time.Now().UTC()
+
+			err = database.UpdateOperation(ctx, tx, record.UUID, record)
+			if err != nil {
+				return fmt.Errorf("failed to update operation %s: %w", record.UUID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("Failed to check for interrupted operations: %v", err)
+	}
+}
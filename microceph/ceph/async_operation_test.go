@@ -0,0 +1,49 @@
+package ceph
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type asyncOperationSuite struct {
+	tests.BaseSuite
+}
+
+func TestAsyncOperation(t *testing.T) {
+	suite.Run(t, new(asyncOperationSuite))
+}
+
+func (s *asyncOperationSuite) TestOperationStatus() {
+	assert.Equal(s.T(), types.OperationStatusSuccess, operationStatus(nil, false))
+	assert.Equal(s.T(), types.OperationStatusSuccess, operationStatus(nil, true))
+	assert.Equal(s.T(), types.OperationStatusFailure, operationStatus(errors.New("boom"), false))
+	assert.Equal(s.T(), types.OperationStatusCancelled, operationStatus(errors.New("context canceled"), true))
+}
+
+func (s *asyncOperationSuite) TestOperationToAPI() {
+	now := time.Now().UTC()
+	op := operationToAPI(database.Operation{
+		UUID:      "5b4c9bbd-61a7-4a8e-9e6b-1d7a6c0e0f3a",
+		Member:    "node1",
+		Type:      OperationTypeDiskRemove,
+		Status:    types.OperationStatusFailure,
+		Progress:  "removing osd.1",
+		Error:     "timeout (300s) reached while removing osd.1, abort",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	assert.Equal(s.T(), "5b4c9bbd-61a7-4a8e-9e6b-1d7a6c0e0f3a", op.ID)
+	assert.Equal(s.T(), "node1", op.Location)
+	assert.Equal(s.T(), OperationTypeDiskRemove, op.Type)
+	assert.Equal(s.T(), "removing osd.1", op.Progress)
+	assert.Equal(s.T(), now, op.CreatedAt)
+}
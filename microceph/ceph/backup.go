@@ -0,0 +1,1122 @@
+package ceph
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/revert"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// backupFormat is the version of the layout of backup archives.
+const backupFormat = 1
+
+// Entries of a backup archive.
+const (
+	backupManifestFile   = "manifest.json"
+	backupDatabaseFile   = "database.json"
+	backupEncryptionFile = "encryption.json"
+	backupConfDir        = "conf"
+	backupMonmapFile     = "mon/monmap"
+	backupMonStoreDir    = "mon/store"
+)
+
+// maxReleaseSkew is the number of major Ceph releases a member can move forward when restored.
+const maxReleaseSkew = 2
+
+// restoreServiceOrder is the order in which the services of a member are started after a restore.
+var restoreServiceOrder = []string{"mon", "mgr", "osd", "mds", "rgw"}
+
+// backupDatabase holds the microceph tables of the cluster database.
+type backupDatabase struct {
+	Config         []database.ConfigItem       `json:"config"`
+	Services       []database.Service          `json:"services"`
+	Disks          []database.Disk             `json:"disks"`
+	DiskEncryption []database.DiskEncryption   `json:"disk_encryption"`
+	ClientConfig   []database.ClientConfigItem `json:"client_config"`
+	Remotes        []database.Remote           `json:"remotes"`
+}
+
+// backupWriter writes a gzipped tarball, keeping track of the checksums of its entries.
+type backupWriter struct {
+	gz    *gzip.Writer
+	tw    *tar.Writer
+	files map[string]string
+}
+
+func newBackupWriter(out io.Writer) *backupWriter {
+	gz := gzip.NewWriter(out)
+	return &backupWriter{gz: gz, tw: tar.NewWriter(gz), files: map[string]string{}}
+}
+
+// addBytes adds an entry holding data to the archive.
+func (w *backupWriter) addBytes(name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+
+	err := w.tw.WriteHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to backup: %w", name, err)
+	}
+
+	_, err = w.tw.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to backup: %w", name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	w.files[name] = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// addFile adds the file at path to the archive under name.
+func (w *backupWriter) addFile(name string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to add %s to backup: %w", name, err)
+	}
+
+	header.Name = name
+	err = w.tw.WriteHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to backup: %w", name, err)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(w.tw, hash), f)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to backup: %w", name, err)
+	}
+
+	w.files[name] = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// addDir adds the regular files found under dir to the archive, below prefix.
+func (w *backupWriter) addDir(prefix string, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		return w.addFile(filepath.ToSlash(filepath.Join(prefix, rel)), path)
+	})
+}
+
+// finish records the checksums of the entries in the manifest, adds it and closes the archive.
+func (w *backupWriter) finish(manifest types.BackupManifest) error {
+	manifest.Files = w.files
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+
+	err = w.addBytes(backupManifestFile, data)
+	if err != nil {
+		return err
+	}
+
+	delete(w.files, backupManifestFile)
+	return w.close()
+}
+
+// close flushes and closes the archive.
+func (w *backupWriter) close() error {
+	err := w.tw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return w.gz.Close()
+}
+
+// dumpDatabase reads the microceph tables of the cluster database.
+func dumpDatabase(ctx context.Context, s interfaces.StateInterface) (backupDatabase, error) {
+	db := backupDatabase{}
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items, err := database.GetConfigItems(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch config: %w", err)
+		}
+
+		// Secrets left in the database by older releases stay out of backups.
+		for _, item := range items {
+			if item.Key != keyStoreTokenKey {
+				db.Config = append(db.Config, item)
+			}
+		}
+
+		db.Services, err = database.GetServices(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch services: %w", err)
+		}
+
+		db.Disks, err = database.GetDisks(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch disks: %w", err)
+		}
+
+		db.DiskEncryption, err = database.GetDiskEncryptions(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch disk encryptions: %w", err)
+		}
+
+		db.Remotes, err = database.GetRemotes(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch remotes: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return db, err
+	}
+
+	db.ClientConfig, err = database.ClientConfigQuery.GetAll(ctx, s.ClusterState())
+	if err != nil {
+		return db, fmt.Errorf("failed to fetch client config: %w", err)
+	}
+
+	return db, nil
+}
+
+// memberServices returns the services recorded for a member.
+func memberServices(services []database.Service, member string) []string {
+	ret := []string{}
+	for _, service := range services {
+		if service.Member == member {
+			ret = append(ret, service.Service)
+		}
+	}
+
+	return ret
+}
+
+// memberDisks returns the disks recorded for a member.
+func memberDisks(disks []database.Disk, member string) []database.Disk {
+	ret := []database.Disk{}
+	for _, disk := range disks {
+		if disk.Member == member {
+			ret = append(ret, disk)
+		}
+	}
+
+	return ret
+}
+
+// encryptionKeyRefs lists where the keys of the encrypted devices of the OSDs on a member are stored.
+func encryptionKeyRefs(member string, config types.KeyStoreConfig, disks []database.Disk) types.EncryptionKeyRefs {
+	refs := types.EncryptionKeyRefs{}
+	for _, disk := range memberDisks(disks, member) {
+		osd := int64(disk.ID)
+		for _, suffix := range encryptedDevices(getOSDDataPath(osd)) {
+			device := strings.TrimPrefix(suffix, ".")
+			if device == "" {
+				device = "data"
+			}
+
+			refs = append(refs, types.EncryptionKeyRef{
+				OSD:     osd,
+				Device:  device,
+				Backend: config.Backend,
+				URL:     config.URL,
+				Name:    keyName(osd, suffix),
+			})
+		}
+	}
+
+	return refs
+}
+
+// backupConf adds the regular files of the conf directory to the archive. Symlinks
+// point to keyrings kept elsewhere and are recreated when the services are enabled.
+func backupConf(w *backupWriter, confPath string) error {
+	entries, err := os.ReadDir(confPath)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", confPath, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		err = w.addFile(backupConfDir+"/"+entry.Name(), filepath.Join(confPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupMonmap adds the current monmap of the cluster to the archive.
+func backupMonmap(w *backupWriter) error {
+	tmp, err := os.CreateTemp("", "monmap")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	_, err = cephRun("mon", "getmap", "-o", tmp.Name())
+	if err != nil {
+		return fmt.Errorf("failed to fetch monmap: %w", err)
+	}
+
+	return w.addFile(backupMonmapFile, tmp.Name())
+}
+
+// backupMonStore adds a copy of the store of the local monitor to the archive. The monitor is
+// stopped while the copy is taken, which is only done when the others keep quorum without it.
+// Returns a warning when the store was skipped.
+func backupMonStore(w *backupWriter, hostname string) (string, error) {
+	err := checkMonRemoval(hostname)
+	if err != nil {
+		return fmt.Sprintf("mon store skipped, stopping mon.%s would break quorum: %v", hostname, err), nil
+	}
+
+	err = snapStop("mon", false)
+	if err != nil {
+		return "", fmt.Errorf("failed to stop mon.%s: %w", hostname, err)
+	}
+
+	defer func() {
+		err := snapStart("mon", false)
+		if err != nil {
+			logger.Errorf("Failed to start mon.%s after taking a backup of its store: %v", hostname, err)
+		}
+	}()
+
+	monPath := monStorePath(hostname)
+	err = w.addDir(backupMonStoreDir, monPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up mon store: %w", err)
+	}
+
+	return "", nil
+}
+
+// BackupMember writes an archive of the control plane of this member to path: the microceph
+// database, the conf directory, the monmap, the store of the local monitor and references to
+// the keys of encrypted OSDs.
+func BackupMember(ctx context.Context, s interfaces.StateInterface, path string) (types.BackupManifest, error) {
+	if !filepath.IsAbs(path) {
+		return types.BackupManifest{}, api.StatusErrorf(http.StatusBadRequest, "backup path %q must be absolute", path)
+	}
+
+	hostname := s.ClusterState().Name()
+	version, err := GetMemberVersion(s.ClusterState())
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return types.BackupManifest{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	db, err := dumpDatabase(ctx, s)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	manifest := types.BackupManifest{
+		Format:    backupFormat,
+		CreatedAt: time.Now().UTC(),
+		Member:    hostname,
+		FSID:      config["fsid"],
+		Version:   version,
+		Warnings:  []string{},
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return types.BackupManifest{}, api.StatusErrorf(http.StatusConflict, "%s already exists", path)
+	}
+	if err != nil {
+		return types.BackupManifest{}, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	defer f.Close()
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() { os.Remove(path) })
+
+	w := newBackupWriter(f)
+
+	data, err := json.Marshal(db)
+	if err != nil {
+		return types.BackupManifest{}, fmt.Errorf("failed to encode database: %w", err)
+	}
+
+	err = w.addBytes(backupDatabaseFile, data)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	data, err = json.Marshal(encryptionKeyRefs(hostname, keyStoreConfigFromDb(config), db.Disks))
+	if err != nil {
+		return types.BackupManifest{}, fmt.Errorf("failed to encode encryption key references: %w", err)
+	}
+
+	err = w.addBytes(backupEncryptionFile, data)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	err = backupConf(w, constants.GetPathConst().ConfPath)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	err = backupMonmap(w)
+	if err != nil {
+		logger.Warnf("Backup of %s has no monmap: %v", hostname, err)
+		manifest.Warnings = append(manifest.Warnings, fmt.Sprintf("monmap skipped: %v", err))
+	}
+
+	services := memberServices(db.Services, hostname)
+	if slices.Contains(services, "mon") {
+		warning, err := backupMonStore(w, hostname)
+		if err != nil {
+			return types.BackupManifest{}, err
+		}
+
+		if warning != "" {
+			logger.Warnf("Backup of %s: %s", hostname, warning)
+			manifest.Warnings = append(manifest.Warnings, warning)
+		}
+	}
+
+	err = w.finish(manifest)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+
+	manifest.Files = w.files
+	revert.Success()
+	return manifest, nil
+}
+
+// backupEntryPath returns where an archive entry is extracted to, refusing entries which
+// would land outside of dir.
+func backupEntryPath(dir string, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid backup entry %q", name)
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// extractArchive extracts a gzipped tarball of regular files into dir.
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected backup entry %q", header.Name)
+		}
+
+		target, err := backupEntryPath(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(target), 0700)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+	}
+}
+
+// extractBackup extracts the archive at path into dir and returns its manifest.
+func extractBackup(path string, dir string) (types.BackupManifest, error) {
+	manifest := types.BackupManifest{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	defer f.Close()
+
+	err = extractArchive(f, dir)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to extract %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, backupManifestFile))
+	if err != nil {
+		return manifest, fmt.Errorf("backup has no manifest: %w", err)
+	}
+
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to decode backup manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// verifyBackup checks the extracted files of a backup match the checksums of its manifest.
+func verifyBackup(dir string, manifest types.BackupManifest) error {
+	if manifest.Format < 1 || manifest.Format > backupFormat {
+		return fmt.Errorf("unsupported backup format %d, expected at most %d", manifest.Format, backupFormat)
+	}
+
+	_, ok := manifest.Files[backupDatabaseFile]
+	if !ok {
+		return fmt.Errorf("backup has no %s", backupDatabaseFile)
+	}
+
+	for name, sum := range manifest.Files {
+		path, err := backupEntryPath(dir, name)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("backup is missing %s", name)
+		}
+
+		actual := sha256.Sum256(data)
+		if hex.EncodeToString(actual[:]) != sum {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		_, ok := manifest.Files[name]
+		if !ok && name != backupManifestFile {
+			return fmt.Errorf("backup entry %s isn't listed in the manifest", name)
+		}
+
+		return nil
+	})
+}
+
+// majorVersion returns the major number of a Ceph version like "19.2.0".
+func majorVersion(version string) (int, error) {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid ceph version %q", version)
+	}
+
+	return major, nil
+}
+
+// checkBackupVersion checks a backup can be restored with the installed Ceph version: the
+// stores of the daemons can't be downgraded, nor upgraded across too many releases.
+func checkBackupVersion(manifest types.BackupManifest, installed types.MemberVersion) error {
+	backupMajor, err := majorVersion(manifest.Version.Version)
+	if err != nil {
+		return err
+	}
+
+	installedMajor, err := majorVersion(installed.Version)
+	if err != nil {
+		return err
+	}
+
+	if installedMajor < backupMajor {
+		return fmt.Errorf("backup was taken with ceph %s (%s), it can't be restored with the older ceph %s (%s)",
+			manifest.Version.Version, manifest.Version.Release, installed.Version, installed.Release)
+	}
+
+	if installedMajor-backupMajor > maxReleaseSkew {
+		return fmt.Errorf("backup was taken with ceph %s (%s), more than %d releases before the installed ceph %s (%s)",
+			manifest.Version.Version, manifest.Version.Release, maxReleaseSkew, installed.Version, installed.Release)
+	}
+
+	return nil
+}
+
+// Kinds of records a restore changes in the cluster database.
+const (
+	restoreConfig       = "config"
+	restoreService      = "service"
+	restoreDisk         = "disk"
+	restoreRemote       = "remote"
+	restoreClientConfig = "client config"
+)
+
+// restoreChange is a record of the cluster database which a restore adds or overwrites.
+type restoreChange struct {
+	kind   string
+	key    string
+	host   string
+	value  string
+	old    string
+	exists bool
+	id     int64
+}
+
+// String renders the change as a line of a diff.
+func (c restoreChange) String() string {
+	name := fmt.Sprintf("%s %s", c.kind, c.key)
+	if c.host != "" {
+		name = fmt.Sprintf("%s on %s", name, c.host)
+	}
+
+	if c.exists {
+		return fmt.Sprintf("~ %s: %q -> %q", name, c.old, c.value)
+	}
+
+	if c.value == "" {
+		return fmt.Sprintf("+ %s", name)
+	}
+
+	return fmt.Sprintf("+ %s = %q", name, c.value)
+}
+
+// isMemberConfigKey tells whether a config key holds a record of a single member.
+func isMemberConfigKey(key string) bool {
+	return strings.HasPrefix(key, "mon.host.") || strings.HasPrefix(key, database.CrushLocationKey(""))
+}
+
+// planRestore compares a backup against the current cluster database and returns the records to
+// write for hostname: its services, disks, mon host, crush location and client configs. Cluster
+// wide config, remotes and global client configs are only included with clusterConfig.
+func planRestore(current backupDatabase, backup backupDatabase, hostname string, clusterConfig bool) []restoreChange {
+	changes := []restoreChange{}
+
+	config := map[string]string{}
+	for _, item := range current.Config {
+		config[item.Key] = item.Value
+	}
+
+	for _, item := range backup.Config {
+		if isMemberConfigKey(item.Key) {
+			if item.Key != fmt.Sprintf("mon.host.%s", hostname) && item.Key != database.CrushLocationKey(hostname) {
+				continue
+			}
+		} else if !clusterConfig {
+			continue
+		}
+
+		old, exists := config[item.Key]
+		if exists && old == item.Value {
+			continue
+		}
+
+		changes = append(changes, restoreChange{kind: restoreConfig, key: item.Key, value: item.Value, old: old, exists: exists})
+	}
+
+	currentServices := memberServices(current.Services, hostname)
+	for _, service := range memberServices(backup.Services, hostname) {
+		if !slices.Contains(currentServices, service) {
+			changes = append(changes, restoreChange{kind: restoreService, key: service})
+		}
+	}
+
+	currentDisks := memberDisks(current.Disks, hostname)
+	for _, disk := range memberDisks(backup.Disks, hostname) {
+		found := slices.ContainsFunc(currentDisks, func(d database.Disk) bool { return d.Path == disk.Path })
+		if !found {
+			changes = append(changes, restoreChange{kind: restoreDisk, key: disk.Path, value: fmt.Sprintf("osd.%d", disk.ID), id: int64(disk.ID)})
+		}
+	}
+
+	if clusterConfig {
+		for _, remote := range backup.Remotes {
+			found := slices.ContainsFunc(current.Remotes, func(r database.Remote) bool { return r.Name == remote.Name })
+			if !found {
+				changes = append(changes, restoreChange{kind: restoreRemote, key: remote.Name, value: remote.LocalName})
+			}
+		}
+	}
+
+	for _, item := range backup.ClientConfig {
+		if item.Host != hostname && (item.Host != constants.ClientConfigGlobalHostConst || !clusterConfig) {
+			continue
+		}
+
+		change := restoreChange{kind: restoreClientConfig, key: item.Key, host: item.Host, value: item.Value}
+		for _, cur := range current.ClientConfig {
+			if cur.Key == item.Key && cur.Host == item.Host {
+				change.old = cur.Value
+				change.exists = true
+				break
+			}
+		}
+
+		if change.exists && change.old == change.value {
+			continue
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// restoreDatabase writes the changes planned by planRestore for this member to the cluster database.
+func restoreDatabase(ctx context.Context, s interfaces.StateInterface, changes []restoreChange) error {
+	hostname := s.ClusterState().Name()
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, change := range changes {
+			var err error
+			switch change.kind {
+			case restoreConfig:
+				err = upsertConfigItem(ctx, tx, change.key, change.value)
+			case restoreService:
+				_, err = database.CreateService(ctx, tx, database.Service{Member: hostname, Service: change.key})
+			case restoreDisk:
+				err = database.CreateDiskWithID(ctx, tx, change.id, hostname, change.key)
+			case restoreRemote:
+				_, err = database.CreateRemote(ctx, tx, database.Remote{Name: change.key, LocalName: change.value})
+			default:
+				continue
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to restore %s %s: %w", change.kind, change.key, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.kind != restoreClientConfig {
+			continue
+		}
+
+		err = database.ClientConfigQuery.AddNew(ctx, s.ClusterState(), change.key, change.value, change.host)
+		if err != nil {
+			return fmt.Errorf("failed to restore client config %s: %w", change.key, err)
+		}
+	}
+
+	return nil
+}
+
+// confChanges lists the conf files of an extracted backup which differ from the ones in confPath.
+func confChanges(dir string, confPath string) ([]string, error) {
+	changes := []string{}
+	entries, err := os.ReadDir(filepath.Join(dir, backupConfDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return changes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conf files of backup: %w", err)
+	}
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, backupConfDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := os.ReadFile(filepath.Join(confPath, entry.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			changes = append(changes, fmt.Sprintf("+ %s/%s", backupConfDir, entry.Name()))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if string(current) != string(data) {
+			changes = append(changes, fmt.Sprintf("~ %s/%s", backupConfDir, entry.Name()))
+		}
+	}
+
+	return changes, nil
+}
+
+// restoreConf copies the conf files of an extracted backup into confPath.
+func restoreConf(dir string, confPath string) error {
+	entries, err := os.ReadDir(filepath.Join(dir, backupConfDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list conf files of backup: %w", err)
+	}
+
+	for _, entry := range entries {
+		src := filepath.Join(dir, backupConfDir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", src, err)
+		}
+
+		err = os.WriteFile(filepath.Join(confPath, entry.Name()), data, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// monStorePath returns the path of the store of the monitor of a member.
+func monStorePath(hostname string) string {
+	return filepath.Join(constants.GetPathConst().DataPath, "mon", fmt.Sprintf("ceph-%s", hostname))
+}
+
+// restoreMonStore replaces the store of the local monitor with the one of an extracted backup.
+// The current store is kept aside.
+func restoreMonStore(dir string, hostname string) error {
+	monPath := monStorePath(hostname)
+	oldPath := monPath + ".pre-restore"
+
+	err := os.RemoveAll(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", oldPath, err)
+	}
+
+	err = os.Rename(monPath, oldPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to move aside mon store: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(monPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(monPath), err)
+	}
+
+	err = os.Rename(filepath.Join(dir, backupMonStoreDir), monPath)
+	if err != nil {
+		return fmt.Errorf("failed to restore mon store: %w", err)
+	}
+
+	return nil
+}
+
+// putBackMonStore replaces the restored mon store of the member with the one moved aside by
+// restoreMonStore. The restored store is kept next to it for inspection.
+func putBackMonStore(hostname string) error {
+	monPath := monStorePath(hostname)
+	oldPath := monPath + ".pre-restore"
+	failedPath := monPath + ".restore-failed"
+
+	_, err := os.Stat(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to find previous mon store %s: %w", oldPath, err)
+	}
+
+	err = os.RemoveAll(failedPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", failedPath, err)
+	}
+
+	err = os.Rename(monPath, failedPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to move aside restored mon store: %w", err)
+	}
+
+	err = os.Rename(oldPath, monPath)
+	if err != nil {
+		return fmt.Errorf("failed to put back previous mon store %s: %w", oldPath, err)
+	}
+
+	return nil
+}
+
+// checkEncryptionKeys checks the keys of the encrypted OSDs of a backup are still in the key store.
+func checkEncryptionKeys(ctx context.Context, s interfaces.StateInterface, refs types.EncryptionKeyRefs) []string {
+	warnings := []string{}
+	if len(refs) == 0 {
+		return warnings
+	}
+
+	store, err := GetKeyStore(ctx, s)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("can't check the keys of encrypted OSDs: %v", err))
+	}
+
+	for _, ref := range refs {
+		_, err := store.Get(ref.Name)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("key %s of osd.%d isn't available from the %s key store: %v", ref.Name, ref.OSD, ref.Backend, err))
+		}
+	}
+
+	return warnings
+}
+
+// RestoreMember rebuilds the control plane of this member from a backup archive taken on it.
+// The archive is checked against its manifest and the installed Ceph version before anything
+// is changed. Only the records of the member are written to the cluster database, unless
+// cluster wide config is requested. The changes are listed without being made on a dry run.
+// The services of the member are stopped while their files are replaced, and started again
+// should the restore fail.
+func RestoreMember(ctx context.Context, s interfaces.StateInterface, req types.RestorePost) (types.RestoreResult, error) {
+	result := types.RestoreResult{Restored: []string{}, Warnings: []string{}}
+	hostname := s.ClusterState().Name()
+
+	dir, err := os.MkdirTemp("", "microceph-restore")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	manifest, err := extractBackup(req.Path, dir)
+	if err != nil {
+		return result, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	result.Manifest = manifest
+	result.Warnings = append(result.Warnings, manifest.Warnings...)
+
+	err = verifyBackup(dir, manifest)
+	if err != nil {
+		return result, api.StatusErrorf(http.StatusBadRequest, "backup failed integrity check: %v", err)
+	}
+
+	if manifest.Member != hostname {
+		return result, api.StatusErrorf(http.StatusBadRequest, "backup was taken on %s, it can only be restored on that member", manifest.Member)
+	}
+
+	version, err := GetMemberVersion(s.ClusterState())
+	if err != nil {
+		return result, err
+	}
+
+	err = checkBackupVersion(manifest, version)
+	if err != nil {
+		return result, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return result, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	if config["fsid"] != "" && config["fsid"] != manifest.FSID && !req.Force {
+		return result, api.StatusErrorf(http.StatusConflict, "backup belongs to cluster %s but this member is part of %s, use --force to restore it anyway", manifest.FSID, config["fsid"])
+	}
+
+	db := backupDatabase{}
+	data, err := os.ReadFile(filepath.Join(dir, backupDatabaseFile))
+	if err != nil {
+		return result, fmt.Errorf("failed to read backup database: %w", err)
+	}
+
+	err = json.Unmarshal(data, &db)
+	if err != nil {
+		return result, fmt.Errorf("failed to decode backup database: %w", err)
+	}
+
+	refs := types.EncryptionKeyRefs{}
+	data, err = os.ReadFile(filepath.Join(dir, backupEncryptionFile))
+	if err == nil {
+		err = json.Unmarshal(data, &refs)
+		if err != nil {
+			return result, fmt.Errorf("failed to decode encryption key references: %w", err)
+		}
+	}
+
+	current, err := dumpDatabase(ctx, s)
+	if err != nil {
+		return result, err
+	}
+
+	changes := planRestore(current, db, hostname, req.ClusterConfig)
+	for _, change := range changes {
+		result.Restored = append(result.Restored, change.String())
+	}
+
+	files, err := confChanges(dir, constants.GetPathConst().ConfPath)
+	if err != nil {
+		return result, err
+	}
+
+	result.Restored = append(result.Restored, files...)
+
+	_, err = os.Stat(filepath.Join(dir, backupMonStoreDir))
+	hasMonStore := err == nil
+	if hasMonStore && !req.SkipMonStore {
+		result.Restored = append(result.Restored, "~ mon store")
+	}
+
+	services := memberServices(db.Services, hostname)
+	if len(memberDisks(db.Disks, hostname)) > 0 {
+		services = append(services, "osd")
+	}
+
+	if !hasMonStore && slices.Contains(services, "mon") {
+		result.Warnings = append(result.Warnings, "backup has no mon store, the current one is kept")
+	}
+
+	result.Warnings = append(result.Warnings, checkEncryptionKeys(ctx, s, refs)...)
+	if req.DryRun {
+		return result, nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Stop the services of the member in reverse order while their files are replaced.
+	for i := len(restoreServiceOrder) - 1; i >= 0; i-- {
+		service := restoreServiceOrder[i]
+		if !slices.Contains(services, service) {
+			continue
+		}
+
+		err = snapStop(service, false)
+		if err != nil {
+			logger.Warnf("Failed to stop %s before restore: %v", service, err)
+		}
+
+		revert.Add(func() {
+			err := snapStart(service, true)
+			if err != nil {
+				logger.Errorf("Failed to start %s after failed restore: %v", service, err)
+			}
+		})
+	}
+
+	err = restoreDatabase(ctx, s, changes)
+	if err != nil {
+		return result, err
+	}
+
+	err = restoreConf(dir, constants.GetPathConst().ConfPath)
+	if err != nil {
+		return result, err
+	}
+
+	monRestored := false
+	if req.SkipMonStore {
+		logger.Infof("Skipping restore of the mon store of %s", hostname)
+	} else if hasMonStore {
+		err = restoreMonStore(dir, hostname)
+		if err != nil {
+			return result, err
+		}
+
+		revert.Add(func() {
+			err := putBackMonStore(hostname)
+			if err != nil {
+				logger.Errorf("Failed to put back the previous mon store after failed restore: %v", err)
+			}
+		})
+
+		monRestored = true
+	}
+
+	// A restored mon store is only kept once the monitor starts with it, the previous one is put
+	// back otherwise.
+	for _, service := range restoreServiceOrder {
+		if !slices.Contains(services, service) {
+			continue
+		}
+
+		err = snapStart(service, true)
+		if err != nil && service == "mon" && monRestored {
+			return result, fmt.Errorf("failed to start mon after restore, putting back the previous mon store from %s.pre-restore: %w", monStorePath(hostname), err)
+		}
+
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to start %s: %v", service, err))
+		}
+
+		if service == "mon" {
+			revert.Success()
+		}
+	}
+
+	revert.Success()
+
+	return result, nil
+}
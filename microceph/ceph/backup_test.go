@@ -0,0 +1,234 @@
+package ceph
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type backupSuite struct {
+	tests.BaseSuite
+}
+
+func TestBackup(t *testing.T) {
+	suite.Run(t, new(backupSuite))
+}
+
+// writeTestBackup writes an archive holding a database and a conf file to path.
+func (s *backupSuite) writeTestBackup(path string) types.BackupManifest {
+	conf := filepath.Join(s.Tmp, "ceph.conf")
+	err := os.WriteFile(conf, []byte("[global]\nfsid = abc\n"), 0644)
+	assert.NoError(s.T(), err)
+
+	f, err := os.Create(path)
+	assert.NoError(s.T(), err)
+	defer f.Close()
+
+	w := newBackupWriter(f)
+	assert.NoError(s.T(), w.addBytes(backupDatabaseFile, []byte(`{"config":[]}`)))
+	assert.NoError(s.T(), w.addFile(backupConfDir+"/ceph.conf", conf))
+
+	manifest := types.BackupManifest{
+		Format:  backupFormat,
+		Member:  "foohost",
+		FSID:    "abc",
+		Version: types.MemberVersion{Version: "19.2.0", Release: "squid"},
+	}
+	assert.NoError(s.T(), w.finish(manifest))
+
+	return manifest
+}
+
+// TestBackupRoundTrip checks an archive is extracted and verified against its manifest.
+func (s *backupSuite) TestBackupRoundTrip() {
+	path := filepath.Join(s.Tmp, "backup.tar.gz")
+	s.writeTestBackup(path)
+
+	dir := s.T().TempDir()
+	manifest, err := extractBackup(path, dir)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "foohost", manifest.Member)
+	assert.Len(s.T(), manifest.Files, 2)
+	assert.NoError(s.T(), verifyBackup(dir, manifest))
+
+	data, err := os.ReadFile(filepath.Join(dir, "conf", "ceph.conf"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "[global]\nfsid = abc\n", string(data))
+}
+
+// TestVerifyBackupTampered checks modified, missing and unlisted files fail the integrity check.
+func (s *backupSuite) TestVerifyBackupTampered() {
+	path := filepath.Join(s.Tmp, "backup.tar.gz")
+	s.writeTestBackup(path)
+
+	dir := s.T().TempDir()
+	manifest, err := extractBackup(path, dir)
+	assert.NoError(s.T(), err)
+
+	conf := filepath.Join(dir, "conf", "ceph.conf")
+	assert.NoError(s.T(), os.WriteFile(conf, []byte("[global]\nfsid = xyz\n"), 0644))
+	assert.ErrorContains(s.T(), verifyBackup(dir, manifest), "checksum mismatch for conf/ceph.conf")
+
+	assert.NoError(s.T(), os.Remove(conf))
+	assert.ErrorContains(s.T(), verifyBackup(dir, manifest), "backup is missing conf/ceph.conf")
+
+	dir = s.T().TempDir()
+	_, err = extractBackup(path, dir)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(dir, "extra"), []byte("x"), 0644))
+	assert.ErrorContains(s.T(), verifyBackup(dir, manifest), "extra isn't listed in the manifest")
+
+	manifest.Format = backupFormat + 1
+	assert.ErrorContains(s.T(), verifyBackup(dir, manifest), "unsupported backup format")
+}
+
+// TestExtractBackupUnsafeEntry checks entries pointing outside of the extraction directory are refused.
+func (s *backupSuite) TestExtractBackupUnsafeEntry() {
+	path := filepath.Join(s.Tmp, "evil.tar.gz")
+	f, err := os.Create(path)
+	assert.NoError(s.T(), err)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	data := []byte("x")
+	assert.NoError(s.T(), tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "../escape", Mode: 0600, Size: int64(len(data))}))
+	_, err = tw.Write(data)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), tw.Close())
+	assert.NoError(s.T(), gz.Close())
+	assert.NoError(s.T(), f.Close())
+
+	_, err = extractBackup(path, s.T().TempDir())
+	assert.ErrorContains(s.T(), err, "invalid backup entry")
+}
+
+// TestCheckBackupVersion checks backups restore onto the same or a slightly newer Ceph only.
+func (s *backupSuite) TestCheckBackupVersion() {
+	manifest := types.BackupManifest{Version: types.MemberVersion{Version: "18.2.4", Release: "reef"}}
+
+	assert.NoError(s.T(), checkBackupVersion(manifest, types.MemberVersion{Version: "18.2.0", Release: "reef"}))
+	assert.NoError(s.T(), checkBackupVersion(manifest, types.MemberVersion{Version: "19.2.0", Release: "squid"}))
+	assert.NoError(s.T(), checkBackupVersion(manifest, types.MemberVersion{Version: "20.2.0", Release: "tentacle"}))
+
+	err := checkBackupVersion(manifest, types.MemberVersion{Version: "17.2.7", Release: "quincy"})
+	assert.ErrorContains(s.T(), err, "older ceph 17.2.7")
+
+	err = checkBackupVersion(manifest, types.MemberVersion{Version: "21.1.0", Release: "umbrella"})
+	assert.ErrorContains(s.T(), err, "more than 2 releases")
+
+	err = checkBackupVersion(types.BackupManifest{}, types.MemberVersion{Version: "19.2.0"})
+	assert.ErrorContains(s.T(), err, "invalid ceph version")
+}
+
+// TestPlanRestore checks only the records of the member are restored unless cluster config is asked for.
+func (s *backupSuite) TestPlanRestore() {
+	current := backupDatabase{
+		Config: []database.ConfigItem{
+			{Key: "fsid", Value: "abc"},
+			{Key: "mon.host.foohost", Value: "10.0.0.1"},
+			{Key: "mon.host.barhost", Value: "10.0.0.2"},
+		},
+		Services: []database.Service{{Member: "foohost", Service: "mgr"}},
+		Disks:    []database.Disk{{ID: 1, Member: "foohost", Path: "/dev/sdb"}},
+	}
+	backup := backupDatabase{
+		Config: []database.ConfigItem{
+			{Key: "fsid", Value: "abc"},
+			{Key: "public_network", Value: "10.0.0.0/24"},
+			{Key: "mon.host.foohost", Value: "10.0.0.5"},
+			{Key: "mon.host.barhost", Value: "10.0.0.9"},
+			{Key: database.CrushLocationKey("foohost"), Value: "rack=r1"},
+		},
+		Services: []database.Service{
+			{Member: "foohost", Service: "mon"},
+			{Member: "foohost", Service: "mgr"},
+			{Member: "barhost", Service: "mds"},
+		},
+		Disks: []database.Disk{
+			{ID: 1, Member: "foohost", Path: "/dev/sdb"},
+			{ID: 2, Member: "foohost", Path: "/dev/sdc"},
+			{ID: 3, Member: "barhost", Path: "/dev/sdb"},
+		},
+		Remotes: []database.Remote{{Name: "site-b", LocalName: "site-a"}},
+		ClientConfig: []database.ClientConfigItem{
+			{Host: "foohost", Key: "rbd_cache", Value: "true"},
+			{Host: constants.ClientConfigGlobalHostConst, Key: "rbd_cache_size", Value: "64"},
+			{Host: "barhost", Key: "rbd_cache", Value: "false"},
+		},
+	}
+
+	render := func(changes []restoreChange) []string {
+		out := []string{}
+		for _, change := range changes {
+			out = append(out, change.String())
+		}
+
+		return out
+	}
+
+	assert.Equal(s.T(), []string{
+		`~ config mon.host.foohost: "10.0.0.1" -> "10.0.0.5"`,
+		`+ config crush_location.foohost = "rack=r1"`,
+		`+ service mon`,
+		`+ disk /dev/sdc = "osd.2"`,
+		`+ client config rbd_cache on foohost = "true"`,
+	}, render(planRestore(current, backup, "foohost", false)))
+
+	assert.Equal(s.T(), []string{
+		`+ config public_network = "10.0.0.0/24"`,
+		`~ config mon.host.foohost: "10.0.0.1" -> "10.0.0.5"`,
+		`+ config crush_location.foohost = "rack=r1"`,
+		`+ service mon`,
+		`+ disk /dev/sdc = "osd.2"`,
+		`+ remote site-b = "site-a"`,
+		`+ client config rbd_cache on foohost = "true"`,
+		`+ client config rbd_cache_size on ` + constants.ClientConfigGlobalHostConst + ` = "64"`,
+	}, render(planRestore(current, backup, "foohost", true)))
+}
+
+// TestPutBackMonStore checks the mon store moved aside by a restore can be put back.
+func (s *backupSuite) TestPutBackMonStore() {
+	s.CopyCephConfigs()
+
+	monPath := monStorePath("foohost")
+	err := os.MkdirAll(monPath, 0755)
+	assert.NoError(s.T(), err)
+	err = os.WriteFile(filepath.Join(monPath, "store.db"), []byte("live"), 0644)
+	assert.NoError(s.T(), err)
+
+	dir := s.T().TempDir()
+	err = os.MkdirAll(filepath.Join(dir, backupMonStoreDir), 0755)
+	assert.NoError(s.T(), err)
+	err = os.WriteFile(filepath.Join(dir, backupMonStoreDir, "store.db"), []byte("backup"), 0644)
+	assert.NoError(s.T(), err)
+
+	err = restoreMonStore(dir, "foohost")
+	assert.NoError(s.T(), err)
+	data, err := os.ReadFile(filepath.Join(monPath, "store.db"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "backup", string(data))
+
+	err = putBackMonStore("foohost")
+	assert.NoError(s.T(), err)
+	data, err = os.ReadFile(filepath.Join(monPath, "store.db"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "live", string(data))
+	data, err = os.ReadFile(filepath.Join(monPath+".restore-failed", "store.db"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "backup", string(data))
+	assert.NoDirExists(s.T(), monPath+".pre-restore")
+
+	// Nothing to put back a second time.
+	err = putBackMonStore("foohost")
+	assert.ErrorContains(s.T(), err, ".pre-restore")
+}
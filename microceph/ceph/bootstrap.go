@@ -8,6 +8,8 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,10 +37,23 @@ func Bootstrap(ctx context.Context, s interfaces.StateInterface, data common.Boo
 		}
 	}
 
-	// Generate a new FSID.
-	fsid := uuid.NewRandom().String()
+	err := checkBootstrapSettings(data)
+	if err != nil {
+		return err
+	}
+
+	if data.AdoptFsid != "" {
+		return adoptCluster(ctx, s, data)
+	}
+
+	// Generate a new FSID, unless one is given.
+	fsid := data.Fsid
+	if fsid == "" {
+		fsid = uuid.NewRandom().String()
+	}
+
 	conf := NewCephConfig(constants.CephConfFileName)
-	err := prepareCephBootstrapData(s, &data)
+	err = prepareCephBootstrapData(s, &data)
 	if err != nil {
 		return err
 	}
@@ -58,6 +73,9 @@ func Bootstrap(ctx context.Context, s interfaces.StateInterface, data common.Boo
 			"pubNet":   data.PublicNet,
 			"ipv4":     strings.Contains(data.PublicNet, "."),
 			"ipv6":     strings.Contains(data.PublicNet, ":"),
+			// Recorded in the database by populateDatabase, for UpdateConfig to keep rendering them.
+			"msgr2Only":  data.Msgr2Only,
+			"secureMode": data.SecureMode,
 		},
 		0644,
 	)
@@ -77,7 +95,11 @@ func Bootstrap(ctx context.Context, s interfaces.StateInterface, data common.Boo
 		return fmt.Errorf("failed parsing admin keyring: %w", err)
 	}
 
-	err = createMonMap(s, path, fsid, data.MonIp)
+	if data.Msgr2Only {
+		err = createMsgr2MonMap(s, path, fsid, data.MonIp)
+	} else {
+		err = createMonMap(s, path, fsid, data.MonIp)
+	}
 	if err != nil {
 		return err
 	}
@@ -87,21 +109,31 @@ func Bootstrap(ctx context.Context, s interfaces.StateInterface, data common.Boo
 		return err
 	}
 
-	err = initMgr(s, pathConsts.DataPath)
+	// Apply the initial settings before any other daemon starts, the manager creating the
+	// first pool already.
+	err = applyBootstrapSettings(data)
 	if err != nil {
 		return err
 	}
 
-	err = initMds(s, pathConsts.DataPath)
+	err = initMgr(s, pathConsts.DataPath)
 	if err != nil {
 		return err
 	}
 
-	err = enableMsgr2()
+	err = initMds(s, pathConsts.DataPath)
 	if err != nil {
 		return err
 	}
 
+	// The monitor of a msgr2 only cluster has a v2 address already.
+	if !data.Msgr2Only {
+		err = enableMsgr2()
+		if err != nil {
+			return err
+		}
+	}
+
 	err = startOSDs(s, pathConsts.DataPath)
 	if err != nil {
 		return err
@@ -113,13 +145,31 @@ func Bootstrap(ctx context.Context, s interfaces.StateInterface, data common.Boo
 		return err
 	}
 
+	clientKeys := []string{}
+	for key := range data.ClientConfig {
+		clientKeys = append(clientKeys, key)
+	}
+	slices.Sort(clientKeys)
+
+	for _, key := range clientKeys {
+		err = database.ClientConfigQuery.AddNew(ctx, s.ClusterState(), key, data.ClientConfig[key], constants.ClientConfigGlobalHostConst)
+		if err != nil {
+			return fmt.Errorf("failed to record client config %s: %w", key, err)
+		}
+	}
+
 	// setup up crush rules
 	err = ensureCrushRules()
 	if err != nil {
 		return err
 	}
 	// configure the default crush rule for new pools
-	err = setDefaultCrushRule("microceph_auto_osd")
+	failureDomain := data.FailureDomain
+	if failureDomain == "" {
+		failureDomain = "osd"
+	}
+
+	err = setDefaultCrushRule(fmt.Sprintf("microceph_auto_%s", failureDomain))
 	if err != nil {
 		return err
 	}
@@ -156,6 +206,101 @@ func waitForMonitor(timeout time.Duration) error {
 	return fmt.Errorf("timed out waiting for monitor after %v: %w", timeout, lastErr)
 }
 
+// Config keys recording the messenger settings of a cluster, for ceph.conf to carry them.
+const (
+	msgr2OnlyKey  = "msgr2_only"
+	secureModeKey = "secure_mode"
+)
+
+// secureModeOptions are the messenger modes set for all connections to be encrypted.
+var secureModeOptions = []string{
+	"ms_cluster_mode",
+	"ms_service_mode",
+	"ms_client_mode",
+	"ms_mon_cluster_mode",
+	"ms_mon_service_mode",
+	"ms_mon_client_mode",
+}
+
+// checkBootstrapSettings validates the initial settings of a new cluster.
+func checkBootstrapSettings(data common.BootstrapConfig) error {
+	initial := data.Fsid != "" || data.PoolDefaultSize != 0 || data.PoolDefaultMinSize != 0 || data.FailureDomain != "" ||
+		data.Msgr2Only || data.SecureMode || len(data.CephConfig) > 0 || len(data.ClientConfig) > 0
+	if data.AdoptFsid != "" && initial {
+		return fmt.Errorf("initial cluster settings can't be given when adopting a cluster")
+	}
+
+	if data.Fsid != "" && uuid.Parse(data.Fsid) == nil {
+		return fmt.Errorf("invalid fsid %q", data.Fsid)
+	}
+
+	if data.PoolDefaultSize < 0 || data.PoolDefaultMinSize < 0 {
+		return fmt.Errorf("pool default size and min size can't be negative")
+	}
+
+	if data.PoolDefaultSize != 0 && data.PoolDefaultMinSize > data.PoolDefaultSize {
+		return fmt.Errorf("pool default min size %d is larger than the size %d", data.PoolDefaultMinSize, data.PoolDefaultSize)
+	}
+
+	if !slices.Contains([]string{"", "osd", "host"}, data.FailureDomain) {
+		return fmt.Errorf("invalid failure domain %q, use osd or host", data.FailureDomain)
+	}
+
+	for _, item := range data.CephConfig {
+		if item.Who == "" || item.Key == "" {
+			return fmt.Errorf("invalid ceph config %s/%s", item.Who, item.Key)
+		}
+	}
+
+	clientConfigs := GetClientConfigSet()
+	for key := range data.ClientConfig {
+		if _, ok := clientConfigs[key]; !ok {
+			return fmt.Errorf("unknown client config %s", key)
+		}
+	}
+
+	return nil
+}
+
+// bootstrapSettings lists the "ceph config set" calls the initial settings of a new cluster
+// translate to.
+func bootstrapSettings(data common.BootstrapConfig) []common.CephConfigItem {
+	items := []common.CephConfigItem{}
+
+	if data.PoolDefaultSize != 0 {
+		items = append(items, common.CephConfigItem{Who: "global", Key: "osd_pool_default_size", Value: strconv.Itoa(data.PoolDefaultSize)})
+	}
+
+	if data.PoolDefaultMinSize != 0 {
+		items = append(items, common.CephConfigItem{Who: "global", Key: "osd_pool_default_min_size", Value: strconv.Itoa(data.PoolDefaultMinSize)})
+	}
+
+	if data.Msgr2Only {
+		items = append(items, common.CephConfigItem{Who: "global", Key: "ms_bind_msgr1", Value: "false"})
+	}
+
+	if data.SecureMode {
+		for _, key := range secureModeOptions {
+			items = append(items, common.CephConfigItem{Who: "global", Key: key, Value: "secure"})
+		}
+	}
+
+	return append(items, data.CephConfig...)
+}
+
+// applyBootstrapSettings stores the initial settings of a new cluster in the config database of
+// the monitor, for the daemons started afterwards to pick them up.
+func applyBootstrapSettings(data common.BootstrapConfig) error {
+	for _, item := range bootstrapSettings(data) {
+		_, err := cephRun("config", "set", item.Who, item.Key, item.Value)
+		if err != nil {
+			return fmt.Errorf("failed to set %s for %s: %w", item.Key, item.Who, err)
+		}
+	}
+
+	return nil
+}
+
 // setDefaultNetwork configures the cluster network on mon KV store.
 func setDefaultNetwork(cn string, pn string) error {
 	// Cluster Network
@@ -243,6 +388,25 @@ func createKeyrings(confPath string) (string, error) {
 	return path, nil
 }
 
+// createMsgr2MonMap generates the initial monitor map, with a v2 address only.
+func createMsgr2MonMap(s interfaces.StateInterface, path string, fsid string, address string) error {
+	err := genMonmap(filepath.Join(path, "mon.map"), fsid)
+	if err != nil {
+		return fmt.Errorf("failed to generate monitor map: %w", err)
+	}
+
+	if net.ParseIP(address) != nil && strings.Contains(address, ":") {
+		address = fmt.Sprintf("[%s]", address)
+	}
+
+	err = addMonmapV2(filepath.Join(path, "mon.map"), s.ClusterState().Name(), fmt.Sprintf("[v2:%s:3300]", address))
+	if err != nil {
+		return fmt.Errorf("failed to add monitor map: %w", err)
+	}
+
+	return nil
+}
+
 func createMonMap(s interfaces.StateInterface, path string, fsid string, address string) error {
 	// Generate initial monitor map.
 	err := genMonmap(filepath.Join(path, "mon.map"), fsid)
@@ -272,7 +436,12 @@ func initMon(s interfaces.StateInterface, dataPath string, path string) error {
 		return fmt.Errorf("Failed to bootstrap monitor: %w", err)
 	}
 
-	err = snapStart("mon", true)
+	return startMon()
+}
+
+// startMon starts the local monitor and waits for it to respond.
+func startMon() error {
+	err := snapStart("mon", true)
 	if err != nil {
 		return fmt.Errorf("Failed to start monitor: %w", err)
 	}
@@ -354,6 +523,20 @@ func populateDatabase(ctx context.Context, s interfaces.StateInterface, fsid str
 			return fmt.Errorf("failed to record public_network: %w", err)
 		}
 
+		if data.Msgr2Only {
+			_, err = database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: msgr2OnlyKey, Value: "true"})
+			if err != nil {
+				return fmt.Errorf("failed to record %s: %w", msgr2OnlyKey, err)
+			}
+		}
+
+		if data.SecureMode {
+			_, err = database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: secureModeKey, Value: "true"})
+			if err != nil {
+				return fmt.Errorf("failed to record %s: %w", secureModeKey, err)
+			}
+		}
+
 		return nil
 	})
 	return err
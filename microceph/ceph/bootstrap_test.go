@@ -164,3 +164,67 @@ func (s *bootstrapSuite) TestBootstrapDataPrep() {
 		ClusterNet: "",
 	}))
 }
+
+// Test checkBootstrapSettings
+func (s *bootstrapSuite) TestCheckBootstrapSettings() {
+	assert.NoError(s.T(), checkBootstrapSettings(common.BootstrapConfig{
+		Fsid:               "4ebf4a4b-8f4e-4a3c-9c3e-0c1a2b3c4d5e",
+		PoolDefaultSize:    2,
+		PoolDefaultMinSize: 1,
+		FailureDomain:      "host",
+		ClientConfig:       map[string]string{"rbd_cache": "true"},
+	}))
+
+	cases := map[string]common.BootstrapConfig{
+		"can't be given when adopting":       {AdoptFsid: "abc", PoolDefaultSize: 2},
+		"invalid fsid":                       {Fsid: "not-a-uuid"},
+		"can't be negative":                  {PoolDefaultSize: -1},
+		"min size 3 is larger than the size": {PoolDefaultSize: 2, PoolDefaultMinSize: 3},
+		"invalid failure domain":             {FailureDomain: "rack"},
+		"invalid ceph config":                {CephConfig: []common.CephConfigItem{{Who: "global"}}},
+		"unknown client config":              {ClientConfig: map[string]string{"rbd_foo": "1"}},
+	}
+
+	for msg, data := range cases {
+		assert.ErrorContains(s.T(), checkBootstrapSettings(data), msg)
+	}
+}
+
+// Test the initial settings are stored in the config database of the monitor, in order
+func (s *bootstrapSuite) TestApplyBootstrapSettings() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "set", "global", "osd_pool_default_size", "2").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "osd_pool_default_min_size", "1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "ms_bind_msgr1", "false").Return("", nil).Once()
+	for _, key := range secureModeOptions {
+		r.On("RunCommand", "ceph", "config", "set", "global", key, "secure").Return("", nil).Once()
+	}
+	r.On("RunCommand", "ceph", "config", "set", "osd", "osd_memory_target", "4G").Return("", nil).Once()
+	processExec = r
+
+	err := applyBootstrapSettings(common.BootstrapConfig{
+		PoolDefaultSize:    2,
+		PoolDefaultMinSize: 1,
+		Msgr2Only:          true,
+		SecureMode:         true,
+		CephConfig:         []common.CephConfigItem{{Who: "osd", Key: "osd_memory_target", Value: "4G"}},
+	})
+	assert.NoError(s.T(), err)
+
+	// Nothing is set by default.
+	processExec = mocks.NewRunner(s.T())
+	assert.NoError(s.T(), applyBootstrapSettings(common.BootstrapConfig{}))
+}
+
+// Test the monmap of a msgr2 only cluster holds a v2 address only
+func (s *bootstrapSuite) TestCreateMsgr2MonMap() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "monmaptool", "--create", "--fsid", "abc", "/tmp/x/mon.map").Return("ok", nil).Once()
+	r.On("RunCommand", "monmaptool", "--addv", "foohost", "[v2:1.1.1.1:3300]", "/tmp/x/mon.map").Return("ok", nil).Once()
+	r.On("RunCommand", "monmaptool", "--create", "--fsid", "abc", "/tmp/x/mon.map").Return("ok", nil).Once()
+	r.On("RunCommand", "monmaptool", "--addv", "foohost", "[v2:[fd00::1]:3300]", "/tmp/x/mon.map").Return("ok", nil).Once()
+	processExec = r
+
+	assert.NoError(s.T(), createMsgr2MonMap(s.TestStateInterface, "/tmp/x", "abc", "1.1.1.1"))
+	assert.NoError(s.T(), createMsgr2MonMap(s.TestStateInterface, "/tmp/x", "abc", "fd00::1"))
+}
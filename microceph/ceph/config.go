@@ -382,6 +382,8 @@ func UpdateConfig(ctx context.Context, s interfaces.StateInterface) error {
 			"pubNet":              config["public_network"],
 			"ipv4":                strings.Contains(config["public_network"], "."),
 			"ipv6":                strings.Contains(config["public_network"], ":"),
+			"msgr2Only":           config[msgr2OnlyKey] == "true",
+			"secureMode":          config[secureModeKey] == "true",
 			"isCache":             clientConfig.IsCache,
 			"cacheSize":           clientConfig.CacheSize,
 			"isCacheWritethrough": clientConfig.IsCacheWritethrough,
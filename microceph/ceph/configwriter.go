@@ -55,7 +55,14 @@ ms bind ipv4 = {{.ipv4}}
 ms bind ipv6 = {{.ipv6}}
 # https://tracker.ceph.com/issues/70390
 bluestore_elastic_shared_blobs = false
-
+{{if .msgr2Only}}ms_bind_msgr1 = false
+{{end}}{{if .secureMode}}ms_cluster_mode = secure
+ms_service_mode = secure
+ms_client_mode = secure
+ms_mon_cluster_mode = secure
+ms_mon_service_mode = secure
+ms_mon_client_mode = secure
+{{end}}
 [client]
 {{if .isCache}}rbd_cache = {{.isCache}}{{end}}
 {{if .cacheSize}}rbd_cache_size = {{.cacheSize}}{{end}}
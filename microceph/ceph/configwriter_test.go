@@ -56,6 +56,33 @@ func (s *configWriterSuite) TestWriteCephConfig() {
 	assert.Contains(s.T(), string(data), "fsid = fsid1234")
 }
 
+// Test the messenger settings are only rendered when enabled
+func (s *configWriterSuite) TestWriteCephConfigMessenger() {
+	track := constants.GetPathConst
+	defer func() { constants.GetPathConst = track }()
+
+	constants.GetPathConst = func() constants.PathConst {
+		return constants.PathConst{
+			ConfPath: s.Tmp,
+		}
+	}
+
+	config := NewCephConfig(constants.CephConfFileName)
+	err := config.WriteConfig(map[string]any{"fsid": "fsid1234", "msgr2Only": false, "secureMode": false}, 0644)
+	assert.Equal(s.T(), nil, err)
+	data, err := os.ReadFile(config.GetPath())
+	assert.Equal(s.T(), nil, err)
+	assert.NotContains(s.T(), string(data), "ms_bind_msgr1")
+	assert.NotContains(s.T(), string(data), "= secure")
+
+	err = config.WriteConfig(map[string]any{"fsid": "fsid1234", "msgr2Only": true, "secureMode": true}, 0644)
+	assert.Equal(s.T(), nil, err)
+	data, err = os.ReadFile(config.GetPath())
+	assert.Equal(s.T(), nil, err)
+	assert.Contains(s.T(), string(data), "ms_bind_msgr1 = false\n")
+	assert.Contains(s.T(), string(data), "ms_mon_client_mode = secure\n")
+}
+
 // Test ceph config writing
 func (s *configWriterSuite) TestWriteRadosGWNonSSLConfig() {
 	config := newRadosGWConfig(s.Tmp)
@@ -3,6 +3,7 @@ package ceph
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/canonical/microceph/microceph/api/types"
@@ -20,6 +21,21 @@ func addCrushRule(name string, failureDomain string) error {
 	return nil
 }
 
+// addClassCrushRule creates a new crush rule with a given name and failure domain, limited to a device class
+func addClassCrushRule(name string, failureDomain string, class string) error {
+	_, err := processExec.RunCommand("ceph", "osd", "crush", "rule", "create-replicated", name, "default", failureDomain, class)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// classCrushRuleName returns the name of the automatic crush rule for a failure domain and device class
+func classCrushRuleName(failureDomain string, class string) string {
+	return fmt.Sprintf("microceph_auto_%s_%s", failureDomain, class)
+}
+
 // listCrushRules returns a list of crush rule names
 func listCrushRules() ([]string, error) {
 	output, err := processExec.RunCommand("ceph", "osd", "crush", "rule", "ls")
@@ -64,15 +80,20 @@ func getCrushRuleID(name string) (string, error) {
 
 // getPoolsForDomain returns a list of pools that use a given crush failure domain
 func getPoolsForDomain(domain string) ([]string, error) {
+	return getPoolsForRule(fmt.Sprintf("microceph_auto_%s", domain))
+}
+
+// getPoolsForRule returns a list of pools that use a given crush rule
+func getPoolsForRule(rule string) ([]string, error) {
 	var pools []string
 
 	// check if the crush rule exists and bail if not
-	if !haveCrushRule(fmt.Sprintf("microceph_auto_%s", domain)) {
+	if !haveCrushRule(rule) {
 		// nothing to do, bail
 		return pools, nil
 	}
 
-	ruleID, err := getCrushRuleID(fmt.Sprintf("microceph_auto_%s", domain))
+	ruleID, err := getCrushRuleID(rule)
 	if err != nil {
 		return nil, err
 	}
@@ -142,3 +163,104 @@ func ensureCrushRules() error {
 	}
 	return nil
 }
+
+// listDeviceClasses returns the device classes known to the crush map
+func listDeviceClasses() ([]string, error) {
+	output, err := processExec.RunCommand("ceph", "osd", "crush", "class", "ls")
+	if err != nil {
+		return nil, err
+	}
+
+	var classes []string
+	err = json.Unmarshal([]byte(output), &classes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse device classes: %w", err)
+	}
+	return classes, nil
+}
+
+// ensureClassCrushRules sets up the crush rules of a device class for the automatic failure domain handling.
+func ensureClassCrushRules(class string) error {
+	for _, domain := range []string{"osd", "host"} {
+		err := ensureClassCrushRule(domain, class)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureClassCrushRule adds the crush rule of a device class for a failure domain if it does not exist.
+func ensureClassCrushRule(domain string, class string) error {
+	name := classCrushRuleName(domain, class)
+	if haveCrushRule(name) {
+		return nil
+	}
+
+	err := addClassCrushRule(name, domain, class)
+	if err != nil {
+		return fmt.Errorf("failed to add crush rule for device class %s: %w", class, err)
+	}
+	return nil
+}
+
+// ensureRackCrushRule adds the microceph rule with failure domain rack if it does not exist.
+func ensureRackCrushRule() error {
+	if haveCrushRule("microceph_auto_rack") {
+		return nil
+	}
+
+	err := addCrushRule("microceph_auto_rack", "rack")
+	if err != nil {
+		return fmt.Errorf("Failed to add microceph rack crush rule: %w", err)
+	}
+	return nil
+}
+
+// ensureDeviceClass makes sure a device class and its automatic crush rules exist, even before
+// any OSD of the class has registered with it.
+func ensureDeviceClass(class string) error {
+	classes, err := listDeviceClasses()
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(classes, class) {
+		_, err = processExec.RunCommand("ceph", "osd", "crush", "class", "create", class)
+		if err != nil {
+			return fmt.Errorf("failed to create device class %s: %w", class, err)
+		}
+	}
+
+	return ensureClassCrushRules(class)
+}
+
+// getFailureDomain returns the failure domain of the automatic crush rules currently in use,
+// "rack" or "host" once switched to that level and "osd" otherwise.
+func getFailureDomain() (string, error) {
+	currentRule, err := getDefaultCrushRule()
+	if err != nil {
+		return "", err
+	}
+
+	hostRule, err := getCrushRuleID("microceph_auto_host")
+	if err != nil {
+		return "", err
+	}
+
+	if currentRule == hostRule {
+		return "host", nil
+	}
+
+	if haveCrushRule("microceph_auto_rack") {
+		rackRule, err := getCrushRuleID("microceph_auto_rack")
+		if err != nil {
+			return "", err
+		}
+
+		if currentRule == rackRule {
+			return "rack", nil
+		}
+	}
+	return "osd", nil
+}
@@ -0,0 +1,320 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// crushLocationTypes are the crush bucket types a host can be placed in, from the lowest up.
+var crushLocationTypes = []string{"chassis", "rack", "row", "pdu", "pod", "room", "datacenter", "zone", "region"}
+
+// validBucketName matches the crush bucket names accepted in a location.
+var validBucketName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// ParseCrushLocation parses a crush location of the form "rack=r1,row=a" into its buckets,
+// keyed by type.
+func ParseCrushLocation(location string) (map[string]string, error) {
+	ret := map[string]string{}
+	if strings.TrimSpace(location) == "" {
+		return ret, nil
+	}
+
+	for _, part := range strings.Split(location, ",") {
+		bucketType, name, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid crush location %q, expected <type>=<name>", part)
+		}
+
+		if !slices.Contains(crushLocationTypes, bucketType) {
+			return nil, fmt.Errorf("invalid crush bucket type %q, expected one of %s", bucketType, strings.Join(crushLocationTypes, ", "))
+		}
+
+		if !validBucketName.MatchString(name) {
+			return nil, fmt.Errorf("invalid crush bucket name %q", name)
+		}
+
+		_, ok = ret[bucketType]
+		if ok {
+			return nil, fmt.Errorf("crush bucket type %q given more than once", bucketType)
+		}
+
+		ret[bucketType] = name
+	}
+
+	return ret, nil
+}
+
+// FormatCrushLocation renders a crush location in its canonical form, lowest bucket first.
+func FormatCrushLocation(location map[string]string) string {
+	parts := []string{}
+	for _, bucketType := range crushLocationTypes {
+		name, ok := location[bucketType]
+		if ok {
+			parts = append(parts, fmt.Sprintf("%s=%s", bucketType, name))
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// crushLocationArgs returns the location arguments for 'ceph osd crush move' placing a bucket
+// below the buckets of the given location of types from index start up.
+func crushLocationArgs(location map[string]string, start int) []string {
+	args := []string{}
+	for _, bucketType := range crushLocationTypes[start:] {
+		name, ok := location[bucketType]
+		if ok {
+			args = append(args, fmt.Sprintf("%s=%s", bucketType, name))
+		}
+	}
+
+	return append(args, "root=default")
+}
+
+// getCrushHostname returns the name of the host bucket of this member, i.e. the short
+// hostname the OSDs register with.
+func getCrushHostname() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	hostname, _, _ = strings.Cut(hostname, ".")
+	return hostname, nil
+}
+
+// applyCrushLocation moves the host bucket of this member, and the buckets holding it, to the
+// given location. Missing buckets are created.
+func applyCrushLocation(location map[string]string) error {
+	hostname, err := getCrushHostname()
+	if err != nil {
+		return err
+	}
+
+	// Place buckets top down, so that each lands below an already placed parent.
+	for i := len(crushLocationTypes) - 1; i >= 0; i-- {
+		bucketType := crushLocationTypes[i]
+		name, ok := location[bucketType]
+		if !ok {
+			continue
+		}
+
+		err = placeCrushBucket(name, bucketType, crushLocationArgs(location, i+1))
+		if err != nil {
+			return err
+		}
+	}
+
+	return placeCrushBucket(hostname, "host", crushLocationArgs(location, 0))
+}
+
+// placeCrushBucket creates a crush bucket if needed and moves it to the given location.
+func placeCrushBucket(name string, bucketType string, location []string) error {
+	// Adding an existing bucket is a no-op.
+	_, err := processExec.RunCommand("ceph", "osd", "crush", "add-bucket", name, bucketType)
+	if err != nil {
+		return fmt.Errorf("failed to add crush bucket %s: %w", name, err)
+	}
+
+	args := append([]string{"osd", "crush", "move", name}, location...)
+	_, err = processExec.RunCommand("ceph", args...)
+	if err != nil {
+		return fmt.Errorf("failed to move crush bucket %s to %s: %w", name, strings.Join(location, " "), err)
+	}
+
+	return nil
+}
+
+// SetCrushLocation records the crush location of this member and moves its host bucket there.
+// The automatic crush rules only move to the rack failure domain when asked for.
+func SetCrushLocation(ctx context.Context, s interfaces.StateInterface, req types.CrushLocationPut) (types.CrushLocationResult, error) {
+	ret := types.CrushLocationResult{}
+
+	parsed, err := ParseCrushLocation(req.Location)
+	if err != nil {
+		return ret, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	key := database.CrushLocationKey(s.ClusterState().Name())
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if len(parsed) == 0 {
+			err := database.DeleteConfigItem(ctx, tx, key)
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return err
+			}
+
+			return nil
+		}
+
+		return upsertConfigItem(ctx, tx, key, FormatCrushLocation(parsed))
+	})
+	if err != nil {
+		return ret, fmt.Errorf("failed to record crush location: %w", err)
+	}
+
+	err = applyCrushLocation(parsed)
+	if err != nil {
+		return ret, err
+	}
+
+	err = updateFailureDomain(ctx, s.ClusterState())
+	if err != nil {
+		return ret, err
+	}
+
+	if req.RackFailureDomain {
+		err = switchToRackFailureDomain(ctx, s.ClusterState())
+		if err != nil {
+			return ret, err
+		}
+	}
+
+	ret.RackFailureDomainReady, err = isRackFailureDomainReady(ctx, s.ClusterState())
+	if err != nil {
+		return ret, err
+	}
+
+	ret.FailureDomain, err = getFailureDomain()
+	if err != nil {
+		return ret, fmt.Errorf("failed to get failure domain: %w", err)
+	}
+
+	return ret, nil
+}
+
+// recordCrushLocation records the crush location of this member, e.g. as given on join.
+func recordCrushLocation(ctx context.Context, s state.State, location string) error {
+	parsed, err := ParseCrushLocation(location)
+	if err != nil {
+		return err
+	}
+
+	if len(parsed) == 0 {
+		return nil
+	}
+
+	return s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return upsertConfigItem(ctx, tx, database.CrushLocationKey(s.Name()), FormatCrushLocation(parsed))
+	})
+}
+
+// applyLocalCrushLocation moves the host bucket of this member to its recorded crush location,
+// so that its OSDs register there. Members without a recorded location are left alone.
+func applyLocalCrushLocation(ctx context.Context, s state.State) error {
+	locations, err := database.CrushLocationQuery.List(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	location, ok := locations[s.Name()]
+	if !ok {
+		return nil
+	}
+
+	parsed, err := ParseCrushLocation(location)
+	if err != nil {
+		return err
+	}
+
+	return applyCrushLocation(parsed)
+}
+
+// isRackFailureDomainReady checks if the rack failure domain can be used: the OSDs are spread over
+// at least 3 racks and every member holding OSDs sits in one, as OSDs outside of racks would be left
+// unused. Racks of members without OSDs don't count, just as when the rule is created.
+func isRackFailureDomainReady(ctx context.Context, s state.State) (bool, error) {
+	locations, err := database.CrushLocationQuery.List(ctx, s)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	racks := map[string]string{}
+	for member, location := range locations {
+		parsed, err := ParseCrushLocation(location)
+		if err != nil {
+			logger.Warnf("Ignoring invalid crush location of %s: %v", member, err)
+			continue
+		}
+
+		rack, ok := parsed["rack"]
+		if ok {
+			racks[member] = rack
+		}
+	}
+
+	distinct := map[string]bool{}
+	for _, rack := range racks {
+		distinct[rack] = true
+	}
+
+	// No need to look at the OSDs when there aren't 3 racks to begin with.
+	if len(distinct) < 3 {
+		return false, nil
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s)
+	if err != nil {
+		return false, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	used := map[string]bool{}
+	for _, disk := range disks {
+		rack, ok := racks[disk.Location]
+		if !ok {
+			logger.Debugf("Not switching to rack failure domain, %s is not in a rack", disk.Location)
+			return false, nil
+		}
+
+		used[rack] = true
+	}
+
+	return len(used) >= 3, nil
+}
+
+// countRacks returns the number of distinct racks holding the OSDs of members other than the
+// excluded one.
+func countRacks(ctx context.Context, s state.State, exclude string) (int, error) {
+	locations, err := database.CrushLocationQuery.List(ctx, s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	racks := map[string]bool{}
+	for _, disk := range disks {
+		if disk.Location == exclude {
+			continue
+		}
+
+		parsed, err := ParseCrushLocation(locations[disk.Location])
+		if err != nil {
+			logger.Warnf("Ignoring invalid crush location of %s: %v", disk.Location, err)
+			continue
+		}
+
+		rack, ok := parsed["rack"]
+		if ok {
+			racks[rack] = true
+		}
+	}
+
+	return len(racks), nil
+}
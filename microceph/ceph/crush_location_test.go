@@ -0,0 +1,126 @@
+package ceph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type crushLocationSuite struct {
+	tests.BaseSuite
+}
+
+func TestCrushLocation(t *testing.T) {
+	suite.Run(t, new(crushLocationSuite))
+}
+
+func (s *crushLocationSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+func (s *crushLocationSuite) TestParseCrushLocation() {
+	location, err := ParseCrushLocation("row=a, rack=r1")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"rack": "r1", "row": "a"}, location)
+	assert.Equal(s.T(), "rack=r1,row=a", FormatCrushLocation(location))
+
+	location, err = ParseCrushLocation("")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), location)
+
+	_, err = ParseCrushLocation("rack")
+	assert.ErrorContains(s.T(), err, "expected <type>=<name>")
+
+	_, err = ParseCrushLocation("host=foo")
+	assert.ErrorContains(s.T(), err, "invalid crush bucket type")
+
+	_, err = ParseCrushLocation("rack=r 1")
+	assert.ErrorContains(s.T(), err, "invalid crush bucket name")
+
+	_, err = ParseCrushLocation("rack=r1,rack=r2")
+	assert.ErrorContains(s.T(), err, "given more than once")
+}
+
+func (s *crushLocationSuite) TestApplyCrushLocation() {
+	hostname, err := getCrushHostname()
+	assert.NoError(s.T(), err)
+
+	// Buckets are placed top down, the host last.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "add-bucket", "a", "row").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "move", "a", "root=default").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "add-bucket", "r1", "rack").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "move", "r1", "row=a", "root=default").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "add-bucket", hostname, "host").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "move", hostname, "rack=r1", "row=a", "root=default").Return("", nil).Once()
+	processExec = r
+
+	err = applyCrushLocation(map[string]string{"rack": "r1", "row": "a"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *crushLocationSuite) TestIsRackFailureDomainReady() {
+	l := mocks.NewCrushLocationQueryInterface(s.T())
+	database.CrushLocationQuery = l
+	q := mocks.NewOSDQueryInterface(s.T())
+	database.OSDQuery = q
+
+	// Two racks are not enough.
+	l.On("List", mock.Anything, mock.Anything).Return(map[string]string{
+		"node1": "rack=r1",
+		"node2": "rack=r2",
+		"node3": "rack=r2",
+	}, nil).Once()
+
+	ready, err := isRackFailureDomainReady(context.Background(), nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), ready)
+
+	locations := map[string]string{
+		"node1": "rack=r1",
+		"node2": "rack=r2",
+		"node3": "rack=r3,row=a",
+	}
+	l.On("List", mock.Anything, mock.Anything).Return(locations, nil).Times(3)
+
+	// Three racks, but only two of them hold OSDs: the rack of node3 without OSDs doesn't count.
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Location: "node1"},
+		{OSD: 1, Location: "node2"},
+		{OSD: 2, Location: "node2"},
+	}, nil).Once()
+
+	ready, err = isRackFailureDomainReady(context.Background(), nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), ready)
+
+	// Three racks, but node4 holding OSDs is in none.
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Location: "node1"},
+		{OSD: 1, Location: "node2"},
+		{OSD: 2, Location: "node4"},
+	}, nil).Once()
+
+	ready, err = isRackFailureDomainReady(context.Background(), nil)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), ready)
+
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Location: "node1"},
+		{OSD: 1, Location: "node2"},
+		{OSD: 2, Location: "node3"},
+	}, nil).Once()
+
+	ready, err = isRackFailureDomainReady(context.Background(), nil)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), ready)
+}
@@ -0,0 +1,280 @@
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// Crush rule types, as named by the API.
+const (
+	CrushRuleReplicated = "replicated"
+	CrushRuleErasure    = "erasure"
+)
+
+// crushRuleDump holds the parts of 'ceph osd crush rule dump' output we care about.
+type crushRuleDump struct {
+	RuleID   int64  `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Type     int64  `json:"type"`
+	Steps    []struct {
+		Op       string `json:"op"`
+		ItemName string `json:"item_name"`
+		Type     string `json:"type"`
+	} `json:"steps"`
+}
+
+// crushPoolDump holds the parts of 'ceph osd pool ls detail' output we care about.
+type crushPoolDump struct {
+	PoolName  string `json:"pool_name"`
+	CrushRule int64  `json:"crush_rule"`
+}
+
+// crushFailureDomains returns the bucket types a rule can spread replicas over.
+func crushFailureDomains() []string {
+	return append([]string{"osd", "host"}, crushLocationTypes...)
+}
+
+// parseCrushRule turns a dumped crush rule into its API representation.
+func parseCrushRule(dump crushRuleDump) types.CrushRule {
+	rule := types.CrushRule{ID: dump.RuleID, Name: dump.RuleName, Pools: []string{}}
+
+	// Ceph numbers erasure rules 3, replicated ones 1.
+	switch dump.Type {
+	case 1:
+		rule.Type = CrushRuleReplicated
+	case 3:
+		rule.Type = CrushRuleErasure
+	default:
+		rule.Type = strconv.FormatInt(dump.Type, 10)
+	}
+
+	for _, step := range dump.Steps {
+		switch {
+		case step.Op == "take":
+			// Rules limited to a device class take the shadow tree, e.g. default~ssd.
+			rule.Root, rule.DeviceClass, _ = strings.Cut(step.ItemName, "~")
+		case strings.HasPrefix(step.Op, "choose"):
+			rule.FailureDomain = step.Type
+		}
+	}
+
+	return rule
+}
+
+// ListCrushRules returns the crush rules of the cluster along with the pools using them.
+func ListCrushRules() (types.CrushRules, error) {
+	output, err := processExec.RunCommand("ceph", "osd", "crush", "rule", "dump", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump crush rules: %w", err)
+	}
+
+	var dumps []crushRuleDump
+	err = json.Unmarshal([]byte(output), &dumps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse crush rules: %w", err)
+	}
+
+	output, err = processExec.RunCommand("ceph", "osd", "pool", "ls", "detail", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pools: %w", err)
+	}
+
+	var pools []crushPoolDump
+	err = json.Unmarshal([]byte(output), &pools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pools: %w", err)
+	}
+
+	rules := types.CrushRules{}
+	for _, dump := range dumps {
+		rule := parseCrushRule(dump)
+		for _, pool := range pools {
+			if pool.CrushRule == rule.ID {
+				rule.Pools = append(rule.Pools, pool.PoolName)
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// CreateCrushRule adds a replicated or erasure crush rule. Erasure rules are created from an
+// erasure code profile of the same name.
+func CreateCrushRule(req types.CrushRulePost) error {
+	if req.Root == "" {
+		req.Root = "default"
+	}
+
+	if req.FailureDomain == "" {
+		req.FailureDomain = "host"
+	}
+
+	err := validateCrushRule(req)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	if haveCrushRule(req.Name) {
+		return api.StatusErrorf(http.StatusConflict, "crush rule %s already exists", req.Name)
+	}
+
+	if req.DeviceClass != "" {
+		classes, err := listDeviceClasses()
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(classes, req.DeviceClass) {
+			return api.StatusErrorf(http.StatusBadRequest, "no OSDs of device class %s", req.DeviceClass)
+		}
+	}
+
+	if req.Type == CrushRuleErasure {
+		return addErasureCrushRule(req)
+	}
+
+	args := []string{"osd", "crush", "rule", "create-replicated", req.Name, req.Root, req.FailureDomain}
+	if req.DeviceClass != "" {
+		args = append(args, req.DeviceClass)
+	}
+
+	_, err = processExec.RunCommand("ceph", args...)
+	if err != nil {
+		return fmt.Errorf("failed to create crush rule %s: %w", req.Name, err)
+	}
+
+	return nil
+}
+
+// validateCrushRule checks the parameters of a new crush rule.
+func validateCrushRule(req types.CrushRulePost) error {
+	if !validBucketName.MatchString(req.Name) {
+		return fmt.Errorf("invalid crush rule name %q", req.Name)
+	}
+
+	if strings.HasPrefix(req.Name, "microceph_auto_") {
+		return fmt.Errorf("crush rule names starting with microceph_auto_ are reserved")
+	}
+
+	if req.Type != CrushRuleReplicated && req.Type != CrushRuleErasure {
+		return fmt.Errorf("invalid crush rule type %q, expected %s or %s", req.Type, CrushRuleReplicated, CrushRuleErasure)
+	}
+
+	if !validBucketName.MatchString(req.Root) {
+		return fmt.Errorf("invalid crush root %q", req.Root)
+	}
+
+	if !slices.Contains(crushFailureDomains(), req.FailureDomain) {
+		return fmt.Errorf("invalid failure domain %q, expected one of %s", req.FailureDomain, strings.Join(crushFailureDomains(), ", "))
+	}
+
+	if req.DeviceClass != "" && !validDeviceClass.MatchString(req.DeviceClass) {
+		return fmt.Errorf("invalid device class %q", req.DeviceClass)
+	}
+
+	if req.Type == CrushRuleReplicated && (req.K != 0 || req.M != 0) {
+		return fmt.Errorf("k and m only apply to erasure rules")
+	}
+
+	if req.Type == CrushRuleErasure && (req.K < 0 || req.M < 0) {
+		return fmt.Errorf("k and m must not be negative")
+	}
+
+	return nil
+}
+
+// addErasureCrushRule sets up an erasure code profile and creates the crush rule from it.
+func addErasureCrushRule(req types.CrushRulePost) error {
+	if req.K == 0 {
+		req.K = 2
+	}
+
+	if req.M == 0 {
+		req.M = 1
+	}
+
+	args := []string{
+		"osd", "erasure-code-profile", "set", req.Name,
+		fmt.Sprintf("k=%d", req.K),
+		fmt.Sprintf("m=%d", req.M),
+		fmt.Sprintf("crush-root=%s", req.Root),
+		fmt.Sprintf("crush-failure-domain=%s", req.FailureDomain),
+	}
+	if req.DeviceClass != "" {
+		args = append(args, fmt.Sprintf("crush-device-class=%s", req.DeviceClass))
+	}
+
+	_, err := processExec.RunCommand("ceph", args...)
+	if err != nil {
+		return fmt.Errorf("failed to set erasure code profile %s: %w", req.Name, err)
+	}
+
+	_, err = processExec.RunCommand("ceph", "osd", "crush", "rule", "create-erasure", req.Name, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create crush rule %s: %w", req.Name, err)
+	}
+
+	return nil
+}
+
+// DeleteCrushRule removes a crush rule. Rules used by pools, the default rule for new pools
+// and the automatic rules are refused.
+func DeleteCrushRule(name string) error {
+	if strings.HasPrefix(name, "microceph_auto_") {
+		return api.StatusErrorf(http.StatusBadRequest, "crush rule %s is managed by MicroCeph", name)
+	}
+
+	rules, err := ListCrushRules()
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(rules, func(rule types.CrushRule) bool { return rule.Name == name })
+	if idx < 0 {
+		return api.StatusErrorf(http.StatusNotFound, "crush rule %s not found", name)
+	}
+	rule := rules[idx]
+
+	pools, err := getPoolsForRule(name)
+	if err != nil {
+		return fmt.Errorf("failed to list pools using crush rule %s: %w", name, err)
+	}
+
+	if len(pools) > 0 {
+		return api.StatusErrorf(http.StatusConflict, "crush rule %s is in use by pools: %s", name, strings.Join(pools, ", "))
+	}
+
+	defaultRule, err := getDefaultCrushRule()
+	if err != nil {
+		return err
+	}
+
+	if defaultRule == strconv.FormatInt(rule.ID, 10) {
+		return api.StatusErrorf(http.StatusConflict, "crush rule %s is the default for new pools", name)
+	}
+
+	_, err = processExec.RunCommand("ceph", "osd", "crush", "rule", "rm", name)
+	if err != nil {
+		return fmt.Errorf("failed to remove crush rule %s: %w", name, err)
+	}
+
+	if rule.Type == CrushRuleErasure {
+		// Profiles are named after the rules created from them, others are left alone.
+		_, err = processExec.RunCommand("ceph", "osd", "erasure-code-profile", "rm", name)
+		if err != nil {
+			logger.Debugf("Failed to remove erasure code profile %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package ceph
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type crushRulesSuite struct {
+	tests.BaseSuite
+}
+
+func TestCrushRules(t *testing.T) {
+	suite.Run(t, new(crushRulesSuite))
+}
+
+func (s *crushRulesSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const crushRulesDump = `[
+  {"rule_id": 0, "rule_name": "replicated_rule", "type": 1, "steps": [
+    {"op": "take", "item": -1, "item_name": "default"},
+    {"op": "chooseleaf_firstn", "num": 0, "type": "host"},
+    {"op": "emit"}]},
+  {"rule_id": 1, "rule_name": "microceph_auto_osd", "type": 1, "steps": [
+    {"op": "take", "item": -1, "item_name": "default"},
+    {"op": "choose_firstn", "num": 0, "type": "osd"},
+    {"op": "emit"}]},
+  {"rule_id": 4, "rule_name": "fast", "type": 3, "steps": [
+    {"op": "set_chooseleaf_tries", "num": 5},
+    {"op": "take", "item": -2, "item_name": "default~ssd"},
+    {"op": "chooseleaf_indep", "num": 0, "type": "rack"},
+    {"op": "emit"}]}
+]`
+
+const crushRulesPools = `[
+  {"pool_name": ".mgr", "crush_rule": 1},
+  {"pool_name": "rbd", "crush_rule": 1},
+  {"pool_name": "ec", "crush_rule": 4}
+]`
+
+func addCrushRulesListExpectations(r *mocks.Runner) {
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "--format=json").Return(crushRulesDump, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(crushRulesPools, nil).Once()
+}
+
+func (s *crushRulesSuite) TestListCrushRules() {
+	r := mocks.NewRunner(s.T())
+	addCrushRulesListExpectations(r)
+	processExec = r
+
+	rules, err := ListCrushRules()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), types.CrushRules{
+		{ID: 0, Name: "replicated_rule", Type: "replicated", Root: "default", FailureDomain: "host", Pools: []string{}},
+		{ID: 1, Name: "microceph_auto_osd", Type: "replicated", Root: "default", FailureDomain: "osd", Pools: []string{".mgr", "rbd"}},
+		{ID: 4, Name: "fast", Type: "erasure", Root: "default", FailureDomain: "rack", DeviceClass: "ssd", Pools: []string{"ec"}},
+	}, rules)
+}
+
+func (s *crushRulesSuite) TestCreateReplicatedCrushRule() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("replicated_rule", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return(`["hdd", "ssd"]`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "create-replicated", "fast", "default", "rack", "ssd").Return("", nil).Once()
+	processExec = r
+
+	err := CreateCrushRule(types.CrushRulePost{Name: "fast", Type: "replicated", FailureDomain: "rack", DeviceClass: "ssd"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *crushRulesSuite) TestCreateErasureCrushRule() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("replicated_rule", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "erasure-code-profile", "set", "ec", "k=4", "m=2", "crush-root=default", "crush-failure-domain=host").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "create-erasure", "ec", "ec").Return("", nil).Once()
+	processExec = r
+
+	err := CreateCrushRule(types.CrushRulePost{Name: "ec", Type: "erasure", K: 4, M: 2})
+	assert.NoError(s.T(), err)
+}
+
+func (s *crushRulesSuite) TestCreateInvalidCrushRule() {
+	// No mocked runner: nothing must reach ceph.
+	for _, req := range []types.CrushRulePost{
+		{Name: "bad name", Type: "replicated"},
+		{Name: "microceph_auto_rack", Type: "replicated"},
+		{Name: "foo", Type: "mirrored"},
+		{Name: "foo", Type: "replicated", FailureDomain: "shelf"},
+		{Name: "foo", Type: "replicated", K: 2},
+	} {
+		err := CreateCrushRule(req)
+		assert.True(s.T(), api.StatusErrorCheck(err, http.StatusBadRequest), "%v: %v", req, err)
+	}
+}
+
+func (s *crushRulesSuite) TestDeleteCrushRuleInUse() {
+	r := mocks.NewRunner(s.T())
+	addCrushRulesListExpectations(r)
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("replicated_rule\nmicroceph_auto_osd\nfast", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "fast").Return(`{ "rule_id": 4 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(crushRulesPools, nil).Once()
+	processExec = r
+
+	err := DeleteCrushRule("fast")
+	assert.True(s.T(), api.StatusErrorCheck(err, http.StatusConflict))
+	assert.ErrorContains(s.T(), err, "in use by pools: ec")
+}
+
+func (s *crushRulesSuite) TestDeleteCrushRule() {
+	r := mocks.NewRunner(s.T())
+	addCrushRulesListExpectations(r)
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("replicated_rule\nmicroceph_auto_osd\nfast", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "replicated_rule").Return(`{ "rule_id": 0 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(crushRulesPools, nil).Once()
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("1", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "rm", "replicated_rule").Return("", nil).Once()
+	processExec = r
+
+	err := DeleteCrushRule("replicated_rule")
+	assert.NoError(s.T(), err)
+
+	err = DeleteCrushRule("microceph_auto_osd")
+	assert.True(s.T(), api.StatusErrorCheck(err, http.StatusBadRequest))
+}
@@ -0,0 +1,121 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/canonical/lxd/shared/logger"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Health states reported for the device backing an OSD.
+const (
+	DiskHealthOK      = "ok"
+	DiskHealthFailing = "failing"
+	DiskHealthUnknown = "unknown"
+)
+
+// smartctlOutput holds the parts of 'smartctl -H -j' output we care about.
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+}
+
+// GetLocalDiskHealth reports the health of the devices backing the OSDs of this member.
+func GetLocalDiskHealth(ctx context.Context, s interfaces.StateInterface) (types.DiskHealths, error) {
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	ret := types.DiskHealths{}
+	for _, disk := range disks {
+		if disk.Location != s.ClusterState().Name() {
+			continue
+		}
+
+		ret = append(ret, getDiskHealth(disk.OSD, disk.Path))
+	}
+
+	return ret, nil
+}
+
+// getDiskHealth checks a device through SMART, falling back to the device state in sysfs
+// for devices smartctl can't report on.
+func getDiskHealth(osd int64, path string) types.DiskHealth {
+	health := types.DiskHealth{OSD: osd, Path: path, Health: DiskHealthUnknown}
+
+	fileInfo, err := os.Stat(path)
+	if err == nil && fileInfo.Mode().IsRegular() {
+		health.Details = "backed by a loop file"
+		return health
+	}
+
+	ok := smartHealth(&health)
+	if ok {
+		return health
+	}
+
+	state, err := common.GetDeviceState(path)
+	if err != nil {
+		logger.Debugf("Failed to read device state of %s: %v", path, err)
+		return health
+	}
+
+	switch state {
+	case "":
+		health.Details = "no health data available"
+	case "running", "live":
+		health.Health = DiskHealthOK
+		health.Source = "sysfs"
+		health.Details = fmt.Sprintf("device state %s", state)
+	default:
+		health.Health = DiskHealthFailing
+		health.Source = "sysfs"
+		health.Details = fmt.Sprintf("device state %s", state)
+	}
+
+	return health
+}
+
+// smartHealth fills in the SMART overall health of a device, returning false if smartctl
+// has no verdict for it.
+func smartHealth(health *types.DiskHealth) bool {
+	// smartctl flags failing disks through its exit status, the verdict is in its output either way.
+	out, err := processExec.RunCommand("smartctl", "-H", "-j", health.Path)
+	if out == "" {
+		logger.Debugf("Failed to run smartctl on %s: %v", health.Path, err)
+		return false
+	}
+
+	var smart smartctlOutput
+	err = json.Unmarshal([]byte(out), &smart)
+	if err != nil || smart.SmartStatus == nil {
+		return false
+	}
+
+	health.Source = "smartctl"
+	if smart.SmartStatus.Passed {
+		health.Health = DiskHealthOK
+		health.Details = "SMART overall-health passed"
+	} else {
+		health.Health = DiskHealthFailing
+		health.Details = "SMART overall-health failed"
+	}
+
+	if smart.Temperature.Current > 0 {
+		health.Details = fmt.Sprintf("%s, %d°C", health.Details, smart.Temperature.Current)
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type diskHealthSuite struct {
+	tests.BaseSuite
+}
+
+func TestDiskHealth(t *testing.T) {
+	suite.Run(t, new(diskHealthSuite))
+}
+
+func (s *diskHealthSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+func (s *diskHealthSuite) TestSmartPassed() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "-H", "-j", "/dev/sdb").Return(`{"smart_status": {"passed": true}, "temperature": {"current": 31}}`, nil).Once()
+	processExec = r
+
+	health := getDiskHealth(1, "/dev/sdb")
+	assert.Equal(s.T(), DiskHealthOK, health.Health)
+	assert.Equal(s.T(), "smartctl", health.Source)
+	assert.Equal(s.T(), "SMART overall-health passed, 31°C", health.Details)
+}
+
+func (s *diskHealthSuite) TestSmartFailed() {
+	// smartctl exits non-zero for failing disks, but still reports on them.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "-H", "-j", "/dev/sdb").Return(`{"smart_status": {"passed": false}}`, fmt.Errorf("exit status 8")).Once()
+	processExec = r
+
+	health := getDiskHealth(1, "/dev/sdb")
+	assert.Equal(s.T(), DiskHealthFailing, health.Health)
+	assert.Equal(s.T(), "SMART overall-health failed", health.Details)
+}
+
+func (s *diskHealthSuite) TestNoHealthData() {
+	// Neither smartctl nor sysfs know about the device.
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "smartctl", "-H", "-j", "/dev/sdb").Return("", fmt.Errorf("not found")).Once()
+	processExec = r
+
+	health := getDiskHealth(1, "/dev/sdb")
+	assert.Equal(s.T(), DiskHealthUnknown, health.Health)
+	assert.Equal(s.T(), "", health.Source)
+}
+
+func (s *diskHealthSuite) TestLoopFile() {
+	backing := filepath.Join(s.Tmp, "osd-backing.img")
+	os.Create(backing)
+
+	// No mocked runner: smartctl must not be called for loop files.
+	health := getDiskHealth(1, backing)
+	assert.Equal(s.T(), DiskHealthUnknown, health.Health)
+	assert.Equal(s.T(), "backed by a loop file", health.Details)
+}
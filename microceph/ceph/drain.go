@@ -0,0 +1,154 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/canonical/lxd/shared/api"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Drain states reported for an OSD.
+const (
+	DrainStatusActive   = "active"
+	DrainStatusDraining = "draining"
+	DrainStatusDrained  = "drained"
+)
+
+// OSDDfNode holds the usage of a single OSD as reported by 'ceph osd df'.
+type OSDDfNode struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	DeviceClass string  `json:"device_class"`
+	CrushWeight float64 `json:"crush_weight"`
+	Reweight    float64 `json:"reweight"`
+	KB          uint64  `json:"kb"`
+	KBUsed      uint64  `json:"kb_used"`
+	Utilization float64 `json:"utilization"`
+	PGs         int64   `json:"pgs"`
+	Status      string  `json:"status"`
+}
+
+// OSDDf holds the output of 'ceph osd df'.
+type OSDDf struct {
+	Nodes []OSDDfNode `json:"nodes"`
+}
+
+// getOSDDf fetches the usage of all OSDs, keyed by OSD id.
+func getOSDDf() (map[int64]OSDDfNode, error) {
+	out, err := processExec.RunCommand("ceph", "osd", "df", "-f", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get osd df: %w", err)
+	}
+
+	var df OSDDf
+	err = json.Unmarshal([]byte(out), &df)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse osd df: %w", err)
+	}
+
+	ret := make(map[int64]OSDDfNode, len(df.Nodes))
+	for _, node := range df.Nodes {
+		ret[node.ID] = node
+	}
+
+	return ret, nil
+}
+
+// DrainOSDs sets the crush weight of the given OSDs to zero, so that Ceph migrates their data away.
+func DrainOSDs(ctx context.Context, s interfaces.StateInterface, osds []int64) error {
+	if len(osds) == 0 {
+		return fmt.Errorf("no OSDs given")
+	}
+
+	for _, osd := range osds {
+		ok, err := database.OSDQuery.HaveOSD(ctx, s.ClusterState(), osd)
+		if err != nil {
+			return fmt.Errorf("failed to check osd.%d: %w", osd, err)
+		}
+
+		if !ok {
+			return api.StatusErrorf(404, "osd.%d not found", osd)
+		}
+
+		isPresent, err := haveOSDInCeph(osd)
+		if err != nil {
+			return fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+		}
+
+		if !isPresent {
+			return fmt.Errorf("osd.%d is not present in Ceph", osd)
+		}
+	}
+
+	for _, osd := range osds {
+		reweightOSD(ctx, osd, 0)
+	}
+
+	return nil
+}
+
+// drainStatus derives the drain state of an OSD from its usage.
+func drainStatus(node OSDDfNode, safeToDestroy bool) string {
+	if node.CrushWeight > 0 {
+		return DrainStatusActive
+	}
+
+	if node.PGs > 0 || !safeToDestroy {
+		return DrainStatusDraining
+	}
+
+	return DrainStatusDrained
+}
+
+// GetDiskStatus reports weight, PG count and drain state of all OSDs in the cluster.
+func GetDiskStatus(ctx context.Context, s interfaces.StateInterface) (types.DiskStatuses, error) {
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	df, err := getOSDDf()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(types.DiskStatuses, 0, len(disks))
+	for _, disk := range disks {
+		status := types.DiskStatus{
+			OSD:      disk.OSD,
+			Location: disk.Location,
+			Path:     disk.Path,
+		}
+
+		node, ok := df[disk.OSD]
+		if !ok {
+			// Recorded, but not (or no longer) known to Ceph.
+			status.Status = "unknown"
+			ret = append(ret, status)
+			continue
+		}
+
+		status.Weight = node.CrushWeight
+		status.PGs = node.PGs
+		status.Up = node.Status == "up"
+		status.In = node.Reweight > 0
+		status.DeviceClass = node.DeviceClass
+		status.UsedBytes = node.KBUsed * 1024
+		status.Utilization = node.Utilization
+
+		// Only ask Ceph about destroying once the OSD is being drained.
+		if node.CrushWeight == 0 {
+			status.SafeToDestroy = testSafeDestroy(disk.OSD)
+		}
+
+		status.Status = drainStatus(node, status.SafeToDestroy)
+		ret = append(ret, status)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,49 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type drainSuite struct {
+	tests.BaseSuite
+}
+
+func TestDrain(t *testing.T) {
+	suite.Run(t, new(drainSuite))
+}
+
+func (s *drainSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const drainOsdDf = `{"nodes": [
+{"id": 0, "name": "osd.0", "device_class": "ssd", "crush_weight": 0.5, "reweight": 1, "kb": 1048576, "kb_used": 2048, "utilization": 0.19, "pgs": 33, "status": "up"},
+{"id": 1, "name": "osd.1", "device_class": "ssd", "crush_weight": 0, "reweight": 1, "kb": 1048576, "kb_used": 1024, "utilization": 0.09, "pgs": 0, "status": "up"}
+], "summary": {}}`
+
+func (s *drainSuite) TestGetOSDDf() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "df", "-f", "json").Return(drainOsdDf, nil).Once()
+	processExec = r
+
+	df, err := getOSDDf()
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), df, 2)
+	assert.Equal(s.T(), int64(33), df[0].PGs)
+	assert.Equal(s.T(), "ssd", df[1].DeviceClass)
+	assert.Equal(s.T(), uint64(1024), df[1].KBUsed)
+}
+
+func (s *drainSuite) TestDrainStatus() {
+	assert.Equal(s.T(), DrainStatusActive, drainStatus(OSDDfNode{CrushWeight: 0.5, PGs: 10}, false))
+	assert.Equal(s.T(), DrainStatusDraining, drainStatus(OSDDfNode{PGs: 10}, false))
+	assert.Equal(s.T(), DrainStatusDraining, drainStatus(OSDDfNode{}, false))
+	assert.Equal(s.T(), DrainStatusDrained, drainStatus(OSDDfNode{}, true))
+}
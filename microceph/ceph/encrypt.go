@@ -0,0 +1,455 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+
+	apiTypes "github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Steps of converting a plaintext OSD into an encrypted one. The status recorded
+// in the database is the step to (re)start from.
+const (
+	EncryptStatusPending    = "pending"
+	EncryptStatusDraining   = "draining"
+	EncryptStatusRecreating = "recreating"
+	EncryptStatusRecovering = "recovering"
+	EncryptStatusDone       = "done"
+)
+
+var (
+	// encryptRecoveryInterval is the interval at which recovery is polled after re-creating an OSD.
+	encryptRecoveryInterval = 30 * time.Second
+	// encryptRecoveryTimeout is how long the cluster gets to recover after re-creating an OSD. The
+	// conversion is marked failed past it and resumes waiting when retried.
+	encryptRecoveryTimeout = 6 * time.Hour
+)
+
+// encryptWorker tracks the background conversion loop of this member.
+var encryptWorker struct {
+	sync.Mutex
+	running bool
+	again   bool
+}
+
+// validateEncryptOSD checks that an OSD on this member can be converted and returns its device path.
+func validateEncryptOSD(disks apiTypes.Disks, member string, osd int64) (string, error) {
+	var disk *apiTypes.Disk
+	for i := range disks {
+		if disks[i].OSD == osd {
+			disk = &disks[i]
+			break
+		}
+	}
+
+	if disk == nil {
+		return "", api.StatusErrorf(404, "osd.%d not found", osd)
+	}
+
+	if disk.Location != member {
+		return "", fmt.Errorf("osd.%d is located on %s, not %s", osd, disk.Location, member)
+	}
+
+	info, err := os.Stat(disk.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat osd.%d device %s: %w", osd, disk.Path, err)
+	}
+
+	if info.Mode()&os.ModeDevice == 0 {
+		return "", fmt.Errorf("osd.%d is not backed by a block device, loop file OSDs can't be encrypted", osd)
+	}
+
+	osdPath := getOSDDataPath(osd)
+	if len(encryptedDevices(osdPath)) > 0 {
+		return "", fmt.Errorf("osd.%d is already encrypted", osd)
+	}
+
+	for _, link := range []string{"block.wal", "block.db"} {
+		_, err = os.Lstat(filepath.Join(osdPath, link))
+		if err == nil {
+			return "", fmt.Errorf("osd.%d uses a separate WAL/DB device, converting it is not supported", osd)
+		}
+	}
+
+	return disk.Path, nil
+}
+
+// EncryptOSDs records the given OSDs of this member for conversion to encrypted OSDs
+// and starts converting them, one at a time, in the background.
+// Conversions that previously failed are resumed from the step they failed at.
+func EncryptOSDs(ctx context.Context, s interfaces.StateInterface, osds []int64) error {
+	if len(osds) == 0 {
+		return fmt.Errorf("no OSDs given")
+	}
+
+	err := checkEncryptSupport()
+	if err != nil {
+		return fmt.Errorf("encryption unsupported on this machine: %w", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	member := s.ClusterState().Name()
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, osd := range osds {
+			record, err := database.GetDiskEncryption(ctx, tx, osd)
+			if err != nil && !api.StatusErrorCheck(err, 404) {
+				return err
+			}
+
+			// Retry a failed or resume an interrupted conversion.
+			if record != nil && record.Status != EncryptStatusDone {
+				record.Error = ""
+				err = database.UpdateDiskEncryption(ctx, tx, osd, *record)
+				if err != nil {
+					return fmt.Errorf("failed to update conversion of osd.%d: %w", osd, err)
+				}
+
+				continue
+			}
+
+			_, err = validateEncryptOSD(disks, member, osd)
+			if err != nil {
+				return err
+			}
+
+			if record != nil {
+				err = database.DeleteDiskEncryption(ctx, tx, osd)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = database.CreateDiskEncryption(ctx, tx, database.DiskEncryption{Member: member, OSD: osd, Status: EncryptStatusPending})
+			if err != nil {
+				return fmt.Errorf("failed to record conversion of osd.%d: %w", osd, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	startDiskEncryption(s)
+	return nil
+}
+
+// ListDiskEncryptions returns the state of all OSD encryption conversions in the cluster.
+func ListDiskEncryptions(ctx context.Context, s interfaces.StateInterface) (apiTypes.DiskEncryptions, error) {
+	var records []database.DiskEncryption
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		records, err = database.GetDiskEncryptions(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch disk encryption records: %w", err)
+	}
+
+	ret := make(apiTypes.DiskEncryptions, 0, len(records))
+	for _, record := range records {
+		ret = append(ret, apiTypes.DiskEncryption{
+			OSD:      record.OSD,
+			Location: record.Member,
+			Status:   record.Status,
+			Error:    record.Error,
+		})
+	}
+
+	return ret, nil
+}
+
+// ResumeDiskEncryption picks up conversions of this member interrupted by a daemon restart.
+func ResumeDiskEncryption(ctx context.Context, s interfaces.StateInterface) {
+	records, err := ListDiskEncryptions(ctx, s)
+	if err != nil {
+		logger.Warnf("Failed to check for interrupted disk encryption: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		if record.Location == s.ClusterState().Name() && record.Status != EncryptStatusDone && len(record.Error) == 0 {
+			logger.Infof("Resuming encryption of osd.%d from step %s", record.OSD, record.Status)
+			startDiskEncryption(s)
+			return
+		}
+	}
+}
+
+// startDiskEncryption runs the conversion loop in the background, unless it is already running.
+func startDiskEncryption(s interfaces.StateInterface) {
+	encryptWorker.Lock()
+	defer encryptWorker.Unlock()
+
+	if encryptWorker.running {
+		// Have the running loop look for work once more before it exits.
+		encryptWorker.again = true
+		return
+	}
+
+	encryptWorker.running = true
+	go func() {
+		for {
+			runDiskEncryption(context.Background(), s)
+
+			encryptWorker.Lock()
+			if !encryptWorker.again {
+				encryptWorker.running = false
+				encryptWorker.Unlock()
+				return
+			}
+			encryptWorker.again = false
+			encryptWorker.Unlock()
+		}
+	}()
+}
+
+// runDiskEncryption converts the pending OSDs of this member one after the other.
+// It stops at the first failure, leaving the remaining OSDs untouched.
+func runDiskEncryption(ctx context.Context, s interfaces.StateInterface) {
+	member := s.ClusterState().Name()
+
+	for {
+		var records []database.DiskEncryption
+		err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			var err error
+			records, err = database.GetDiskEncryptions(ctx, tx, database.DiskEncryptionFilter{Member: &member})
+			return err
+		})
+		if err != nil {
+			logger.Errorf("Failed to fetch disk encryption records: %v", err)
+			return
+		}
+
+		var next *database.DiskEncryption
+		for i := range records {
+			if records[i].Status == EncryptStatusDone {
+				continue
+			}
+
+			if len(records[i].Error) > 0 {
+				logger.Warnf("Encryption of osd.%d failed, not converting further OSDs: %s", records[i].OSD, records[i].Error)
+				return
+			}
+
+			if next == nil {
+				next = &records[i]
+			}
+		}
+
+		if next == nil {
+			return
+		}
+
+		record := *next
+		update := func(status string, convErr error) error {
+			record.Status = status
+			record.Error = ""
+			if convErr != nil {
+				record.Error = convErr.Error()
+			}
+
+			return s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+				return database.UpdateDiskEncryption(ctx, tx, record.OSD, record)
+			})
+		}
+
+		err = encryptOSD(ctx, s, record.OSD, record.Status, func(status string) error { return update(status, nil) })
+		if err != nil {
+			logger.Errorf("Failed to encrypt osd.%d: %v", record.OSD, err)
+			updErr := update(record.Status, err)
+			if updErr != nil {
+				logger.Errorf("Failed to record failed encryption of osd.%d: %v", record.OSD, updErr)
+			}
+
+			return
+		}
+
+		logger.Infof("osd.%d is now encrypted", record.OSD)
+	}
+}
+
+// encryptOSD walks an OSD through the conversion steps, starting at status.
+// setStatus is called to record each step once it has been reached.
+func encryptOSD(ctx context.Context, s interfaces.StateInterface, osd int64, status string, setStatus func(string) error) error {
+	for status != EncryptStatusDone {
+		var next string
+		var err error
+
+		switch status {
+		case EncryptStatusPending:
+			next, err = encryptStepStart(osd)
+		case EncryptStatusDraining:
+			next, err = encryptStepDrain(osd)
+		case EncryptStatusRecreating:
+			next, err = encryptStepRecreate(ctx, s, osd)
+		case EncryptStatusRecovering:
+			next, err = EncryptStatusDone, encryptStepRecover(ctx)
+		default:
+			return fmt.Errorf("unknown disk encryption status %q", status)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = setStatus(next)
+		if err != nil {
+			return fmt.Errorf("failed to record progress of osd.%d: %w", osd, err)
+		}
+
+		status = next
+	}
+
+	return nil
+}
+
+// encryptStepStart starts draining the data off the OSD.
+func encryptStepStart(osd int64) (string, error) {
+	isPresent, err := haveOSDInCeph(osd)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+	}
+
+	if isPresent {
+		reweightOSD(context.Background(), osd, 0)
+	}
+
+	return EncryptStatusDraining, nil
+}
+
+// encryptStepDrain waits for the OSD to be safe to destroy and then purges it.
+func encryptStepDrain(osd int64) (string, error) {
+	isPresent, err := haveOSDInCeph(osd)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+	}
+
+	// Already purged by a previous, interrupted run.
+	if !isPresent {
+		return EncryptStatusRecreating, nil
+	}
+
+	err = safetyCheckDestroy(osd)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the device class in the data directory, the OSD is recreated with it.
+	class, err := getOSDDeviceClass(osd)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeDeviceClass(getOSDDataPath(osd), class)
+	if err != nil {
+		return "", err
+	}
+
+	err = outDownOSD(osd)
+	if err != nil {
+		return "", err
+	}
+
+	// stop the OSD service, but don't fail if it's not running
+	_ = killOSD(osd)
+
+	err = purgeOSD(osd)
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptStatusRecreating, nil
+}
+
+// encryptStepRecreate re-creates the OSD with the same id on its device, this time encrypted.
+func encryptStepRecreate(ctx context.Context, s interfaces.StateInterface, osd int64) (string, error) {
+	isPresent, err := haveOSDInCeph(osd)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+	}
+
+	// A previous attempt already brought the OSD back, drain it again before starting over.
+	if isPresent {
+		reweightOSD(ctx, osd, 0)
+		return EncryptStatusDraining, nil
+	}
+
+	path, err := database.OSDQuery.Path(ctx, s.ClusterState(), osd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get path of osd.%d: %w", osd, err)
+	}
+
+	store, err := GetKeyStore(ctx, s)
+	if err != nil {
+		return "", fmt.Errorf("failed to get key store: %w", err)
+	}
+
+	// Clean up leftovers of an interrupted attempt.
+	mapper := fmt.Sprintf("luksosd-%d", osd)
+	_, err = os.Stat(filepath.Join("/dev/mapper", mapper))
+	if err == nil {
+		_, err = processExec.RunCommand("cryptsetup", "luksClose", mapper)
+		if err != nil {
+			return "", fmt.Errorf("failed to close %s: %w", mapper, err)
+		}
+	}
+
+	data := apiTypes.DiskParameter{Path: path, Encrypt: true, Wipe: true}
+	osdPath := getOSDDataPath(osd)
+
+	// The class recorded while draining, or the detected one as for a new OSD.
+	class := readDeviceClass(osdPath)
+	if class == "" {
+		class, err = getDeviceClass(data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = removeOSDConfig(osd)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(osdPath, 0700)
+	if err != nil {
+		return "", fmt.Errorf("failed to create OSD directory: %w", err)
+	}
+
+	err = createOSD(osdPath, osd, &data, nil, nil, nil, store)
+	if err != nil {
+		return "", err
+	}
+
+	err = startNewOSD(ctx, s.ClusterState(), osd, osdPath, class)
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptStatusRecovering, nil
+}
+
+// encryptStepRecover waits for the cluster to recover onto the re-created OSD.
+func encryptStepRecover(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, encryptRecoveryTimeout)
+	defer cancel()
+
+	return waitForRecovery(ctx, encryptRecoveryInterval)
+}
@@ -0,0 +1,153 @@
+package ceph
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type encryptSuite struct {
+	tests.BaseSuite
+}
+
+func TestEncrypt(t *testing.T) {
+	suite.Run(t, new(encryptSuite))
+}
+
+func (s *encryptSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const encryptOsdTree = `{"nodes": [{"id": -1, "type": "root"}, {"id": 1, "type": "osd"}]}`
+const encryptOsdTreeEmpty = `{"nodes": [{"id": -1, "type": "root"}]}`
+
+func (s *encryptSuite) TestValidateEncryptOSD() {
+	backing := filepath.Join(s.Tmp, "osd-backing.img")
+	err := os.WriteFile(backing, []byte(""), 0600)
+	assert.NoError(s.T(), err)
+
+	disks := types.Disks{
+		{OSD: 1, Path: backing, Location: "node1"},
+		{OSD: 2, Path: "/dev/null", Location: "node2"},
+	}
+
+	_, err = validateEncryptOSD(disks, "node1", 3)
+	assert.ErrorContains(s.T(), err, "not found")
+
+	_, err = validateEncryptOSD(disks, "node1", 2)
+	assert.ErrorContains(s.T(), err, "located on node2")
+
+	_, err = validateEncryptOSD(disks, "node1", 1)
+	assert.ErrorContains(s.T(), err, "loop file")
+}
+
+func (s *encryptSuite) TestValidateEncryptOSDAlreadyEncrypted() {
+	osdPath := getOSDDataPath(1)
+	err := os.MkdirAll(osdPath, 0700)
+	assert.NoError(s.T(), err)
+	err = os.Symlink("/dev/sdx", filepath.Join(osdPath, "unencrypted"))
+	assert.NoError(s.T(), err)
+
+	// /dev/null stands in for a block device, it is a device node too.
+	disks := types.Disks{{OSD: 1, Path: "/dev/null", Location: "node1"}}
+	_, err = validateEncryptOSD(disks, "node1", 1)
+	assert.ErrorContains(s.T(), err, "already encrypted")
+}
+
+func (s *encryptSuite) TestEncryptStepStart() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(encryptOsdTree, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.1", "0.000000").Return("", nil).Once()
+	processExec = r
+
+	next, err := encryptStepStart(1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), EncryptStatusDraining, next)
+}
+
+func (s *encryptSuite) TestEncryptStepDrain() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(encryptOsdTree, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "safe-to-destroy", "osd.1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "get-device-class", "osd.1").Return("ssd\n", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "out", "osd.1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "down", "osd.1").Return("", nil).Once()
+	r.On("RunCommand", "pkill", "-f", "ceph-osd .* --id 1$").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "purge", "osd.1", "--yes-i-really-mean-it").Return("", nil).Once()
+	processExec = r
+
+	assert.NoError(s.T(), os.MkdirAll(getOSDDataPath(1), 0700))
+
+	next, err := encryptStepDrain(1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), EncryptStatusRecreating, next)
+
+	// The OSD is recreated with its device class.
+	assert.Equal(s.T(), "ssd", readDeviceClass(getOSDDataPath(1)))
+}
+
+func (s *encryptSuite) TestEncryptStepDrainAlreadyPurged() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "tree", "-f", "json").Return(encryptOsdTreeEmpty, nil).Once()
+	processExec = r
+
+	next, err := encryptStepDrain(1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), EncryptStatusRecreating, next)
+}
+
+func (s *encryptSuite) TestEncryptOSDRecovering() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(`{"pg_ready": true, "pg_summary": {"num_pg_by_state": [{"name": "active+clean", "num": 3}, {"name": "active+clean+scrubbing", "num": 1}], "num_pgs": 4}}`, nil).Once()
+	processExec = r
+
+	recorded := []string{}
+	err := encryptOSD(context.Background(), nil, 1, EncryptStatusRecovering, func(status string) error {
+		recorded = append(recorded, status)
+		return nil
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{EncryptStatusDone}, recorded)
+}
+
+func (s *encryptSuite) TestEncryptOSDUnknownStatus() {
+	err := encryptOSD(context.Background(), nil, 1, "bogus", func(status string) error { return nil })
+	assert.Error(s.T(), err)
+}
+
+func (s *encryptSuite) TestPGStatActiveClean() {
+	stat := PGStat{PGSummary: PGSummary{
+		NumPGByState: []PGStateCount{
+			{Name: "active+clean", Num: 10},
+			{Name: "active+undersized+degraded", Num: 2},
+			{Name: "active+clean+scrubbing+deep", Num: 1},
+		},
+		NumPGs: 13,
+	}}
+
+	assert.Equal(s.T(), int64(11), stat.ActiveClean())
+	assert.False(s.T(), stat.AllActiveClean())
+}
+
+func (s *encryptSuite) TestEncryptOSDRecoveryTimeout() {
+	interval, timeout := encryptRecoveryInterval, encryptRecoveryTimeout
+	encryptRecoveryInterval, encryptRecoveryTimeout = time.Millisecond, 0
+	defer func() { encryptRecoveryInterval, encryptRecoveryTimeout = interval, timeout }()
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(`{"pg_ready": true, "pg_summary": {"num_pg_by_state": [{"name": "active+undersized", "num": 4}], "num_pgs": 4}}`, nil).Maybe()
+	processExec = r
+
+	err := encryptOSD(context.Background(), nil, 1, EncryptStatusRecovering, func(status string) error { return nil })
+	assert.ErrorContains(s.T(), err, "gave up waiting for recovery")
+}
@@ -15,7 +15,7 @@ import (
 )
 
 // Join will join an existing Ceph deployment.
-func Join(ctx context.Context, s interfaces.StateInterface) error {
+func Join(ctx context.Context, s interfaces.StateInterface, data common.JoinConfig) error {
 	pathFileMode := constants.GetPathFileMode()
 	var spt = GetServicePlacementTable()
 
@@ -64,6 +64,19 @@ func Join(ctx context.Context, s interfaces.StateInterface) error {
 		}
 	}
 
+	if data.CrushLocation != "" {
+		err = recordCrushLocation(ctx, s.ClusterState(), data.CrushLocation)
+		if err != nil {
+			return fmt.Errorf("failed to record crush location: %w", err)
+		}
+
+		// Place the host before its OSDs come up, they can be moved later on too.
+		err = applyLocalCrushLocation(ctx, s.ClusterState())
+		if err != nil {
+			logger.Warnf("Failed to apply crush location: %v", err)
+		}
+	}
+
 	// Start OSD service.
 	err = snapStart("osd", true)
 	if err != nil {
@@ -100,8 +113,17 @@ func checkAndCreateServiceRecord(s interfaces.StateInterface, ctx context.Contex
 		return err
 	}
 
+	count, err := getServiceCount(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		count = defaultServiceCount
+	}
+
 	// create record if service is to be spawned.
-	if len(services) < 3 {
+	if len(services) < count {
 		_, err := database.CreateService(ctx, tx, database.Service{Member: s.ClusterState().Name(), Service: name})
 		if err != nil {
 			return fmt.Errorf("failed to record role: %w", err)
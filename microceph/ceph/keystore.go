@@ -0,0 +1,634 @@
+package ceph
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Key store backends.
+const (
+	KeyStoreConfigKey = "config-key"
+	KeyStoreHTTP      = "http"
+)
+
+// Database config keys holding the cluster wide key store settings.
+const (
+	keyStoreBackendKey = "keystore.backend"
+	keyStoreURLKey     = "keystore.url"
+	// keyStoreTokenKey is where older releases kept the token. It is only read to move the
+	// token out of the database.
+	keyStoreTokenKey = "keystore.token"
+)
+
+// keyStoreTokenPath is where each member keeps the key store token. It stays out of the
+// replicated database and of the conf directory, so that it is neither handed out by config
+// reads nor copied into backups.
+var keyStoreTokenPath = func() string {
+	return filepath.Join(os.Getenv("SNAP_DATA"), "keystore", "token")
+}
+
+// encryptedDeviceSuffixes lists the suffixes used for the data, WAL and DB devices of an OSD.
+var encryptedDeviceSuffixes = []string{"", ".wal", ".db"}
+
+// ErrKeyNotFound is returned by a KeyStore when no key is stored under the requested name.
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyStore stores the keys of encrypted OSD devices.
+type KeyStore interface {
+	// GetName returns the name of the key store backend.
+	GetName() string
+	// Get fetches the key stored under name.
+	Get(name string) ([]byte, error)
+	// Put stores key under name, replacing any previous key.
+	Put(name string, key []byte) error
+	// Delete removes the key stored under name.
+	Delete(name string) error
+}
+
+// ConfigKeyStore keeps keys in the ceph config-key store.
+type ConfigKeyStore struct{}
+
+func (c ConfigKeyStore) GetName() string {
+	return KeyStoreConfigKey
+}
+
+func (c ConfigKeyStore) Get(name string) ([]byte, error) {
+	out, err := processExec.RunCommand("ceph", "config-key", "get", name)
+	if err != nil {
+		if strings.Contains(err.Error(), "ENOENT") {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch key %s: %w", name, err)
+	}
+
+	return []byte(strings.TrimSpace(out)), nil
+}
+
+func (c ConfigKeyStore) Put(name string, key []byte) error {
+	_, err := processExec.RunCommand("ceph", "config-key", "set", name, string(key))
+	if err != nil {
+		return fmt.Errorf("failed to store key %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (c ConfigKeyStore) Delete(name string) error {
+	_, err := processExec.RunCommand("ceph", "config-key", "rm", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// HTTPKeyStore keeps keys in an external secret store speaking a Vault KV (v1) style HTTP API.
+//
+// Keys are stored as {"key": "<key>"} documents below URL, authenticated with the
+// X-Vault-Token header.
+type HTTPKeyStore struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+// keyStoreHTTPTimeout bounds each request to the HTTP key store.
+const keyStoreHTTPTimeout = 30 * time.Second
+
+type httpKeyStoreData struct {
+	Key string `json:"key"`
+}
+
+type httpKeyStoreResponse struct {
+	Data httpKeyStoreData `json:"data"`
+}
+
+func (h HTTPKeyStore) GetName() string {
+	return KeyStoreHTTP
+}
+
+func (h HTTPKeyStore) Get(name string) ([]byte, error) {
+	resp, err := h.do("GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data httpKeyStoreResponse
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key %s: %w", name, err)
+	}
+
+	if len(data.Data.Key) == 0 {
+		return nil, fmt.Errorf("empty key returned for %s", name)
+	}
+
+	return []byte(data.Data.Key), nil
+}
+
+func (h HTTPKeyStore) Put(name string, key []byte) error {
+	body, err := json.Marshal(httpKeyStoreData{Key: string(key)})
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.do("POST", name, body)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (h HTTPKeyStore) Delete(name string) error {
+	resp, err := h.do("DELETE", name, nil)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	if resp != nil {
+		return resp.Body.Close()
+	}
+
+	return nil
+}
+
+// do performs a request against the key store and checks the response status.
+func (h HTTPKeyStore) do(method string, name string, body []byte) (*http.Response, error) {
+	target, err := url.JoinPath(h.URL, name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key store URL %s: %w", h.URL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyStoreHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.Token) > 0 {
+		req.Header.Set("X-Vault-Token", h.Token)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("key store request %s %s failed: %w", method, name, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrKeyNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("key store request %s %s failed: %s: %s", method, name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// Read the body fully before the request context is cancelled.
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key store response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	return resp, nil
+}
+
+// keyName returns the name under which the key of an OSD device is stored.
+func keyName(osdID int64, suffix string) string {
+	return fmt.Sprintf("microceph:osd%s.%d/key", suffix, osdID)
+}
+
+// newKeyStore instantiates the key store described by the given config.
+func newKeyStore(config types.KeyStoreConfig) (KeyStore, error) {
+	switch config.Backend {
+	case "", KeyStoreConfigKey:
+		return ConfigKeyStore{}, nil
+	case KeyStoreHTTP:
+		if len(config.URL) == 0 {
+			return nil, fmt.Errorf("key store backend %s requires a URL", KeyStoreHTTP)
+		}
+
+		u, err := url.Parse(config.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("invalid key store URL %q", config.URL)
+		}
+
+		return HTTPKeyStore{URL: config.URL, Token: config.Token}, nil
+	default:
+		return nil, fmt.Errorf("unknown key store backend %q, expected one of %s, %s", config.Backend, KeyStoreConfigKey, KeyStoreHTTP)
+	}
+}
+
+// ReadKeyStoreToken returns the key store token kept on this member, if any.
+func ReadKeyStoreToken() (string, error) {
+	data, err := os.ReadFile(keyStoreTokenPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read key store token: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// WriteKeyStoreToken keeps the key store token on this member, readable by root only. An empty
+// token removes it.
+func WriteKeyStoreToken(token string) error {
+	path := keyStoreTokenPath()
+	if len(token) == 0 {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove key store token: %w", err)
+		}
+
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, []byte(token), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write key store token: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// keyStoreToken returns the key store token of this member. Members which joined after the
+// token was set fetch it from the others, and a token left in the database by older releases
+// is moved over.
+func keyStoreToken(ctx context.Context, s interfaces.StateInterface, config map[string]string) (string, error) {
+	token, err := ReadKeyStoreToken()
+	if err != nil || len(token) > 0 {
+		return token, err
+	}
+
+	token = config[keyStoreTokenKey]
+	if len(token) == 0 {
+		token, err = client.FetchKeyStoreToken(ctx, s)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(token) > 0 {
+		err = WriteKeyStoreToken(token)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return token, nil
+}
+
+// keyStoreConfigFromDb extracts the key store settings from the database config. The token is
+// kept outside of the database and left empty.
+func keyStoreConfigFromDb(config map[string]string) types.KeyStoreConfig {
+	ret := types.KeyStoreConfig{
+		Backend: config[keyStoreBackendKey],
+		URL:     config[keyStoreURLKey],
+	}
+
+	if len(ret.Backend) == 0 {
+		ret.Backend = KeyStoreConfigKey
+	}
+
+	return ret
+}
+
+// GetKeyStoreConfig returns the cluster wide key store settings.
+func GetKeyStoreConfig(ctx context.Context, s interfaces.StateInterface) (types.KeyStoreConfig, error) {
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return types.KeyStoreConfig{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	ret := keyStoreConfigFromDb(config)
+	if ret.Backend == KeyStoreHTTP {
+		ret.Token, err = keyStoreToken(ctx, s, config)
+		if err != nil {
+			return types.KeyStoreConfig{}, err
+		}
+	}
+
+	return ret, nil
+}
+
+// GetKeyStore returns the key store configured for the cluster.
+func GetKeyStore(ctx context.Context, s interfaces.StateInterface) (KeyStore, error) {
+	config, err := GetKeyStoreConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKeyStore(config)
+}
+
+// migrateKeys copies the keys of the given OSDs from one key store to another.
+// Returns the names of the migrated keys.
+func migrateKeys(from KeyStore, to KeyStore, osds []int64) ([]string, error) {
+	migrated := []string{}
+	for _, osd := range osds {
+		for _, suffix := range encryptedDeviceSuffixes {
+			name := keyName(osd, suffix)
+			key, err := from.Get(name)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return migrated, err
+			}
+
+			err = to.Put(name, key)
+			if err != nil {
+				return migrated, err
+			}
+
+			migrated = append(migrated, name)
+		}
+	}
+
+	return migrated, nil
+}
+
+// SetKeyStore switches the cluster wide key store, migrating the keys of all
+// known OSDs to the new backend.
+func SetKeyStore(ctx context.Context, s interfaces.StateInterface, config types.KeyStoreConfig) error {
+	if len(config.Backend) == 0 {
+		config.Backend = KeyStoreConfigKey
+	}
+
+	if config.Backend == KeyStoreConfigKey {
+		config.URL = ""
+		config.Token = ""
+	}
+
+	newStore, err := newKeyStore(config)
+	if err != nil {
+		return err
+	}
+
+	oldConfig, err := GetKeyStoreConfig(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	oldStore, err := newKeyStore(oldConfig)
+	if err != nil {
+		return err
+	}
+
+	// Keys only need to move if they end up in a different place.
+	migrate := oldConfig.Backend != config.Backend || oldConfig.URL != config.URL
+
+	migrated := []string{}
+	if migrate {
+		disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+		if err != nil {
+			return fmt.Errorf("failed to list disks: %w", err)
+		}
+
+		osds := make([]int64, 0, len(disks))
+		for _, disk := range disks {
+			osds = append(osds, disk.OSD)
+		}
+
+		migrated, err = migrateKeys(oldStore, newStore, osds)
+		if err != nil {
+			return fmt.Errorf("failed to migrate keys to %s key store: %w", newStore.GetName(), err)
+		}
+	}
+
+	// The token goes to every member before the new backend is on record, as OSDs can't be
+	// unlocked without it.
+	err = WriteKeyStoreToken(config.Token)
+	if err != nil {
+		return err
+	}
+
+	err = client.SendKeyStoreTokenToClusterMembers(ctx, s, config.Token)
+	if err != nil {
+		return fmt.Errorf("failed to hand the key store token to other members: %w", err)
+	}
+
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items := map[string]string{
+			keyStoreBackendKey: config.Backend,
+			keyStoreURLKey:     config.URL,
+		}
+
+		for key, value := range items {
+			err := upsertConfigItem(ctx, tx, key, value)
+			if err != nil {
+				return fmt.Errorf("failed to record %s: %w", key, err)
+			}
+		}
+
+		err := database.DeleteConfigItem(ctx, tx, keyStoreTokenKey)
+		if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to remove %s: %w", keyStoreTokenKey, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only drop the old copies once the new backend is on record.
+	for _, name := range migrated {
+		err = oldStore.Delete(name)
+		if err != nil {
+			logger.Warnf("failed to delete key %s from %s key store: %v", name, oldStore.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// upsertConfigItem creates or updates a database config item.
+func upsertConfigItem(ctx context.Context, tx *sql.Tx, key string, value string) error {
+	exists, err := database.ConfigItemExists(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return database.UpdateConfigItem(ctx, tx, key, database.ConfigItem{Key: key, Value: value})
+	}
+
+	_, err = database.CreateConfigItem(ctx, tx, database.ConfigItem{Key: key, Value: value})
+	return err
+}
+
+// getOSDDataPath returns the data directory of the given OSD.
+func getOSDDataPath(osd int64) string {
+	return filepath.Join(constants.GetPathConst().DataPath, "osd", fmt.Sprintf("ceph-%d", osd))
+}
+
+// encryptedDevices returns the suffixes of the encrypted devices of an OSD.
+func encryptedDevices(osdPath string) []string {
+	suffixes := []string{}
+	for _, suffix := range encryptedDeviceSuffixes {
+		_, err := os.Lstat(filepath.Join(osdPath, "unencrypted"+suffix))
+		if err == nil {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+
+	return suffixes
+}
+
+// UnlockOSD opens the encrypted devices of an OSD using keys from the cluster key store.
+// Devices which are already open are skipped.
+func UnlockOSD(ctx context.Context, s interfaces.StateInterface, osd int64) error {
+	osdPath := getOSDDataPath(osd)
+	suffixes := encryptedDevices(osdPath)
+	if len(suffixes) == 0 {
+		return nil
+	}
+
+	store, err := GetKeyStore(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	for _, suffix := range suffixes {
+		_, err := os.Stat(fmt.Sprintf("/dev/mapper/luksosd%s-%d", suffix, osd))
+		if err == nil {
+			continue
+		}
+
+		key, err := store.Get(keyName(osd, suffix))
+		if err != nil {
+			return fmt.Errorf("failed to fetch key for osd%s.%d: %w", suffix, osd, err)
+		}
+
+		_, err = openEncryptedDevice(filepath.Join(osdPath, "unencrypted"+suffix), osd, key, suffix)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RekeyOSD rotates the LUKS keys of all encrypted devices of an OSD.
+func RekeyOSD(ctx context.Context, s interfaces.StateInterface, osd int64) error {
+	store, err := GetKeyStore(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	return rekeyOSD(store, osd)
+}
+
+func rekeyOSD(store KeyStore, osd int64) error {
+	osdPath := getOSDDataPath(osd)
+	suffixes := encryptedDevices(osdPath)
+	if len(suffixes) == 0 {
+		return fmt.Errorf("osd.%d has no encrypted devices", osd)
+	}
+
+	for _, suffix := range suffixes {
+		err := rekeyDevice(store, filepath.Join(osdPath, "unencrypted"+suffix), osd, suffix)
+		if err != nil {
+			return fmt.Errorf("failed to rekey osd%s.%d: %w", suffix, osd, err)
+		}
+	}
+
+	return nil
+}
+
+// rekeyDevice adds a fresh key to a LUKS device, records it in the key store
+// and then removes the previous key from the device.
+func rekeyDevice(store KeyStore, path string, osd int64, suffix string) error {
+	name := keyName(osd, suffix)
+
+	oldKey, err := store.Get(name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch current key: %w", err)
+	}
+
+	newKey, err := createKey()
+	if err != nil {
+		return fmt.Errorf("key creation error: %w", err)
+	}
+
+	// cryptsetup wants both keys as files, keep them in a private temporary directory.
+	dir, err := os.MkdirTemp(constants.GetPathConst().RunPath, "rekey")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldKeyFile := filepath.Join(dir, "old")
+	newKeyFile := filepath.Join(dir, "new")
+	for file, key := range map[string][]byte{oldKeyFile: oldKey, newKeyFile: newKey} {
+		err = os.WriteFile(file, key, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write key file: %w", err)
+		}
+	}
+
+	_, err = processExec.RunCommand("cryptsetup", "--batch-mode", "--key-file", oldKeyFile, "luksAddKey", path, newKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to add new key: %w", err)
+	}
+
+	err = store.Put(name, newKey)
+	if err != nil {
+		// The old key is still the one on record, drop the new one again.
+		_, rmErr := processExec.RunCommand("cryptsetup", "--batch-mode", "luksRemoveKey", path, newKeyFile)
+		if rmErr != nil {
+			logger.Errorf("failed to remove unrecorded key from %s: %v", path, rmErr)
+		}
+		return fmt.Errorf("key store error: %w", err)
+	}
+
+	_, err = processExec.RunCommand("cryptsetup", "--batch-mode", "luksRemoveKey", path, oldKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to remove old key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type keyStoreSuite struct {
+	tests.BaseSuite
+}
+
+func TestKeyStore(t *testing.T) {
+	suite.Run(t, new(keyStoreSuite))
+}
+
+func (s *keyStoreSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+// fakeVault is a minimal stand-in for a Vault KV (v1) secret store.
+type fakeVault struct {
+	mu    sync.Mutex
+	token string
+	data  map[string]string
+}
+
+func newFakeVault(token string) *fakeVault {
+	return &fakeVault{token: token, data: map[string]string{}}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		http.Error(w, "permission denied", http.StatusForbidden)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	switch r.Method {
+	case "GET":
+		key, ok := f.data[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"key": key}})
+	case "POST":
+		var body map[string]string
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.data[name] = body["key"]
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		delete(f.data, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *keyStoreSuite) TestHTTPKeyStore() {
+	vault := newFakeVault("s3cr3t")
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	store := HTTPKeyStore{URL: srv.URL + "/v1/secret", Token: "s3cr3t", Client: srv.Client()}
+
+	_, err := store.Get(keyName(1, ""))
+	assert.ErrorIs(s.T(), err, ErrKeyNotFound)
+
+	err = store.Put(keyName(1, ""), []byte("foo"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "foo", vault.data["microceph:osd.1/key"])
+
+	key, err := store.Get(keyName(1, ""))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("foo"), key)
+
+	err = store.Delete(keyName(1, ""))
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), vault.data)
+}
+
+func (s *keyStoreSuite) TestHTTPKeyStoreBadToken() {
+	srv := httptest.NewServer(newFakeVault("s3cr3t"))
+	defer srv.Close()
+
+	store := HTTPKeyStore{URL: srv.URL + "/v1/secret", Token: "wrong", Client: srv.Client()}
+
+	err := store.Put(keyName(1, ""), []byte("foo"))
+	assert.ErrorContains(s.T(), err, "403")
+}
+
+func (s *keyStoreSuite) TestConfigKeyStore() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config-key", "set", "microceph:osd.db.2/key", "foo").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.db.2/key").Return("foo\n", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.3/key").Return("", fmt.Errorf("Error ENOENT: key 'microceph:osd.3/key' doesn't exist")).Once()
+	processExec = r
+
+	store := ConfigKeyStore{}
+
+	err := store.Put(keyName(2, ".db"), []byte("foo"))
+	assert.NoError(s.T(), err)
+
+	key, err := store.Get(keyName(2, ".db"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("foo"), key)
+
+	_, err = store.Get(keyName(3, ""))
+	assert.ErrorIs(s.T(), err, ErrKeyNotFound)
+}
+
+func (s *keyStoreSuite) TestNewKeyStore() {
+	store, err := newKeyStore(types.KeyStoreConfig{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), KeyStoreConfigKey, store.GetName())
+
+	store, err = newKeyStore(types.KeyStoreConfig{Backend: KeyStoreHTTP, URL: "https://vault:8200/v1/secret", Token: "t"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), KeyStoreHTTP, store.GetName())
+
+	_, err = newKeyStore(types.KeyStoreConfig{Backend: KeyStoreHTTP})
+	assert.Error(s.T(), err)
+
+	_, err = newKeyStore(types.KeyStoreConfig{Backend: KeyStoreHTTP, URL: "vault:8200"})
+	assert.Error(s.T(), err)
+
+	_, err = newKeyStore(types.KeyStoreConfig{Backend: "kmip"})
+	assert.Error(s.T(), err)
+}
+
+func (s *keyStoreSuite) TestMigrateKeys() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.1/key").Return("data1", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.wal.1/key").Return("", fmt.Errorf("Error ENOENT")).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.db.1/key").Return("db1", nil).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.2/key").Return("", fmt.Errorf("Error ENOENT")).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.wal.2/key").Return("", fmt.Errorf("Error ENOENT")).Once()
+	r.On("RunCommand", "ceph", "config-key", "get", "microceph:osd.db.2/key").Return("", fmt.Errorf("Error ENOENT")).Once()
+	processExec = r
+
+	vault := newFakeVault("t")
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	to := HTTPKeyStore{URL: srv.URL + "/v1/secret", Token: "t", Client: srv.Client()}
+
+	migrated, err := migrateKeys(ConfigKeyStore{}, to, []int64{1, 2})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"microceph:osd.1/key", "microceph:osd.db.1/key"}, migrated)
+	assert.Equal(s.T(), map[string]string{"microceph:osd.1/key": "data1", "microceph:osd.db.1/key": "db1"}, vault.data)
+}
+
+func (s *keyStoreSuite) TestRekeyOSD() {
+	vault := newFakeVault("t")
+	vault.data["microceph:osd.4/key"] = "oldkey"
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	store := HTTPKeyStore{URL: srv.URL + "/v1/secret", Token: "t", Client: srv.Client()}
+
+	osdPath := getOSDDataPath(4)
+	err := os.MkdirAll(osdPath, 0700)
+	assert.NoError(s.T(), err)
+	err = os.Symlink("/dev/sdx", filepath.Join(osdPath, "unencrypted"))
+	assert.NoError(s.T(), err)
+
+	dev := filepath.Join(osdPath, "unencrypted")
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "--key-file", mock.Anything, "luksAddKey", dev, mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "luksRemoveKey", dev, mock.MatchedBy(func(file string) bool {
+		// The old key is the one removed from the device.
+		key, err := os.ReadFile(file)
+		return err == nil && string(key) == "oldkey"
+	})).Return("", nil).Once()
+	processExec = r
+
+	err = rekeyOSD(store, 4)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), vault.data["microceph:osd.4/key"], 128)
+}
+
+func (s *keyStoreSuite) TestRekeyOSDStoreFailure() {
+	vault := newFakeVault("t")
+	vault.data["microceph:osd.5/key"] = "oldkey"
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	// Reads succeed, writes are rejected.
+	store := HTTPKeyStore{URL: srv.URL + "/v1/secret", Token: "t", Client: srv.Client()}
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "read only", http.StatusForbidden)
+			return
+		}
+		vault.ServeHTTP(w, r)
+	})
+
+	osdPath := getOSDDataPath(5)
+	err := os.MkdirAll(osdPath, 0700)
+	assert.NoError(s.T(), err)
+	err = os.Symlink("/dev/sdy", filepath.Join(osdPath, "unencrypted"))
+	assert.NoError(s.T(), err)
+
+	dev := filepath.Join(osdPath, "unencrypted")
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "--key-file", mock.Anything, "luksAddKey", dev, mock.Anything).Return("", nil).Once()
+	// The freshly added key is dropped again.
+	r.On("RunCommand", "cryptsetup", "--batch-mode", "luksRemoveKey", dev, mock.MatchedBy(func(file string) bool {
+		return strings.HasSuffix(file, "new")
+	})).Return("", nil).Once()
+	processExec = r
+
+	err = rekeyOSD(store, 5)
+	assert.Error(s.T(), err)
+	assert.Equal(s.T(), "oldkey", vault.data["microceph:osd.5/key"])
+}
+
+func (s *keyStoreSuite) TestRekeyOSDNotEncrypted() {
+	err := os.MkdirAll(getOSDDataPath(6), 0700)
+	assert.NoError(s.T(), err)
+
+	err = rekeyOSD(ConfigKeyStore{}, 6)
+	assert.ErrorContains(s.T(), err, "no encrypted devices")
+}
+
+// TestKeyStoreToken checks the token is kept in a file readable by root only, out of the conf directory.
+func (s *keyStoreSuite) TestKeyStoreToken() {
+	token, err := ReadKeyStoreToken()
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), token)
+
+	assert.NoError(s.T(), WriteKeyStoreToken("s3cr3t"))
+
+	info, err := os.Stat(keyStoreTokenPath())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), os.FileMode(0600), info.Mode().Perm())
+	assert.NotEqual(s.T(), filepath.Join(s.Tmp, "SNAP_DATA", "conf"), filepath.Dir(keyStoreTokenPath()))
+
+	token, err = ReadKeyStoreToken()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "s3cr3t", token)
+
+	// The token goes away along with the HTTP key store.
+	assert.NoError(s.T(), WriteKeyStoreToken(""))
+	assert.NoFileExists(s.T(), keyStoreTokenPath())
+	assert.NoError(s.T(), WriteKeyStoreToken(""))
+}
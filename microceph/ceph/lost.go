@@ -0,0 +1,234 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/microcluster/v2/cluster"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// GetLostMember reports the OSDs and services that removing the given lost member takes away.
+func GetLostMember(ctx context.Context, s interfaces.StateInterface, name string) (types.LostMember, error) {
+	ret := types.LostMember{Name: name, OSDs: []int64{}, Services: []string{}}
+
+	if name == s.ClusterState().Name() {
+		return ret, api.StatusErrorf(http.StatusBadRequest, "%s is the local member and can't be removed as lost", name)
+	}
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := cluster.GetCoreClusterMember(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		services, err := database.GetServices(ctx, tx, database.ServiceFilter{Member: &name})
+		if err != nil {
+			return fmt.Errorf("failed to fetch services: %w", err)
+		}
+
+		for _, service := range services {
+			ret.Services = append(ret.Services, service.Service)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return ret, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if disk.Location == name {
+			ret.OSDs = append(ret.OSDs, disk.OSD)
+		}
+	}
+
+	ret.FailureDomain, ret.FailureDomainTarget, err = isDowngradeNeededForMember(ctx, s, name, len(ret.OSDs) > 0)
+	if err != nil {
+		return ret, fmt.Errorf("failed to check failure domain: %w", err)
+	}
+
+	ret.FailureDomainDowngrade = ret.FailureDomainTarget != ""
+
+	return ret, nil
+}
+
+// isDowngradeNeededForMember checks if losing the OSDs of a member leaves too few racks for
+// the 'rack' failure domain, or too few hosts for the 'host' failure domain. It returns the
+// current failure domain and the one to fall back to, or an empty target if none is needed.
+func isDowngradeNeededForMember(ctx context.Context, s interfaces.StateInterface, name string, hasOSDs bool) (string, string, error) {
+	if !hasOSDs {
+		return "", "", nil
+	}
+
+	domain, err := getFailureDomain()
+	if err != nil {
+		return "", "", err
+	}
+
+	if domain == "osd" {
+		// either we're at 'osd' level or we're using a custom rule
+		return domain, "", nil
+	}
+
+	if domain == "rack" {
+		numRacks, err := countRacks(ctx, s.ClusterState(), name)
+		if err != nil {
+			return "", "", err
+		}
+
+		if numRacks >= 3 {
+			return domain, "", nil
+		}
+	}
+
+	numNodes, err := database.MemberCounter.Count(ctx, s.ClusterState())
+	if err != nil {
+		return "", "", err
+	}
+
+	if numNodes-1 < 3 {
+		return domain, "osd", nil
+	}
+
+	if domain == "rack" {
+		return domain, "host", nil
+	}
+
+	return domain, "", nil
+}
+
+// RemoveLostMember cleans up after a member that is permanently gone: its OSDs are purged
+// from Ceph, its monitor is dropped from the monmap and its disk and service records are
+// deleted. The member itself still needs removing from the cluster afterwards.
+func RemoveLostMember(ctx context.Context, s interfaces.StateInterface, name string) error {
+	lost, err := GetLostMember(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Removing lost member %s: OSDs %v, services %v", name, lost.OSDs, lost.Services)
+
+	if lost.FailureDomainDowngrade {
+		err = switchFailureDomain(lost.FailureDomain, lost.FailureDomainTarget)
+		if err != nil {
+			return fmt.Errorf("failed to switch failure domain: %w", err)
+		}
+	}
+
+	err = purgeLostOSDs(ctx, s, lost.OSDs)
+	if err != nil {
+		return err
+	}
+
+	// The host bucket is empty now, a leftover would only clutter the crush map.
+	if len(lost.OSDs) > 0 {
+		_, err = processExec.RunCommand("ceph", "osd", "crush", "rm", name)
+		if err != nil {
+			logger.Warnf("Failed to remove crush bucket of %s: %v", name, err)
+		}
+	}
+
+	for _, service := range lost.Services {
+		err = removeLostService(name, service)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := database.DeleteServices(ctx, tx, name)
+		if err != nil {
+			return fmt.Errorf("failed to remove services of %s from db: %w", name, err)
+		}
+
+		err = database.DeleteConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", name))
+		if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return err
+		}
+
+		err = database.DeleteConfigItem(ctx, tx, database.CrushLocationKey(name))
+		if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Drop the monitor from the local ceph.conf right away, other members follow on refresh.
+	err = UpdateConfig(ctx, s)
+	if err != nil {
+		logger.Warnf("Failed to update config after removing %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// purgeLostOSDs purges the given OSDs from Ceph and deletes their records.
+// The OSDs can't be reached any more, so their devices are left as they are.
+func purgeLostOSDs(ctx context.Context, s interfaces.StateInterface, osds []int64) error {
+	for _, osd := range osds {
+		// check if the osd is still in the cluster -- if we're being re-run, it might not be
+		isPresent, err := haveOSDInCeph(osd)
+		if err != nil {
+			return fmt.Errorf("failed to check if osd.%d is present in Ceph: %w", osd, err)
+		}
+
+		if isPresent {
+			err = outDownOSD(osd)
+			if err != nil {
+				return err
+			}
+
+			err = purgeOSD(osd)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = database.OSDQuery.Delete(ctx, s.ClusterState(), osd)
+		if err != nil {
+			logger.Errorf("Failed to remove osd.%d from database: %v", osd, err)
+			return fmt.Errorf("failed to remove osd.%d from database: %w", osd, err)
+		}
+	}
+
+	return nil
+}
+
+// removeLostService removes what Ceph keeps about a service of a lost member.
+func removeLostService(name string, service string) error {
+	switch service {
+	case "mon":
+		mons, err := getMons()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := mons[name]; ok {
+			return removeMon(name)
+		}
+	case "mgr", "mds":
+		_, err := processExec.RunCommand("ceph", "auth", "del", fmt.Sprintf("%s.%s", service, name))
+		if err != nil {
+			logger.Warnf("Failed to remove %s key of %s: %v", service, name, err)
+		}
+	}
+
+	return nil
+}
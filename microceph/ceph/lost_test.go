@@ -0,0 +1,149 @@
+package ceph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type lostSuite struct {
+	tests.BaseSuite
+	TestStateInterface *mocks.StateInterface
+}
+
+func TestLost(t *testing.T) {
+	suite.Run(t, new(lostSuite))
+}
+
+func (s *lostSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+
+	s.TestStateInterface = mocks.NewStateInterface(s.T())
+	s.TestStateInterface.On("ClusterState").Return(&mocks.MockState{
+		URL:         api.NewURL(),
+		ClusterName: "foohost",
+	}).Maybe()
+}
+
+// TestPurgeLostOSDs checks OSDs still known to Ceph are purged, and all records are deleted.
+func (s *lostSuite) TestPurgeLostOSDs() {
+	r := mocks.NewRunner(s.T())
+	// osd.0 is in the tree
+	addOsdTreeExpectations(r)
+	r.On("RunCommand", "ceph", "osd", "out", "osd.0").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "down", "osd.0").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "purge", "osd.0", "--yes-i-really-mean-it").Return("ok", nil).Once()
+	// osd.77 has been purged already
+	addOsdTreeExpectations(r)
+	processExec = r
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("Delete", mock.Anything, mock.Anything, int64(0)).Return(nil).Once()
+	q.On("Delete", mock.Anything, mock.Anything, int64(77)).Return(nil).Once()
+	database.OSDQuery = q
+
+	err := purgeLostOSDs(context.Background(), s.TestStateInterface, []int64{0, 77})
+	assert.NoError(s.T(), err)
+}
+
+// TestIsDowngradeNeededForMember checks the host failure domain is only given up below 3 hosts.
+func (s *lostSuite) TestIsDowngradeNeededForMember() {
+	// members without OSDs don't matter
+	_, target, err := isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "foo", false)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "", target)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("77", nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 77 }`, nil).Twice()
+	processExec = r
+
+	c := mocks.NewMemberCounterInterface(s.T())
+	c.On("Count", mock.Anything, mock.Anything).Return(3, nil).Once()
+	c.On("Count", mock.Anything, mock.Anything).Return(4, nil).Once()
+	database.MemberCounter = c
+
+	domain, target, err := isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "foo", true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "host", domain)
+	assert.Equal(s.T(), "osd", target)
+
+	_, target, err = isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "foo", true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "", target)
+}
+
+// TestIsDowngradeNeededForMemberRack checks the rack failure domain is given up below 3 racks,
+// falling back to hosts while there are enough of them.
+func (s *lostSuite) TestIsDowngradeNeededForMemberRack() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("78", nil).Times(3)
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 77 }`, nil).Times(3)
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("microceph_auto_host\nmicroceph_auto_rack", nil).Times(3)
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_rack").Return(`{ "rule_id": 78 }`, nil).Times(3)
+	processExec = r
+
+	l := mocks.NewCrushLocationQueryInterface(s.T())
+	l.On("List", mock.Anything, mock.Anything).Return(map[string]string{
+		"foo": "rack=r1",
+		"bar": "rack=r2",
+		"baz": "rack=r3",
+		"qux": "rack=r3",
+	}, nil).Times(3)
+	database.CrushLocationQuery = l
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Location: "foo"},
+		{OSD: 1, Location: "bar"},
+		{OSD: 2, Location: "baz"},
+		{OSD: 3, Location: "qux"},
+	}, nil).Times(3)
+	database.OSDQuery = q
+
+	c := mocks.NewMemberCounterInterface(s.T())
+	c.On("Count", mock.Anything, mock.Anything).Return(4, nil).Once()
+	c.On("Count", mock.Anything, mock.Anything).Return(3, nil).Once()
+	database.MemberCounter = c
+
+	// losing qux leaves r3 with baz
+	_, target, err := isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "qux", true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "", target)
+
+	// losing foo leaves 2 racks and 3 hosts
+	domain, target, err := isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "foo", true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "rack", domain)
+	assert.Equal(s.T(), "host", target)
+
+	// losing foo leaves 2 racks and 2 hosts
+	_, target, err = isDowngradeNeededForMember(context.Background(), s.TestStateInterface, "foo", true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "osd", target)
+}
+
+// TestRemoveLostService checks the monitor is only removed while still in the monmap.
+func (s *lostSuite) TestRemoveLostService() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "dump", "-f", "json-pretty").Return(`{"mons": [{"name": "foo"}, {"name": "bar"}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "mon", "rm", "foo").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "dump", "-f", "json-pretty").Return(`{"mons": [{"name": "bar"}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "auth", "del", "mgr.foo").Return("ok", nil).Once()
+	processExec = r
+
+	assert.NoError(s.T(), removeLostService("foo", "mon"))
+	assert.NoError(s.T(), removeLostService("foo", "mon"))
+	assert.NoError(s.T(), removeLostService("foo", "mgr"))
+	assert.NoError(s.T(), removeLostService("foo", "rgw"))
+}
@@ -38,6 +38,23 @@ func addMonmap(path string, name string, address string) error {
 	return nil
 }
 
+// addMonmapV2 adds a monitor with the given address vector, like [v2:10.0.0.1:3300].
+func addMonmapV2(path string, name string, addrs string) error {
+	args := []string{
+		"--addv",
+		name,
+		addrs,
+		path,
+	}
+
+	_, err := processExec.RunCommand("monmaptool", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func bootstrapMon(hostname string, path string, monmap string, keyring string) error {
 	args := []string{
 		"--mkfs",
@@ -0,0 +1,488 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// OperationTypeNetworkMigrate is the type of public network migrations.
+const OperationTypeNetworkMigrate = "network-migrate"
+
+// migrateRestartOrder is the order the other services are restarted in once the monitors moved.
+var migrateRestartOrder = []string{"mgr", "mds", "rgw", "osd"}
+
+// Config keys recording the progress of a network migration, for it to be resumed or rolled back.
+const (
+	networkMigrationPrefix    = "network_migration."
+	networkMigrationSourceKey = networkMigrationPrefix + "source"
+	networkMigrationTargetKey = networkMigrationPrefix + "target"
+	networkMigrationMonPrefix = networkMigrationPrefix + "mon."
+)
+
+var (
+	// migrateQuorumInterval is how often the quorum is polled after a monitor moved.
+	migrateQuorumInterval = 5 * time.Second
+	// migrateQuorumTimeout is how long a monitor gets to rejoin the quorum on its new address.
+	migrateQuorumTimeout = 5 * time.Minute
+)
+
+// monAddr is an entry of the address vector of a monitor.
+type monAddr struct {
+	Type string
+	Port string
+}
+
+// monAddrs is a monitor of the monmap, along with the IP it is bound to.
+type monAddrs struct {
+	IP    string
+	Addrs []monAddr
+}
+
+// String formats the address vector of the monitor on the given IP, as taken by 'ceph mon set-addrs'.
+func (m monAddrs) String(ip string) string {
+	addrs := make([]string, len(m.Addrs))
+	for i, addr := range m.Addrs {
+		addrs[i] = fmt.Sprintf("%s:%s", addr.Type, net.JoinHostPort(ip, addr.Port))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(addrs, ","))
+}
+
+// parseMonAddrs returns the addresses of the monitors in a 'ceph mon dump' output, keyed by name.
+func parseMonAddrs(out string) (map[string]monAddrs, error) {
+	ret := map[string]monAddrs{}
+
+	for _, mon := range gjson.Get(out, "mons").Array() {
+		name := mon.Get("name").String()
+		addrs := monAddrs{}
+		for _, entry := range mon.Get("public_addrs.addrvec").Array() {
+			host, port, err := net.SplitHostPort(entry.Get("addr").String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q of mon %s: %w", entry.Get("addr").String(), name, err)
+			}
+
+			addrs.IP = host
+			addrs.Addrs = append(addrs.Addrs, monAddr{Type: entry.Get("type").String(), Port: port})
+		}
+
+		if len(addrs.Addrs) == 0 {
+			return nil, fmt.Errorf("no address found for mon %s", name)
+		}
+
+		ret[name] = addrs
+	}
+
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no monitors found")
+	}
+
+	return ret, nil
+}
+
+// monInQuorum tells whether the monitor is part of the quorum.
+func monInQuorum(name string) (bool, error) {
+	output, err := cephRun("quorum_status", "-f", "json")
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch quorum status: %w", err)
+	}
+
+	for _, mon := range gjson.Get(output, "quorum_names").Array() {
+		if mon.String() == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// waitForMonQuorum polls the quorum until the monitor is part of it.
+func waitForMonQuorum(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, migrateQuorumTimeout)
+	defer cancel()
+
+	for {
+		inQuorum, err := monInQuorum(name)
+		if err == nil {
+			if inQuorum {
+				return nil
+			}
+
+			err = fmt.Errorf("mon.%s is out of quorum", name)
+		}
+
+		logger.Debugf("Waiting for mon.%s to rejoin the quorum: %v", name, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for mon.%s to rejoin the quorum: %w", name, err)
+		case <-time.After(migrateQuorumInterval):
+		}
+	}
+}
+
+// GetNetworkAddress returns the address of this member on the given network.
+func GetNetworkAddress(req types.NetworkAddressPost) (types.NetworkAddress, error) {
+	address, err := common.Network.FindIpOnSubnet(req.Subnet)
+	if err != nil {
+		return types.NetworkAddress{}, api.StatusErrorf(http.StatusNotFound, "no address on network %s: %v", req.Subnet, err)
+	}
+
+	return types.NetworkAddress{Address: address}, nil
+}
+
+// memberAddresses asks every member for its address on the given network. All members need one,
+// as ceph.conf can't be rendered on a member without an address on the public network.
+func memberAddresses(ctx context.Context, s interfaces.StateInterface, subnet string) (map[string]string, error) {
+	online, offline, err := onlineMembers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(offline) > 0 {
+		return nil, api.StatusErrorf(http.StatusConflict, "members %s are offline, their addresses on %s are unknown", strings.Join(offline, ", "), subnet)
+	}
+
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	req := types.NetworkAddressPost{Subnet: subnet}
+	addresses := map[string]string{}
+	for _, member := range online {
+		var address types.NetworkAddress
+		if member == s.ClusterState().Name() {
+			address, err = GetNetworkAddress(req)
+		} else {
+			address, err = client.GetNetworkAddress(ctx, leader.UseTarget(member), &req)
+		}
+		if err != nil {
+			return nil, api.StatusErrorf(http.StatusConflict, "member %s has no address on %s: %v", member, subnet, err)
+		}
+
+		addresses[member] = address.Address
+	}
+
+	return addresses, nil
+}
+
+// updateMonHost records the address of a monitor and renders ceph.conf on every member, so that
+// clients keep reaching the monitors while they move.
+func updateMonHost(ctx context.Context, s interfaces.StateInterface, name string, address string) error {
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return upsertConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", name), address)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record address of mon.%s: %w", name, err)
+	}
+
+	return updateConfigEverywhere(ctx, s)
+}
+
+// updateConfigEverywhere renders ceph.conf on this member, then on every other member.
+func updateConfigEverywhere(ctx context.Context, s interfaces.StateInterface) error {
+	err := UpdateConfig(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+
+	err = client.SendUpdateClientConfRequestToClusterMembers(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to update the config of other members: %w", err)
+	}
+
+	return nil
+}
+
+// networkMigration is the progress of a network migration, as recorded in the config table.
+type networkMigration struct {
+	Source string
+	Target string
+	// Mons holds the previous addresses of the monitors moved so far, keyed by name.
+	Mons map[string]string
+}
+
+// getNetworkMigration returns the network migration recorded in the config, or nil if there is none.
+func getNetworkMigration(config map[string]string) *networkMigration {
+	if config[networkMigrationTargetKey] == "" {
+		return nil
+	}
+
+	migration := &networkMigration{
+		Source: config[networkMigrationSourceKey],
+		Target: config[networkMigrationTargetKey],
+		Mons:   map[string]string{},
+	}
+
+	for key, value := range config {
+		name, ok := strings.CutPrefix(key, networkMigrationMonPrefix)
+		if ok {
+			migration.Mons[name] = value
+		}
+	}
+
+	return migration
+}
+
+// recordNetworkMigration records the config items of a network migration.
+func recordNetworkMigration(ctx context.Context, s interfaces.StateInterface, items map[string]string) error {
+	return s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for key, value := range items {
+			err := upsertConfigItem(ctx, tx, key, value)
+			if err != nil {
+				return fmt.Errorf("failed to record %s: %w", key, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// clearNetworkMigration drops the progress of a network migration from the config table.
+func clearNetworkMigration(ctx context.Context, s interfaces.StateInterface) error {
+	return s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items, err := database.GetConfigItems(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch config: %w", err)
+		}
+
+		for _, item := range items {
+			if !strings.HasPrefix(item.Key, networkMigrationPrefix) {
+				continue
+			}
+
+			err = database.DeleteConfigItem(ctx, tx, item.Key)
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// StartNetworkMigration moves the cluster to a new public network. Every member needs an address
+// on the new network beforehand. The monitors move one at a time, the next one only once the
+// previous one rejoined the quorum on its new address. The other services are restarted
+// afterwards, host by host. The progress is recorded in the config table: an interrupted
+// migration is resumed when started again with the same network, or rolled back, moving the
+// monitors back to their previous addresses.
+func StartNetworkMigration(ctx context.Context, s interfaces.StateInterface, req types.NetworkMigratePost) (types.Operation, error) {
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return types.Operation{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	migration := getNetworkMigration(config)
+
+	var pubNet string
+	var addresses map[string]string
+	if req.Rollback {
+		if migration == nil {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "there is no network migration to roll back")
+		}
+
+		pubNet = migration.Source
+		addresses = migration.Mons
+	} else {
+		_, subnet, err := net.ParseCIDR(req.PublicNetwork)
+		if err != nil {
+			return types.Operation{}, api.StatusErrorf(http.StatusBadRequest, "invalid public network %q: %v", req.PublicNetwork, err)
+		}
+
+		pubNet = subnet.String()
+		if migration != nil && migration.Target != pubNet {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the migration to %s is unfinished, resume it or roll it back first", migration.Target)
+		}
+
+		if migration == nil && config["public_network"] == pubNet {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the public network is %s already", pubNet)
+		}
+	}
+
+	upgrade, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	if upgrade.State == types.UpgradeStateRunning {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "an upgrade is running on %s", upgrade.Member)
+	}
+
+	if !req.Rollback {
+		addresses, err = memberAddresses(ctx, s, pubNet)
+		if err != nil {
+			return types.Operation{}, err
+		}
+
+		if migration == nil {
+			err = recordNetworkMigration(ctx, s, map[string]string{
+				networkMigrationSourceKey: config["public_network"],
+				networkMigrationTargetKey: pubNet,
+			})
+			if err != nil {
+				return types.Operation{}, err
+			}
+		}
+	}
+
+	return StartAsyncOperation(ctx, s, OperationTypeNetworkMigrate, func(ctx context.Context, op *AsyncOperation) (string, error) {
+		result, err := migrateNetwork(ctx, s, op, pubNet, addresses, req.Rollback)
+
+		data, jsonErr := json.Marshal(result)
+		if jsonErr != nil {
+			logger.Errorf("Failed to encode network migration result: %v", jsonErr)
+		}
+
+		return string(data), err
+	})
+}
+
+// migrateNetwork moves the monitors to the given addresses, then records the public network and
+// restarts the other services. On a rollback, only the monitors with an address are moved back.
+func migrateNetwork(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, pubNet string, addresses map[string]string, rollback bool) (types.NetworkMigrateResult, error) {
+	result := types.NetworkMigrateResult{PublicNetwork: pubNet, Monitors: []types.NetworkMonAddress{}, Warnings: []string{}}
+
+	output, err := cephRun("mon", "dump", "-f", "json")
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch monmap: %w", err)
+	}
+
+	mons, err := parseMonAddrs(output)
+	if err != nil {
+		return result, err
+	}
+
+	names := make([]string, 0, len(mons))
+	for name := range mons {
+		if _, ok := addresses[name]; ok {
+			names = append(names, name)
+		} else if !rollback {
+			return result, fmt.Errorf("mon.%s isn't run by a member, its address can't be migrated", name)
+		}
+	}
+
+	slices.Sort(names)
+
+	for i, name := range names {
+		mon := mons[name]
+		address := addresses[name]
+		result.Monitors = append(result.Monitors, types.NetworkMonAddress{Name: name, Previous: mon.IP, Address: address})
+
+		if net.ParseIP(mon.IP).Equal(net.ParseIP(address)) {
+			inQuorum, err := monInQuorum(name)
+			if err != nil {
+				return result, err
+			}
+
+			if inQuorum {
+				logger.Infof("mon.%s is on %s already", name, address)
+				continue
+			}
+
+			// An interrupted move left the monitor out of quorum, it still needs restarting.
+			logger.Infof("Finishing the move of mon.%s to %s", name, address)
+		} else {
+			// Moving a monitor takes it out of the quorum until it restarts, which the others
+			// only survive when all of them are in quorum.
+			op.SetProgress(fmt.Sprintf("checking quorum before moving mon.%s (%d/%d)", name, i+1, len(names)))
+			err = checkMonQuorum()
+			if err != nil {
+				return result, fmt.Errorf("not moving mon.%s: %w", name, err)
+			}
+
+			// The previous address is where a rollback moves the monitor back to.
+			if !rollback {
+				err = recordNetworkMigration(ctx, s, map[string]string{networkMigrationMonPrefix + name: mon.IP})
+				if err != nil {
+					return result, err
+				}
+			}
+
+			op.SetProgress(fmt.Sprintf("moving mon.%s to %s (%d/%d)", name, address, i+1, len(names)))
+			_, err = cephRun("mon", "set-addrs", name, mon.String(address))
+			if err != nil {
+				return result, fmt.Errorf("failed to set address of mon.%s: %w", name, err)
+			}
+		}
+
+		err = updateMonHost(ctx, s, name, address)
+		if err != nil {
+			return result, err
+		}
+
+		err = restartOnHost(ctx, s, "mon", name)
+		if err != nil {
+			return result, fmt.Errorf("failed to restart mon.%s: %w", name, err)
+		}
+
+		op.SetProgress(fmt.Sprintf("waiting for mon.%s to rejoin the quorum (%d/%d)", name, i+1, len(names)))
+		err = waitForMonQuorum(ctx, name)
+		if err != nil {
+			return result, err
+		}
+
+		logger.Infof("Moved mon.%s from %s to %s", name, mon.IP, address)
+	}
+
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return result, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	// Services only need restarting once the public network changed, which a rollback may
+	// come before.
+	restart := !rollback || config["public_network"] != pubNet
+
+	op.SetProgress(fmt.Sprintf("recording public network %s", pubNet))
+	err = s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return upsertConfigItem(ctx, tx, "public_network", pubNet)
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to record public network: %w", err)
+	}
+
+	err = SetConfigItemUnsafe(types.Config{Key: "public_network", Value: pubNet})
+	if err != nil {
+		return result, fmt.Errorf("failed to set public network: %w", err)
+	}
+
+	err = updateConfigEverywhere(ctx, s)
+	if err != nil {
+		return result, err
+	}
+
+	// The remaining services bind to the new network on restart.
+	if restart {
+		for _, service := range migrateRestartOrder {
+			_, err = RollingRestart(ctx, s, service, op)
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to restart %s: %v", service, err))
+			}
+		}
+	}
+
+	err = clearNetworkMigration(ctx, s)
+	if err != nil {
+		return result, fmt.Errorf("failed to clear network migration: %w", err)
+	}
+
+	return result, nil
+}
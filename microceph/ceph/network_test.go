@@ -0,0 +1,116 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type networkSuite struct {
+	tests.BaseSuite
+}
+
+func TestNetwork(t *testing.T) {
+	suite.Run(t, new(networkSuite))
+}
+
+const monDumpAddrs = `{
+  "epoch": 3,
+  "mons": [
+    {"rank": 0, "name": "node1", "public_addrs": {"addrvec": [
+      {"type": "v2", "addr": "10.0.0.1:3300", "nonce": 0},
+      {"type": "v1", "addr": "10.0.0.1:6789", "nonce": 0}
+    ]}},
+    {"rank": 1, "name": "node2", "public_addrs": {"addrvec": [
+      {"type": "v2", "addr": "[fd00::2]:3300", "nonce": 0}
+    ]}}
+  ]
+}`
+
+// TestParseMonAddrs checks the address vectors of the monitors are moved to a new IP, ports kept.
+func (s *networkSuite) TestParseMonAddrs() {
+	mons, err := parseMonAddrs(monDumpAddrs)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), mons, 2)
+
+	assert.Equal(s.T(), "10.0.0.1", mons["node1"].IP)
+	assert.Equal(s.T(), "[v2:10.1.0.1:3300,v1:10.1.0.1:6789]", mons["node1"].String("10.1.0.1"))
+
+	assert.Equal(s.T(), "fd00::2", mons["node2"].IP)
+	assert.Equal(s.T(), "[v2:[fd01::2]:3300]", mons["node2"].String("fd01::2"))
+
+	_, err = parseMonAddrs(`{"mons": []}`)
+	assert.ErrorContains(s.T(), err, "no monitors found")
+
+	_, err = parseMonAddrs(`{"mons": [{"name": "node1", "public_addrs": {"addrvec": []}}]}`)
+	assert.ErrorContains(s.T(), err, "no address found for mon node1")
+}
+
+// TestGetNetworkMigration checks the progress of a migration is read back from the config.
+func (s *networkSuite) TestGetNetworkMigration() {
+	assert.Nil(s.T(), getNetworkMigration(map[string]string{"public_network": "10.0.0.0/24"}))
+
+	migration := getNetworkMigration(map[string]string{
+		"public_network":              "10.0.0.0/24",
+		networkMigrationSourceKey:     "10.0.0.0/24",
+		networkMigrationTargetKey:     "10.1.0.0/24",
+		"network_migration.mon.node1": "10.0.0.1",
+		"mon.host.node1":              "10.1.0.1",
+	})
+	assert.Equal(s.T(), &networkMigration{
+		Source: "10.0.0.0/24",
+		Target: "10.1.0.0/24",
+		Mons:   map[string]string{"node1": "10.0.0.1"},
+	}, migration)
+}
+
+// TestWaitForMonQuorum checks the wait ends once the monitor is back in quorum, and times out otherwise.
+func (s *networkSuite) TestWaitForMonQuorum() {
+	interval, timeout := migrateQuorumInterval, migrateQuorumTimeout
+	migrateQuorumInterval = time.Millisecond
+	defer func() { migrateQuorumInterval, migrateQuorumTimeout = interval, timeout }()
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names": ["node2"]}`, nil).Once()
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return("", fmt.Errorf("timed out")).Once()
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names": ["node1", "node2"]}`, nil).Once()
+	processExec = r
+
+	assert.NoError(s.T(), waitForMonQuorum(context.Background(), "node1"))
+
+	migrateQuorumTimeout = 0
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names": ["node2"]}`, nil)
+	processExec = r
+
+	err := waitForMonQuorum(context.Background(), "node1")
+	assert.ErrorContains(s.T(), err, "mon.node1 is out of quorum")
+}
+
+// TestGetNetworkAddress checks a member without an address on the network is reported as such.
+func (s *networkSuite) TestGetNetworkAddress() {
+	nw := mocks.NewNetworkIntf(s.T())
+	nw.On("FindIpOnSubnet", "10.1.0.0/24").Return("10.1.0.1", nil).Once()
+	nw.On("FindIpOnSubnet", "10.2.0.0/24").Return("", fmt.Errorf("no IP on subnet")).Once()
+	network := common.Network
+	common.Network = nw
+	defer func() { common.Network = network }()
+
+	address, err := GetNetworkAddress(types.NetworkAddressPost{Subnet: "10.1.0.0/24"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "10.1.0.1", address.Address)
+
+	_, err = GetNetworkAddress(types.NetworkAddressPost{Subnet: "10.2.0.0/24"})
+	assert.True(s.T(), api.StatusErrorCheck(err, http.StatusNotFound))
+}
@@ -114,6 +114,9 @@ type CheckNonOsdSvcEnoughOps struct {
 	MinMon int
 	MinMds int
 	MinMgr int
+
+	// MinMonPerSite is the number of mon services each site of a stretch cluster needs to keep.
+	MinMonPerSite int
 }
 
 // Run checks if non-osds service in a node are enough.
@@ -140,6 +143,16 @@ func (o *CheckNonOsdSvcEnoughOps) Run(name string) error {
 	if remains["mon"] < o.MinMon || remains["mds"] < o.MinMds || remains["mgr"] < o.MinMgr {
 		return fmt.Errorf("need at least %d mon, %d mds, and %d mgr services in the cluster besides those in node '%s'", o.MinMon, o.MinMds, o.MinMgr, name)
 	}
+
+	stretch, err := GetStretchMode(o.Context, o.State)
+	if err != nil {
+		return err
+	}
+
+	err = checkStretchMons(stretch, services, name, o.MinMonPerSite)
+	if err != nil {
+		return err
+	}
 	logger.Infof("remaining mon (%d), mds (%d), and mgr (%d) services in the cluster are enough after '%s' enters maintenance mode", remains["mon"], remains["mds"], remains["mgr"], name)
 
 	return nil
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +19,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/canonical/microceph/microceph/common"
 	"github.com/canonical/microceph/microceph/constants"
 	"github.com/canonical/microceph/microceph/interfaces"
 
@@ -34,7 +36,7 @@ import (
 	"github.com/canonical/microceph/microceph/database"
 )
 
-func prepareDisk(disk *types.DiskParameter, suffix string, osdPath string, osdID int64) error {
+func prepareDisk(disk *types.DiskParameter, suffix string, osdPath string, osdID int64, store KeyStore) error {
 	if disk.Wipe {
 		err := timeoutWipe(disk.Path)
 		if err != nil {
@@ -46,7 +48,7 @@ func prepareDisk(disk *types.DiskParameter, suffix string, osdPath string, osdID
 		if err != nil {
 			return fmt.Errorf("encryption unsupported on this machine: %w", err)
 		}
-		path, err := setupEncryptedOSD(disk.Path, osdPath, osdID, suffix)
+		path, err := setupEncryptedOSD(disk.Path, osdPath, osdID, suffix, store)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt device %s: %w", disk.Path, err)
 		}
@@ -62,10 +64,11 @@ func prepareDisk(disk *types.DiskParameter, suffix string, osdPath string, osdID
 
 // setupEncryptedOSD sets up an encrypted OSD on the given disk.
 //
-// Takes a path to the disk device as well as the OSD data path, the OSD id and
-// a suffix (to differentiate invocations between data, WAL and DB devices).
+// Takes a path to the disk device as well as the OSD data path, the OSD id,
+// a suffix (to differentiate invocations between data, WAL and DB devices) and
+// the key store to record the device key in.
 // Returns the path to the encrypted device and an error if any.
-func setupEncryptedOSD(devicePath string, osdDataPath string, osdID int64, suffix string) (string, error) {
+func setupEncryptedOSD(devicePath string, osdDataPath string, osdID int64, suffix string, store KeyStore) (string, error) {
 	if err := os.Symlink(devicePath, filepath.Join(osdDataPath, "unencrypted"+suffix)); err != nil {
 		return "", fmt.Errorf("failed to add unencrypted block symlink: %w", err)
 	}
@@ -76,8 +79,8 @@ func setupEncryptedOSD(devicePath string, osdDataPath string, osdID int64, suffi
 		return "", fmt.Errorf("key creation error: %w", err)
 	}
 
-	// Store key in ceph key value store
-	if err = storeKey(key, osdID, suffix); err != nil {
+	// Store key in the cluster key store
+	if err = storeKey(store, key, osdID, suffix); err != nil {
 		return "", fmt.Errorf("key store error: %w", err)
 	}
 
@@ -131,10 +134,9 @@ func encryptDevice(path string, key []byte) error {
 	return nil
 }
 
-// Store the key in the ceph key value store, under a name that derives from the osd id.
-func storeKey(key []byte, osdID int64, suffix string) error {
-	// Run the ceph config-key set command
-	_, err := processExec.RunCommand("ceph", "config-key", "set", fmt.Sprintf("microceph:osd%s.%d/key", suffix, osdID), string(key))
+// Store the key in the key store, under a name that derives from the osd id.
+func storeKey(store KeyStore, key []byte, osdID int64, suffix string) error {
+	err := store.Put(keyName(osdID, suffix), key)
 	if err != nil {
 		return fmt.Errorf("failed to store key: %w", err)
 	}
@@ -224,11 +226,44 @@ func switchFailureDomain(old string, new string) error {
 			return err
 		}
 	}
+
+	return switchClassFailureDomain(old, new)
+}
+
+// switchClassFailureDomain moves the pools pinned to a device class from the old to the new failure domain
+func switchClassFailureDomain(old string, new string) error {
+	classes, err := listDeviceClasses()
+	if err != nil {
+		return err
+	}
+
+	for _, class := range classes {
+		err = ensureClassCrushRule(new, class)
+		if err != nil {
+			return err
+		}
+
+		newRule := classCrushRuleName(new, class)
+		classPools, err := getPoolsForRule(classCrushRuleName(old, class))
+		logger.Debugf("Found pools %v for domain %v and device class %v", classPools, old, class)
+		if err != nil {
+			return err
+		}
+		for _, pool := range classPools {
+			logger.Debugf("Setting pool %v crush rule to %v", pool, newRule)
+			err = setPoolCrushRule(pool, newRule)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
 // updateFailureDomain checks if we need to update the crush rules failure domain.
 // Once we have at least 3 nodes with at least 1 OSD each, we set the failure domain to host.
+// Once these are spread over at least 3 racks, the rack rule is added, but only switched to
+// on request as it moves data around, see switchToRackFailureDomain.
 // Currently this function only handles scale-up scenarios, i.e. adding a new node.
 func updateFailureDomain(ctx context.Context, s state.State) error {
 	numNodes, err := database.MemberCounter.Count(ctx, s)
@@ -236,12 +271,75 @@ func updateFailureDomain(ctx context.Context, s state.State) error {
 		return fmt.Errorf("failed to count members: %w", err)
 	}
 
-	if numNodes >= 3 {
-		err = switchFailureDomain("osd", "host")
+	if numNodes < 3 {
+		return nil
+	}
+
+	// Pools of stretch clusters are placed by the stretch rule.
+	stretch, err := database.StretchQuery.Get(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to fetch stretch mode: %w", err)
+	}
+
+	if stretch.Enabled {
+		return nil
+	}
+
+	domain, err := getFailureDomain()
+	if err != nil {
+		return fmt.Errorf("failed to get failure domain: %w", err)
+	}
+
+	// The rack failure domain was asked for, don't fall back to hosts behind the user's back.
+	if domain == "rack" {
+		return nil
+	}
+
+	rackReady, err := isRackFailureDomainReady(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if rackReady {
+		err = ensureRackCrushRule()
+		if err != nil {
+			return err
+		}
+
+		logger.Infof("OSDs are spread over at least 3 racks, the rack failure domain is available")
+	}
+
+	err = switchFailureDomain("osd", "host")
+	if err != nil {
+		return fmt.Errorf("failed to set host failure domain: %w", err)
+	}
+	return nil
+}
+
+// switchToRackFailureDomain moves the automatic crush rules to the rack failure domain, once the
+// OSDs are spread over at least 3 racks.
+func switchToRackFailureDomain(ctx context.Context, s state.State) error {
+	rackReady, err := isRackFailureDomainReady(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if !rackReady {
+		return api.StatusErrorf(http.StatusConflict, "the OSDs aren't spread over at least 3 racks, or some are outside of a rack")
+	}
+
+	err = ensureRackCrushRule()
+	if err != nil {
+		return err
+	}
+
+	for _, old := range []string{"osd", "host"} {
+		err = switchFailureDomain(old, "rack")
 		if err != nil {
-			return fmt.Errorf("failed to set host failure domain: %w", err)
+			return fmt.Errorf("failed to set rack failure domain: %w", err)
 		}
 	}
+
 	return nil
 }
 
@@ -258,6 +356,16 @@ func setStablePath(storage *api.ResourcesStorage, param *types.DiskParameter) er
 
 	dev := fmt.Sprintf("%d:%d", major, minor)
 
+	// LVM logical volumes are named by their volume group and volume name.
+	lvPath, err := common.GetLVPath(param.Path)
+	if err != nil {
+		return fmt.Errorf("failed to check for logical volume: %w", err)
+	}
+	if lvPath != "" {
+		param.Path = lvPath
+		return nil
+	}
+
 	for _, disk := range storage.Disks {
 		// Check if full disk.
 		if disk.Device == dev {
@@ -279,7 +387,17 @@ func setStablePath(storage *api.ResourcesStorage, param *types.DiskParameter) er
 		// Check if partition.
 		for _, part := range disk.Partitions {
 			if part.Device == dev {
-				candidate := fmt.Sprintf("/dev/disk/by-id/%s-part%d", disk.DeviceID, part.Partition)
+				// The partition UUID survives the disk moving to another port or controller.
+				candidate, err := common.GetPartUUIDPath(param.Path)
+				if err != nil {
+					return fmt.Errorf("failed to look up partition UUID: %w", err)
+				}
+				if candidate != "" {
+					param.Path = candidate
+					break
+				}
+
+				candidate = fmt.Sprintf("/dev/disk/by-id/%s-part%d", disk.DeviceID, part.Partition)
 				if shared.PathExists(candidate) {
 					param.Path = candidate
 				} else {
@@ -298,38 +416,59 @@ func setStablePath(storage *api.ResourcesStorage, param *types.DiskParameter) er
 }
 
 // parseBackingSpec parses a loopback file specification.
-// The specification is of the form "loop,<size><unit>,<number>".
-// The function returns the size in MB and the number of disks.
-func parseBackingSpec(spec string) (uint64, int, error) {
-	r := regexp.MustCompile("loop,([1-9][0-9]*[MGT]),([1-9][0-9]*)")
+// The specification is of the form "loop,<size><unit>,<number>[,<directory>]".
+// The function returns the size in MB, the number of disks and the directory
+// to place the backing files in, empty if none was given.
+func parseBackingSpec(spec string) (uint64, int, string, error) {
+	r := regexp.MustCompile("loop,([1-9][0-9]*[MGT]),([1-9][0-9]*)(?:,(.+))?")
 
 	match := r.FindStringSubmatch(spec)
 	if match == nil {
-		return 0, 0, fmt.Errorf("illegal spec: %s", spec)
+		return 0, 0, "", fmt.Errorf("illegal spec: %s", spec)
+	}
+
+	size, err := parseLoopSize(match[1])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("failed to parse size from spec %s: %w", spec, err)
+	}
+
+	num, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("failed to parse number disks from spec %s: %w", spec, err)
+	}
+
+	dir := match[3]
+	if dir != "" && !filepath.IsAbs(dir) {
+		return 0, 0, "", fmt.Errorf("directory in spec %s must be an absolute path", spec)
+	}
+
+	return size, num, dir, nil
+}
+
+// parseLoopSize parses a size of the form "<size><unit>", with M, G or T as unit.
+// The function returns the size in MB.
+func parseLoopSize(spec string) (uint64, error) {
+	r := regexp.MustCompile("^([1-9][0-9]*)([MGT])$")
+
+	match := r.FindStringSubmatch(strings.ToUpper(spec))
+	if match == nil {
+		return 0, fmt.Errorf("illegal size %s, expected a number followed by M, G or T", spec)
 	}
-	// Parse the size and unit from the first matched group.
-	sizeStr := match[1][:len(match[1])-1]
-	unit := match[1][len(match[1])-1:]
 
-	size, err := strconv.ParseUint(sizeStr, 10, 64)
+	size, err := strconv.ParseUint(match[1], 10, 64)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to parse size from spec %s: %w", spec, err)
+		return 0, err
 	}
 
 	// Convert the size to MB.
-	switch strings.ToUpper(unit) {
+	switch match[2] {
 	case "G":
 		size *= 1024
 	case "T":
 		size *= 1024 * 1024
 	}
 
-	num, err := strconv.Atoi(match[2])
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to parse number disks from spec %s: %w", spec, err)
-	}
-
-	return size, num, nil
+	return size, nil
 }
 
 // getFreeSpace returns the number of free megabytes of disk capacity
@@ -351,25 +490,44 @@ func getFreeSpace(path string) (uint64, error) {
 	return freeSpace, nil
 }
 
-// createBackingFile creates a backing file of the given size in MB
-// and returns the file name.
-func createBackingFile(dir string, size uint64) (string, error) {
-	backing := filepath.Join(dir, "osd-backing.img")
+// backingFilePath returns the path of the backing file of a loop OSD.
+// Backing files are kept in the OSD data directory, unless another directory is given.
+func backingFilePath(osdDataPath string, dir string, nr int64) string {
+	if dir == "" {
+		return filepath.Join(osdDataPath, "osd-backing.img")
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("osd-backing-%d.img", nr))
+}
+
+// createBackingFile creates a backing file of the given size in MB.
+func createBackingFile(backing string, size uint64) error {
 	_, err := processExec.RunCommand("truncate", "-s", fmt.Sprintf("%dM", size), backing)
 	if err != nil {
-		return "", fmt.Errorf("failed to create backing file %s: %w", backing, err)
+		return fmt.Errorf("failed to create backing file %s: %w", backing, err)
 	}
-	return backing, nil
+	return nil
 }
 
 // AddLoopBackOSDs adds OSDs to the cluster backed by loopback files
-func AddLoopBackOSDs(ctx context.Context, s state.State, spec string) error {
-	size, num, err := parseBackingSpec(spec)
+func AddLoopBackOSDs(ctx context.Context, s state.State, spec string, class string) error {
+	size, num, dir, err := parseBackingSpec(spec)
 	if err != nil {
 		return err
 	}
-	// check available capacity for backing files under $SNAP_COMMON
-	freeSpace, err := getFreeSpace(os.Getenv("SNAP_COMMON"))
+	// check available capacity for backing files under $SNAP_COMMON, or the given directory
+	target := os.Getenv("SNAP_COMMON")
+	if dir != "" {
+		fileInfo, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("failed to access backing file directory: %w", err)
+		}
+		if !fileInfo.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		target = dir
+	}
+	freeSpace, err := getFreeSpace(target)
 	if err != nil {
 		return err
 	}
@@ -378,7 +536,7 @@ func AddLoopBackOSDs(ctx context.Context, s state.State, spec string) error {
 	}
 	// create backing files in a loop and add them to the cluster
 	for i := 0; i < num; i++ {
-		err = AddOSD(ctx, s, types.DiskParameter{LoopSize: size}, nil, nil)
+		err = AddOSD(ctx, s, types.DiskParameter{LoopSize: size, LoopDir: dir, DeviceClass: class}, nil, nil)
 		if err != nil {
 			return fmt.Errorf("failed to add loop OSD: %w", err)
 		}
@@ -387,7 +545,7 @@ func AddLoopBackOSDs(ctx context.Context, s state.State, spec string) error {
 }
 
 // bootstrapOSD bootstraps an OSD.
-func bootstrapOSD(osdDataPath string, nr int64, wal, db *types.DiskParameter, storage *api.ResourcesStorage) error {
+func bootstrapOSD(osdDataPath string, nr int64, wal, db *types.DiskParameter, storage *api.ResourcesStorage, store KeyStore) error {
 	var err error
 
 	args := []string{"--mkfs", "--no-mon-config", "-i", fmt.Sprintf("%d", nr)}
@@ -396,7 +554,7 @@ func bootstrapOSD(osdDataPath string, nr int64, wal, db *types.DiskParameter, st
 			return fmt.Errorf("failed to set stable path for WAL: %w", err)
 		}
 
-		err = prepareDisk(wal, ".wal", osdDataPath, nr)
+		err = prepareDisk(wal, ".wal", osdDataPath, nr, store)
 		if err != nil {
 			return fmt.Errorf("failed to set up WAL device: %w", err)
 		}
@@ -407,7 +565,7 @@ func bootstrapOSD(osdDataPath string, nr int64, wal, db *types.DiskParameter, st
 			return fmt.Errorf("failed to set stable path for DB: %w", err)
 		}
 
-		err = prepareDisk(db, ".db", osdDataPath, nr)
+		err = prepareDisk(db, ".db", osdDataPath, nr, store)
 		if err != nil {
 			return fmt.Errorf("failed to set up DB device: %w", err)
 		}
@@ -498,7 +656,7 @@ func AddBulkDisks(ctx context.Context, s state.State, disks []types.DiskParamete
 func AddSingleDisk(ctx context.Context, s state.State, disk types.DiskParameter, wal *types.DiskParameter, db *types.DiskParameter) types.DiskAddReport {
 	if strings.Contains(disk.Path, constants.LoopSpecId) {
 		// Add file based OSDs.
-		err := AddLoopBackOSDs(ctx, s, disk.Path)
+		err := AddLoopBackOSDs(ctx, s, disk.Path, disk.DeviceClass)
 		if err != nil {
 			logger.Errorf("failed to add disk: spec %s, err %v", disk.Path, err)
 			return types.DiskAddReport{Path: disk.Path, Report: "Failure", Error: err.Error()}
@@ -529,13 +687,34 @@ func AddOSD(ctx context.Context, s state.State, data types.DiskParameter, wal *t
 		return fmt.Errorf("loopback and WAL/DB are mutually exclusive")
 	}
 
+	if data.DeviceClass != "" && !validDeviceClass.MatchString(data.DeviceClass) {
+		return fmt.Errorf("invalid device class %q", data.DeviceClass)
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
 	var storage *api.ResourcesStorage
 
+	// Lookup the key store for encrypted devices.
+	store, err := GetKeyStore(ctx, interfaces.CephState{State: s})
+	if err != nil {
+		return fmt.Errorf("failed to get key store: %w", err)
+	}
+
 	if data.LoopSize == 0 {
-		// We have a physical device.
+		// We have a physical device, partition or logical volume.
+		// Make sure none of the devices is in use already.
+		for _, param := range []*types.DiskParameter{&data, wal, db} {
+			if param == nil {
+				continue
+			}
+			err = common.CheckDeviceAvailable(param.Path)
+			if err != nil {
+				return fmt.Errorf("unable to use %s: %w", param.Path, err)
+			}
+		}
+
 		// Lookup a stable path for it.
 		storage, err = resources.GetStorage()
 		if err != nil {
@@ -580,10 +759,15 @@ func AddOSD(ctx context.Context, s state.State, data types.DiskParameter, wal *t
 
 	// do we have a loopback file request?
 	if data.LoopSize != 0 {
-		backing, err := createBackingFile(osdDataPath, data.LoopSize)
+		backing := backingFilePath(osdDataPath, data.LoopDir, nr)
+		err = createBackingFile(backing, data.LoopSize)
 		if err != nil {
 			return err
 		}
+		if data.LoopDir != "" {
+			// the backing file is not cleaned up along with the data directory
+			revert.Add(func() { os.Remove(backing) })
+		}
 		data.Path = backing
 		// update db, it didn't have a path before
 		err = s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
@@ -595,33 +779,41 @@ func AddOSD(ctx context.Context, s state.State, data types.DiskParameter, wal *t
 		})
 	}
 
-	// Wipe and/or encrypt the disk if needed.
-	err = prepareDisk(&data, "", osdDataPath, nr)
+	// Detect the device class before the device gets wrapped by encryption.
+	class, err := getDeviceClass(data)
 	if err != nil {
-		return fmt.Errorf("failed to prepare data device: %w", err)
+		return err
 	}
 
-	// Generate keyring.
-	err = genAuth(filepath.Join(osdDataPath, "keyring"), fmt.Sprintf("osd.%d", nr), []string{"mgr", "allow profile osd"}, []string{"mon", "allow profile osd"}, []string{"osd", "allow *"})
+	err = createOSD(osdDataPath, nr, &data, wal, db, storage, store)
 	if err != nil {
-		return fmt.Errorf("failed to generate OSD keyring: %w", err)
+		return err
 	}
 
-	// Generate OSD uuid.
-	fsid := uuid.NewRandom().String()
-
-	// Write fsid file.
-	err = os.WriteFile(filepath.Join(osdDataPath, "fsid"), []byte(fsid), 0600)
+	err = startNewOSD(ctx, s, nr, osdDataPath, class)
 	if err != nil {
-		return fmt.Errorf("failed to write fsid: %w", err)
+		return err
 	}
 
-	// Bootstrap OSD.
-	err = bootstrapOSD(osdDataPath, nr, wal, db, storage)
+	revert.Success() // Revert functions added are not run on return.
+	logger.Debugf("Added osd.%d", nr)
+	return nil
+}
+
+// startNewOSD registers a freshly created OSD with the cluster: it is given its device class and
+// the crush location of the host, spawned, and the crush rules are updated for it.
+func startNewOSD(ctx context.Context, s state.State, nr int64, osdDataPath string, class string) error {
+	err := writeDeviceClass(osdDataPath, class)
 	if err != nil {
 		return err
 	}
 
+	// Make sure the host is at its recorded crush location before the OSD registers.
+	err = applyLocalCrushLocation(ctx, s)
+	if err != nil {
+		logger.Warnf("Failed to apply crush location: %v", err)
+	}
+
 	// Spawn the OSD.
 	logger.Debugf("Spawning OSD %d", nr)
 	err = snapRestart("osd", true)
@@ -635,11 +827,94 @@ func AddOSD(ctx context.Context, s state.State, data types.DiskParameter, wal *t
 		return err
 	}
 
-	revert.Success() // Revert functions added are not run on return.
-	logger.Debugf("Added osd.%d", nr)
+	if class != "" {
+		// The OSD is up already, missing rules get added along with the next failure domain update.
+		err = ensureDeviceClass(class)
+		if err != nil {
+			logger.Warnf("Failed to set up crush rules for device class %s: %v", class, err)
+		}
+	}
+
 	return nil
 }
 
+// validDeviceClass matches the crush device class names accepted on disk addition.
+var validDeviceClass = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// getDeviceClass returns the crush device class of a new OSD: the one requested, or the one
+// detected for its device. Loop files are left for Ceph to detect.
+func getDeviceClass(data types.DiskParameter) (string, error) {
+	if data.DeviceClass != "" || data.LoopSize != 0 {
+		return data.DeviceClass, nil
+	}
+
+	class, err := common.GetDeviceClass(data.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect device class of %s: %w", data.Path, err)
+	}
+	return class, nil
+}
+
+// writeDeviceClass records the crush device class an OSD registers with when it first starts.
+func writeDeviceClass(osdDataPath string, class string) error {
+	if class == "" {
+		return nil
+	}
+
+	err := os.WriteFile(filepath.Join(osdDataPath, "crush_device_class"), []byte(class), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write device class: %w", err)
+	}
+	return nil
+}
+
+// readDeviceClass returns the crush device class recorded in the data directory of an OSD, if any.
+func readDeviceClass(osdDataPath string) string {
+	class, err := os.ReadFile(filepath.Join(osdDataPath, "crush_device_class"))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(class))
+}
+
+// getOSDDeviceClass returns the crush device class of an OSD known to Ceph, empty if it has none.
+func getOSDDeviceClass(osd int64) (string, error) {
+	out, err := processExec.RunCommand("ceph", "osd", "crush", "get-device-class", fmt.Sprintf("osd.%d", osd))
+	if err != nil {
+		return "", fmt.Errorf("failed to get device class of osd.%d: %w", osd, err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// createOSD prepares the devices of an OSD and bootstraps its data directory.
+func createOSD(osdDataPath string, nr int64, data *types.DiskParameter, wal *types.DiskParameter, db *types.DiskParameter, storage *api.ResourcesStorage, store KeyStore) error {
+	// Wipe and/or encrypt the disk if needed.
+	err := prepareDisk(data, "", osdDataPath, nr, store)
+	if err != nil {
+		return fmt.Errorf("failed to prepare data device: %w", err)
+	}
+
+	// Generate keyring.
+	err = genAuth(filepath.Join(osdDataPath, "keyring"), fmt.Sprintf("osd.%d", nr), []string{"mgr", "allow profile osd"}, []string{"mon", "allow profile osd"}, []string{"osd", "allow *"})
+	if err != nil {
+		return fmt.Errorf("failed to generate OSD keyring: %w", err)
+	}
+
+	// Generate OSD uuid.
+	fsid := uuid.NewRandom().String()
+
+	// Write fsid file.
+	err = os.WriteFile(filepath.Join(osdDataPath, "fsid"), []byte(fsid), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write fsid: %w", err)
+	}
+
+	// Bootstrap OSD.
+	return bootstrapOSD(osdDataPath, nr, wal, db, storage, store)
+}
+
 // ListOSD lists current OSD disks
 func ListOSD(ctx context.Context, s state.State) (types.Disks, error) {
 	return database.OSDQuery.List(ctx, s)
@@ -924,6 +1199,24 @@ func clearStorage(ctx context.Context, s interfaces.StateInterface, osd int64) e
 		// wipe the device
 		wipeDevice(ctx, s, path)
 	}
+	// WAL and DB devices are not recorded, find them through the OSD data directory
+	for _, suffix := range []string{".wal", ".db"} {
+		device := filepath.Join(getOSDDataPath(osd), "unencrypted"+suffix)
+		if _, err := os.Lstat(device); err != nil {
+			device = filepath.Join(getOSDDataPath(osd), "block"+suffix)
+		}
+		devInfo, err := os.Stat(device) // Follow the symlink
+		if err == nil && devInfo.Mode()&os.ModeDevice != 0 {
+			wipeDevice(ctx, s, device)
+		}
+	}
+	// backing files placed outside the data directory need removing on their own
+	if fileInfo.Mode().IsRegular() && filepath.Dir(path) != getOSDDataPath(osd) {
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("failed to remove backing file %s: %w", path, err)
+		}
+	}
 	// backing files etc. are being removed later along with config
 	return nil
 }
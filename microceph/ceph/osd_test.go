@@ -8,6 +8,7 @@ import (
 	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/microceph/microceph/tests"
 
+	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/database"
 	"github.com/canonical/microceph/microceph/mocks"
 	"github.com/stretchr/testify/assert"
@@ -56,6 +57,11 @@ func addSetDefaultRuleExpectations(r *mocks.Runner) {
 	r.On("RunCommand", tests.CmdAny("ceph", 7)...).Return("ok", nil).Once()
 }
 
+// Expect: run ceph osd crush class ls
+func addDeviceClassLsExpectations(r *mocks.Runner, classes string) {
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return(classes, nil).Once()
+}
+
 // Expect: run ceph osd tree
 func addOsdTreeExpectations(r *mocks.Runner) {
 	json := `{
@@ -164,6 +170,8 @@ func (s *osdSuite) TestSwitchHostFailureDomain() {
 	addCrushRuleLsJsonExpectations(r)
 	// set pool crush rule
 	addOsdPoolSetExpectations(r)
+	// list device classes
+	addDeviceClassLsExpectations(r, "[]")
 
 	processExec = r
 
@@ -171,6 +179,27 @@ func (s *osdSuite) TestSwitchHostFailureDomain() {
 	assert.NoError(s.T(), err)
 }
 
+// TestSwitchClassFailureDomain tests moving pools pinned to a device class
+func (s *osdSuite) TestSwitchClassFailureDomain() {
+	r := mocks.NewRunner(s.T())
+
+	addDeviceClassLsExpectations(r, `["ssd"]`)
+	// the host rule of the class is missing and gets added
+	rules := "microceph_auto_osd\nmicroceph_auto_host\nmicroceph_auto_osd_ssd"
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "create-replicated", "microceph_auto_host_ssd", "default", "host", "ssd").Return("ok", nil).Once()
+	// pools using the osd rule of the class move to its host rule
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_osd_ssd").Return(`{ "rule_id": 3 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(`[{"pool_name": "fast", "crush_rule": 3}, {"pool_name": "slow", "crush_rule": 1}]`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "set", "fast", "crush_rule", "microceph_auto_host_ssd").Return("ok", nil).Once()
+
+	processExec = r
+
+	err := switchClassFailureDomain("osd", "host")
+	assert.NoError(s.T(), err)
+}
+
 // TestUpdateFailureDomain tests the updateFailureDomain function
 func (s *osdSuite) TestUpdateFailureDomain() {
 	u := api.NewURL()
@@ -181,6 +210,10 @@ func (s *osdSuite) TestUpdateFailureDomain() {
 
 	r := mocks.NewRunner(s.T())
 
+	// failure domain is still 'osd'
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("0", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 77 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("microceph_auto_osd\nmicroceph_auto_host", nil).Once()
 	// dump crush rules to resolve names
 	addCrushRuleDumpExpectations(r)
 	// set default crush rule
@@ -193,6 +226,8 @@ func (s *osdSuite) TestUpdateFailureDomain() {
 	addCrushRuleLsJsonExpectations(r)
 	// set pool crush rule
 	addOsdPoolSetExpectations(r)
+	// list device classes
+	addDeviceClassLsExpectations(r, "[]")
 
 	processExec = r
 
@@ -200,6 +235,16 @@ func (s *osdSuite) TestUpdateFailureDomain() {
 	c.On("Count", mock.Anything).Return(3, nil).Once()
 	database.MemberCounter = c
 
+	// no stretch mode
+	q := mocks.NewStretchQueryInterface(s.T())
+	q.On("Get", mock.Anything, mock.Anything).Return(types.StretchMode{}, nil).Once()
+	database.StretchQuery = q
+
+	// no racks recorded
+	l := mocks.NewCrushLocationQueryInterface(s.T())
+	l.On("List", mock.Anything, mock.Anything).Return(map[string]string{}, nil).Once()
+	database.CrushLocationQuery = l
+
 	s.TestStateInterface = mocks.NewStateInterface(s.T())
 	s.TestStateInterface.On("ClusterState").Return(state).Maybe()
 	err := updateFailureDomain(context.Background(), s.TestStateInterface.ClusterState())
@@ -207,6 +252,115 @@ func (s *osdSuite) TestUpdateFailureDomain() {
 
 }
 
+// TestUpdateRackFailureDomain checks the rack rule is added once there are 3 racks, but not switched to.
+func (s *osdSuite) TestUpdateRackFailureDomain() {
+	u := api.NewURL()
+	state := &mocks.MockState{
+		URL:         u,
+		ClusterName: "foohost",
+	}
+
+	rules := "microceph_auto_osd\nmicroceph_auto_host"
+	pools := `[{"pool_name": "foopool", "crush_rule": 0}]`
+
+	r := mocks.NewRunner(s.T())
+	// failure domain is 'osd'
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("0", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 2 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	// the rack rule gets added
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "create-replicated", "microceph_auto_rack", "default", "rack").Return("ok", nil).Once()
+	// the osd rule is switched to host only
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 2 }`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "osd_pool_default_crush_rule", "2", "-f", "json-pretty").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules+"\nmicroceph_auto_rack", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_osd").Return(`{ "rule_id": 0 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(pools, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "set", "foopool", "crush_rule", "microceph_auto_host").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return("[]", nil).Once()
+	processExec = r
+
+	c := mocks.NewMemberCounterInterface(s.T())
+	c.On("Count", mock.Anything).Return(3, nil).Once()
+	database.MemberCounter = c
+
+	sq := mocks.NewStretchQueryInterface(s.T())
+	sq.On("Get", mock.Anything, mock.Anything).Return(types.StretchMode{}, nil).Once()
+	database.StretchQuery = sq
+
+	s.addRackExpectations()
+
+	err := updateFailureDomain(context.Background(), state)
+	assert.NoError(s.T(), err)
+}
+
+// TestUpdateFailureDomainKeepsRack checks a rack failure domain in use is left alone.
+func (s *osdSuite) TestUpdateFailureDomainKeepsRack() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("3", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 2 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("microceph_auto_osd\nmicroceph_auto_host\nmicroceph_auto_rack", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_rack").Return(`{ "rule_id": 3 }`, nil).Once()
+	processExec = r
+
+	c := mocks.NewMemberCounterInterface(s.T())
+	c.On("Count", mock.Anything).Return(3, nil).Once()
+	database.MemberCounter = c
+
+	sq := mocks.NewStretchQueryInterface(s.T())
+	sq.On("Get", mock.Anything, mock.Anything).Return(types.StretchMode{}, nil).Once()
+	database.StretchQuery = sq
+
+	err := updateFailureDomain(context.Background(), &mocks.MockState{URL: api.NewURL(), ClusterName: "foohost"})
+	assert.NoError(s.T(), err)
+}
+
+// TestSwitchToRackFailureDomain checks the osd and host rules are switched to rack on request.
+func (s *osdSuite) TestSwitchToRackFailureDomain() {
+	rules := "microceph_auto_osd\nmicroceph_auto_host"
+	pools := `[{"pool_name": "foopool", "crush_rule": 2}]`
+
+	r := mocks.NewRunner(s.T())
+	// the rack rule gets added
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "create-replicated", "microceph_auto_rack", "default", "rack").Return("ok", nil).Once()
+	// osd and host rules are switched in turn
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_rack").Return(`{ "rule_id": 3 }`, nil).Twice()
+	r.On("RunCommand", "ceph", "config", "set", "global", "osd_pool_default_crush_rule", "3", "-f", "json-pretty").Return("ok", nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules+"\nmicroceph_auto_rack", nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_osd").Return(`{ "rule_id": 0 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 2 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "--format=json").Return(pools, nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "pool", "set", "foopool", "crush_rule", "microceph_auto_rack").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return("[]", nil).Twice()
+	processExec = r
+
+	s.addRackExpectations()
+
+	err := switchToRackFailureDomain(context.Background(), &mocks.MockState{URL: api.NewURL(), ClusterName: "foohost"})
+	assert.NoError(s.T(), err)
+}
+
+// addRackExpectations records 3 members holding OSDs in 3 racks.
+func (s *osdSuite) addRackExpectations() {
+	l := mocks.NewCrushLocationQueryInterface(s.T())
+	l.On("List", mock.Anything, mock.Anything).Return(map[string]string{
+		"node1": "rack=r1",
+		"node2": "rack=r2",
+		"node3": "rack=r3",
+	}, nil).Once()
+	database.CrushLocationQuery = l
+
+	q := mocks.NewOSDQueryInterface(s.T())
+	q.On("List", mock.Anything, mock.Anything).Return(types.Disks{
+		{OSD: 0, Location: "node1"},
+		{OSD: 1, Location: "node2"},
+		{OSD: 2, Location: "node3"},
+	}, nil).Once()
+	database.OSDQuery = q
+}
+
 // TestHaveOSDInCeph tests the haveOSDInCeph function
 func (s *osdSuite) TestHaveOSDInCeph() {
 	r := mocks.NewRunner(s.T())
@@ -0,0 +1,98 @@
+package ceph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/logger"
+)
+
+// PGStateCount holds the number of PGs in a given state.
+type PGStateCount struct {
+	Name string `json:"name"`
+	Num  int64  `json:"num"`
+}
+
+// PGSummary holds the PG summary of the cluster.
+type PGSummary struct {
+	NumPGByState []PGStateCount `json:"num_pg_by_state"`
+	NumPGs       int64          `json:"num_pgs"`
+}
+
+// PGStat holds the output of 'ceph pg stat'.
+type PGStat struct {
+	PGReady   bool      `json:"pg_ready"`
+	PGSummary PGSummary `json:"pg_summary"`
+}
+
+// getPGStat fetches the current PG summary of the cluster.
+func getPGStat() (PGStat, error) {
+	var stat PGStat
+
+	out, err := processExec.RunCommand("ceph", "pg", "stat", "-f", "json")
+	if err != nil {
+		return stat, fmt.Errorf("failed to get pg stat: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(out), &stat)
+	if err != nil {
+		return stat, fmt.Errorf("failed to parse pg stat: %w", err)
+	}
+
+	return stat, nil
+}
+
+// isActiveClean returns true if a '+' separated PG state is both active and clean.
+func isActiveClean(state string) bool {
+	active, clean := false, false
+	for _, s := range strings.Split(state, "+") {
+		switch s {
+		case "active":
+			active = true
+		case "clean":
+			clean = true
+		}
+	}
+
+	return active && clean
+}
+
+// ActiveClean returns the number of PGs that are active+clean.
+func (p PGStat) ActiveClean() int64 {
+	var num int64
+	for _, state := range p.PGSummary.NumPGByState {
+		if isActiveClean(state.Name) {
+			num += state.Num
+		}
+	}
+
+	return num
+}
+
+// AllActiveClean returns true if every PG of the cluster is active+clean.
+func (p PGStat) AllActiveClean() bool {
+	return p.ActiveClean() == p.PGSummary.NumPGs
+}
+
+// waitForRecovery polls the cluster until all PGs are active+clean or the context is done.
+func waitForRecovery(ctx context.Context, interval time.Duration) error {
+	for {
+		stat, err := getPGStat()
+		if err != nil {
+			logger.Warnf("Failed to check recovery state: %v", err)
+		} else if stat.AllActiveClean() {
+			return nil
+		} else {
+			logger.Debugf("Waiting for recovery, %d/%d PGs active+clean", stat.ActiveClean(), stat.PGSummary.NumPGs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for recovery: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ceph
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// CreatePool creates a replicated pool. Pools pinned to a device class use the automatic
+// crush rule of the class for the current failure domain.
+func CreatePool(req types.PoolPost) error {
+	if req.Name == "" {
+		return api.StatusErrorf(http.StatusBadRequest, "pool name is required")
+	}
+
+	rule := ""
+	if req.DeviceClass != "" {
+		classes, err := listDeviceClasses()
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(classes, req.DeviceClass) {
+			return api.StatusErrorf(http.StatusBadRequest, "device class %q is not used by any OSD", req.DeviceClass)
+		}
+
+		domain, err := getFailureDomain()
+		if err != nil {
+			return fmt.Errorf("failed to get failure domain: %w", err)
+		}
+
+		err = ensureClassCrushRule(domain, req.DeviceClass)
+		if err != nil {
+			return err
+		}
+
+		rule = classCrushRuleName(domain, req.DeviceClass)
+	}
+
+	_, err := processExec.RunCommand("ceph", "osd", "pool", "create", req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create pool %s: %w", req.Name, err)
+	}
+
+	if rule != "" {
+		logger.Debugf("Setting pool %v crush rule to %v", req.Name, rule)
+		err = setPoolCrushRule(req.Name, rule)
+		if err != nil {
+			return fmt.Errorf("failed to set crush rule of pool %s: %w", req.Name, err)
+		}
+	}
+
+	if req.Size > 0 {
+		_, err = processExec.RunCommand("ceph", "osd", "pool", "set", req.Name, "size", fmt.Sprintf("%d", req.Size), "--yes-i-really-mean-it")
+		if err != nil {
+			return fmt.Errorf("failed to set pool size for %s: %w", req.Name, err)
+		}
+	}
+
+	if req.Application != "" {
+		_, err = processExec.RunCommand("ceph", "osd", "pool", "application", "enable", req.Name, req.Application)
+		if err != nil {
+			return fmt.Errorf("failed to enable application %s on pool %s: %w", req.Application, req.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package ceph
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type poolSuite struct {
+	tests.BaseSuite
+}
+
+func TestPool(t *testing.T) {
+	suite.Run(t, new(poolSuite))
+}
+
+func (s *poolSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+func (s *poolSuite) TestCreatePool() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "pool", "create", "data").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "set", "data", "size", "2", "--yes-i-really-mean-it").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "application", "enable", "data", "rbd").Return("ok", nil).Once()
+	processExec = r
+
+	err := CreatePool(types.PoolPost{Name: "data", Size: 2, Application: "rbd"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *poolSuite) TestCreatePoolDeviceClass() {
+	rules := "microceph_auto_osd\nmicroceph_auto_host\nmicroceph_auto_osd_ssd\nmicroceph_auto_host_ssd"
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return(`["hdd", "ssd"]`, nil).Once()
+	// the cluster is at host level
+	r.On("RunCommand", "ceph", "config", "get", "mon", "osd_pool_default_crush_rule").Return("2", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_auto_host").Return(`{ "rule_id": 2 }`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return(rules, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "create", "fast").Return("ok", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "pool", "set", "fast", "crush_rule", "microceph_auto_host_ssd").Return("ok", nil).Once()
+	processExec = r
+
+	err := CreatePool(types.PoolPost{Name: "fast", DeviceClass: "ssd"})
+	assert.NoError(s.T(), err)
+}
+
+func (s *poolSuite) TestCreatePoolUnknownDeviceClass() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "crush", "class", "ls").Return(`["hdd"]`, nil).Once()
+	processExec = r
+
+	err := CreatePool(types.PoolPost{Name: "fast", DeviceClass: "nvme"})
+	assert.True(s.T(), api.StatusErrorCheck(err, http.StatusBadRequest))
+}
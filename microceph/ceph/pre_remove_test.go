@@ -1,8 +1,12 @@
 package ceph
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
 
+	"github.com/canonical/lxd/shared/api"
+
 	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/client"
 	"github.com/canonical/microceph/microceph/mocks"
@@ -46,13 +50,57 @@ func (s *clusterRemoveSuite) TestRemoveNode() {
 		servicesData,
 		nil,
 	)
-	m.On("DeleteService", mock.Anything, "foonode", "mon").Return(nil).Once()
+	m.On("GetStretchMode", mock.Anything).Return(types.StretchMode{}, nil).Once()
+	m.On("DeleteService", mock.Anything, "foonode", "mon", false).Return(nil).Once()
+
+	err := removeNode(nil, "foonode", false)
+
+	assert.NoError(s.T(), err)
+}
+
+// TestRemoveNodeWithoutStretchEndpoint tests removal goes ahead when the member predates stretch mode
+func (s *clusterRemoveSuite) TestRemoveNodeWithoutStretchEndpoint() {
+	m := mocks.NewClientInterface(s.T())
+
+	client.MClient = m
+	m.On("GetClusterMembers", mock.Anything).Return([]string{"foonode", "barnode", "quuxnode", "baznode"}, nil).Once()
+	m.On("GetDisks", mock.Anything).Return(types.Disks{}, nil).Once()
+
+	services := types.Services{}
+	for _, node := range []string{"foonode", "barnode", "quuxnode", "baznode"} {
+		services = append(services, types.Service{Service: "mon", Location: node})
+	}
+	services = append(services, types.Service{Service: "mgr", Location: "barnode"}, types.Service{Service: "mds", Location: "barnode"})
+	m.On("GetServices", mock.Anything).Return(services, nil)
+	m.On("GetStretchMode", mock.Anything).Return(types.StretchMode{}, fmt.Errorf("failed to fetch stretch mode: %w", api.StatusErrorf(http.StatusNotFound, "not found"))).Once()
+	m.On("DeleteService", mock.Anything, "foonode", "mon", false).Return(nil).Once()
 
 	err := removeNode(nil, "foonode", false)
 
 	assert.NoError(s.T(), err)
 }
 
+// TestRemoveNodeStretchModeError tests removal is refused when the stretch mode can't be fetched
+func (s *clusterRemoveSuite) TestRemoveNodeStretchModeError() {
+	m := mocks.NewClientInterface(s.T())
+
+	client.MClient = m
+	m.On("GetClusterMembers", mock.Anything).Return([]string{"foonode", "barnode", "quuxnode", "baznode"}, nil).Once()
+	m.On("GetDisks", mock.Anything).Return(types.Disks{}, nil).Once()
+
+	services := types.Services{}
+	for _, node := range []string{"foonode", "barnode", "quuxnode", "baznode"} {
+		services = append(services, types.Service{Service: "mon", Location: node})
+	}
+	services = append(services, types.Service{Service: "mgr", Location: "barnode"}, types.Service{Service: "mds", Location: "barnode"})
+	m.On("GetServices", mock.Anything).Return(services, nil).Once()
+	m.On("GetStretchMode", mock.Anything).Return(types.StretchMode{}, fmt.Errorf("failed to fetch stretch mode: %w", api.StatusErrorf(http.StatusInternalServerError, "database is locked"))).Once()
+
+	err := removeNode(nil, "foonode", false)
+
+	assert.ErrorContains(s.T(), err, "Error getting stretch mode")
+}
+
 // TestRemoveNodeWithDisks tests that we don't try to delete a node that has OSDs
 func (s *clusterRemoveSuite) TestRemoveNodeWithDisks() {
 	m := mocks.NewClientInterface(s.T())
@@ -107,9 +155,37 @@ func (s *clusterRemoveSuite) TestRemoveNodeForce() {
 		},
 		nil,
 	)
-	m.On("DeleteService", mock.Anything, "foonode", "mon").Return(nil).Once()
+	m.On("DeleteService", mock.Anything, "foonode", "mon", true).Return(nil).Once()
 
 	err := removeNode(nil, "foonode", true)
 
 	assert.NoError(s.T(), err)
 }
+
+// TestRemoveNodeStretchTiebreaker tests that we don't delete the tiebreaker of a stretch cluster
+func (s *clusterRemoveSuite) TestRemoveNodeStretchTiebreaker() {
+	m := mocks.NewClientInterface(s.T())
+
+	client.MClient = m
+	m.On("GetClusterMembers", mock.Anything).Return([]string{"node1", "node2", "node3", "node4", "node5"}, nil).Once()
+	m.On("GetDisks", mock.Anything).Return(types.Disks{}, nil).Once()
+
+	services := types.Services{}
+	for _, node := range []string{"node1", "node2", "node3", "node4", "node5"} {
+		services = append(services, types.Service{Service: "mon", Location: node})
+	}
+	services = append(services, types.Service{Service: "mgr", Location: "node1"}, types.Service{Service: "mds", Location: "node1"})
+	m.On("GetServices", mock.Anything).Return(services, nil).Once()
+	m.On("GetStretchMode", mock.Anything).Return(types.StretchMode{
+		Enabled:    true,
+		Tiebreaker: "node5",
+		Sites: []types.StretchSite{
+			{Name: "dc1", Members: []string{"node1", "node2"}},
+			{Name: "dc2", Members: []string{"node3", "node4"}},
+		},
+	}, nil).Once()
+
+	err := removeNode(nil, "node5", false)
+
+	assert.ErrorContains(s.T(), err, "tiebreaker")
+}
@@ -0,0 +1,703 @@
+package ceph
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/lxd/shared/revert"
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// OperationTypeRecoverMonStore is the type of mon store recovery operations.
+const OperationTypeRecoverMonStore = "recover-mon-store"
+
+// recoveredKeyCaps are the capabilities given to the keys of daemons added to a rebuilt mon store.
+var recoveredKeyCaps = map[string][][]string{
+	"mgr": {{"mon", "allow profile mgr"}, {"osd", "allow *"}, {"mds", "allow *"}},
+	"mds": {{"mon", "allow profile mds"}, {"mgr", "allow profile mds"}, {"mds", "allow *"}, {"osd", "allow *"}},
+}
+
+// packDir returns a gzipped tarball of the regular files under dir.
+func packDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := newBackupWriter(&buf)
+	err := w.addDir("", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// unpackDir extracts a tarball made by packDir into dir. An empty tarball leaves dir empty.
+func unpackDir(data []byte, dir string) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return extractArchive(bytes.NewReader(data), dir)
+}
+
+// localDaemonKeys returns the keys of the manager and metadata server running on this member.
+func localDaemonKeys(hostname string) map[string]string {
+	keys := map[string]string{}
+	for _, service := range []string{"mgr", "mds"} {
+		path := filepath.Join(constants.GetPathConst().DataPath, service, fmt.Sprintf("ceph-%s", hostname), "keyring")
+		key, err := parseKeyring(path)
+		if err != nil {
+			continue
+		}
+
+		keys[fmt.Sprintf("%s.%s", service, hostname)] = key
+	}
+
+	return keys
+}
+
+// collectOSDMaps adds the cluster maps held by the given OSDs to the mon store in storePath.
+// The OSDs need to be stopped.
+func collectOSDMaps(storePath string, osdPaths []string) error {
+	for _, osdPath := range osdPaths {
+		_, err := processExec.RunCommand("ceph-objectstore-tool", "--data-path", osdPath, "--no-mon-config", "--op", "update-mon-db", "--mon-store-path", storePath)
+		if err != nil {
+			return fmt.Errorf("failed to collect cluster maps from %s: %w", filepath.Base(osdPath), err)
+		}
+	}
+
+	return nil
+}
+
+// CollectMonStore stops the OSDs of this member and adds the cluster maps they hold to the
+// given mon store. The OSDs are left stopped, they can't start before the monitor is back.
+func CollectMonStore(req types.MonStoreCollectPost, hostname string) (types.MonStoreCollectResult, error) {
+	result := types.MonStoreCollectResult{}
+
+	dir, err := os.MkdirTemp("", "microceph-mon-store")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	err = unpackDir(req.Store, dir)
+	if err != nil {
+		return result, err
+	}
+
+	err = snapStop("osd", false)
+	if err != nil {
+		return result, fmt.Errorf("failed to stop OSDs: %w", err)
+	}
+
+	osdPaths, err := filepath.Glob(filepath.Join(constants.GetPathConst().DataPath, "osd", "ceph-*"))
+	if err != nil {
+		return result, err
+	}
+
+	err = collectOSDMaps(dir, osdPaths)
+	if err != nil {
+		return result, err
+	}
+
+	result.Store, err = packDir(dir)
+	if err != nil {
+		return result, err
+	}
+
+	result.Keys = localDaemonKeys(hostname)
+	return result, nil
+}
+
+// addKey adds an existing key to a keyring.
+func addKey(path string, name string, key string, caps ...[]string) error {
+	args := []string{path, "--add-key", key, "-n", name}
+	for _, capability := range caps {
+		args = append(args, "--cap", capability[0], capability[1])
+	}
+
+	_, err := processExec.RunCommand("ceph-authtool", args...)
+	if err != nil {
+		return fmt.Errorf("failed to add key of %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// genRecoveryKeyring writes the keyring a mon store is rebuilt with: a new monitor key, the
+// admin key recorded in the database and the keys of the daemons of the cluster.
+func genRecoveryKeyring(path string, adminKey string, keys map[string]string) error {
+	err := genKeyring(path, "mon.", []string{"mon", "allow *"})
+	if err != nil {
+		return fmt.Errorf("failed to generate monitor keyring: %w", err)
+	}
+
+	err = addKey(path, "client.admin", adminKey, []string{"mon", "allow *"}, []string{"osd", "allow *"}, []string{"mds", "allow *"}, []string{"mgr", "allow *"})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		service := strings.SplitN(name, ".", 2)[0]
+		err = addKey(path, name, keys[name], recoveredKeyCaps[service]...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildMonStore rebuilds the mon store collected in storePath and turns it into the store
+// of a new monitor on this member, bootstrapped the way the first monitor of a cluster is.
+func rebuildMonStore(s interfaces.StateInterface, storePath string, keyring string, fsid string, address string) error {
+	hostname := s.ClusterState().Name()
+
+	_, err := processExec.RunCommand("ceph-monstore-tool", storePath, "rebuild", "--", "--keyring", keyring, "--mon-ids", hostname)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild mon store: %w", err)
+	}
+
+	path, err := os.MkdirTemp("", "")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(path)
+
+	err = createMonMap(s, path, fsid, address)
+	if err != nil {
+		return err
+	}
+
+	monDataPath := filepath.Join(constants.GetPathConst().DataPath, "mon", fmt.Sprintf("ceph-%s", hostname))
+	oldPath := monDataPath + ".corrupted"
+
+	err = os.RemoveAll(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", oldPath, err)
+	}
+
+	err = os.Rename(monDataPath, oldPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to move aside the mon store: %w", err)
+	}
+
+	err = os.MkdirAll(monDataPath, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", monDataPath, err)
+	}
+
+	err = bootstrapMon(hostname, monDataPath, filepath.Join(path, "mon.map"), keyring)
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap monitor: %w", err)
+	}
+
+	// Swap the empty store of the new monitor for the rebuilt one.
+	err = os.RemoveAll(filepath.Join(monDataPath, "store.db"))
+	if err != nil {
+		return fmt.Errorf("failed to remove empty mon store: %w", err)
+	}
+
+	err = os.Rename(filepath.Join(storePath, "store.db"), filepath.Join(monDataPath, "store.db"))
+	if err != nil {
+		return fmt.Errorf("failed to move rebuilt mon store: %w", err)
+	}
+
+	_, err = processExec.RunCommand("ceph-mon", "-i", hostname, "--mon-data", monDataPath, "--inject-monmap", filepath.Join(path, "mon.map"))
+	if err != nil {
+		return fmt.Errorf("failed to inject monmap: %w", err)
+	}
+
+	return nil
+}
+
+// recoveryMembers returns the members whose OSDs hold cluster maps, sorted by name. Members
+// which are offline are only skipped when forced to.
+func recoveryMembers(disks []database.Disk, online []string, force bool) ([]string, []string, error) {
+	members := []string{}
+	offline := []string{}
+	for _, disk := range disks {
+		if slices.Contains(members, disk.Member) || slices.Contains(offline, disk.Member) {
+			continue
+		}
+
+		if slices.Contains(online, disk.Member) {
+			members = append(members, disk.Member)
+		} else {
+			offline = append(offline, disk.Member)
+		}
+	}
+
+	sort.Strings(members)
+	sort.Strings(offline)
+
+	if len(members) == 0 {
+		return nil, nil, fmt.Errorf("no online member has OSDs to recover the mon store from")
+	}
+
+	if len(offline) > 0 && !force {
+		return nil, nil, fmt.Errorf("members %s are offline, the maps held by their OSDs would be missing", strings.Join(offline, ", "))
+	}
+
+	return members, offline, nil
+}
+
+// recordSoleMon makes the monitor of this member the only one recorded in the database.
+// Returns the members whose monitors were dropped.
+func recordSoleMon(ctx context.Context, s interfaces.StateInterface, address string) ([]string, error) {
+	hostname := s.ClusterState().Name()
+	removed := []string{}
+
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		serviceName := "mon"
+		mons, err := database.GetServices(ctx, tx, database.ServiceFilter{Service: &serviceName})
+		if err != nil {
+			return fmt.Errorf("failed to fetch monitors: %w", err)
+		}
+
+		for _, mon := range mons {
+			if mon.Member == hostname {
+				continue
+			}
+
+			err = database.DeleteService(ctx, tx, mon.Member, "mon")
+			if err != nil {
+				return fmt.Errorf("failed to remove monitor of %s from db: %w", mon.Member, err)
+			}
+
+			err = database.DeleteConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", mon.Member))
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return err
+			}
+
+			removed = append(removed, mon.Member)
+		}
+
+		exists, err := database.ServiceExists(ctx, tx, hostname, "mon")
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			_, err = database.CreateService(ctx, tx, database.Service{Member: hostname, Service: "mon"})
+			if err != nil {
+				return fmt.Errorf("failed to record monitor: %w", err)
+			}
+		}
+
+		return upsertConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", hostname), address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// StartMonStoreRecovery rebuilds the mon store from the cluster maps held by the OSDs of
+// all members, once every monitor is lost. The monitor of this member is recreated from it,
+// and becomes the only monitor of the cluster.
+func StartMonStoreRecovery(ctx context.Context, s interfaces.StateInterface, req types.RecoverMonStorePost) (types.Operation, error) {
+	if !req.Force {
+		_, err := cephRun("--connect-timeout", "15", "quorum_status")
+		if err == nil {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "monitors are in quorum, there is no mon store to recover")
+		}
+	}
+
+	config, err := GetConfigDb(ctx, s)
+	if err != nil {
+		return types.Operation{}, fmt.Errorf("failed to get config db: %w", err)
+	}
+
+	if config["fsid"] == "" || config[constants.AdminKeyringFieldName] == "" {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the database has no fsid or admin key to recover the mon store with")
+	}
+
+	return StartAsyncOperation(ctx, s, OperationTypeRecoverMonStore, func(ctx context.Context, op *AsyncOperation) (string, error) {
+		result, err := recoverMonStore(ctx, s, op, config, req.Force)
+
+		data, jsonErr := json.Marshal(result)
+		if jsonErr != nil {
+			logger.Errorf("Failed to encode mon store recovery result: %v", jsonErr)
+		}
+
+		return string(data), err
+	})
+}
+
+func recoverMonStore(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, config map[string]string, force bool) (types.RecoverMonStoreResult, error) {
+	hostname := s.ClusterState().Name()
+	result := types.RecoverMonStoreResult{Mon: hostname, Members: []string{}, Warnings: []string{}}
+
+	address := config[fmt.Sprintf("mon.host.%s", hostname)]
+	if address == "" {
+		var err error
+		address, err = common.Network.FindIpOnSubnet(config["public_network"])
+		if err != nil {
+			return result, fmt.Errorf("failed to find an address on the public network %s: %w", config["public_network"], err)
+		}
+	}
+
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return result, fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	clusterMembers, err := leader.GetClusterMembers(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to get cluster members: %w", err)
+	}
+
+	online := []string{}
+	for _, member := range clusterMembers {
+		if member.Status == microTypes.MemberOnline {
+			online = append(online, member.Name)
+		}
+	}
+
+	db, err := dumpDatabase(ctx, s)
+	if err != nil {
+		return result, err
+	}
+
+	members, offline, err := recoveryMembers(db.Disks, online, force)
+	if err != nil {
+		return result, err
+	}
+
+	for _, member := range offline {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("skipped OSDs of offline member %s", member))
+	}
+
+	// Pass the store from member to member, each adding the maps held by its OSDs.
+	store := []byte{}
+	keys := localDaemonKeys(hostname)
+	for _, member := range members {
+		op.SetProgress(fmt.Sprintf("collecting cluster maps from the OSDs of %s", member))
+
+		req := types.MonStoreCollectPost{Store: store}
+		var collected types.MonStoreCollectResult
+		if member == hostname {
+			collected, err = CollectMonStore(req, hostname)
+		} else {
+			collected, err = client.CollectMonStore(ctx, leader.UseTarget(member), &req)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to collect cluster maps on %s: %w", member, err)
+		}
+
+		store = collected.Store
+		for name, key := range collected.Keys {
+			keys[name] = key
+		}
+
+		result.Members = append(result.Members, member)
+	}
+
+	op.SetProgress("rebuilding mon store")
+	path, err := os.MkdirTemp("", "microceph-mon-store")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(path)
+
+	storePath := filepath.Join(path, "store")
+	err = unpackDir(store, storePath)
+	if err != nil {
+		return result, err
+	}
+
+	keyring := filepath.Join(path, "keyring")
+	err = genRecoveryKeyring(keyring, config[constants.AdminKeyringFieldName], keys)
+	if err != nil {
+		return result, err
+	}
+
+	err = snapStop("mon", false)
+	if err != nil {
+		logger.Warnf("Failed to stop the monitor of %s: %v", hostname, err)
+	}
+
+	err = rebuildMonStore(s, storePath, keyring, config["fsid"], address)
+	if err != nil {
+		return result, err
+	}
+
+	_, err = recordSoleMon(ctx, s, address)
+	if err != nil {
+		return result, err
+	}
+
+	err = UpdateConfig(ctx, s)
+	if err != nil {
+		return result, fmt.Errorf("failed to update config: %w", err)
+	}
+
+	op.SetProgress(fmt.Sprintf("starting monitor on %s", hostname))
+	err = startMon()
+	if err != nil {
+		return result, err
+	}
+
+	err = client.SendUpdateClientConfRequestToClusterMembers(ctx, s)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to update the config of other members: %v", err))
+	}
+
+	// Bring back the daemons which were stopped or lost their monitors.
+	for _, member := range members {
+		op.SetProgress(fmt.Sprintf("restarting daemons on %s", member))
+
+		services := types.Services{}
+		for _, service := range []string{"mgr", "osd", "mds"} {
+			if service == "osd" || slices.Contains(memberServices(db.Services, member), service) {
+				services = append(services, types.Service{Service: service})
+			}
+		}
+
+		if member == hostname {
+			names := []string{}
+			for _, service := range services {
+				names = append(names, service.Service)
+			}
+
+			err = RestartCephServices(ctx, s, names)
+		} else {
+			err = client.RestartService(ctx, leader.UseTarget(member), &services)
+		}
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to restart daemons on %s: %v", member, err))
+		}
+	}
+
+	return result, nil
+}
+
+// monmapNames returns the names of the monitors in the output of 'monmaptool --print'.
+func monmapNames(output string) []string {
+	names := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasSuffix(fields[0], ":") || !strings.HasPrefix(fields[len(fields)-1], "mon.") {
+			continue
+		}
+
+		names = append(names, strings.TrimPrefix(fields[len(fields)-1], "mon."))
+	}
+
+	return names
+}
+
+// quorumRemovals returns the monitors of the monmap to remove when recovering quorum: the
+// requested ones, or else the ones of members which aren't online. The kept monitor stays.
+func quorumRemovals(names []string, keep string, online []string, remove []string) ([]string, error) {
+	if !slices.Contains(names, keep) {
+		return nil, fmt.Errorf("mon.%s isn't part of the monmap", keep)
+	}
+
+	if len(remove) > 0 {
+		for _, name := range remove {
+			if name == keep {
+				return nil, fmt.Errorf("mon.%s is kept, it can't be removed", keep)
+			}
+
+			if !slices.Contains(names, name) {
+				return nil, fmt.Errorf("mon.%s isn't part of the monmap", name)
+			}
+		}
+
+		return remove, nil
+	}
+
+	removals := []string{}
+	for _, name := range names {
+		if name != keep && !slices.Contains(online, name) {
+			removals = append(removals, name)
+		}
+	}
+
+	if len(removals) == 0 {
+		return nil, fmt.Errorf("the monitors of the monmap are all on online members, name the ones to remove")
+	}
+
+	return removals, nil
+}
+
+// editMonmap removes monitors from the monmap at path, as selected by quorumRemovals. Returns
+// the removed monitors.
+func editMonmap(path string, keep string, online []string, remove []string) ([]string, error) {
+	output, err := processExec.RunCommand("monmaptool", "--print", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to print monmap: %w", err)
+	}
+
+	removals, err := quorumRemovals(monmapNames(output), keep, online, remove)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, name := range removals {
+		_, err = processExec.RunCommand("monmaptool", path, "--rm", name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove mon.%s from monmap: %w", name, err)
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
+
+// forgetMons drops the monitors of the given members from the database.
+func forgetMons(ctx context.Context, s interfaces.StateInterface, members []string) error {
+	return s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, member := range members {
+			err := database.DeleteService(ctx, tx, member, "mon")
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return fmt.Errorf("failed to remove monitor of %s from db: %w", member, err)
+			}
+
+			err = database.DeleteConfigItem(ctx, tx, fmt.Sprintf("mon.host.%s", member))
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecoverQuorum restores quorum after a majority of monitors is lost, by removing the lost
+// monitors from the monmap of the kept member: the requested ones, or else the ones of members
+// which aren't online. The request is forwarded to the kept member as its monitor has to be
+// stopped to edit the monmap. The monitor is started again should the edit fail.
+func RecoverQuorum(ctx context.Context, s interfaces.StateInterface, req types.RecoverQuorumPost) (types.RecoverQuorumResult, error) {
+	hostname := s.ClusterState().Name()
+	if req.Keep != hostname {
+		leader, err := s.ClusterState().Leader()
+		if err != nil {
+			return types.RecoverQuorumResult{}, fmt.Errorf("failed to get dqlite leader: %w", err)
+		}
+
+		return client.RecoverQuorum(ctx, leader.UseTarget(req.Keep), &req)
+	}
+
+	result := types.RecoverQuorumResult{Mon: hostname, Removed: []string{}, Warnings: []string{}}
+
+	services, err := ListServices(ctx, s.ClusterState())
+	if err != nil {
+		return result, err
+	}
+
+	if !isServicePlacementOnHost(services, "mon", hostname) {
+		return result, api.StatusErrorf(http.StatusBadRequest, "%s doesn't run a monitor", hostname)
+	}
+
+	if !req.Force {
+		_, err = cephRun("--connect-timeout", "15", "quorum_status")
+		if err == nil {
+			return result, api.StatusErrorf(http.StatusConflict, "monitors are in quorum, there is no quorum to recover")
+		}
+	}
+
+	online, _, err := onlineMembers(ctx, s)
+	if err != nil {
+		return result, err
+	}
+
+	path, err := os.MkdirTemp("", "microceph-monmap")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(path)
+
+	err = snapStop("mon", false)
+	if err != nil {
+		return result, fmt.Errorf("failed to stop mon.%s: %w", hostname, err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() {
+		err := startMon()
+		if err != nil {
+			logger.Errorf("Failed to start mon.%s after failed quorum recovery: %v", hostname, err)
+		}
+	})
+
+	monDataPath := filepath.Join(constants.GetPathConst().DataPath, "mon", fmt.Sprintf("ceph-%s", hostname))
+	monmap := filepath.Join(path, "monmap")
+	_, err = processExec.RunCommand("ceph-mon", "-i", hostname, "--mon-data", monDataPath, "--extract-monmap", monmap)
+	if err != nil {
+		return result, fmt.Errorf("failed to extract monmap: %w", err)
+	}
+
+	removed, err := editMonmap(monmap, hostname, online, req.Remove)
+	if err != nil {
+		return result, err
+	}
+
+	_, err = processExec.RunCommand("ceph-mon", "-i", hostname, "--mon-data", monDataPath, "--inject-monmap", monmap)
+	if err != nil {
+		return result, fmt.Errorf("failed to inject monmap: %w", err)
+	}
+
+	revert.Success()
+	err = startMon()
+	if err != nil {
+		return result, err
+	}
+
+	result.Removed = removed
+	err = forgetMons(ctx, s, removed)
+	if err != nil {
+		return result, err
+	}
+
+	err = UpdateConfig(ctx, s)
+	if err != nil {
+		return result, fmt.Errorf("failed to update config: %w", err)
+	}
+
+	err = client.SendUpdateClientConfRequestToClusterMembers(ctx, s)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to update the config of other members: %v", err))
+	}
+
+	return result, nil
+}
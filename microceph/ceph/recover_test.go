@@ -0,0 +1,162 @@
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type recoverSuite struct {
+	tests.BaseSuite
+}
+
+func TestRecover(t *testing.T) {
+	suite.Run(t, new(recoverSuite))
+}
+
+// TestPackUnpackDir checks a mon store survives the round trip between members.
+func (s *recoverSuite) TestPackUnpackDir() {
+	src := filepath.Join(s.Tmp, "store")
+	assert.NoError(s.T(), os.MkdirAll(filepath.Join(src, "store.db"), 0700))
+	assert.NoError(s.T(), os.WriteFile(filepath.Join(src, "store.db", "CURRENT"), []byte("MANIFEST-000001\n"), 0600))
+
+	data, err := packDir(src)
+	assert.NoError(s.T(), err)
+
+	dst := filepath.Join(s.Tmp, "copy")
+	assert.NoError(s.T(), unpackDir(data, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "store.db", "CURRENT"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "MANIFEST-000001\n", string(content))
+
+	// An empty store leaves the directory untouched.
+	assert.NoError(s.T(), unpackDir(nil, filepath.Join(s.Tmp, "empty")))
+}
+
+// TestRecoveryMembers checks the members holding OSDs are picked and offline ones refused unless forced.
+func (s *recoverSuite) TestRecoveryMembers() {
+	disks := []database.Disk{
+		{ID: 1, Member: "b", Path: "/dev/sdb"},
+		{ID: 2, Member: "a", Path: "/dev/sdb"},
+		{ID: 3, Member: "b", Path: "/dev/sdc"},
+		{ID: 4, Member: "c", Path: "/dev/sdb"},
+	}
+
+	members, offline, err := recoveryMembers(disks, []string{"a", "b", "c", "d"}, false)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"a", "b", "c"}, members)
+	assert.Empty(s.T(), offline)
+
+	_, _, err = recoveryMembers(disks, []string{"a", "b"}, false)
+	assert.ErrorContains(s.T(), err, "members c are offline")
+
+	members, offline, err = recoveryMembers(disks, []string{"a", "b"}, true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"a", "b"}, members)
+	assert.Equal(s.T(), []string{"c"}, offline)
+
+	_, _, err = recoveryMembers(disks, []string{"d"}, true)
+	assert.ErrorContains(s.T(), err, "no online member")
+}
+
+// TestCollectOSDMaps checks the maps of each OSD are added to the store in turn.
+func (s *recoverSuite) TestCollectOSDMaps() {
+	r := mocks.NewRunner(s.T())
+	for _, osd := range []string{"/osd/ceph-0", "/osd/ceph-1"} {
+		r.On("RunCommand", "ceph-objectstore-tool", "--data-path", osd, "--no-mon-config", "--op", "update-mon-db", "--mon-store-path", "/store").Return("", nil).Once()
+	}
+	processExec = r
+
+	assert.NoError(s.T(), collectOSDMaps("/store", []string{"/osd/ceph-0", "/osd/ceph-1"}))
+
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph-objectstore-tool", "--data-path", "/osd/ceph-2", "--no-mon-config", "--op", "update-mon-db", "--mon-store-path", "/store").Return("", fmt.Errorf("osd is running")).Once()
+	processExec = r
+
+	err := collectOSDMaps("/store", []string{"/osd/ceph-2"})
+	assert.ErrorContains(s.T(), err, "failed to collect cluster maps from ceph-2")
+}
+
+// TestGenRecoveryKeyring checks the keyring holds the admin key and the keys of the daemons with their caps.
+func (s *recoverSuite) TestGenRecoveryKeyring() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph-authtool", "--create-keyring", "/kr", "--gen-key", "-n", "mon.", "--cap", "mon", "allow *").Return("", nil).Once()
+	r.On("RunCommand", "ceph-authtool", "/kr", "--add-key", "adminkey", "-n", "client.admin",
+		"--cap", "mon", "allow *", "--cap", "osd", "allow *", "--cap", "mds", "allow *", "--cap", "mgr", "allow *").Return("", nil).Once()
+	r.On("RunCommand", "ceph-authtool", "/kr", "--add-key", "mdskey", "-n", "mds.a",
+		"--cap", "mon", "allow profile mds", "--cap", "mgr", "allow profile mds", "--cap", "mds", "allow *", "--cap", "osd", "allow *").Return("", nil).Once()
+	r.On("RunCommand", "ceph-authtool", "/kr", "--add-key", "mgrkey", "-n", "mgr.a",
+		"--cap", "mon", "allow profile mgr", "--cap", "osd", "allow *", "--cap", "mds", "allow *").Return("", nil).Once()
+	processExec = r
+
+	err := genRecoveryKeyring("/kr", "adminkey", map[string]string{"mgr.a": "mgrkey", "mds.a": "mdskey"})
+	assert.NoError(s.T(), err)
+}
+
+const monmapPrint = `monmaptool: monmap file /tmp/monmap
+epoch 3
+fsid 4ebf4a4b-8f4e-4a3c-9c3e-0c1a2b3c4d5e
+last_changed 2024-05-02T10:00:00.000000+0000
+created 2024-05-01T10:00:00.000000+0000
+min_mon_release 19 (squid)
+election_strategy: 1
+0: [v2:10.0.0.1:3300/0,v1:10.0.0.1:6789/0] mon.node1
+1: [v2:10.0.0.2:3300/0,v1:10.0.0.2:6789/0] mon.node2
+2: [v2:10.0.0.3:3300/0,v1:10.0.0.3:6789/0] mon.node3
+`
+
+func (s *recoverSuite) TestMonmapNames() {
+	assert.Equal(s.T(), []string{"node1", "node2", "node3"}, monmapNames(monmapPrint))
+	assert.Empty(s.T(), monmapNames("epoch 1\n"))
+}
+
+// TestEditMonmap checks the monitors of members which aren't online are removed from the monmap.
+func (s *recoverSuite) TestEditMonmap() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "monmaptool", "--print", "/monmap").Return(monmapPrint, nil).Once()
+	r.On("RunCommand", "monmaptool", "/monmap", "--rm", "node1").Return("", nil).Once()
+	r.On("RunCommand", "monmaptool", "/monmap", "--rm", "node3").Return("", nil).Once()
+	processExec = r
+
+	removed, err := editMonmap("/monmap", "node2", []string{"node2"}, nil)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"node1", "node3"}, removed)
+
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "monmaptool", "--print", "/monmap").Return(monmapPrint, nil).Once()
+	processExec = r
+
+	_, err = editMonmap("/monmap", "node4", []string{"node4"}, nil)
+	assert.ErrorContains(s.T(), err, "mon.node4 isn't part of the monmap")
+}
+
+// TestQuorumRemovals checks monitors of online members are kept unless named explicitly.
+func (s *recoverSuite) TestQuorumRemovals() {
+	names := []string{"node1", "node2", "node3"}
+
+	removals, err := quorumRemovals(names, "node2", []string{"node1", "node2"}, nil)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"node3"}, removals)
+
+	removals, err = quorumRemovals(names, "node2", []string{"node1", "node2", "node3"}, []string{"node1"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"node1"}, removals)
+
+	_, err = quorumRemovals(names, "node2", []string{"node1", "node2", "node3"}, nil)
+	assert.ErrorContains(s.T(), err, "name the ones to remove")
+
+	_, err = quorumRemovals(names, "node2", nil, []string{"node2"})
+	assert.ErrorContains(s.T(), err, "mon.node2 is kept")
+
+	_, err = quorumRemovals(names, "node2", nil, []string{"node4"})
+	assert.ErrorContains(s.T(), err, "mon.node4 isn't part of the monmap")
+}
@@ -3,7 +3,9 @@ package ceph
 import (
 	"context"
 	"fmt"
+	"net/http"
 
+	"github.com/canonical/lxd/shared/api"
 	"github.com/canonical/lxd/shared/logger"
 	microCli "github.com/canonical/microcluster/v2/client"
 	"github.com/canonical/microcluster/v2/microcluster"
@@ -36,8 +38,8 @@ func removeNode(cli *microCli.Client, node string, force bool) error {
 		}
 	}
 
-	// delete from ceph
-	err := deleteNodeServices(cli, node)
+	// delete from ceph, forcing skips the availability checks of each service
+	err := deleteNodeServices(cli, node, force)
 	if err != nil {
 		// forcing makes errs non-fatal
 		if !force {
@@ -105,10 +107,24 @@ func checkPrerequisites(cli *microCli.Client, name string) error {
 		return fmt.Errorf("Need at least 3 mon, 1 mds, and 1 mgr besides %v", name)
 	}
 
+	// stretch clusters need the tiebreaker and 2 mons per site to survive the loss of a site
+	// Members predating stretch mode don't serve it, their cluster can't be stretched.
+	stretch, err := client.MClient.GetStretchMode(cli)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("Error getting stretch mode: %v", err)
+	}
+	if stretch.Enabled && stretch.Tiebreaker == name {
+		return fmt.Errorf("Node %v is the stretch mode tiebreaker", name)
+	}
+	err = checkStretchMons(stretch, services, name, 2)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func deleteNodeServices(cli *microCli.Client, name string) error {
+func deleteNodeServices(cli *microCli.Client, name string, force bool) error {
 	services, err := client.MClient.GetServices(cli)
 	if err != nil {
 		return err
@@ -117,7 +133,7 @@ func deleteNodeServices(cli *microCli.Client, name string) error {
 		logger.Debugf("Check for deletion: %s", service)
 		if service.Location == name {
 			logger.Debugf("Deleting service %s", service)
-			err = client.MClient.DeleteService(cli, service.Location, service.Service)
+			err = client.MClient.DeleteService(cli, service.Location, service.Service, force)
 			if err != nil {
 				logger.Warnf("Fault deleting service %v on node %v: %v", service.Service, service.Location, err)
 			}
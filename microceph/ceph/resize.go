@@ -0,0 +1,158 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// osdStopTimeout is how long to wait for an OSD to shut down before giving up.
+const osdStopTimeout = 60 * time.Second
+
+// ResizeOSD grows the backing file of a loop OSD of this member to the given size
+// and expands BlueStore into the new space.
+func ResizeOSD(ctx context.Context, s interfaces.StateInterface, osd int64, size string) error {
+	newSize, err := parseLoopSize(size)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if disk.OSD != osd {
+			continue
+		}
+
+		if disk.Location != s.ClusterState().Name() {
+			return api.StatusErrorf(http.StatusBadRequest, "osd.%d is located on %s", osd, disk.Location)
+		}
+
+		return resizeBackingFile(ctx, osd, disk.Path, newSize)
+	}
+
+	return api.StatusErrorf(http.StatusNotFound, "osd.%d not found", osd)
+}
+
+// resizeBackingFile grows the backing file of a loop OSD to the given size in MB.
+func resizeBackingFile(ctx context.Context, osd int64, backing string, size uint64) error {
+	fileInfo, err := os.Stat(backing)
+	if err != nil {
+		return fmt.Errorf("failed to access backing file of osd.%d: %w", osd, err)
+	}
+
+	if !fileInfo.Mode().IsRegular() {
+		return api.StatusErrorf(http.StatusBadRequest, "osd.%d is not backed by a loop file", osd)
+	}
+
+	current := uint64(fileInfo.Size()) / 1024 / 1024
+	if size <= current {
+		return api.StatusErrorf(http.StatusBadRequest, "osd.%d can only grow, %dMB is not larger than its current %dMB", osd, size, current)
+	}
+
+	freeSpace, err := getFreeSpace(filepath.Dir(backing))
+	if err != nil {
+		return err
+	}
+
+	if freeSpace < size-current {
+		return fmt.Errorf("insufficient free space to grow osd.%d by %dMB", osd, size-current)
+	}
+
+	// Keep Ceph from marking the OSD out while it is briefly stopped.
+	osdName := fmt.Sprintf("osd.%d", osd)
+	_, err = processExec.RunCommand("ceph", "osd", "add-noout", osdName)
+	if err != nil {
+		return fmt.Errorf("failed to set noout on %s: %w", osdName, err)
+	}
+
+	defer func() {
+		_, err := processExec.RunCommand("ceph", "osd", "rm-noout", osdName)
+		if err != nil {
+			logger.Warnf("Failed to unset noout on %s: %v", osdName, err)
+		}
+	}()
+
+	// BlueStore needs exclusive access to the device to expand.
+	err = killOSD(osd)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err := snapRestart("osd", true)
+		if err != nil {
+			logger.Errorf("Failed to restart %s: %v", osdName, err)
+		}
+	}()
+
+	err = waitOSDStopped(osd, osdStopTimeout)
+	if err != nil {
+		return err
+	}
+
+	err = createBackingFile(backing, size)
+	if err != nil {
+		return err
+	}
+
+	_, err = processExec.RunCommand("ceph-bluestore-tool", "bluefs-bdev-expand", "--path", getOSDDataPath(osd))
+	if err != nil {
+		return fmt.Errorf("failed to expand BlueStore of %s: %w", osdName, err)
+	}
+
+	updateResizedWeight(ctx, osd, size)
+
+	logger.Infof("Resized %s to %dMB", osdName, size)
+	return nil
+}
+
+// updateResizedWeight sets the crush weight of a resized OSD to match its new size.
+// Drained OSDs keep their zero weight.
+func updateResizedWeight(ctx context.Context, osd int64, size uint64) {
+	df, err := getOSDDf()
+	if err != nil {
+		logger.Warnf("Failed to look up weight of osd.%d: %v", osd, err)
+		return
+	}
+
+	node, ok := df[osd]
+	if !ok || node.CrushWeight == 0 {
+		return
+	}
+
+	// Crush weights are expressed in TiB.
+	reweightOSD(ctx, osd, float64(size)/1024/1024)
+}
+
+// waitOSDStopped waits until the process of the given OSD has exited.
+func waitOSDStopped(osd int64, timeout time.Duration) error {
+	cmdline := fmt.Sprintf("ceph-osd .* --id %d$", osd)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		// pgrep fails once no process matches anymore.
+		_, err := processExec.RunCommand("pgrep", "-f", cmdline)
+		if err != nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for osd.%d to stop", osd)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,108 @@
+package ceph
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type resizeSuite struct {
+	tests.BaseSuite
+}
+
+func TestResize(t *testing.T) {
+	suite.Run(t, new(resizeSuite))
+}
+
+func (s *resizeSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+// createBacking creates a sparse backing file of the given size in MB.
+func (s *resizeSuite) createBacking(size int64) string {
+	backing := filepath.Join(s.Tmp, "osd-backing-1.img")
+	f, err := os.Create(backing)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), f.Truncate(size*1024*1024))
+	assert.NoError(s.T(), f.Close())
+
+	return backing
+}
+
+func (s *resizeSuite) TestParseBackingSpec() {
+	size, num, dir, err := parseBackingSpec("loop,4G,3")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(4096), size)
+	assert.Equal(s.T(), 3, num)
+	assert.Equal(s.T(), "", dir)
+
+	size, num, dir, err = parseBackingSpec("loop,512M,1,/mnt/fast")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(512), size)
+	assert.Equal(s.T(), 1, num)
+	assert.Equal(s.T(), "/mnt/fast", dir)
+
+	_, _, _, err = parseBackingSpec("loop,4G,3,relative/dir")
+	assert.ErrorContains(s.T(), err, "absolute path")
+
+	_, _, _, err = parseBackingSpec("loop,4X,3")
+	assert.ErrorContains(s.T(), err, "illegal spec")
+}
+
+func (s *resizeSuite) TestParseLoopSize() {
+	size, err := parseLoopSize("2T")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(2*1024*1024), size)
+
+	size, err = parseLoopSize("16g")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), uint64(16*1024), size)
+
+	_, err = parseLoopSize("16")
+	assert.Error(s.T(), err)
+}
+
+func (s *resizeSuite) TestBackingFilePath() {
+	assert.Equal(s.T(), "/data/osd/ceph-1/osd-backing.img", backingFilePath("/data/osd/ceph-1", "", 1))
+	assert.Equal(s.T(), "/mnt/fast/osd-backing-1.img", backingFilePath("/data/osd/ceph-1", "/mnt/fast", 1))
+}
+
+func (s *resizeSuite) TestResizeBackingFile() {
+	backing := s.createBacking(1024)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "add-noout", "osd.1").Return("", nil).Once()
+	r.On("RunCommand", "pkill", "-f", "ceph-osd .* --id 1$").Return("", nil).Once()
+	r.On("RunCommand", "pgrep", "-f", "ceph-osd .* --id 1$").Return("", errors.New("exit status 1")).Once()
+	r.On("RunCommand", "truncate", "-s", "2048M", backing).Return("", nil).Once()
+	r.On("RunCommand", "ceph-bluestore-tool", "bluefs-bdev-expand", "--path", getOSDDataPath(1)).Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "df", "-f", "json").Return(`{"nodes": [{"id": 1, "crush_weight": 0.001}]}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "reweight", "osd.1", "0.001953").Return("", nil).Once()
+	r.On("RunCommand", "snapctl", "restart", "--reload", "microceph.osd").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "rm-noout", "osd.1").Return("", nil).Once()
+	processExec = r
+
+	err := resizeBackingFile(context.Background(), 1, backing, 2048)
+	assert.NoError(s.T(), err)
+}
+
+func (s *resizeSuite) TestResizeBackingFileShrink() {
+	backing := s.createBacking(1024)
+
+	err := resizeBackingFile(context.Background(), 1, backing, 512)
+	assert.ErrorContains(s.T(), err, "can only grow")
+}
+
+func (s *resizeSuite) TestResizeBackingFileNotLoop() {
+	err := resizeBackingFile(context.Background(), 1, "/dev/null", 2048)
+	assert.ErrorContains(s.T(), err, "not backed by a loop file")
+}
@@ -0,0 +1,244 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// OperationTypeRollingRestart is the type of rolling restart operations.
+const OperationTypeRollingRestart = "rolling-restart"
+
+var (
+	// restartHealthInterval is how often the cluster health is polled between hosts.
+	restartHealthInterval = 10 * time.Second
+	// restartHealthTimeout is how long the cluster gets to recover after a host restarted.
+	restartHealthTimeout = 10 * time.Minute
+)
+
+// nooutHealthChecks are the health warnings expected while noout is set for the restart.
+var nooutHealthChecks = []string{"OSDMAP_FLAGS"}
+
+// checkHealth returns an error unless the cluster is healthy, ignoring the given health checks.
+// degraded is set when the cluster is in error, there's no point in waiting for it to recover.
+func checkHealth(ignore []string) (degraded bool, err error) {
+	output, err := cephRun("health", "-f", "json")
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch cluster health: %w", err)
+	}
+
+	status := gjson.Get(output, "status").String()
+	if status == "HEALTH_OK" {
+		return false, nil
+	}
+
+	checks := []string{}
+	for name := range gjson.Get(output, "checks").Map() {
+		if !slices.Contains(ignore, name) {
+			checks = append(checks, name)
+		}
+	}
+
+	if len(checks) == 0 {
+		return false, nil
+	}
+
+	slices.Sort(checks)
+	return status == "HEALTH_ERR", fmt.Errorf("cluster is %s: %v", status, checks)
+}
+
+// waitForHealthy polls the cluster until it is healthy and all PGs are active+clean. It gives up
+// after the timeout, or right away if the cluster is in error.
+func waitForHealthy(ctx context.Context, ignore []string) error {
+	ctx, cancel := context.WithTimeout(ctx, restartHealthTimeout)
+	defer cancel()
+
+	for {
+		degraded, err := checkHealth(ignore)
+		if degraded {
+			return err
+		}
+
+		if err == nil {
+			var stat PGStat
+			stat, err = getPGStat()
+			if err == nil && !stat.AllActiveClean() {
+				err = fmt.Errorf("%d/%d PGs active+clean", stat.ActiveClean(), stat.PGSummary.NumPGs)
+			}
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		logger.Debugf("Waiting for the cluster to be healthy: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for the cluster to be healthy: %w", err)
+		case <-time.After(restartHealthInterval):
+		}
+	}
+}
+
+// holdNoout sets noout while OSDs restart. The returned function unsets it again, unless it
+// was set already.
+func holdNoout() (func(), error) {
+	isSet, err := isOsdNooutSet()
+	if err != nil {
+		return nil, err
+	}
+
+	if isSet {
+		return func() {}, nil
+	}
+
+	err = setOsdNooutFlag(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		err := setOsdNooutFlag(false)
+		if err != nil {
+			logger.Errorf("Failed to unset noout after restarting OSDs: %v", err)
+		}
+	}, nil
+}
+
+// ListRestartableServices lists the services of the cluster, along with an osd service on each
+// member holding OSDs.
+func ListRestartableServices(ctx context.Context, s interfaces.StateInterface) (types.Services, error) {
+	services, err := ListServices(ctx, s.ClusterState())
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := ListOSD(ctx, s.ClusterState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	for _, disk := range disks {
+		if !isServicePlacementOnHost(services, "osd", disk.Location) {
+			services = append(services, types.Service{Service: "osd", Location: disk.Location})
+		}
+	}
+
+	return services, nil
+}
+
+// restartHosts returns the hosts running the service, in order.
+func restartHosts(services types.Services, service string) []string {
+	hosts := []string{}
+	for _, svc := range services {
+		if svc.Service == service && !slices.Contains(hosts, svc.Location) {
+			hosts = append(hosts, svc.Location)
+		}
+	}
+
+	slices.Sort(hosts)
+	return hosts
+}
+
+// restartOnHost restarts the service on a member and waits for its workers to be back.
+func restartOnHost(ctx context.Context, s interfaces.StateInterface, service string, host string) error {
+	if host == s.ClusterState().Name() {
+		return RestartCephServices(ctx, s, []string{service})
+	}
+
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	return client.RestartService(ctx, leader.UseTarget(host), &types.Services{{Service: service}})
+}
+
+// RollingRestart restarts a service across the cluster, one host at a time. Before moving on to
+// the next host, the workers of the service need to be back, the cluster healthy and all PGs
+// active+clean. noout is set while OSDs restart. The restart is aborted on the first host which
+// fails or doesn't recover, the remaining hosts are skipped.
+func RollingRestart(ctx context.Context, s interfaces.StateInterface, service string, op *AsyncOperation) (types.RollingRestartResults, error) {
+	if _, ok := serviceWorkerTable[service]; !ok {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "rolling restart of %s is not supported", service)
+	}
+
+	services, err := ListRestartableServices(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := restartHosts(services, service)
+	if len(hosts) == 0 {
+		return nil, api.StatusErrorf(http.StatusNotFound, "no %s service in the cluster", service)
+	}
+
+	restart := func(host string) error {
+		return restartOnHost(ctx, s, service, host)
+	}
+
+	progress := func(msg string) {
+		if op != nil {
+			op.SetProgress(msg)
+		}
+	}
+
+	return rollingRestart(ctx, service, hosts, restart, progress)
+}
+
+// rollingRestart restarts the service on the hosts in turn, gated by the cluster health.
+func rollingRestart(ctx context.Context, service string, hosts []string, restart func(host string) error, progress func(msg string)) (types.RollingRestartResults, error) {
+	results := make(types.RollingRestartResults, len(hosts))
+	for i, host := range hosts {
+		results[i] = types.RollingRestartResult{Host: host, Status: types.RestartStatusSkipped}
+	}
+
+	progress("checking cluster health")
+	err := waitForHealthy(ctx, nil)
+	if err != nil {
+		return results, fmt.Errorf("not restarting %s: %w", service, err)
+	}
+
+	ignore := []string{}
+	if service == "osd" {
+		release, err := holdNoout()
+		if err != nil {
+			return results, err
+		}
+		defer release()
+
+		ignore = nooutHealthChecks
+	}
+
+	for i, host := range hosts {
+		progress(fmt.Sprintf("restarting %s on %s (%d/%d)", service, host, i+1, len(hosts)))
+
+		err := restart(host)
+		if err == nil {
+			progress(fmt.Sprintf("waiting for the cluster to recover from restarting %s on %s (%d/%d)", service, host, i+1, len(hosts)))
+			err = waitForHealthy(ctx, ignore)
+		}
+
+		if err != nil {
+			results[i].Status = types.RestartStatusFailed
+			results[i].Error = err.Error()
+			return results, fmt.Errorf("aborted restarting %s on %s: %w", service, host, err)
+		}
+
+		logger.Infof("Restarted %s on %s", service, host)
+		results[i].Status = types.RestartStatusRestarted
+	}
+
+	return results, nil
+}
@@ -0,0 +1,137 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type rollingRestartSuite struct {
+	tests.BaseSuite
+}
+
+func TestRollingRestart(t *testing.T) {
+	suite.Run(t, new(rollingRestartSuite))
+}
+
+func (s *rollingRestartSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+
+	restartHealthInterval = time.Millisecond
+	restartHealthTimeout = 50 * time.Millisecond
+}
+
+const (
+	healthOK      = `{"status":"HEALTH_OK","checks":{}}`
+	healthNoout   = `{"status":"HEALTH_WARN","checks":{"OSDMAP_FLAGS":{"severity":"HEALTH_WARN"}}}`
+	healthErr     = `{"status":"HEALTH_ERR","checks":{"PG_DAMAGED":{"severity":"HEALTH_ERR"}}}`
+	pgStatClean   = `{"pg_ready":true,"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":8}],"num_pgs":8}}`
+	pgStatDegrade = `{"pg_ready":true,"pg_summary":{"num_pg_by_state":[{"name":"active+undersized+degraded","num":8}],"num_pgs":8}}`
+)
+
+func noProgress(string) {}
+
+// TestRollingRestartOSDs checks hosts are restarted in turn under noout, which is unset afterwards.
+func (s *rollingRestartSuite) TestRollingRestartOSDs() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthOK, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatClean, nil).Times(3)
+	r.On("RunCommand", "ceph", "osd", "dump").Return("flags sortbitwise", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "set", "noout").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthNoout, nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "unset", "noout").Return("", nil).Once()
+	processExec = r
+
+	restarted := []string{}
+	restart := func(host string) error {
+		restarted = append(restarted, host)
+		return nil
+	}
+
+	results, err := rollingRestart(context.Background(), "osd", []string{"a", "b"}, restart, noProgress)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"a", "b"}, restarted)
+	assert.Equal(s.T(), types.RollingRestartResults{
+		{Host: "a", Status: types.RestartStatusRestarted},
+		{Host: "b", Status: types.RestartStatusRestarted},
+	}, results)
+}
+
+// TestRollingRestartAbortsOnDegradation checks the remaining hosts are skipped once the cluster degrades.
+func (s *rollingRestartSuite) TestRollingRestartAbortsOnDegradation() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthOK, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatClean, nil).Once()
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthErr, nil).Once()
+	processExec = r
+
+	restarted := []string{}
+	restart := func(host string) error {
+		restarted = append(restarted, host)
+		return nil
+	}
+
+	results, err := rollingRestart(context.Background(), "mon", []string{"a", "b", "c"}, restart, noProgress)
+	assert.ErrorContains(s.T(), err, "aborted restarting mon on a")
+	assert.Equal(s.T(), []string{"a"}, restarted)
+	assert.Equal(s.T(), types.RestartStatusFailed, results[0].Status)
+	assert.Contains(s.T(), results[0].Error, "PG_DAMAGED")
+	assert.Equal(s.T(), types.RestartStatusSkipped, results[1].Status)
+	assert.Equal(s.T(), types.RestartStatusSkipped, results[2].Status)
+}
+
+// TestRollingRestartWaitsForRecovery checks a host which doesn't recover in time aborts the restart.
+func (s *rollingRestartSuite) TestRollingRestartWaitsForRecovery() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthOK, nil)
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatClean, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatDegrade, nil)
+	processExec = r
+
+	results, err := rollingRestart(context.Background(), "mon", []string{"a", "b"}, func(string) error { return nil }, noProgress)
+	assert.ErrorContains(s.T(), err, "gave up waiting")
+	assert.Equal(s.T(), types.RestartStatusFailed, results[0].Status)
+	assert.Equal(s.T(), types.RestartStatusSkipped, results[1].Status)
+}
+
+// TestRollingRestartFailedHost checks a failed restart stops the rolling restart.
+func (s *rollingRestartSuite) TestRollingRestartFailedHost() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthOK, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatClean, nil).Once()
+	processExec = r
+
+	restart := func(host string) error {
+		return fmt.Errorf("workers not back")
+	}
+
+	results, err := rollingRestart(context.Background(), "rgw", []string{"a", "b"}, restart, noProgress)
+	assert.ErrorContains(s.T(), err, "workers not back")
+	assert.Equal(s.T(), types.RollingRestartResults{
+		{Host: "a", Status: types.RestartStatusFailed, Error: "workers not back"},
+		{Host: "b", Status: types.RestartStatusSkipped},
+	}, results)
+}
+
+// TestRollingRestartUnhealthy checks nothing is restarted while the cluster is in error.
+func (s *rollingRestartSuite) TestRollingRestartUnhealthy() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthErr, nil).Once()
+	processExec = r
+
+	results, err := rollingRestart(context.Background(), "mon", []string{"a"}, func(string) error {
+		s.T().Fatal("unexpected restart")
+		return nil
+	}, noProgress)
+	assert.ErrorContains(s.T(), err, "not restarting mon")
+	assert.Equal(s.T(), types.RestartStatusSkipped, results[0].Status)
+}
@@ -0,0 +1,432 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// defaultServiceCount is the number of auto services placed as members join, unless a policy says otherwise.
+const defaultServiceCount = 3
+
+// policyServices are the services whose count can be set by policy.
+var policyServices = []string{"mon", "mgr", "mds"}
+
+// getServiceCount returns the desired count of a service, or 0 if there's no policy for it.
+func getServiceCount(ctx context.Context, tx *sql.Tx, service string) (int, error) {
+	item, err := database.GetConfigItem(ctx, tx, database.ServiceCountKey(service))
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	count, err := strconv.Atoi(item.Value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s count %q: %w", service, item.Value, err)
+	}
+
+	return count, nil
+}
+
+// GetServicePolicy returns the desired service counts of the cluster.
+func GetServicePolicy(ctx context.Context, s state.State) (types.ServicePolicy, error) {
+	policy := types.ServicePolicy{}
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		counts := map[string]*int{"mon": &policy.Mon, "mgr": &policy.Mgr, "mds": &policy.Mds}
+		for service, count := range counts {
+			var err error
+			*count, err = getServiceCount(ctx, tx, service)
+			if err != nil {
+				return err
+			}
+		}
+
+		item, err := database.GetConfigItem(ctx, tx, database.ServiceReplaceAfterKey)
+		if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return err
+		}
+
+		if err == nil {
+			policy.ReplaceAfter = item.Value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return policy, fmt.Errorf("failed to fetch service policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// validateServicePolicy checks the desired service counts, 0 counts are left unchanged.
+func validateServicePolicy(policy types.ServicePolicy) error {
+	if policy.Mon != 0 && !slices.Contains([]int{3, 5, 7}, policy.Mon) {
+		return fmt.Errorf("mon count must be 3, 5 or 7")
+	}
+
+	if policy.Mgr < 0 || policy.Mds < 0 {
+		return fmt.Errorf("service counts must not be negative")
+	}
+
+	if policy.ReplaceAfter != "" {
+		replaceAfter, err := time.ParseDuration(policy.ReplaceAfter)
+		if err != nil {
+			return fmt.Errorf("invalid replace-after duration %q: %w", policy.ReplaceAfter, err)
+		}
+
+		if replaceAfter < 0 {
+			return fmt.Errorf("replace-after duration must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// SetServicePolicy records the desired service counts. Services with a count of 0 are left unchanged,
+// as is the replace-after duration when empty. A duration of 0 stops replacing services of offline
+// members.
+func SetServicePolicy(ctx context.Context, s state.State, policy types.ServicePolicy) error {
+	err := validateServicePolicy(policy)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	return s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		counts := map[string]int{"mon": policy.Mon, "mgr": policy.Mgr, "mds": policy.Mds}
+		for service, count := range counts {
+			if count == 0 {
+				continue
+			}
+
+			err := upsertConfigItem(ctx, tx, database.ServiceCountKey(service), strconv.Itoa(count))
+			if err != nil {
+				return err
+			}
+		}
+
+		if policy.ReplaceAfter == "" {
+			return nil
+		}
+
+		replaceAfter, _ := time.ParseDuration(policy.ReplaceAfter)
+		if replaceAfter == 0 {
+			err := database.DeleteConfigItem(ctx, tx, database.ServiceReplaceAfterKey)
+			if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+				return err
+			}
+
+			return nil
+		}
+
+		return upsertConfigItem(ctx, tx, database.ServiceReplaceAfterKey, replaceAfter.String())
+	})
+}
+
+// servicePolicyCount returns the desired count of a service from a policy.
+func servicePolicyCount(policy types.ServicePolicy, service string) int {
+	switch service {
+	case "mon":
+		return policy.Mon
+	case "mgr":
+		return policy.Mgr
+	case "mds":
+		return policy.Mds
+	}
+
+	return 0
+}
+
+// failedMembers returns the unreachable members which missed heartbeats for longer than the given
+// duration. A duration of 0 never gives up on offline members.
+func failedMembers(members []microTypes.ClusterMember, replaceAfter time.Duration, now time.Time) []string {
+	failed := []string{}
+	if replaceAfter == 0 {
+		return failed
+	}
+
+	for _, member := range members {
+		if member.Status == microTypes.MemberUnreachable && now.Sub(member.LastHeartbeat) > replaceAfter {
+			failed = append(failed, member.Name)
+		}
+	}
+
+	return failed
+}
+
+// planServicePlacement works out a single step towards the desired count of a service: a member
+// to add it to or one to remove it from. Every recorded service counts, so that a member briefly
+// going offline, e.g. on a reboot, doesn't have its services moved, unless the member is listed
+// as failed. Services are added on online members and removed from online members only. They are
+// spread over crush locations, adding to the least and removing from the most populated location
+// first.
+func planServicePlacement(service string, count int, services types.Services, online []string, failed []string, locations map[string]string) (string, string) {
+	hosts := []string{}
+	healthy := []string{}
+	for _, svc := range services {
+		if svc.Service != service {
+			continue
+		}
+
+		hosts = append(hosts, svc.Location)
+		if !slices.Contains(failed, svc.Location) {
+			healthy = append(healthy, svc.Location)
+		}
+	}
+
+	zone := func(member string) string {
+		parsed, err := ParseCrushLocation(locations[member])
+		if err != nil {
+			return ""
+		}
+		return FormatCrushLocation(parsed)
+	}
+
+	perZone := map[string]int{}
+	for _, host := range healthy {
+		perZone[zone(host)]++
+	}
+
+	// less orders members by the number of services in their location, then by name.
+	less := func(a string, b string) int {
+		diff := perZone[zone(a)] - perZone[zone(b)]
+		if diff != 0 {
+			return diff
+		}
+		if a < b {
+			return -1
+		}
+		return 1
+	}
+
+	if len(healthy) < count {
+		candidates := []string{}
+		for _, member := range online {
+			if !slices.Contains(hosts, member) {
+				candidates = append(candidates, member)
+			}
+		}
+
+		if len(candidates) == 0 {
+			return "", ""
+		}
+
+		return slices.MinFunc(candidates, less), ""
+	}
+
+	if len(healthy) > count {
+		removable := []string{}
+		for _, host := range healthy {
+			if slices.Contains(online, host) {
+				removable = append(removable, host)
+			}
+		}
+
+		if len(removable) == 0 {
+			return "", ""
+		}
+
+		return "", slices.MaxFunc(removable, less)
+	}
+
+	return "", ""
+}
+
+// planFailedMonRemoval returns the monitor of a failed member to drop once enough monitors of
+// healthy members are recorded to meet the desired count, i.e. once it has been replaced, along
+// with the healthy monitors which have to be in quorum first. Dropping it keeps the monmap from
+// growing with every replacement.
+func planFailedMonRemoval(count int, services types.Services, failed []string) (string, []string) {
+	retire := []string{}
+	healthy := []string{}
+	for _, svc := range services {
+		if svc.Service != "mon" {
+			continue
+		}
+
+		if slices.Contains(failed, svc.Location) {
+			retire = append(retire, svc.Location)
+		} else {
+			healthy = append(healthy, svc.Location)
+		}
+	}
+
+	if len(retire) == 0 || len(healthy) < count {
+		return "", nil
+	}
+
+	return slices.Min(retire), healthy
+}
+
+// removeFailedMon removes the monitor of a failed member from the monmap, once all the given
+// healthy monitors are in quorum. It returns whether the monitor was removed.
+func removeFailedMon(failed string, healthy []string) (bool, error) {
+	for _, mon := range healthy {
+		inQuorum, err := monInQuorum(mon)
+		if err != nil {
+			return false, err
+		}
+
+		if !inQuorum {
+			logger.Debugf("Not removing monitor of %s until %s is in quorum", failed, mon)
+			return false, nil
+		}
+	}
+
+	err := removeMon(failed)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove monitor of %s: %w", failed, err)
+	}
+
+	return true, nil
+}
+
+// retireFailedMon drops the monitor of a failed member which has been replaced, from the monmap
+// and the database, and updates the config so that members stop looking for it.
+func retireFailedMon(ctx context.Context, s interfaces.StateInterface, failed string, healthy []string) error {
+	removed, err := removeFailedMon(failed, healthy)
+	if err != nil || !removed {
+		return err
+	}
+
+	logger.Infof("Removed monitor of failed member %s after its replacement joined quorum", failed)
+	err = forgetMons(ctx, s, []string{failed})
+	if err != nil {
+		return err
+	}
+
+	err = UpdateConfig(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+
+	// The failed member can't be reached, the others are still told about the change.
+	err = client.SendUpdateClientConfRequestToClusterMembers(ctx, s)
+	if err != nil {
+		logger.Warnf("Failed to update the config of other members: %v", err)
+	}
+
+	return nil
+}
+
+// ReconcileServices takes a step towards the desired service counts, adding services on online
+// members when some were removed, lost or offline for longer than the replace-after duration,
+// and removing extra ones. The monitor of a failed member is removed once its replacement is in
+// quorum. It only acts on the dqlite
+// leader, so that members don't race each other. A single change per service is made per call
+// to let monitors settle in between.
+func ReconcileServices(ctx context.Context, s interfaces.StateInterface) error {
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	if leader.URL().URL.Host != s.ClusterState().Address().URL.Host {
+		return nil
+	}
+
+	policy, err := GetServicePolicy(ctx, s.ClusterState())
+	if err != nil {
+		return err
+	}
+
+	if policy == (types.ServicePolicy{}) {
+		return nil
+	}
+
+	members, err := leader.GetClusterMembers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster members: %w", err)
+	}
+
+	online := []string{}
+	for _, member := range members {
+		if member.Status == microTypes.MemberOnline {
+			online = append(online, member.Name)
+		}
+	}
+
+	var replaceAfter time.Duration
+	if policy.ReplaceAfter != "" {
+		replaceAfter, err = time.ParseDuration(policy.ReplaceAfter)
+		if err != nil {
+			return fmt.Errorf("invalid replace-after duration %q: %w", policy.ReplaceAfter, err)
+		}
+	}
+
+	failed := failedMembers(members, replaceAfter, time.Now())
+
+	services, err := ListServices(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	locations, err := database.CrushLocationQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	stretch, err := database.StretchQuery.Get(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to fetch stretch mode: %w", err)
+	}
+
+	for _, service := range policyServices {
+		count := servicePolicyCount(policy, service)
+		if count == 0 {
+			continue
+		}
+
+		if service == "mon" && stretch.Enabled {
+			// Stretch mode monitors need a location of their own, these are placed by hand.
+			logger.Debugf("Not reconciling mon services of a stretch cluster")
+			continue
+		}
+
+		add, remove := planServicePlacement(service, count, services, online, failed, locations)
+		if add != "" {
+			logger.Infof("Adding %s service on %s to meet the desired count of %d", service, add, count)
+			req := &types.EnableService{Name: service, Wait: true}
+			err = client.SendServicePlacementReq(ctx, leader, req, add)
+			if err != nil {
+				return err
+			}
+		}
+
+		if remove != "" {
+			logger.Infof("Removing %s service from %s to meet the desired count of %d", service, remove, count)
+			err = client.DeleteService(ctx, leader, remove, service, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		if service == "mon" && add == "" && remove == "" {
+			retire, healthy := planFailedMonRemoval(count, services, failed)
+			if retire != "" {
+				err = retireFailedMon(ctx, s, retire, healthy)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package ceph
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+)
+
+type servicePolicySuite struct {
+	suite.Suite
+}
+
+func TestServicePolicy(t *testing.T) {
+	suite.Run(t, new(servicePolicySuite))
+}
+
+func (s *servicePolicySuite) TestValidateServicePolicy() {
+	assert.NoError(s.T(), validateServicePolicy(types.ServicePolicy{Mon: 5}))
+	assert.NoError(s.T(), validateServicePolicy(types.ServicePolicy{Mgr: 2, Mds: 1}))
+	assert.Error(s.T(), validateServicePolicy(types.ServicePolicy{Mon: 4}))
+	assert.Error(s.T(), validateServicePolicy(types.ServicePolicy{Mon: 1}))
+	assert.Error(s.T(), validateServicePolicy(types.ServicePolicy{Mgr: -1}))
+	assert.NoError(s.T(), validateServicePolicy(types.ServicePolicy{ReplaceAfter: "30m"}))
+	assert.NoError(s.T(), validateServicePolicy(types.ServicePolicy{ReplaceAfter: "0"}))
+	assert.Error(s.T(), validateServicePolicy(types.ServicePolicy{ReplaceAfter: "soon"}))
+	assert.Error(s.T(), validateServicePolicy(types.ServicePolicy{ReplaceAfter: "-5m"}))
+}
+
+func monServices(hosts ...string) types.Services {
+	services := types.Services{}
+	for _, host := range hosts {
+		services = append(services, types.Service{Service: "mon", Location: host})
+	}
+	return services
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementKeepsOfflineMember() {
+	// m2 is offline, e.g. rebooting, its mon still counts.
+	services := monServices("m1", "m2", "m3")
+	online := []string{"m1", "m3", "m4", "m5"}
+
+	add, remove := planServicePlacement("mon", 3, services, online, nil, nil)
+	assert.Empty(s.T(), add)
+	assert.Empty(s.T(), remove)
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementAddsOnFailedMember() {
+	// m2 has been offline for too long, its mon doesn't count.
+	services := monServices("m1", "m2", "m3")
+	online := []string{"m1", "m3", "m4", "m5"}
+
+	add, remove := planServicePlacement("mon", 3, services, online, []string{"m2"}, nil)
+	assert.Equal(s.T(), "m4", add)
+	assert.Empty(s.T(), remove)
+
+	// m2 is back after being replaced, an online mon goes.
+	services = monServices("m1", "m2", "m3", "m4")
+	online = []string{"m1", "m2", "m3", "m4", "m5"}
+
+	add, remove = planServicePlacement("mon", 3, services, online, nil, nil)
+	assert.Empty(s.T(), add)
+	assert.Equal(s.T(), "m4", remove)
+}
+
+// TestFailedMonReplaced walks through replacing the monitor of a failed member and checks the
+// monmap ends up with the desired count of monitors, the failed one dropped.
+func (s *servicePolicySuite) TestFailedMonReplaced() {
+	services := monServices("m1", "m2", "m3")
+	online := []string{"m1", "m3", "m4", "m5"}
+	failed := []string{"m2"}
+	monmap := []string{"m1", "m2", "m3"}
+
+	// Nothing to drop before the replacement is placed.
+	retire, _ := planFailedMonRemoval(3, services, failed)
+	assert.Empty(s.T(), retire)
+
+	add, remove := planServicePlacement("mon", 3, services, online, failed, nil)
+	assert.Equal(s.T(), "m4", add)
+	assert.Empty(s.T(), remove)
+	services = append(services, types.Service{Service: "mon", Location: add})
+	monmap = append(monmap, add)
+
+	// The replacement hasn't joined quorum yet, the failed mon is kept.
+	add, remove = planServicePlacement("mon", 3, services, online, failed, nil)
+	assert.Empty(s.T(), add)
+	assert.Empty(s.T(), remove)
+	retire, healthy := planFailedMonRemoval(3, services, failed)
+	assert.Equal(s.T(), "m2", retire)
+	assert.Equal(s.T(), []string{"m1", "m3", "m4"}, healthy)
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names": ["m1", "m3"]}`, nil).Times(3)
+	processExec = r
+
+	removed, err := removeFailedMon(retire, healthy)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), removed)
+
+	// Once it has, the failed mon goes.
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names": ["m1", "m3", "m4"]}`, nil).Times(3)
+	r.On("RunCommand", "ceph", "mon", "rm", "m2").Return("", nil).Once()
+	processExec = r
+
+	removed, err = removeFailedMon(retire, healthy)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), removed)
+	monmap = slices.DeleteFunc(monmap, func(mon string) bool { return mon == retire })
+	services = monServices(monmap...)
+
+	// The monmap is back to the desired count and nothing is left to do.
+	assert.Equal(s.T(), []string{"m1", "m3", "m4"}, monmap)
+	add, remove = planServicePlacement("mon", 3, services, online, failed, nil)
+	assert.Empty(s.T(), add)
+	assert.Empty(s.T(), remove)
+	retire, _ = planFailedMonRemoval(3, services, failed)
+	assert.Empty(s.T(), retire)
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementAddsOnRemovedMember() {
+	// m2 was removed along with its services.
+	services := monServices("m1", "m3")
+	online := []string{"m1", "m3", "m4"}
+
+	add, remove := planServicePlacement("mon", 3, services, online, nil, nil)
+	assert.Equal(s.T(), "m4", add)
+	assert.Empty(s.T(), remove)
+}
+
+func (s *servicePolicySuite) TestFailedMembers() {
+	now := time.Now()
+	members := []microTypes.ClusterMember{
+		{ClusterMemberLocal: microTypes.ClusterMemberLocal{Name: "m1"}, Status: microTypes.MemberOnline, LastHeartbeat: now},
+		{ClusterMemberLocal: microTypes.ClusterMemberLocal{Name: "m2"}, Status: microTypes.MemberUnreachable, LastHeartbeat: now.Add(-time.Hour)},
+		{ClusterMemberLocal: microTypes.ClusterMemberLocal{Name: "m3"}, Status: microTypes.MemberUnreachable, LastHeartbeat: now.Add(-time.Minute)},
+	}
+
+	assert.Equal(s.T(), []string{"m2"}, failedMembers(members, 30*time.Minute, now))
+	assert.Empty(s.T(), failedMembers(members, 0, now))
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementSpreadsLocations() {
+	services := monServices("m1", "m2")
+	online := []string{"m1", "m2", "m3", "m4"}
+	locations := map[string]string{"m1": "rack=r1", "m2": "rack=r2", "m3": "rack=r1", "m4": "rack=r3"}
+
+	add, remove := planServicePlacement("mon", 3, services, online, nil, locations)
+	assert.Equal(s.T(), "m4", add)
+	assert.Empty(s.T(), remove)
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementRemovesExtra() {
+	services := monServices("m1", "m2", "m3", "m4", "m5")
+	online := []string{"m1", "m2", "m3", "m4", "m5"}
+	locations := map[string]string{"m1": "rack=r1", "m2": "rack=r1", "m3": "rack=r2", "m4": "rack=r3", "m5": "rack=r3"}
+
+	// r1 and r3 hold two mons each, the last one by name goes.
+	add, remove := planServicePlacement("mon", 3, services, online, nil, locations)
+	assert.Empty(s.T(), add)
+	assert.Equal(s.T(), "m5", remove)
+}
+
+func (s *servicePolicySuite) TestPlanServicePlacementSatisfied() {
+	services := monServices("m1", "m2", "m3")
+	online := []string{"m1", "m2", "m3"}
+
+	add, remove := planServicePlacement("mon", 3, services, online, nil, nil)
+	assert.Empty(s.T(), add)
+	assert.Empty(s.T(), remove)
+
+	// Nowhere left to place a mon.
+	add, remove = planServicePlacement("mon", 5, services, online, nil, nil)
+	assert.Empty(s.T(), add)
+	assert.Empty(s.T(), remove)
+}
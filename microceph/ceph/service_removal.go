@@ -0,0 +1,138 @@
+package ceph
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/tidwall/gjson"
+)
+
+// checkServiceRemoval checks that the cluster stays available without the service on the host.
+func checkServiceRemoval(service string, hostname string) error {
+	var err error
+	switch service {
+	case "mon":
+		err = checkMonRemoval(hostname)
+	case "mgr":
+		err = checkMgrRemoval(hostname)
+	case "mds":
+		err = checkMdsRemoval(hostname)
+	}
+
+	if err != nil {
+		return api.StatusErrorf(http.StatusConflict, "can't disable %s on %s: %v", service, hostname, err)
+	}
+
+	return nil
+}
+
+// checkMonRemoval checks that the remaining monitors keep quorum without the one on the host.
+func checkMonRemoval(hostname string) error {
+	output, err := cephRun("quorum_status", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch quorum status: %w", err)
+	}
+
+	found := false
+	remaining := 0
+	for _, mon := range gjson.Get(output, "monmap.mons.#.name").Array() {
+		if mon.String() == hostname {
+			found = true
+		} else {
+			remaining++
+		}
+	}
+
+	if !found {
+		// The monitor isn't part of the monmap, there's no quorum to break.
+		return nil
+	}
+
+	if remaining == 0 {
+		return fmt.Errorf("it is the last monitor")
+	}
+
+	inQuorum := 0
+	for _, mon := range gjson.Get(output, "quorum_names").Array() {
+		if mon.String() != hostname {
+			inQuorum++
+		}
+	}
+
+	if inQuorum <= remaining/2 {
+		return fmt.Errorf("only %d of the remaining %d monitors are in quorum", inQuorum, remaining)
+	}
+
+	logger.Debugf("%d of the remaining %d monitors are in quorum without %s", inQuorum, remaining, hostname)
+	return nil
+}
+
+// checkMgrRemoval checks that another manager is active or on standby to take over.
+func checkMgrRemoval(hostname string) error {
+	output, err := cephRun("mgr", "dump", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch manager map: %w", err)
+	}
+
+	active := gjson.Get(output, "active_name").String()
+	if active != "" && active != hostname && gjson.Get(output, "available").Bool() {
+		return nil
+	}
+
+	for _, standby := range gjson.Get(output, "standbys.#.name").Array() {
+		if standby.String() != hostname {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no other manager is available")
+}
+
+// checkMdsRemoval checks that a standby is available to take over the ranks the MDS on the host holds.
+func checkMdsRemoval(hostname string) error {
+	output, err := cephRun("fs", "dump", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch filesystem map: %w", err)
+	}
+
+	active := false
+	for _, fs := range gjson.Get(output, "filesystems").Array() {
+		for _, info := range fs.Get("mdsmap.info").Map() {
+			if info.Get("name").String() == hostname {
+				active = true
+			}
+		}
+	}
+
+	if !active {
+		return nil
+	}
+
+	for _, standby := range gjson.Get(output, "standbys.#.name").Array() {
+		if standby.String() != hostname {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no standby MDS is available to take over")
+}
+
+// removeServiceKey removes the Ceph key of a service on the host.
+func removeServiceKey(service string, hostname string) {
+	var entity string
+	switch service {
+	case "mgr", "mds":
+		entity = fmt.Sprintf("%s.%s", service, hostname)
+	case "rbd-mirror":
+		entity = fmt.Sprintf("client.rbd-mirror.%s", hostname)
+	default:
+		return
+	}
+
+	_, err := cephRun("auth", "del", entity)
+	if err != nil {
+		logger.Warnf("Failed to remove %s key: %v", entity, err)
+	}
+}
@@ -0,0 +1,88 @@
+package ceph
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type serviceRemovalSuite struct {
+	tests.BaseSuite
+}
+
+func TestServiceRemoval(t *testing.T) {
+	suite.Run(t, new(serviceRemovalSuite))
+}
+
+func (s *serviceRemovalSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const quorumStatus = `{"quorum_names":["m1","m2"],"monmap":{"mons":[{"name":"m1"},{"name":"m2"},{"name":"m3"}]}}`
+
+// TestCheckMonRemoval checks a mon is only removed if the rest keep quorum.
+func (s *serviceRemovalSuite) TestCheckMonRemoval() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(quorumStatus, nil).Times(3)
+	processExec = r
+
+	// m1 and m2 stay in quorum without m3
+	assert.NoError(s.T(), checkServiceRemoval("mon", "m3"))
+
+	// m3 is down, only m2 would be left in quorum out of 2
+	err := checkServiceRemoval("mon", "m1")
+	assert.Error(s.T(), err)
+	assert.True(s.T(), api.StatusErrorCheck(err, http.StatusConflict))
+
+	// not in the monmap at all
+	assert.NoError(s.T(), checkServiceRemoval("mon", "m4"))
+}
+
+// TestCheckLastMonRemoval checks the last mon is never removed.
+func (s *serviceRemovalSuite) TestCheckLastMonRemoval() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(`{"quorum_names":["m1"],"monmap":{"mons":[{"name":"m1"}]}}`, nil).Once()
+	processExec = r
+
+	assert.ErrorContains(s.T(), checkServiceRemoval("mon", "m1"), "last monitor")
+}
+
+// TestCheckMgrRemoval checks a mgr is only removed if another one can be active.
+func (s *serviceRemovalSuite) TestCheckMgrRemoval() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mgr", "dump", "-f", "json").Return(`{"active_name":"m1","available":true,"standbys":[]}`, nil).Twice()
+	r.On("RunCommand", "ceph", "mgr", "dump", "-f", "json").Return(`{"active_name":"m1","available":true,"standbys":[{"name":"m2"}]}`, nil).Once()
+	processExec = r
+
+	// a standby goes
+	assert.NoError(s.T(), checkServiceRemoval("mgr", "m2"))
+	// the only mgr goes
+	assert.Error(s.T(), checkServiceRemoval("mgr", "m1"))
+	// the active mgr goes, m2 takes over
+	assert.NoError(s.T(), checkServiceRemoval("mgr", "m1"))
+}
+
+// TestCheckMdsRemoval checks an active mds is only removed if a standby can take over.
+func (s *serviceRemovalSuite) TestCheckMdsRemoval() {
+	fsDump := `{"standbys":[],"filesystems":[{"mdsmap":{"info":{"gid_4242":{"name":"m1","state":"up:active"}}}}]}`
+	fsDumpStandby := `{"standbys":[{"name":"m2"}],"filesystems":[{"mdsmap":{"info":{"gid_4242":{"name":"m1","state":"up:active"}}}}]}`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "fs", "dump", "-f", "json").Return(fsDump, nil).Twice()
+	r.On("RunCommand", "ceph", "fs", "dump", "-f", "json").Return(fsDumpStandby, nil).Once()
+	processExec = r
+
+	// m2 holds no rank
+	assert.NoError(s.T(), checkServiceRemoval("mds", "m2"))
+	// no standby for m1
+	assert.Error(s.T(), checkServiceRemoval("mds", "m1"))
+	// m2 is on standby
+	assert.NoError(s.T(), checkServiceRemoval("mds", "m1"))
+}
@@ -27,12 +27,14 @@ import (
 var serviceWorkerTable = map[string](func() (common.Set, error)){
 	"osd": getUpOsds,
 	"mon": getMons,
+	"mgr": getUpMgrs,
+	"mds": getUpMdss,
 	"rgw": getUpRgws,
 }
 
 // Restarts (in order) all Ceph Services provided in the input slice on the host.
 func RestartCephServices(ctx context.Context, s interfaces.StateInterface, services []string) error {
-	clusterServices, err := ListServices(ctx, s.ClusterState())
+	clusterServices, err := ListRestartableServices(ctx, s)
 	if err != nil {
 		logger.Errorf("failed fetching services from db: %v", err)
 		return err
@@ -138,6 +140,48 @@ func getMons() (common.Set, error) {
 	return retval, nil
 }
 
+func getUpMgrs() (common.Set, error) {
+	retval := common.Set{}
+	output, err := processExec.RunCommand("ceph", "mgr", "dump", "-f", "json-pretty")
+	if err != nil {
+		logger.Errorf("Failed fetching Mgr dump: %v", err)
+		return nil, err
+	}
+
+	logger.Debugf("Mgr Dump:\n%s", output)
+	// Get the active mgr, if available, and the standbys.
+	if gjson.Get(output, "available").Bool() {
+		retval[gjson.Get(output, "active_name").String()] = struct{}{}
+	}
+	for _, key := range gjson.Get(output, "standbys.#.name").Array() {
+		retval[key.String()] = struct{}{}
+	}
+
+	return retval, nil
+}
+
+func getUpMdss() (common.Set, error) {
+	retval := common.Set{}
+	output, err := processExec.RunCommand("ceph", "fs", "dump", "-f", "json-pretty")
+	if err != nil {
+		logger.Errorf("Failed fetching FS dump: %v", err)
+		return nil, err
+	}
+
+	logger.Debugf("FS Dump:\n%s", output)
+	// Get the mds holding a rank in any filesystem, and the standbys.
+	for _, fs := range gjson.Get(output, "filesystems").Array() {
+		for _, info := range fs.Get("mdsmap.info").Map() {
+			retval[info.Get("name").String()] = struct{}{}
+		}
+	}
+	for _, key := range gjson.Get(output, "standbys.#.name").Array() {
+		retval[key.String()] = struct{}{}
+	}
+
+	return retval, nil
+}
+
 func getUpOsds() (common.Set, error) {
 	retval := common.Set{}
 	output, err := processExec.RunCommand("ceph", "osd", "dump", "-f", "json-pretty")
@@ -201,6 +245,15 @@ func cleanService(hostname, service string) error {
 		logger.Errorf("failed to remove service %q data: %v", service, err)
 		return fmt.Errorf("failed to remove service %q data: %w", service, err)
 	}
+
+	// Client services also link their keyring into the conf dir.
+	if service == "rbd-mirror" {
+		keyringPath := filepath.Join(paths.ConfPath, fmt.Sprintf("ceph.client.%s.%s.keyring", service, hostname))
+		err = os.Remove(keyringPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove service %q keyring: %w", service, err)
+		}
+	}
 	return nil
 }
 
@@ -231,9 +284,25 @@ func removeServiceDatabase(ctx context.Context, s interfaces.StateInterface, ser
 	return err
 }
 
-// DeleteService deletes a service from the node.
-func DeleteService(ctx context.Context, s interfaces.StateInterface, service string) error {
-	err := snapStop(service, true)
+// DeleteService deletes a service from the node. Unless forced, it first checks that the
+// cluster stays available without it.
+func DeleteService(ctx context.Context, s interfaces.StateInterface, service string, force bool) error {
+	var err error
+	if !force {
+		err = checkServiceRemoval(service, s.ClusterState().Name())
+		if err != nil {
+			return err
+		}
+
+		if service == "mon" {
+			err = checkStretchMonRemoval(ctx, s)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = snapStop(service, true)
 	if err != nil {
 		logger.Errorf("failed to stop daemon %q: %v", service, err)
 		return fmt.Errorf("failed to stop daemon %q: %w", service, err)
@@ -246,6 +315,8 @@ func DeleteService(ctx context.Context, s interfaces.StateInterface, service str
 		}
 	}
 
+	removeServiceKey(service, s.ClusterState().Name())
+
 	err = cleanService(s.ClusterState().Name(), service)
 	if err != nil {
 		return fmt.Errorf("failed to clean service %q: %w", service, err)
@@ -0,0 +1,493 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Types of cluster shutdown and startup operations.
+const (
+	OperationTypeShutdown = "shutdown"
+	OperationTypeStartup  = "startup"
+)
+
+// shutdownOrder is the order in which services are stopped across the cluster. They are started
+// in the reverse order.
+var shutdownOrder = []string{"rgw", "mds", "osd", "mgr", "mon"}
+
+// shutdownFlags are the OSD flags set while the cluster is shut down, so that OSDs going away
+// don't trigger data movement.
+var shutdownFlags = []string{"noout", "norebalance", "nobackfill"}
+
+// startupOSDTimeout is how long a startup waits for all OSDs to be up before giving up.
+var startupOSDTimeout = 15 * time.Minute
+
+// startupPollInterval is how often a startup checks on the OSDs coming up.
+var startupPollInterval = 5 * time.Second
+
+// getShutdownStatus returns the recorded state of the cluster shutdown.
+func getShutdownStatus(ctx context.Context, s interfaces.StateInterface) (types.ShutdownStatus, error) {
+	status := types.ShutdownStatus{Flags: []string{}}
+	flags := ""
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		fields := map[string]*string{
+			database.ShutdownStateKey:  &status.State,
+			database.ShutdownFlagsKey:  &flags,
+			database.ShutdownMemberKey: &status.Member,
+		}
+
+		for key, field := range fields {
+			item, err := database.GetConfigItem(ctx, tx, key)
+			if err != nil {
+				if api.StatusErrorCheck(err, http.StatusNotFound) {
+					continue
+				}
+				return err
+			}
+
+			*field = item.Value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return status, fmt.Errorf("failed to fetch shutdown state: %w", err)
+	}
+
+	if flags != "" {
+		status.Flags = strings.Split(flags, ",")
+	}
+
+	return status, nil
+}
+
+// setShutdownStatus records the state of the cluster shutdown.
+func setShutdownStatus(ctx context.Context, s interfaces.StateInterface, status types.ShutdownStatus) error {
+	err := s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		fields := map[string]string{
+			database.ShutdownStateKey:  status.State,
+			database.ShutdownFlagsKey:  strings.Join(status.Flags, ","),
+			database.ShutdownMemberKey: status.Member,
+		}
+
+		for key, value := range fields {
+			err := upsertConfigItem(ctx, tx, key, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record shutdown state: %w", err)
+	}
+
+	return nil
+}
+
+// getOsdFlags returns the flags set on the OSD map.
+func getOsdFlags() ([]string, error) {
+	output, err := cephRun("osd", "dump", "-f", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump osd map: %w", err)
+	}
+
+	flags := gjson.Get(output, "flags").String()
+	if flags == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(flags, ","), nil
+}
+
+// setShutdownFlags sets the shutdown flags on the OSD map and returns the ones which weren't
+// set already, so that startup only unsets those.
+func setShutdownFlags() ([]string, error) {
+	current, err := getOsdFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	set := []string{}
+	for _, flag := range shutdownFlags {
+		if slices.Contains(current, flag) {
+			continue
+		}
+
+		_, err := cephRun("osd", "set", flag)
+		if err != nil {
+			return set, fmt.Errorf("failed to set %s: %w", flag, err)
+		}
+
+		set = append(set, flag)
+	}
+
+	return set, nil
+}
+
+// unsetShutdownFlags unsets the flags a shutdown set on the OSD map.
+func unsetShutdownFlags(flags []string) error {
+	for _, flag := range flags {
+		_, err := cephRun("osd", "unset", flag)
+		if err != nil {
+			return fmt.Errorf("failed to unset %s: %w", flag, err)
+		}
+	}
+
+	return nil
+}
+
+// waitForOSDsUp waits for all OSDs of the cluster to be up, reporting how many are.
+func waitForOSDsUp(ctx context.Context, timeout time.Duration, progress func(msg string)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		output, err := cephRun("osd", "stat", "-f", "json")
+		if err == nil {
+			num := gjson.Get(output, "num_osds").Int()
+			up := gjson.Get(output, "num_up_osds").Int()
+			if up >= num {
+				return nil
+			}
+
+			progress(fmt.Sprintf("waiting for OSDs, %d of %d up", up, num))
+			err = fmt.Errorf("only %d of %d osds are up", up, num)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for OSDs after %v: %w", timeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(startupPollInterval):
+		}
+	}
+}
+
+// StopServices stops the given services on this member, without disabling them.
+func StopServices(services []string) error {
+	for _, service := range services {
+		err := snapStop(service, false)
+		if err != nil {
+			return fmt.Errorf("failed to stop %s: %w", service, err)
+		}
+	}
+
+	return nil
+}
+
+// StartServices starts the given services on this member.
+func StartServices(services []string) error {
+	for _, service := range services {
+		err := snapStart(service, false)
+		if err != nil {
+			return fmt.Errorf("failed to start %s: %w", service, err)
+		}
+	}
+
+	return nil
+}
+
+// powerOnHost stops or starts a service on a member.
+func powerOnHost(ctx context.Context, s interfaces.StateInterface, start bool, service string, host string) error {
+	if host == s.ClusterState().Name() {
+		if start {
+			return StartServices([]string{service})
+		}
+
+		return StopServices([]string{service})
+	}
+
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	if start {
+		return client.StartService(ctx, leader.UseTarget(host), &types.Services{{Service: service}})
+	}
+
+	return client.StopService(ctx, leader.UseTarget(host), &types.Services{{Service: service}})
+}
+
+// powerServices stops or starts the services in order, member by member. Offline members are
+// skipped. Failures are recorded and don't stop the sequence, the number of failures is returned.
+// after is called once a service went through all of its members.
+func powerServices(order []string, services types.Services, online []string, start bool, power func(service string, host string) error, after func(service string) error, progress func(msg string)) (types.PowerResults, int) {
+	action, done := "stopping", types.PowerStatusStopped
+	if start {
+		action, done = "starting", types.PowerStatusStarted
+	}
+
+	results := types.PowerResults{}
+	failures := 0
+	for _, service := range order {
+		hosts := restartHosts(services, service)
+		for _, host := range hosts {
+			result := types.PowerResult{Host: host, Service: service, Status: done}
+			if !slices.Contains(online, host) {
+				result.Status = types.PowerStatusSkipped
+				result.Error = "member is offline"
+				results = append(results, result)
+				continue
+			}
+
+			progress(fmt.Sprintf("%s %s on %s", action, service, host))
+			err := power(service, host)
+			if err != nil {
+				logger.Errorf("Failed %s %s on %s: %v", action, service, host, err)
+				result.Status = types.PowerStatusFailed
+				result.Error = err.Error()
+				failures++
+			}
+
+			results = append(results, result)
+		}
+
+		if len(hosts) > 0 && after != nil {
+			err := after(service)
+			if err != nil {
+				results = append(results, types.PowerResult{Service: service, Status: types.PowerStatusFailed, Error: err.Error()})
+				failures++
+			}
+		}
+	}
+
+	return results, failures
+}
+
+// onlineMembers returns the names of the members which are online, and the ones which aren't.
+func onlineMembers(ctx context.Context, s interfaces.StateInterface) ([]string, []string, error) {
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	members, err := leader.GetClusterMembers(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get cluster members: %w", err)
+	}
+
+	online, offline := []string{}, []string{}
+	for _, member := range members {
+		if member.Status == microTypes.MemberOnline {
+			online = append(online, member.Name)
+		} else {
+			offline = append(offline, member.Name)
+		}
+	}
+
+	return online, offline, nil
+}
+
+// StartShutdown shuts the cluster down: the shutdown flags are set on the OSD map, then RGW,
+// MDS, OSD, MGR and MON services are stopped, in that order, on every member. The state is
+// recorded in the database, for startup to pick up once the cluster is powered up again.
+func StartShutdown(ctx context.Context, s interfaces.StateInterface, req types.ShutdownPost) (types.Operation, error) {
+	status, err := getShutdownStatus(ctx, s)
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	if status.State != "" && !req.Force {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the cluster is %s already", status.State)
+	}
+
+	upgrade, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	if upgrade.State == types.UpgradeStateRunning {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "an upgrade is running on %s", upgrade.Member)
+	}
+
+	_, offline, err := onlineMembers(ctx, s)
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	if len(offline) > 0 && !req.Force {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "members %s are offline, their services can't be stopped", strings.Join(offline, ", "))
+	}
+
+	return StartAsyncOperation(ctx, s, OperationTypeShutdown, func(ctx context.Context, op *AsyncOperation) (string, error) {
+		results, err := shutdownCluster(ctx, s, op, status)
+
+		data, jsonErr := json.Marshal(results)
+		if jsonErr != nil {
+			logger.Errorf("Failed to encode shutdown results: %v", jsonErr)
+		}
+
+		return string(data), err
+	})
+}
+
+func shutdownCluster(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, previous types.ShutdownStatus) (types.PowerResults, error) {
+	op.SetProgress("setting OSD flags")
+	flags, err := setShutdownFlags()
+	if err != nil {
+		// Roll back the flags set so far.
+		_ = unsetShutdownFlags(flags)
+		return nil, err
+	}
+
+	// A forced shutdown of a cluster shut down already keeps the flags set by the first one.
+	for _, flag := range previous.Flags {
+		if !slices.Contains(flags, flag) {
+			flags = append(flags, flag)
+		}
+	}
+
+	status := types.ShutdownStatus{
+		State:  types.ShutdownStateStopping,
+		Flags:  flags,
+		Member: s.ClusterState().Name(),
+	}
+
+	err = setShutdownStatus(ctx, s, status)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := ListRestartableServices(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	online, _, err := onlineMembers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	power := func(service string, host string) error {
+		return powerOnHost(ctx, s, false, service, host)
+	}
+
+	results, failures := powerServices(shutdownOrder, services, online, false, power, nil, op.SetProgress)
+
+	// Services are down, or partially so, either way startup is needed to bring them back.
+	status.State = types.ShutdownStateShutdown
+	err = setShutdownStatus(ctx, s, status)
+	if err != nil {
+		return results, err
+	}
+
+	if failures > 0 {
+		return results, fmt.Errorf("failed to stop %d services", failures)
+	}
+
+	op.SetProgress("shut down")
+	return results, nil
+}
+
+// StartStartup brings the cluster back after a shutdown: MON, MGR, OSD, MDS and RGW services are
+// started, in that order, on every member. Once all OSDs are up, the flags set by the shutdown
+// are unset and its state cleared.
+func StartStartup(ctx context.Context, s interfaces.StateInterface, req types.ShutdownPost) (types.Operation, error) {
+	status, err := getShutdownStatus(ctx, s)
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	switch status.State {
+	case types.ShutdownStateShutdown, types.ShutdownStateStarting:
+	case types.ShutdownStateStopping:
+		if !req.Force {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the cluster is being shut down by %s", status.Member)
+		}
+	default:
+		if !req.Force {
+			return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the cluster wasn't shut down")
+		}
+	}
+
+	return StartAsyncOperation(ctx, s, OperationTypeStartup, func(ctx context.Context, op *AsyncOperation) (string, error) {
+		results, err := startupCluster(ctx, s, op, status)
+
+		data, jsonErr := json.Marshal(results)
+		if jsonErr != nil {
+			logger.Errorf("Failed to encode startup results: %v", jsonErr)
+		}
+
+		return string(data), err
+	})
+}
+
+func startupCluster(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, status types.ShutdownStatus) (types.PowerResults, error) {
+	status.State = types.ShutdownStateStarting
+	status.Member = s.ClusterState().Name()
+	err := setShutdownStatus(ctx, s, status)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := ListRestartableServices(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	online, _, err := onlineMembers(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	order := slices.Clone(shutdownOrder)
+	slices.Reverse(order)
+
+	power := func(service string, host string) error {
+		return powerOnHost(ctx, s, true, service, host)
+	}
+
+	after := func(service string) error {
+		if service != "mon" {
+			return nil
+		}
+
+		op.SetProgress("waiting for monitor quorum")
+		return waitForMonitor(3 * time.Minute)
+	}
+
+	results, failures := powerServices(order, services, online, true, power, after, op.SetProgress)
+	if failures > 0 {
+		return results, fmt.Errorf("failed to start %d services, the OSD flags are kept", failures)
+	}
+
+	op.SetProgress("waiting for OSDs")
+	err = waitForOSDsUp(ctx, startupOSDTimeout, op.SetProgress)
+	if err != nil {
+		return results, fmt.Errorf("the OSD flags are kept: %w", err)
+	}
+
+	op.SetProgress("unsetting OSD flags")
+	err = unsetShutdownFlags(status.Flags)
+	if err != nil {
+		return results, err
+	}
+
+	err = setShutdownStatus(ctx, s, types.ShutdownStatus{})
+	if err != nil {
+		return results, err
+	}
+
+	op.SetProgress("started")
+	return results, nil
+}
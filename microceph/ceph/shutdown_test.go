@@ -0,0 +1,107 @@
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type shutdownSuite struct {
+	tests.BaseSuite
+}
+
+func TestShutdown(t *testing.T) {
+	suite.Run(t, new(shutdownSuite))
+}
+
+// TestSetShutdownFlags checks only the flags which weren't set already are set and reported.
+func (s *shutdownSuite) TestSetShutdownFlags() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "dump", "-f", "json").Return(`{"flags":"noout,sortbitwise,recovery_deletes"}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "set", "norebalance").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "set", "nobackfill").Return("", nil).Once()
+	processExec = r
+
+	flags, err := setShutdownFlags()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"norebalance", "nobackfill"}, flags)
+
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "unset", "norebalance").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "unset", "nobackfill").Return("", nil).Once()
+	processExec = r
+
+	assert.NoError(s.T(), unsetShutdownFlags(flags))
+}
+
+// TestWaitForOSDsUp checks the wait ends once all OSDs are up, and times out otherwise.
+func (s *shutdownSuite) TestWaitForOSDsUp() {
+	interval := startupPollInterval
+	startupPollInterval = time.Millisecond
+	defer func() { startupPollInterval = interval }()
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "stat", "-f", "json").Return(`{"num_osds":3,"num_up_osds":1}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "stat", "-f", "json").Return("", fmt.Errorf("no quorum")).Once()
+	r.On("RunCommand", "ceph", "osd", "stat", "-f", "json").Return(`{"num_osds":3,"num_up_osds":3}`, nil).Once()
+	processExec = r
+
+	progress := []string{}
+	err := waitForOSDsUp(context.Background(), time.Minute, func(msg string) { progress = append(progress, msg) })
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"waiting for OSDs, 1 of 3 up"}, progress)
+
+	r = mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "stat", "-f", "json").Return(`{"num_osds":3,"num_up_osds":2}`, nil)
+	processExec = r
+
+	err = waitForOSDsUp(context.Background(), 0, func(string) {})
+	assert.ErrorContains(s.T(), err, "only 2 of 3 osds are up")
+}
+
+// TestPowerServices checks services go through their members in order, offline members being
+// skipped and failures not stopping the sequence.
+func (s *shutdownSuite) TestPowerServices() {
+	services := types.Services{
+		{Service: "mon", Location: "b"},
+		{Service: "mon", Location: "a"},
+		{Service: "osd", Location: "a"},
+		{Service: "osd", Location: "c"},
+		{Service: "rgw", Location: "b"},
+	}
+
+	calls := []string{}
+	power := func(service string, host string) error {
+		calls = append(calls, fmt.Sprintf("%s/%s", service, host))
+		if service == "rgw" {
+			return fmt.Errorf("rgw is stuck")
+		}
+		return nil
+	}
+
+	after := []string{}
+	afterFn := func(service string) error {
+		after = append(after, service)
+		return nil
+	}
+
+	results, failures := powerServices(shutdownOrder, services, []string{"a", "b"}, false, power, afterFn, func(string) {})
+	assert.Equal(s.T(), 1, failures)
+	assert.Equal(s.T(), []string{"rgw/b", "osd/a", "mon/a", "mon/b"}, calls)
+	assert.Equal(s.T(), []string{"rgw", "osd", "mon"}, after)
+	assert.Equal(s.T(), types.PowerResults{
+		{Host: "b", Service: "rgw", Status: types.PowerStatusFailed, Error: "rgw is stuck"},
+		{Host: "a", Service: "osd", Status: types.PowerStatusStopped},
+		{Host: "c", Service: "osd", Status: types.PowerStatusSkipped, Error: "member is offline"},
+		{Host: "a", Service: "mon", Status: types.PowerStatusStopped},
+		{Host: "b", Service: "mon", Status: types.PowerStatusStopped},
+	}, results)
+}
@@ -206,11 +206,50 @@ func Start(ctx context.Context, s interfaces.StateInterface) error {
 
 	go func() {
 		time.Sleep(10 * time.Second) // wait for the mons to converge
-		err := PostRefresh()
+		pending, err := isUpgradePending()
+		if err != nil {
+			logger.Warnf("Failed to check for a pending upgrade: %v", err)
+		} else if pending {
+			logger.Infof("Ceph daemons don't run the installed version yet, run 'microceph cluster upgrade' once all members are refreshed")
+			return
+		}
+
+		err = PostRefresh()
 		if err != nil {
 			logger.Errorf("PostRefresh failed: %v", err)
 		}
 	}()
 
+	go func() {
+		// Wait for the database before looking for interrupted operations and upgrades, disk encryption and crush location.
+		for s.ClusterState().Database().IsOpen(context.Background()) != nil {
+			time.Sleep(10 * time.Second)
+		}
+		FailInterruptedOperations(ctx, s)
+		pauseInterruptedUpgrade(ctx, s)
+		ResumeDiskEncryption(ctx, s)
+
+		err := applyLocalCrushLocation(ctx, s.ClusterState())
+		if err != nil {
+			logger.Warnf("Failed to apply crush location: %v", err)
+		}
+	}()
+
+	// Start background loop to enforce the service count policy.
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+
+			if s.ClusterState().Database().IsOpen(context.Background()) != nil {
+				continue
+			}
+
+			err := ReconcileServices(ctx, s)
+			if err != nil {
+				logger.Warnf("start: failed to reconcile services: %v", err)
+			}
+		}
+	}()
+
 	return nil
 }
@@ -0,0 +1,351 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+const (
+	// stretchCrushRule is the crush rule placing two replicas in each site.
+	stretchCrushRule = "microceph_stretch"
+	// stretchBucketType is the crush bucket type the sites are made of.
+	stretchBucketType = "datacenter"
+	// stretchTiebreakerSite is the location of the tiebreaker monitor.
+	stretchTiebreakerSite = "tiebreaker"
+)
+
+// crushRuleID matches the ids of the rules in a decompiled crush map.
+var crushRuleID = regexp.MustCompile(`(?m)^rule\s+\S+\s*\{\s*id\s+(\d+)`)
+
+// GetStretchMode returns the stretch mode of the cluster along with the members of each site.
+func GetStretchMode(ctx context.Context, s state.State) (types.StretchMode, error) {
+	stretch, err := database.StretchQuery.Get(ctx, s)
+	if err != nil {
+		return stretch, fmt.Errorf("failed to fetch stretch mode: %w", err)
+	}
+
+	err = fillStretchSites(ctx, s, &stretch)
+	if err != nil {
+		return stretch, err
+	}
+
+	return stretch, nil
+}
+
+// fillStretchSites looks up the members of each site by their crush location.
+func fillStretchSites(ctx context.Context, s state.State, stretch *types.StretchMode) error {
+	if len(stretch.Sites) == 0 {
+		return nil
+	}
+
+	locations, err := database.CrushLocationQuery.List(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	for i := range stretch.Sites {
+		stretch.Sites[i].Members = []string{}
+	}
+
+	for member, location := range locations {
+		parsed, err := ParseCrushLocation(location)
+		if err != nil {
+			logger.Warnf("Ignoring invalid crush location of %s: %v", member, err)
+			continue
+		}
+
+		for i, site := range stretch.Sites {
+			if parsed[stretchBucketType] == site.Name {
+				stretch.Sites[i].Members = append(stretch.Sites[i].Members, member)
+			}
+		}
+	}
+
+	for _, site := range stretch.Sites {
+		slices.Sort(site.Members)
+	}
+
+	return nil
+}
+
+// stretchSiteOf returns the name of the site a member is in, or an empty string.
+func stretchSiteOf(stretch types.StretchMode, member string) string {
+	for _, site := range stretch.Sites {
+		if slices.Contains(site.Members, member) {
+			return site.Name
+		}
+	}
+
+	return ""
+}
+
+// checkStretchMons checks each site keeps at least minPerSite monitors besides those on the
+// given member, so that the cluster can still survive the loss of a site.
+func checkStretchMons(stretch types.StretchMode, services types.Services, name string, minPerSite int) error {
+	if !stretch.Enabled {
+		return nil
+	}
+
+	for _, site := range stretch.Sites {
+		mons := 0
+		for _, service := range services {
+			if service.Service == "mon" && service.Location != name && slices.Contains(site.Members, service.Location) {
+				mons++
+			}
+		}
+
+		if mons < minPerSite {
+			return fmt.Errorf("need at least %d mon in site %s besides those in node '%s'", minPerSite, site.Name, name)
+		}
+	}
+
+	return nil
+}
+
+// checkStretchMonRemoval checks that a stretch cluster keeps its tiebreaker and 2 mons per site
+// without the mon of this member.
+func checkStretchMonRemoval(ctx context.Context, s interfaces.StateInterface) error {
+	stretch, err := GetStretchMode(ctx, s.ClusterState())
+	if err != nil {
+		return err
+	}
+
+	if !stretch.Enabled {
+		return nil
+	}
+
+	name := s.ClusterState().Name()
+	if stretch.Tiebreaker == name {
+		return api.StatusErrorf(http.StatusConflict, "can't disable mon on %s: it is the stretch mode tiebreaker", name)
+	}
+
+	services, err := ListServices(ctx, s.ClusterState())
+	if err != nil {
+		return err
+	}
+
+	err = checkStretchMons(stretch, services, name, 2)
+	if err != nil {
+		return api.StatusErrorf(http.StatusConflict, "can't disable mon on %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// validateStretchMode checks the cluster layout is fit for stretch mode: the tiebreaker runs a
+// monitor outside of both sites, every other monitor and all OSDs are within a site and each
+// site has at least 2 monitors.
+func validateStretchMode(stretch types.StretchMode, services types.Services, disks types.Disks) error {
+	for _, site := range stretch.Sites {
+		if len(site.Members) == 0 {
+			return fmt.Errorf("no members in site %s, set their crush location to %s=%s first", site.Name, stretchBucketType, site.Name)
+		}
+	}
+
+	if stretchSiteOf(stretch, stretch.Tiebreaker) != "" {
+		return fmt.Errorf("tiebreaker %s must not be within a site", stretch.Tiebreaker)
+	}
+
+	if !isServicePlacementOnHost(services, "mon", stretch.Tiebreaker) {
+		return fmt.Errorf("tiebreaker %s needs to run a mon", stretch.Tiebreaker)
+	}
+
+	for _, service := range services {
+		if service.Service == "mon" && service.Location != stretch.Tiebreaker && stretchSiteOf(stretch, service.Location) == "" {
+			return fmt.Errorf("mon on %s is neither within a site nor the tiebreaker", service.Location)
+		}
+	}
+
+	for _, disk := range disks {
+		if stretchSiteOf(stretch, disk.Location) == "" {
+			return fmt.Errorf("osd.%d on %s is not within a site", disk.OSD, disk.Location)
+		}
+	}
+
+	return checkStretchMons(stretch, services, "", 2)
+}
+
+// EnableStretchMode switches the cluster to stretch mode across two sites, with a tiebreaker
+// monitor in a third location. Monitors get located, a crush rule placing two replicas in each
+// site becomes the default and Ceph moves all pools over to it.
+func EnableStretchMode(ctx context.Context, s interfaces.StateInterface, req types.StretchModePost) error {
+	for _, site := range []string{req.SiteA, req.SiteB} {
+		if !validBucketName.MatchString(site) {
+			return api.StatusErrorf(http.StatusBadRequest, "invalid site name %q", site)
+		}
+	}
+
+	if req.SiteA == req.SiteB {
+		return api.StatusErrorf(http.StatusBadRequest, "sites need to differ")
+	}
+
+	if req.Tiebreaker == "" {
+		return api.StatusErrorf(http.StatusBadRequest, "no tiebreaker given")
+	}
+
+	current, err := database.StretchQuery.Get(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to fetch stretch mode: %w", err)
+	}
+
+	if current.Enabled {
+		return api.StatusErrorf(http.StatusConflict, "stretch mode is enabled already")
+	}
+
+	stretch := types.StretchMode{
+		Enabled:    true,
+		Tiebreaker: req.Tiebreaker,
+		Sites:      []types.StretchSite{{Name: req.SiteA}, {Name: req.SiteB}},
+	}
+
+	err = fillStretchSites(ctx, s.ClusterState(), &stretch)
+	if err != nil {
+		return err
+	}
+
+	services, err := ListServices(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	disks, err := database.OSDQuery.List(ctx, s.ClusterState())
+	if err != nil {
+		return fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	err = validateStretchMode(stretch, services, disks)
+	if err != nil {
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	err = applyStretchMode(stretch, services)
+	if err != nil {
+		return err
+	}
+
+	return s.ClusterState().Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := upsertConfigItem(ctx, tx, database.StretchSitesKey, fmt.Sprintf("%s,%s", req.SiteA, req.SiteB))
+		if err != nil {
+			return err
+		}
+
+		return upsertConfigItem(ctx, tx, database.StretchTiebreakerKey, req.Tiebreaker)
+	})
+}
+
+// applyStretchMode locates the monitors and enables stretch mode in Ceph.
+func applyStretchMode(stretch types.StretchMode, services types.Services) error {
+	_, err := processExec.RunCommand("ceph", "mon", "set", "election_strategy", "connectivity")
+	if err != nil {
+		return fmt.Errorf("failed to set mon election strategy: %w", err)
+	}
+
+	for _, service := range services {
+		if service.Service != "mon" {
+			continue
+		}
+
+		site := stretchSiteOf(stretch, service.Location)
+		if service.Location == stretch.Tiebreaker {
+			site = stretchTiebreakerSite
+		}
+
+		_, err = processExec.RunCommand("ceph", "mon", "set_location", service.Location, fmt.Sprintf("%s=%s", stretchBucketType, site))
+		if err != nil {
+			return fmt.Errorf("failed to set location of mon %s: %w", service.Location, err)
+		}
+	}
+
+	if !haveCrushRule(stretchCrushRule) {
+		err = addStretchCrushRule(stretchCrushRule)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = processExec.RunCommand("ceph", "mon", "enable_stretch_mode", stretch.Tiebreaker, stretchCrushRule, stretchBucketType)
+	if err != nil {
+		return fmt.Errorf("failed to enable stretch mode: %w", err)
+	}
+
+	return setDefaultCrushRule(stretchCrushRule)
+}
+
+// addStretchCrushRule adds a rule placing two replicas on different hosts in each site. Such
+// rules take several choose steps, which the ceph CLI can't create, so the crush map is
+// edited instead.
+func addStretchCrushRule(name string) error {
+	dir, err := os.MkdirTemp("", "crushmap")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	current := filepath.Join(dir, "crushmap")
+	text := filepath.Join(dir, "crushmap.txt")
+	compiled := filepath.Join(dir, "crushmap.new")
+
+	_, err = processExec.RunCommand("ceph", "osd", "getcrushmap", "-o", current)
+	if err != nil {
+		return fmt.Errorf("failed to get crush map: %w", err)
+	}
+
+	_, err = processExec.RunCommand("crushtool", "-d", current, "-o", text)
+	if err != nil {
+		return fmt.Errorf("failed to decompile crush map: %w", err)
+	}
+
+	data, err := os.ReadFile(text)
+	if err != nil {
+		return err
+	}
+
+	id := 0
+	for _, match := range crushRuleID.FindAllStringSubmatch(string(data), -1) {
+		ruleID, _ := strconv.Atoi(match[1])
+		id = max(id, ruleID+1)
+	}
+
+	rule := fmt.Sprintf(`
+rule %s {
+	id %d
+	type replicated
+	step take default
+	step choose firstn 0 type %s
+	step chooseleaf firstn 2 type host
+	step emit
+}
+`, name, id, stretchBucketType)
+
+	err = os.WriteFile(text, append(data, []byte(rule)...), 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = processExec.RunCommand("crushtool", "-c", text, "-o", compiled)
+	if err != nil {
+		return fmt.Errorf("failed to compile crush map: %w", err)
+	}
+
+	_, err = processExec.RunCommand("ceph", "osd", "setcrushmap", "-i", compiled)
+	if err != nil {
+		return fmt.Errorf("failed to set crush map: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package ceph
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type stretchSuite struct {
+	tests.BaseSuite
+}
+
+func TestStretch(t *testing.T) {
+	suite.Run(t, new(stretchSuite))
+}
+
+func (s *stretchSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+// stretchLayout returns a stretch mode over two sites of two nodes each, with node5 as tiebreaker.
+func stretchLayout() (types.StretchMode, types.Services, types.Disks) {
+	stretch := types.StretchMode{
+		Enabled:    true,
+		Tiebreaker: "node5",
+		Sites: []types.StretchSite{
+			{Name: "dc1", Members: []string{"node1", "node2"}},
+			{Name: "dc2", Members: []string{"node3", "node4"}},
+		},
+	}
+
+	services := types.Services{}
+	disks := types.Disks{}
+	for i, node := range []string{"node1", "node2", "node3", "node4"} {
+		services = append(services, types.Service{Service: "mon", Location: node})
+		disks = append(disks, types.Disk{OSD: int64(i), Location: node})
+	}
+	services = append(services, types.Service{Service: "mon", Location: "node5"})
+
+	return stretch, services, disks
+}
+
+func (s *stretchSuite) TestValidateStretchMode() {
+	stretch, services, disks := stretchLayout()
+	assert.NoError(s.T(), validateStretchMode(stretch, services, disks))
+
+	// OSDs outside of the sites
+	err := validateStretchMode(stretch, services, append(disks, types.Disk{OSD: 4, Location: "node5"}))
+	assert.ErrorContains(s.T(), err, "osd.4 on node5 is not within a site")
+
+	// no tiebreaker mon
+	err = validateStretchMode(stretch, services[:4], disks)
+	assert.ErrorContains(s.T(), err, "needs to run a mon")
+
+	// a single mon in dc2
+	err = validateStretchMode(stretch, append(services[:3:3], services[4]), disks)
+	assert.ErrorContains(s.T(), err, "need at least 2 mon in site dc2")
+
+	// empty site
+	stretch.Sites[1].Members = []string{}
+	err = validateStretchMode(stretch, services, disks[:2])
+	assert.ErrorContains(s.T(), err, "no members in site dc2")
+}
+
+func (s *stretchSuite) TestCheckStretchMons() {
+	stretch, services, _ := stretchLayout()
+
+	assert.NoError(s.T(), checkStretchMons(stretch, services, "node1", 1))
+	assert.ErrorContains(s.T(), checkStretchMons(stretch, services, "node1", 2), "site dc1 besides those in node 'node1'")
+
+	// only applies to stretch clusters
+	assert.NoError(s.T(), checkStretchMons(types.StretchMode{}, services, "node1", 2))
+}
+
+func (s *stretchSuite) TestAddStretchCrushRule() {
+	crushmap := "# rules\nrule replicated_rule {\n\tid 0\n\ttype replicated\n}\nrule microceph_auto_host {\n\tid 2\n\ttype replicated\n}\n"
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "getcrushmap", "-o", mock.Anything).Return("", nil).Once()
+	r.On("RunCommand", "crushtool", "-d", mock.Anything, "-o", mock.Anything).Return("", nil).Run(func(args mock.Arguments) {
+		_ = os.WriteFile(args.String(4), []byte(crushmap), 0600)
+	}).Once()
+	r.On("RunCommand", "crushtool", "-c", mock.Anything, "-o", mock.Anything).Return("", nil).Run(func(args mock.Arguments) {
+		data, err := os.ReadFile(args.String(2))
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), strings.HasPrefix(string(data), crushmap))
+		assert.Contains(s.T(), string(data), "rule microceph_stretch {\n\tid 3\n")
+		assert.Contains(s.T(), string(data), "step choose firstn 0 type datacenter\n\tstep chooseleaf firstn 2 type host\n")
+	}).Once()
+	r.On("RunCommand", "ceph", "osd", "setcrushmap", "-i", mock.Anything).Return("", nil).Once()
+	processExec = r
+
+	err := addStretchCrushRule(stretchCrushRule)
+	assert.NoError(s.T(), err)
+}
+
+func (s *stretchSuite) TestApplyStretchMode() {
+	stretch, services, _ := stretchLayout()
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "set", "election_strategy", "connectivity").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set_location", "node1", "datacenter=dc1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set_location", "node2", "datacenter=dc1").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set_location", "node3", "datacenter=dc2").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set_location", "node4", "datacenter=dc2").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "set_location", "node5", "datacenter=tiebreaker").Return("", nil).Once()
+	// the stretch rule exists already
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "ls").Return("microceph_auto_osd\nmicroceph_stretch", nil).Once()
+	r.On("RunCommand", "ceph", "mon", "enable_stretch_mode", "node5", "microceph_stretch", "datacenter").Return("", nil).Once()
+	r.On("RunCommand", "ceph", "osd", "crush", "rule", "dump", "microceph_stretch").Return(`{ "rule_id": 3 }`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "set", "global", "osd_pool_default_crush_rule", "3", "-f", "json-pretty").Return("ok", nil).Once()
+	processExec = r
+
+	err := applyStretchMode(stretch, services)
+	assert.NoError(s.T(), err)
+}
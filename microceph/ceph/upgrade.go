@@ -0,0 +1,514 @@
+package ceph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+	"github.com/canonical/microcluster/v2/state"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/database"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// OperationTypeUpgrade is the type of cluster upgrade operations.
+const OperationTypeUpgrade = "upgrade"
+
+// upgradeOrder is the order in which services are upgraded across the cluster.
+var upgradeOrder = []string{"mon", "mgr", "osd", "mds", "rgw"}
+
+// parseCephVersion extracts the version and release name from a version string
+// like "ceph version 19.2.0 (e7ad534...) squid (stable)".
+func parseCephVersion(output string) (string, string, error) {
+	parts := strings.Fields(output)
+	if len(parts) < 6 {
+		return "", "", fmt.Errorf("invalid version string format: %s", output)
+	}
+
+	return parts[2], parts[len(parts)-2], nil
+}
+
+// GetMemberVersion returns the Ceph version installed on this member.
+func GetMemberVersion(s state.State) (types.MemberVersion, error) {
+	version := types.MemberVersion{Name: s.Name(), Revision: os.Getenv("SNAP_REVISION")}
+
+	output, err := processExec.RunCommand("ceph", "-v")
+	if err != nil {
+		return version, fmt.Errorf("failed to get ceph version: %w", err)
+	}
+
+	version.Version, version.Release, err = parseCephVersion(output)
+	if err != nil {
+		return version, err
+	}
+
+	return version, nil
+}
+
+// getMemberVersions asks each cluster member for its installed Ceph version. Members which
+// can't be reached have no version.
+func getMemberVersions(ctx context.Context, s interfaces.StateInterface) ([]types.MemberVersion, error) {
+	leader, err := s.ClusterState().Leader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dqlite leader: %w", err)
+	}
+
+	members, err := leader.GetClusterMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster members: %w", err)
+	}
+
+	versions := []types.MemberVersion{}
+	for _, member := range members {
+		version := types.MemberVersion{Name: member.Name}
+		if member.Status == microTypes.MemberOnline {
+			version, err = client.GetMemberVersion(ctx, leader.UseTarget(member.Name))
+			if err != nil {
+				logger.Warnf("Failed to get the version of %s: %v", member.Name, err)
+				version = types.MemberVersion{Name: member.Name}
+			}
+		}
+
+		versions = append(versions, version)
+	}
+
+	slices.SortFunc(versions, func(a types.MemberVersion, b types.MemberVersion) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return versions, nil
+}
+
+// checkVersionSkew checks all members have the target version installed.
+func checkVersionSkew(members []types.MemberVersion, target string) error {
+	skewed := []string{}
+	for _, member := range members {
+		if member.Version != target {
+			version := member.Version
+			if version == "" {
+				version = "unknown"
+			}
+			skewed = append(skewed, fmt.Sprintf("%s (%s)", member.Name, version))
+		}
+	}
+
+	if len(skewed) > 0 {
+		return fmt.Errorf("members %s don't have ceph %s installed, refresh the snap on all members first", strings.Join(skewed, ", "), target)
+	}
+
+	return nil
+}
+
+// isUpgradePending checks whether any Ceph daemon runs another version than the one installed,
+// e.g. as it wasn't restarted since the snap refreshed.
+func isUpgradePending() (bool, error) {
+	output, err := processExec.RunCommand("ceph", "-v")
+	if err != nil {
+		return false, fmt.Errorf("failed to get ceph version: %w", err)
+	}
+
+	installed, _, err := parseCephVersion(output)
+	if err != nil {
+		return false, err
+	}
+
+	output, err = cephRun("versions")
+	if err != nil {
+		return false, fmt.Errorf("failed to get Ceph versions: %w", err)
+	}
+
+	for running := range gjson.Get(output, "overall").Map() {
+		version, _, _ := parseCephVersion(running)
+		if version != installed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getDaemonVersions returns the running version of each daemon of a service, keyed by daemon id.
+func getDaemonVersions(service string) (map[string]string, error) {
+	output, err := cephRun(service, "metadata", "-f", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s metadata: %w", service, err)
+	}
+
+	versions := map[string]string{}
+	for _, meta := range gjson.Parse(output).Array() {
+		id := meta.Get("name").String()
+		if service == "osd" {
+			id = meta.Get("id").String()
+		}
+
+		version, _, err := parseCephVersion(meta.Get("ceph_version").String())
+		if err != nil {
+			logger.Warnf("Failed to parse the version of %s.%s: %v", service, id, err)
+		}
+
+		versions[id] = version
+	}
+
+	return versions, nil
+}
+
+// hostNeedsUpgrade checks whether any daemon of the service on the host doesn't run the target version.
+func hostNeedsUpgrade(service string, host string, disks types.Disks, target string) (bool, error) {
+	if service == "rgw" {
+		// RGW daemons don't report metadata by host, restart them until all run the target version.
+		output, err := cephRun("versions")
+		if err != nil {
+			return false, fmt.Errorf("failed to get Ceph versions: %w", err)
+		}
+
+		for running := range gjson.Get(output, "rgw").Map() {
+			version, _, _ := parseCephVersion(running)
+			if version != target {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	versions, err := getDaemonVersions(service)
+	if err != nil {
+		return false, err
+	}
+
+	ids := []string{host}
+	if service == "osd" {
+		ids = []string{}
+		for _, disk := range disks {
+			if disk.Location == host {
+				ids = append(ids, strconv.FormatInt(disk.OSD, 10))
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if versions[id] != target {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getUpgradeStatus returns the recorded state of the cluster upgrade.
+func getUpgradeStatus(ctx context.Context, s state.State) (types.UpgradeStatus, error) {
+	status := types.UpgradeStatus{}
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		fields := map[string]*string{
+			database.UpgradeStateKey:  &status.State,
+			database.UpgradeTargetKey: &status.Target,
+			database.UpgradeMemberKey: &status.Member,
+			database.UpgradeStepKey:   &status.Step,
+			database.UpgradeErrorKey:  &status.Error,
+		}
+
+		for key, field := range fields {
+			item, err := database.GetConfigItem(ctx, tx, key)
+			if err != nil {
+				if api.StatusErrorCheck(err, http.StatusNotFound) {
+					continue
+				}
+				return err
+			}
+
+			*field = item.Value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return status, fmt.Errorf("failed to fetch upgrade state: %w", err)
+	}
+
+	return status, nil
+}
+
+// setUpgradeStatus records the state of the cluster upgrade.
+func setUpgradeStatus(ctx context.Context, s state.State, status types.UpgradeStatus) error {
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		fields := map[string]string{
+			database.UpgradeStateKey:  status.State,
+			database.UpgradeTargetKey: status.Target,
+			database.UpgradeMemberKey: status.Member,
+			database.UpgradeStepKey:   status.Step,
+			database.UpgradeErrorKey:  status.Error,
+		}
+
+		for key, value := range fields {
+			err := upsertConfigItem(ctx, tx, key, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record upgrade state: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpgradeStatus returns the state of the cluster upgrade along with the version installed on each member.
+func GetUpgradeStatus(ctx context.Context, s interfaces.StateInterface) (types.UpgradeStatus, error) {
+	status, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return status, err
+	}
+
+	status.Members, err = getMemberVersions(ctx, s)
+	if err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// PauseUpgrade pauses the running cluster upgrade once the current host is done.
+func PauseUpgrade(ctx context.Context, s interfaces.StateInterface) error {
+	status, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return err
+	}
+
+	if status.State != types.UpgradeStateRunning {
+		return api.StatusErrorf(http.StatusConflict, "no upgrade is running")
+	}
+
+	status.State = types.UpgradeStatePaused
+	return setUpgradeStatus(ctx, s.ClusterState(), status)
+}
+
+// StartUpgrade starts, or resumes, the upgrade of the cluster to the Ceph version installed on
+// this member. Every member needs to have it installed already.
+func StartUpgrade(ctx context.Context, s interfaces.StateInterface, resume bool) (types.Operation, error) {
+	status, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	switch {
+	case status.State == types.UpgradeStateRunning:
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "an upgrade is running already on %s", status.Member)
+	case resume && status.State != types.UpgradeStatePaused:
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "no upgrade is paused")
+	case !resume && status.State == types.UpgradeStatePaused:
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "an upgrade is paused, resume it instead")
+	}
+
+	installed, err := GetMemberVersion(s.ClusterState())
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	if resume && installed.Version != status.Target {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "the paused upgrade is to ceph %s, but %s is installed", status.Target, installed.Version)
+	}
+
+	members, err := getMemberVersions(ctx, s)
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	err = checkVersionSkew(members, installed.Version)
+	if err != nil {
+		return types.Operation{}, api.StatusErrorf(http.StatusConflict, "%v", err)
+	}
+
+	status = types.UpgradeStatus{
+		State:  types.UpgradeStateRunning,
+		Target: installed.Version,
+		Member: s.ClusterState().Name(),
+	}
+
+	err = setUpgradeStatus(ctx, s.ClusterState(), status)
+	if err != nil {
+		return types.Operation{}, err
+	}
+
+	return StartAsyncOperation(ctx, s, OperationTypeUpgrade, func(ctx context.Context, op *AsyncOperation) (string, error) {
+		err := runUpgrade(ctx, s, op, installed)
+		if err != nil {
+			status, statusErr := getUpgradeStatus(context.Background(), s.ClusterState())
+			if statusErr != nil {
+				logger.Errorf("Failed to record the failure of the upgrade: %v", statusErr)
+				return "", err
+			}
+
+			status.State = types.UpgradeStateFailed
+			status.Error = err.Error()
+
+			statusErr = setUpgradeStatus(context.Background(), s.ClusterState(), status)
+			if statusErr != nil {
+				logger.Errorf("Failed to record the failure of the upgrade: %v", statusErr)
+			}
+
+			return "", err
+		}
+
+		status, err := getUpgradeStatus(context.Background(), s.ClusterState())
+		if err != nil {
+			return "", err
+		}
+
+		return status.State, nil
+	})
+}
+
+// runUpgrade restarts the daemons not running the target version yet, service by service in
+// upgrade order and one host at a time, gated by the cluster health. Once all run the target
+// version, the required OSD release is bumped.
+func runUpgrade(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, target types.MemberVersion) error {
+	op.SetProgress("checking cluster health")
+	err := waitForHealthy(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("not upgrading: %w", err)
+	}
+
+	for _, service := range upgradeOrder {
+		paused, err := upgradeService(ctx, s, op, service, target.Version)
+		if err != nil {
+			return err
+		}
+
+		if paused {
+			logger.Infof("Upgrade paused before upgrading %s", service)
+			op.SetProgress("paused")
+			return nil
+		}
+	}
+
+	mustUpdate, err := osdReleaseRequired(target.Release)
+	if err != nil {
+		return fmt.Errorf("OSD release check failed: %w", err)
+	}
+
+	if mustUpdate {
+		err = updateOSDRelease(target.Release)
+		if err != nil {
+			return err
+		}
+	}
+
+	status, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		return err
+	}
+
+	status.State = types.UpgradeStateCompleted
+	status.Step = ""
+	op.SetProgress("completed")
+
+	return setUpgradeStatus(ctx, s.ClusterState(), status)
+}
+
+// upgradeService restarts the daemons of a service which don't run the target version yet, one
+// host at a time. It stops early, reporting so, if the upgrade was paused.
+func upgradeService(ctx context.Context, s interfaces.StateInterface, op *AsyncOperation, service string, target string) (bool, error) {
+	services, err := ListRestartableServices(ctx, s)
+	if err != nil {
+		return false, err
+	}
+
+	disks, err := ListOSD(ctx, s.ClusterState())
+	if err != nil {
+		return false, fmt.Errorf("failed to list disks: %w", err)
+	}
+
+	ignore := []string{}
+	if service == "osd" {
+		ignore = nooutHealthChecks
+	}
+
+	nooutHeld := false
+	for _, host := range restartHosts(services, service) {
+		needed, err := hostNeedsUpgrade(service, host, disks, target)
+		if err != nil {
+			return false, err
+		}
+
+		if !needed {
+			logger.Debugf("%s on %s runs ceph %s already", service, host, target)
+			continue
+		}
+
+		status, err := getUpgradeStatus(ctx, s.ClusterState())
+		if err != nil {
+			return false, err
+		}
+
+		if status.State == types.UpgradeStatePaused {
+			return true, nil
+		}
+
+		if service == "osd" && !nooutHeld {
+			release, err := holdNoout()
+			if err != nil {
+				return false, err
+			}
+			defer release()
+
+			nooutHeld = true
+		}
+
+		status.Step = fmt.Sprintf("%s on %s", service, host)
+		err = setUpgradeStatus(ctx, s.ClusterState(), status)
+		if err != nil {
+			return false, err
+		}
+
+		op.SetProgress(fmt.Sprintf("upgrading %s", status.Step))
+
+		err = restartOnHost(ctx, s, service, host)
+		if err == nil {
+			err = waitForHealthy(ctx, ignore)
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("failed upgrading %s on %s: %w", service, host, err)
+		}
+
+		logger.Infof("Upgraded %s on %s to ceph %s", service, host, target)
+	}
+
+	return false, nil
+}
+
+// pauseInterruptedUpgrade pauses an upgrade this member was running before the daemon restarted,
+// so that it can be resumed.
+func pauseInterruptedUpgrade(ctx context.Context, s interfaces.StateInterface) {
+	status, err := getUpgradeStatus(ctx, s.ClusterState())
+	if err != nil {
+		logger.Warnf("Failed to check for an interrupted upgrade: %v", err)
+		return
+	}
+
+	if status.State != types.UpgradeStateRunning || status.Member != s.ClusterState().Name() {
+		return
+	}
+
+	logger.Warnf("Upgrade to ceph %s was interrupted, pausing it", status.Target)
+	status.State = types.UpgradeStatePaused
+
+	err = setUpgradeStatus(ctx, s.ClusterState(), status)
+	if err != nil {
+		logger.Warnf("Failed to pause the interrupted upgrade: %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package ceph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/canonical/lxd/shared/logger"
+	"github.com/tidwall/gjson"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// Raw capacity usage ratios above which the upgrade check warns or fails.
+const (
+	upgradeWarnUsedRatio = 0.75
+	upgradeFailUsedRatio = 0.85
+)
+
+// CheckWarning is returned by a check which found something worth looking at, but which doesn't
+// prevent the upgrade.
+type CheckWarning struct {
+	Message string
+}
+
+func (w *CheckWarning) Error() string {
+	return w.Message
+}
+
+// warnf returns a CheckWarning.
+func warnf(format string, args ...any) error {
+	return &CheckWarning{Message: fmt.Sprintf(format, args...)}
+}
+
+// RunChecks runs all checks, whatever their outcome, and reports each as passed, failed or
+// only warned about.
+func RunChecks(name string, checks []Operation) types.UpgradeCheckResults {
+	results := types.UpgradeCheckResults{}
+	for _, check := range checks {
+		result := types.UpgradeCheckResult{
+			Name:        check.GetName(),
+			Description: check.DryRun(name),
+			Status:      types.CheckStatusPass,
+		}
+
+		err := check.Run(name)
+		if err != nil {
+			var warning *CheckWarning
+			if errors.As(err, &warning) {
+				result.Status = types.CheckStatusWarn
+			} else {
+				result.Status = types.CheckStatusFail
+			}
+
+			result.Message = err.Error()
+			logger.Infof("Upgrade check %s: %s: %v", result.Name, result.Status, err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// UpgradeCheck runs the checks ahead of an upgrade of the cluster.
+func UpgradeCheck(ops ClusterOps) types.UpgradeCheckResults {
+	checks := []Operation{
+		&CheckHealthOkOps{ClusterOps: ops},
+		&CheckOsdsUpOps{ClusterOps: ops},
+		&CheckMonQuorumOps{ClusterOps: ops},
+		&CheckNoDegradedPGsOps{ClusterOps: ops},
+		&CheckFreeSpaceOps{ClusterOps: ops},
+		&CheckPoolMinSizeOps{ClusterOps: ops},
+		&CheckNooutUnsetOps{ClusterOps: ops},
+		&CheckDeprecatedSettingsOps{ClusterOps: ops},
+		&CheckSnapRevisionsOps{ClusterOps: ops},
+	}
+
+	return RunChecks(ops.State.Name(), checks)
+}
+
+// CheckHealthOkOps is an operation to check the cluster is healthy.
+type CheckHealthOkOps struct {
+	ClusterOps
+}
+
+// Run checks the cluster health, warnings are reported as such.
+func (o *CheckHealthOkOps) Run(name string) error {
+	degraded, err := checkHealth(nil)
+	if err != nil && !degraded {
+		return warnf("%v", err)
+	}
+
+	return err
+}
+
+// DryRun prints out the action plan.
+func (o *CheckHealthOkOps) DryRun(name string) string {
+	return "Check the cluster is HEALTH_OK."
+}
+
+// GetName returns the name of the action
+func (o *CheckHealthOkOps) GetName() string {
+	return "check-health-ok-ops"
+}
+
+// CheckOsdsUpOps is an operation to check all OSDs are up and in.
+type CheckOsdsUpOps struct {
+	ClusterOps
+}
+
+// Run checks all OSDs are up and in.
+func (o *CheckOsdsUpOps) Run(name string) error {
+	output, err := cephRun("osd", "stat", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch osd stat: %w", err)
+	}
+
+	num := gjson.Get(output, "num_osds").Int()
+	up := gjson.Get(output, "num_up_osds").Int()
+	in := gjson.Get(output, "num_in_osds").Int()
+
+	if up < num {
+		return fmt.Errorf("only %d of %d osds are up", up, num)
+	}
+
+	if in < num {
+		return warnf("only %d of %d osds are in", in, num)
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckOsdsUpOps) DryRun(name string) string {
+	return "Check all osds are up and in."
+}
+
+// GetName returns the name of the action
+func (o *CheckOsdsUpOps) GetName() string {
+	return "check-osds-up-ops"
+}
+
+// CheckMonQuorumOps is an operation to check all mons are in quorum.
+type CheckMonQuorumOps struct {
+	ClusterOps
+}
+
+// Run checks all mons of the monmap are in quorum.
+func (o *CheckMonQuorumOps) Run(name string) error {
+	return checkMonQuorum()
+}
+
+// checkMonQuorum checks all mons of the monmap are in quorum.
+func checkMonQuorum() error {
+	output, err := cephRun("quorum_status", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch quorum status: %w", err)
+	}
+
+	quorum := map[string]bool{}
+	for _, mon := range gjson.Get(output, "quorum_names").Array() {
+		quorum[mon.String()] = true
+	}
+
+	missing := []string{}
+	for _, mon := range gjson.Get(output, "monmap.mons.#.name").Array() {
+		if !quorum[mon.String()] {
+			missing = append(missing, mon.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("mons %s are out of quorum", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckMonQuorumOps) DryRun(name string) string {
+	return "Check all mons are in quorum."
+}
+
+// GetName returns the name of the action
+func (o *CheckMonQuorumOps) GetName() string {
+	return "check-mon-quorum-ops"
+}
+
+// CheckNoDegradedPGsOps is an operation to check no PGs are degraded.
+type CheckNoDegradedPGsOps struct {
+	ClusterOps
+}
+
+// Run checks no PGs are degraded, and warns about those not active+clean.
+func (o *CheckNoDegradedPGsOps) Run(name string) error {
+	stat, err := getPGStat()
+	if err != nil {
+		return err
+	}
+
+	var degraded int64
+	for _, state := range stat.PGSummary.NumPGByState {
+		if strings.Contains(state.Name, "degraded") {
+			degraded += state.Num
+		}
+	}
+
+	if degraded > 0 {
+		return fmt.Errorf("%d of %d pgs are degraded", degraded, stat.PGSummary.NumPGs)
+	}
+
+	if !stat.AllActiveClean() {
+		return warnf("%d of %d pgs are active+clean", stat.ActiveClean(), stat.PGSummary.NumPGs)
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckNoDegradedPGsOps) DryRun(name string) string {
+	return "Check no pgs are degraded."
+}
+
+// GetName returns the name of the action
+func (o *CheckNoDegradedPGsOps) GetName() string {
+	return "check-no-degraded-pgs-ops"
+}
+
+// CheckFreeSpaceOps is an operation to check the cluster has enough free space.
+type CheckFreeSpaceOps struct {
+	ClusterOps
+}
+
+// Run checks the raw capacity in use stays clear of the nearfull ratio.
+func (o *CheckFreeSpaceOps) Run(name string) error {
+	output, err := cephRun("df", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to fetch cluster usage: %w", err)
+	}
+
+	used := gjson.Get(output, "stats.total_used_raw_ratio").Float()
+	switch {
+	case used >= upgradeFailUsedRatio:
+		return fmt.Errorf("%.0f%% of the raw capacity is in use", used*100)
+	case used >= upgradeWarnUsedRatio:
+		return warnf("%.0f%% of the raw capacity is in use", used*100)
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckFreeSpaceOps) DryRun(name string) string {
+	return fmt.Sprintf("Check less than %.0f%% of the raw capacity is in use.", upgradeWarnUsedRatio*100)
+}
+
+// GetName returns the name of the action
+func (o *CheckFreeSpaceOps) GetName() string {
+	return "check-free-space-ops"
+}
+
+// CheckPoolMinSizeOps is an operation to check pools keep serving I/O with a host down.
+type CheckPoolMinSizeOps struct {
+	ClusterOps
+}
+
+// Run warns about replicated pools which can't lose a replica without dropping below min_size,
+// or which accept writes to a single replica.
+func (o *CheckPoolMinSizeOps) Run(name string) error {
+	output, err := cephRun("osd", "pool", "ls", "detail", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list pools: %w", err)
+	}
+
+	problems := []string{}
+	for _, pool := range gjson.Parse(output).Array() {
+		size := pool.Get("size").Int()
+		minSize := pool.Get("min_size").Int()
+		poolName := pool.Get("pool_name").String()
+
+		switch {
+		case size > 1 && minSize >= size:
+			problems = append(problems, fmt.Sprintf("%s (size %d, min_size %d) blocks I/O while a host restarts", poolName, size, minSize))
+		case size > 1 && minSize < 2:
+			problems = append(problems, fmt.Sprintf("%s (size %d, min_size %d) accepts writes to a single replica", poolName, size, minSize))
+		}
+	}
+
+	if len(problems) > 0 {
+		return warnf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckPoolMinSizeOps) DryRun(name string) string {
+	return "Check pool min_size allows a host to restart safely."
+}
+
+// GetName returns the name of the action
+func (o *CheckPoolMinSizeOps) GetName() string {
+	return "check-pool-min-size-ops"
+}
+
+// CheckNooutUnsetOps is an operation to check noout isn't set already.
+type CheckNooutUnsetOps struct {
+	ClusterOps
+}
+
+// Run warns if noout is set, the upgrade leaves it set.
+func (o *CheckNooutUnsetOps) Run(name string) error {
+	set, err := isOsdNooutSet()
+	if err != nil {
+		return err
+	}
+
+	if set {
+		return warnf("osd has 'noout' flag set, it stays set after the upgrade")
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckNooutUnsetOps) DryRun(name string) string {
+	return "Check osd has 'noout' flag unset."
+}
+
+// GetName returns the name of the action
+func (o *CheckNooutUnsetOps) GetName() string {
+	return "check-noout-unset-ops"
+}
+
+// CheckDeprecatedSettingsOps is an operation to check for settings newer releases dropped.
+type CheckDeprecatedSettingsOps struct {
+	ClusterOps
+}
+
+// Run fails for FileStore OSDs, which recent releases can't run, and warns about deprecated settings.
+func (o *CheckDeprecatedSettingsOps) Run(name string) error {
+	output, err := cephRun("osd", "count-metadata", "osd_objectstore")
+	if err != nil {
+		return fmt.Errorf("failed to fetch osd objectstores: %w", err)
+	}
+
+	filestore := gjson.Get(output, "filestore").Int()
+	if filestore > 0 {
+		return fmt.Errorf("%d osds use filestore, which is no longer supported", filestore)
+	}
+
+	output, err = cephRun("config", "dump", "-f", "json")
+	if err != nil {
+		return fmt.Errorf("failed to dump config: %w", err)
+	}
+
+	deprecated := []string{}
+	for _, item := range gjson.Parse(output).Array() {
+		option := item.Get("name").String()
+		value := item.Get("value").String()
+
+		switch {
+		case strings.HasPrefix(option, "filestore_"):
+			deprecated = append(deprecated, option)
+		case option == "ms_type" && !strings.HasPrefix(value, "async"):
+			deprecated = append(deprecated, fmt.Sprintf("%s=%s", option, value))
+		case option == "osd_objectstore" && value == "filestore":
+			deprecated = append(deprecated, fmt.Sprintf("%s=%s", option, value))
+		}
+	}
+
+	if len(deprecated) > 0 {
+		return warnf("deprecated settings: %s", strings.Join(deprecated, ", "))
+	}
+
+	return nil
+}
+
+// DryRun prints out the action plan.
+func (o *CheckDeprecatedSettingsOps) DryRun(name string) string {
+	return "Check for filestore osds and deprecated settings."
+}
+
+// GetName returns the name of the action
+func (o *CheckDeprecatedSettingsOps) GetName() string {
+	return "check-deprecated-settings-ops"
+}
+
+// CheckSnapRevisionsOps is an operation to check all members run the same snap revision.
+type CheckSnapRevisionsOps struct {
+	ClusterOps
+}
+
+// Run checks all members can be reached and run the same snap revision.
+func (o *CheckSnapRevisionsOps) Run(name string) error {
+	members, err := getMemberVersions(o.Context, interfaces.CephState{State: o.State})
+	if err != nil {
+		return err
+	}
+
+	return checkRevisionSkew(members)
+}
+
+// DryRun prints out the action plan.
+func (o *CheckSnapRevisionsOps) DryRun(name string) string {
+	return "Check all members run the same snap revision."
+}
+
+// GetName returns the name of the action
+func (o *CheckSnapRevisionsOps) GetName() string {
+	return "check-snap-revisions-ops"
+}
+
+// checkRevisionSkew checks all members report the same snap revision.
+func checkRevisionSkew(members []types.MemberVersion) error {
+	revisions := map[string][]string{}
+	for _, member := range members {
+		revision := member.Revision
+		if member.Version == "" {
+			revision = "unknown"
+		}
+
+		revisions[revision] = append(revisions[revision], member.Name)
+	}
+
+	if len(revisions) <= 1 {
+		return nil
+	}
+
+	skew := []string{}
+	for revision, names := range revisions {
+		skew = append(skew, fmt.Sprintf("%s on %s", revision, strings.Join(names, ", ")))
+	}
+
+	slices.Sort(skew)
+	return fmt.Errorf("members run different snap revisions: %s", strings.Join(skew, "; "))
+}
@@ -0,0 +1,138 @@
+package ceph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type upgradeCheckSuite struct {
+	tests.BaseSuite
+	ops ClusterOps
+}
+
+func TestUpgradeCheck(t *testing.T) {
+	suite.Run(t, new(upgradeCheckSuite))
+}
+
+func (s *upgradeCheckSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+
+	s.ops = ClusterOps{State: &mocks.MockState{ClusterName: "foohost"}, Context: context.Background()}
+}
+
+// TestRunChecks checks warnings and failures are told apart, and all checks run.
+func (s *upgradeCheckSuite) TestRunChecks() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthNoout, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "stat", "-f", "json").Return(`{"num_osds":3,"num_up_osds":2,"num_in_osds":3}`, nil).Once()
+	r.On("RunCommand", "ceph", "quorum_status", "-f", "json").Return(quorumStatus, nil).Once()
+	processExec = r
+
+	checks := []Operation{
+		&CheckHealthOkOps{ClusterOps: s.ops},
+		&CheckOsdsUpOps{ClusterOps: s.ops},
+		&CheckMonQuorumOps{ClusterOps: s.ops},
+	}
+
+	results := RunChecks("foohost", checks)
+	assert.Equal(s.T(), types.UpgradeCheckResults{
+		{Name: "check-health-ok-ops", Description: "Check the cluster is HEALTH_OK.", Status: types.CheckStatusWarn, Message: "cluster is HEALTH_WARN: [OSDMAP_FLAGS]"},
+		{Name: "check-osds-up-ops", Description: "Check all osds are up and in.", Status: types.CheckStatusFail, Message: "only 2 of 3 osds are up"},
+		{Name: "check-mon-quorum-ops", Description: "Check all mons are in quorum.", Status: types.CheckStatusFail, Message: "mons m3 are out of quorum"},
+	}, results)
+}
+
+// TestCheckHealth checks a healthy cluster passes and one in error fails.
+func (s *upgradeCheckSuite) TestCheckHealth() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthOK, nil).Once()
+	r.On("RunCommand", "ceph", "health", "-f", "json").Return(healthErr, nil).Once()
+	processExec = r
+
+	results := RunChecks("foohost", []Operation{&CheckHealthOkOps{ClusterOps: s.ops}, &CheckHealthOkOps{ClusterOps: s.ops}})
+	assert.Equal(s.T(), types.CheckStatusPass, results[0].Status)
+	assert.Equal(s.T(), types.CheckStatusFail, results[1].Status)
+}
+
+// TestCheckPGs checks degraded pgs fail, and pgs not yet clean warn.
+func (s *upgradeCheckSuite) TestCheckPGs() {
+	peering := `{"pg_ready":true,"pg_summary":{"num_pg_by_state":[{"name":"active+clean","num":6},{"name":"peering","num":2}],"num_pgs":8}}`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatDegrade, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(peering, nil).Once()
+	r.On("RunCommand", "ceph", "pg", "stat", "-f", "json").Return(pgStatClean, nil).Once()
+	processExec = r
+
+	check := &CheckNoDegradedPGsOps{ClusterOps: s.ops}
+	results := RunChecks("foohost", []Operation{check, check, check})
+	assert.Equal(s.T(), types.CheckStatusFail, results[0].Status)
+	assert.Equal(s.T(), "8 of 8 pgs are degraded", results[0].Message)
+	assert.Equal(s.T(), types.CheckStatusWarn, results[1].Status)
+	assert.Equal(s.T(), types.CheckStatusPass, results[2].Status)
+}
+
+// TestCheckFreeSpace checks the raw usage thresholds.
+func (s *upgradeCheckSuite) TestCheckFreeSpace() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "df", "-f", "json").Return(`{"stats":{"total_used_raw_ratio":0.9}}`, nil).Once()
+	r.On("RunCommand", "ceph", "df", "-f", "json").Return(`{"stats":{"total_used_raw_ratio":0.8}}`, nil).Once()
+	r.On("RunCommand", "ceph", "df", "-f", "json").Return(`{"stats":{"total_used_raw_ratio":0.1}}`, nil).Once()
+	processExec = r
+
+	check := &CheckFreeSpaceOps{ClusterOps: s.ops}
+	results := RunChecks("foohost", []Operation{check, check, check})
+	assert.Equal(s.T(), types.CheckStatusFail, results[0].Status)
+	assert.Equal(s.T(), types.CheckStatusWarn, results[1].Status)
+	assert.Equal(s.T(), types.CheckStatusPass, results[2].Status)
+}
+
+// TestCheckPoolMinSize checks pools which can't lose a replica safely are warned about.
+func (s *upgradeCheckSuite) TestCheckPoolMinSize() {
+	pools := `[{"pool_name":"ok","size":3,"min_size":2},{"pool_name":"single","size":1,"min_size":1},{"pool_name":"strict","size":2,"min_size":2},{"pool_name":"loose","size":3,"min_size":1}]`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "pool", "ls", "detail", "-f", "json").Return(pools, nil).Once()
+	processExec = r
+
+	err := (&CheckPoolMinSizeOps{ClusterOps: s.ops}).Run("foohost")
+	assert.IsType(s.T(), &CheckWarning{}, err)
+	assert.Equal(s.T(), "strict (size 2, min_size 2) blocks I/O while a host restarts; loose (size 3, min_size 1) accepts writes to a single replica", err.Error())
+}
+
+// TestCheckDeprecatedSettings checks filestore osds fail and deprecated settings warn.
+func (s *upgradeCheckSuite) TestCheckDeprecatedSettings() {
+	config := `[{"section":"global","name":"ms_type","value":"simple"},{"section":"osd","name":"osd_memory_target","value":"4294967296"}]`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "osd", "count-metadata", "osd_objectstore").Return(`{"filestore":1,"bluestore":2}`, nil).Once()
+	r.On("RunCommand", "ceph", "osd", "count-metadata", "osd_objectstore").Return(`{"bluestore":3}`, nil).Once()
+	r.On("RunCommand", "ceph", "config", "dump", "-f", "json").Return(config, nil).Once()
+	processExec = r
+
+	check := &CheckDeprecatedSettingsOps{ClusterOps: s.ops}
+	results := RunChecks("foohost", []Operation{check, check})
+	assert.Equal(s.T(), types.CheckStatusFail, results[0].Status)
+	assert.Equal(s.T(), types.CheckStatusWarn, results[1].Status)
+	assert.Equal(s.T(), "deprecated settings: ms_type=simple", results[1].Message)
+}
+
+// TestCheckRevisionSkew checks members need the same snap revision.
+func (s *upgradeCheckSuite) TestCheckRevisionSkew() {
+	members := []types.MemberVersion{
+		{Name: "a", Version: "19.2.0", Revision: "1234"},
+		{Name: "b", Version: "19.2.0", Revision: "1234"},
+	}
+	assert.NoError(s.T(), checkRevisionSkew(members))
+
+	members = append(members, types.MemberVersion{Name: "c", Version: "18.2.4", Revision: "1100"}, types.MemberVersion{Name: "d"})
+	assert.EqualError(s.T(), checkRevisionSkew(members), "members run different snap revisions: 1100 on c; 1234 on a, b; unknown on d")
+}
@@ -0,0 +1,104 @@
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/mocks"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type upgradeSuite struct {
+	tests.BaseSuite
+}
+
+func TestUpgrade(t *testing.T) {
+	suite.Run(t, new(upgradeSuite))
+}
+
+func (s *upgradeSuite) SetupTest() {
+	s.BaseSuite.SetupTest()
+	s.CopyCephConfigs()
+}
+
+const (
+	squid = "ceph version 19.2.0 (e7ad5345525c7aa95470c26863873b581076945d) squid (stable)"
+	reef  = "ceph version 18.2.4 (e7ad5345525c7aa95470c26863873b581076945d) reef (stable)"
+)
+
+func (s *upgradeSuite) TestParseCephVersion() {
+	version, release, err := parseCephVersion(squid)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "19.2.0", version)
+	assert.Equal(s.T(), "squid", release)
+
+	_, _, err = parseCephVersion("invalid version")
+	assert.Error(s.T(), err)
+}
+
+// TestCheckVersionSkew checks all members need the target version installed.
+func (s *upgradeSuite) TestCheckVersionSkew() {
+	members := []types.MemberVersion{
+		{Name: "a", Version: "19.2.0"},
+		{Name: "b", Version: "19.2.0"},
+	}
+	assert.NoError(s.T(), checkVersionSkew(members, "19.2.0"))
+
+	members = append(members, types.MemberVersion{Name: "c", Version: "18.2.4"}, types.MemberVersion{Name: "d"})
+	err := checkVersionSkew(members, "19.2.0")
+	assert.ErrorContains(s.T(), err, "c (18.2.4), d (unknown)")
+}
+
+// TestHostNeedsUpgrade checks daemons are matched to hosts by their ids.
+func (s *upgradeSuite) TestHostNeedsUpgrade() {
+	monMetadata := `[{"name":"a","hostname":"a.lan","ceph_version":"` + squid + `"},{"name":"b","hostname":"b.lan","ceph_version":"` + reef + `"}]`
+	osdMetadata := `[{"id":0,"ceph_version":"` + squid + `"},{"id":1,"ceph_version":"` + reef + `"}]`
+	versions := `{"rgw":{"` + squid + `":1,"` + reef + `":1},"overall":{"` + squid + `":3}}`
+
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "mon", "metadata", "-f", "json").Return(monMetadata, nil).Twice()
+	r.On("RunCommand", "ceph", "osd", "metadata", "-f", "json").Return(osdMetadata, nil).Twice()
+	r.On("RunCommand", "ceph", "versions").Return(versions, nil).Once()
+	processExec = r
+
+	needed, err := hostNeedsUpgrade("mon", "a", nil, "19.2.0")
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), needed)
+
+	needed, err = hostNeedsUpgrade("mon", "b", nil, "19.2.0")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), needed)
+
+	disks := types.Disks{{OSD: 0, Location: "a"}, {OSD: 1, Location: "b"}}
+	needed, err = hostNeedsUpgrade("osd", "a", disks, "19.2.0")
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), needed)
+
+	needed, err = hostNeedsUpgrade("osd", "b", disks, "19.2.0")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), needed)
+
+	needed, err = hostNeedsUpgrade("rgw", "a", disks, "19.2.0")
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), needed)
+}
+
+// TestIsUpgradePending checks daemons running another version than installed hold back the OSD release bump.
+func (s *upgradeSuite) TestIsUpgradePending() {
+	r := mocks.NewRunner(s.T())
+	r.On("RunCommand", "ceph", "-v").Return(squid, nil).Twice()
+	r.On("RunCommand", "ceph", "versions").Return(`{"overall":{"`+reef+`":7}}`, nil).Once()
+	r.On("RunCommand", "ceph", "versions").Return(`{"overall":{"`+squid+`":7}}`, nil).Once()
+	processExec = r
+
+	pending, err := isUpgradePending()
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), pending)
+
+	pending, err = isUpgradePending()
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), pending)
+}
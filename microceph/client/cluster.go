@@ -23,3 +23,243 @@ func GetClusterToken(ctx context.Context, c *microCli.Client, req types.ClusterE
 
 	return state, nil
 }
+
+// GetLostMember returns what removing the given lost member takes away from the cluster.
+func GetLostMember(ctx context.Context, c *microCli.Client, name string) (types.LostMember, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	lost := types.LostMember{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "lost", name), nil, &lost)
+	if err != nil {
+		return lost, fmt.Errorf("failed to check lost member %s: %w", name, err)
+	}
+
+	return lost, nil
+}
+
+// RemoveLostMember purges the OSDs, monitor and records of a permanently lost member.
+func RemoveLostMember(ctx context.Context, c *microCli.Client, name string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	err := c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "lost", name), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove lost member %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// SetCrushLocation sets the crush location of a member, an empty location clears it.
+func SetCrushLocation(ctx context.Context, c *microCli.Client, name string, req types.CrushLocationPut) (types.CrushLocationResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	c = c.UseTarget(name)
+
+	result := types.CrushLocationResult{}
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "locations", name), req, &result)
+	if err != nil {
+		return result, fmt.Errorf("failed to set crush location of %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+// GetCrushLocations returns the recorded crush locations of members, keyed by member name.
+func GetCrushLocations(ctx context.Context, c *microCli.Client) (map[string]string, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	locations := map[string]string{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "locations"), nil, &locations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crush locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// GetStretchMode returns the stretch mode of the cluster.
+func GetStretchMode(ctx context.Context, c *microCli.Client) (types.StretchMode, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	stretch := types.StretchMode{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "stretch"), nil, &stretch)
+	if err != nil {
+		return stretch, fmt.Errorf("failed to fetch stretch mode: %w", err)
+	}
+
+	return stretch, nil
+}
+
+// EnableStretchMode switches the cluster to stretch mode.
+func EnableStretchMode(ctx context.Context, c *microCli.Client, data *types.StretchModePost) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "stretch"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enable stretch mode: %w", err)
+	}
+
+	return nil
+}
+
+// RollingRestart starts a restart of a service across the cluster, one host at a time.
+func RollingRestart(ctx context.Context, c *microCli.Client, data *types.RollingRestartPost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "restart"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to start rolling restart: %w", err)
+	}
+
+	return op, nil
+}
+
+// ShutdownCluster starts the shutdown of the cluster.
+func ShutdownCluster(ctx context.Context, c *microCli.Client, data *types.ShutdownPost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "shutdown"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to start cluster shutdown: %w", err)
+	}
+
+	return op, nil
+}
+
+// StartupCluster starts bringing the cluster back after a shutdown.
+func StartupCluster(ctx context.Context, c *microCli.Client, data *types.ShutdownPost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "startup"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to start cluster startup: %w", err)
+	}
+
+	return op, nil
+}
+
+// GetMemberVersion returns the Ceph version installed on a cluster member.
+func GetMemberVersion(ctx context.Context, c *microCli.Client) (types.MemberVersion, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	version := types.MemberVersion{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("version"), nil, &version)
+	if err != nil {
+		return version, fmt.Errorf("failed to fetch version: %w", err)
+	}
+
+	return version, nil
+}
+
+// GetUpgradeStatus returns the state of the cluster upgrade.
+func GetUpgradeStatus(ctx context.Context, c *microCli.Client) (types.UpgradeStatus, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	status := types.UpgradeStatus{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "upgrade"), nil, &status)
+	if err != nil {
+		return status, fmt.Errorf("failed to fetch upgrade state: %w", err)
+	}
+
+	return status, nil
+}
+
+// UpgradeCluster starts, pauses or resumes the upgrade of the cluster. Starting or resuming it
+// returns the operation running the upgrade.
+func UpgradeCluster(ctx context.Context, c *microCli.Client, data *types.UpgradePost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "upgrade"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to %s upgrade: %w", data.Action, err)
+	}
+
+	return op, nil
+}
+
+// GetUpgradeCheck runs the checks ahead of an upgrade and returns their report.
+func GetUpgradeCheck(ctx context.Context, c *microCli.Client) (types.UpgradeCheckResults, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	results := types.UpgradeCheckResults{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "upgrade", "check"), nil, &results)
+	if err != nil {
+		return results, fmt.Errorf("failed to check upgrade: %w", err)
+	}
+
+	return results, nil
+}
+
+// BackupCluster writes an archive of the control plane of the member to the given path.
+func BackupCluster(ctx context.Context, c *microCli.Client, data *types.BackupPost) (types.BackupManifest, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*600)
+	defer cancel()
+
+	manifest := types.BackupManifest{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "backup"), data, &manifest)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to back up member: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// RestoreCluster rebuilds the control plane of the member from a backup archive.
+func RestoreCluster(ctx context.Context, c *microCli.Client, data *types.RestorePost) (types.RestoreResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*600)
+	defer cancel()
+
+	result := types.RestoreResult{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "restore"), data, &result)
+	if err != nil {
+		return result, fmt.Errorf("failed to restore member: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetNetworkAddress returns the address of a member on a network.
+func GetNetworkAddress(ctx context.Context, c *microCli.Client, data *types.NetworkAddressPost) (types.NetworkAddress, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	address := types.NetworkAddress{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "network", "address"), data, &address)
+	if err != nil {
+		return address, fmt.Errorf("failed to get network address: %w", err)
+	}
+
+	return address, nil
+}
+
+// MigrateNetwork starts moving the cluster to a new public network.
+func MigrateNetwork(ctx context.Context, c *microCli.Client, data *types.NetworkMigratePost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("cluster", "network", "migrate"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to start network migration: %w", err)
+	}
+
+	return op, nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	microCli "github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// GetCrushRules lists the crush rules of the cluster.
+func GetCrushRules(ctx context.Context, c *microCli.Client) (types.CrushRules, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	rules := types.CrushRules{}
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("crush", "rules"), nil, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crush rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// CreateCrushRule adds a crush rule.
+func CreateCrushRule(ctx context.Context, c *microCli.Client, data *types.CrushRulePost) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("crush", "rules"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create crush rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCrushRule removes a crush rule that is not used by any pool.
+func DeleteCrushRule(ctx context.Context, c *microCli.Client, name string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	err := c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("crush", "rules", name), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete crush rule %s: %w", name, err)
+	}
+
+	return nil
+}
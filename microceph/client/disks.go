@@ -58,36 +58,252 @@ func GetResources(ctx context.Context, c *microCli.Client) (*api.ResourcesStorag
 	return &storage, nil
 }
 
-// RemoveDisk requests Ceph removes an OSD.
-func RemoveDisk(ctx context.Context, c *microCli.Client, data *types.DisksDelete) error {
-	timeout := time.Second * time.Duration(data.Timeout+5) // wait a bit longer than the operation timeout
-	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+// GetDiskHealth returns the health of the devices backing the OSDs of the given member.
+func GetDiskHealth(ctx context.Context, c *microCli.Client, target string) (types.DiskHealths, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
 	defer cancel()
 
+	health := types.DiskHealths{}
+
+	err := c.UseTarget(target).Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("resources", "health"), nil, &health)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching disk health from %s: %w", target, err)
+	}
+
+	return health, nil
+}
+
+// RemoveDisk requests Ceph removes an OSD, returning the operation performing the removal.
+// Members without the async_operations extension remove the OSD before replying, the
+// operation has no ID then.
+func RemoveDisk(ctx context.Context, c *microCli.Client, data *types.DisksDelete) (types.Operation, error) {
+	op := types.Operation{}
+
 	// get disks and determine osd location
+	location, err := getDiskLocation(ctx, c, data.OSD)
+	if err != nil {
+		return op, err
+	}
+	c = c.UseTarget(location)
+
+	data.Async, err = HasExtension(ctx, c, ExtensionAsyncOperations)
+	if err != nil {
+		return op, err
+	}
+
+	// A synchronous removal takes up to its timeout, wait a bit longer than that.
+	timeout := time.Second * 60
+	if !data.Async {
+		timeout = time.Second * time.Duration(data.Timeout+5)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(data.OSD, 10)), data, &op)
+	if err != nil {
+		// Checking if the error is a context deadline exceeded error
+		if !data.Async && errors.Is(err, context.DeadlineExceeded) {
+			return op, fmt.Errorf("failed to remove disk, timeout (%ds) reached - abort", data.Timeout)
+		}
+
+		return op, fmt.Errorf("failed to remove disk: %w", err)
+	}
+
+	return op, nil
+}
+
+// getDiskLocation returns the name of the cluster member hosting the given OSD.
+func getDiskLocation(ctx context.Context, c *microCli.Client, osd int64) (string, error) {
 	disks, err := GetDisks(ctx, c)
 	if err != nil {
-		return fmt.Errorf("failed to get disks: %w", err)
+		return "", fmt.Errorf("failed to get disks: %w", err)
 	}
-	var location string
+
 	for _, disk := range disks {
-		if disk.OSD == data.OSD {
-			location = disk.Location
-			break
+		if disk.OSD == osd {
+			return disk.Location, nil
 		}
 	}
-	if location == "" {
-		return fmt.Errorf("failed to find location for osd.%d", data.OSD)
+
+	return "", fmt.Errorf("failed to find location for osd.%d", osd)
+}
+
+// UnlockDisk requests the local daemon opens the encrypted devices of an OSD.
+func UnlockDisk(ctx context.Context, c *microCli.Client, osd int64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "unlock"), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to unlock osd.%d: %w", osd, err)
+	}
+
+	return nil
+}
+
+// RekeyDisk requests the encryption keys of an OSD are rotated.
+func RekeyDisk(ctx context.Context, c *microCli.Client, osd int64) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	location, err := getDiskLocation(ctx, c, osd)
+	if err != nil {
+		return err
 	}
-	c = c.UseTarget(location)
 
-	err = c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(data.OSD, 10)), data, nil)
+	err = c.UseTarget(location).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "rekey"), nil, nil)
 	if err != nil {
-		// Checking if the error is a context deadline exceeded error
-		if errors.Is(err, context.DeadlineExceeded) {
-			return fmt.Errorf("failed to remove disk, timeout (%ds) reached - abort", data.Timeout)
-		}
-		return fmt.Errorf("failed to remove disk: %w", err)
+		return fmt.Errorf("failed to rekey osd.%d: %w", osd, err)
+	}
+
+	return nil
+}
+
+// ResizeDisk requests the backing file of a loop OSD is grown to the given size.
+func ResizeDisk(ctx context.Context, c *microCli.Client, osd int64, size string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	location, err := getDiskLocation(ctx, c, osd)
+	if err != nil {
+		return err
+	}
+
+	data := types.DisksResizePost{Size: size}
+
+	err = c.UseTarget(location).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", strconv.FormatInt(osd, 10), "resize"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to resize osd.%d: %w", osd, err)
 	}
+
 	return nil
 }
+
+// EncryptDisks requests the given OSDs are converted into encrypted OSDs.
+// If host is set, all OSDs located on that host are converted.
+func EncryptDisks(ctx context.Context, c *microCli.Client, osd int64, host string) ([]int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	disks, err := GetDisks(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disks: %w", err)
+	}
+
+	data := types.DisksEncryptPost{}
+	for _, disk := range disks {
+		if (len(host) > 0 && disk.Location == host) || (len(host) == 0 && disk.OSD == osd) {
+			host = disk.Location
+			data.OSDs = append(data.OSDs, disk.OSD)
+		}
+	}
+
+	if len(data.OSDs) == 0 {
+		if len(host) > 0 {
+			return nil, fmt.Errorf("no disks found on %s", host)
+		}
+		return nil, fmt.Errorf("failed to find location for osd.%d", osd)
+	}
+
+	err = c.UseTarget(host).Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", "encrypt"), data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request disk encryption: %w", err)
+	}
+
+	return data.OSDs, nil
+}
+
+// GetDiskEncryptions returns the progress of OSD encryption conversions.
+func GetDiskEncryptions(ctx context.Context, c *microCli.Client) (types.DiskEncryptions, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	encryptions := types.DiskEncryptions{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "encrypt"), nil, &encryptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing disk encryptions: %w", err)
+	}
+
+	return encryptions, nil
+}
+
+// DrainDisks requests the given OSDs are drained of data.
+// If host is set, all OSDs located on that host are drained.
+func DrainDisks(ctx context.Context, c *microCli.Client, osd int64, host string) ([]int64, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	data := types.DisksDrainPost{OSDs: []int64{osd}}
+	if len(host) > 0 {
+		disks, err := GetDisks(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get disks: %w", err)
+		}
+
+		data.OSDs = []int64{}
+		for _, disk := range disks {
+			if disk.Location == host {
+				data.OSDs = append(data.OSDs, disk.OSD)
+			}
+		}
+
+		if len(data.OSDs) == 0 {
+			return nil, fmt.Errorf("no disks found on %s", host)
+		}
+	}
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", "drain"), data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request disk drain: %w", err)
+	}
+
+	return data.OSDs, nil
+}
+
+// GetDiskStatus returns weight, placement group count and drain state of all OSDs.
+func GetDiskStatus(ctx context.Context, c *microCli.Client) (types.DiskStatuses, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	status := types.DiskStatuses{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "status"), nil, &status)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching disk status: %w", err)
+	}
+
+	return status, nil
+}
+
+// GetAdoptableDisks lists the existing OSDs found on the local host.
+func GetAdoptableDisks(ctx context.Context, c *microCli.Client) (types.AdoptableDisks, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	disks := types.AdoptableDisks{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("disks", "adopt"), nil, &disks)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing adoptable disks: %w", err)
+	}
+
+	return disks, nil
+}
+
+// AdoptDisks requests MicroCeph takes over the given existing OSDs of the local host, all if none are given.
+func AdoptDisks(ctx context.Context, c *microCli.Client, osds []int64) (types.AdoptableDisks, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	disks := types.AdoptableDisks{}
+	data := types.DisksAdoptPost{OSDs: osds}
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("disks", "adopt"), data, &disks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adopt disks: %w", err)
+	}
+
+	return disks, nil
+}
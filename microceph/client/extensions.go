@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	microCli "github.com/canonical/microcluster/v2/client"
+	microTypes "github.com/canonical/microcluster/v2/rest/types"
+)
+
+// ExtensionAsyncOperations lets long-running requests run as operations, see api/extensions.go.
+const ExtensionAsyncOperations = "async_operations"
+
+// HasExtension tells whether the member a client talks to supports an API extension. The
+// extensions of the target of the client are looked up in the cluster members if one is set.
+func HasExtension(ctx context.Context, c *microCli.Client, name string) (bool, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	url := c.URL()
+	target := url.URL.Query().Get("target")
+	if target == "" {
+		server := microTypes.ClusterMember{}
+		err := c.Query(queryCtx, "GET", "core/1.0", nil, nil, &server)
+		if err != nil {
+			return false, fmt.Errorf("failed to get API extensions: %w", err)
+		}
+
+		return server.Extensions.HasExtension(name), nil
+	}
+
+	members, err := c.GetClusterMembers(queryCtx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get API extensions of %s: %w", target, err)
+	}
+
+	for _, member := range members {
+		if member.Name == target {
+			return member.Extensions.HasExtension(name), nil
+		}
+	}
+
+	return false, fmt.Errorf("failed to get API extensions of %s: no such member", target)
+}
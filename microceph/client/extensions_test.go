@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/lxd/lxd/response"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHasExtension checks the extensions of the local member and of the target of a client
+// are told apart.
+func TestHasExtension(t *testing.T) {
+	stateDir := t.TempDir()
+	listener, err := net.Listen("unix", filepath.Join(stateDir, "control.socket"))
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/core/1.0", func(w http.ResponseWriter, r *http.Request) {
+		_ = response.SyncResponse(true, map[string]any{"name": "node1", "extensions": []string{"internal", ExtensionAsyncOperations}}).Render(w, r)
+	})
+	mux.HandleFunc("/core/1.0/cluster", func(w http.ResponseWriter, r *http.Request) {
+		_ = response.SyncResponse(true, []map[string]any{
+			{"name": "node1", "extensions": []string{"internal", ExtensionAsyncOperations}},
+			{"name": "node2", "extensions": "internal"},
+		}).Render(w, r)
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	m, err := microcluster.App(microcluster.Args{StateDir: stateDir})
+	assert.NoError(t, err)
+	cli, err := m.LocalClient()
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	has, err := HasExtension(ctx, cli, ExtensionAsyncOperations)
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = HasExtension(ctx, cli.UseTarget("node2"), ExtensionAsyncOperations)
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	_, err = HasExtension(ctx, cli.UseTarget("node3"), ExtensionAsyncOperations)
+	assert.ErrorContains(t, err, "no such member")
+}
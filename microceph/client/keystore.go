@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/lxd/shared/logger"
+	microCli "github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/interfaces"
+)
+
+// KeyStoreSet configures the key store used for encrypted OSDs.
+func KeyStoreSet(ctx context.Context, c *microCli.Client, data *types.KeyStoreConfig) error {
+	// Switching backends migrates existing keys, allow for some time.
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("microceph", "configs", "keystore"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed setting key store: %w", err)
+	}
+
+	return nil
+}
+
+// KeyStoreGet returns the key store used for encrypted OSDs.
+func KeyStoreGet(ctx context.Context, c *microCli.Client) (types.KeyStoreConfig, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	config := types.KeyStoreConfig{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("microceph", "configs", "keystore"), nil, &config)
+	if err != nil {
+		return config, fmt.Errorf("failed getting key store: %w", err)
+	}
+
+	return config, nil
+}
+
+// KeyStoreTokenSet hands the key store token to a member.
+func KeyStoreTokenSet(ctx context.Context, c *microCli.Client, data *types.KeyStoreToken) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("microceph", "configs", "keystore", "token"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed setting key store token: %w", err)
+	}
+
+	return nil
+}
+
+// KeyStoreTokenGet fetches the key store token kept by a member.
+func KeyStoreTokenGet(ctx context.Context, c *microCli.Client) (types.KeyStoreToken, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	token := types.KeyStoreToken{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("microceph", "configs", "keystore", "token"), nil, &token)
+	if err != nil {
+		return token, fmt.Errorf("failed getting key store token: %w", err)
+	}
+
+	return token, nil
+}
+
+// SendKeyStoreTokenToClusterMembers hands the key store token to every other member of the cluster.
+// The requests are cluster notifications, as members only accept the token from each other.
+func SendKeyStoreTokenToClusterMembers(ctx context.Context, s interfaces.StateInterface, token string) error {
+	cluster, err := s.ClusterState().Cluster(true)
+	if err != nil {
+		return fmt.Errorf("failed to get a client for every cluster member: %w", err)
+	}
+
+	for _, remoteClient := range cluster {
+		err = KeyStoreTokenSet(ctx, &remoteClient, &types.KeyStoreToken{Token: token})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchKeyStoreToken asks the other members of the cluster for the key store token, returning
+// the first one found. The requests are cluster notifications, as members only hand the token
+// out to each other. An error is returned when no member could be asked.
+func FetchKeyStoreToken(ctx context.Context, s interfaces.StateInterface) (string, error) {
+	cluster, err := s.ClusterState().Cluster(true)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a client for every cluster member: %w", err)
+	}
+
+	var lastErr error
+	for _, remoteClient := range cluster {
+		token, err := KeyStoreTokenGet(ctx, &remoteClient)
+		if err != nil {
+			url := remoteClient.URL()
+			logger.Warnf("Failed to fetch key store token from %s: %v", url.String(), err)
+			lastErr = err
+			continue
+		}
+
+		if len(token.Token) > 0 {
+			return token.Token, nil
+		}
+	}
+
+	if lastErr != nil {
+		return "", fmt.Errorf("failed to fetch key store token from other members: %w", lastErr)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/lxd/lxd/response"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/interfaces"
+	"github.com/canonical/microceph/microceph/mocks"
+)
+
+type keyStoreSuite struct {
+	suite.Suite
+
+	stateDir string
+	token    string
+	server   *http.Server
+}
+
+func TestKeyStore(t *testing.T) {
+	suite.Run(t, new(keyStoreSuite))
+}
+
+// clusterState hands out clients to a single member listening on the control socket of stateDir,
+// flagged as cluster notifications the way microcluster does.
+type clusterState struct {
+	mocks.MockState
+
+	stateDir string
+}
+
+// Cluster returns a client for the member, as a notification client if asked to.
+func (c *clusterState) Cluster(isNotification bool) (microCli.Cluster, error) {
+	m, err := microcluster.App(microcluster.Args{StateDir: c.stateDir})
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if isNotification {
+		cli.SetClusterNotification()
+	}
+
+	return microCli.Cluster{*cli}, nil
+}
+
+// SetupTest serves the key store token endpoint the way the API does: to cluster members only.
+func (s *keyStoreSuite) SetupTest() {
+	s.stateDir = s.T().TempDir()
+	s.token = "secret"
+
+	listener, err := net.Listen("unix", filepath.Join(s.stateDir, "control.socket"))
+	assert.NoError(s.T(), err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1.0/microceph/configs/keystore/token", func(w http.ResponseWriter, r *http.Request) {
+		var resp response.Response
+		if !microCli.IsNotification(r) {
+			resp = response.Forbidden(fmt.Errorf("only cluster members are served"))
+		} else if r.Method == http.MethodPut {
+			req := types.KeyStoreToken{}
+			err := json.NewDecoder(r.Body).Decode(&req)
+			if err != nil {
+				resp = response.BadRequest(err)
+			} else {
+				s.token = req.Token
+				resp = response.EmptySyncResponse
+			}
+		} else {
+			resp = response.SyncResponse(true, types.KeyStoreToken{Token: s.token})
+		}
+
+		_ = resp.Render(w, r)
+	})
+
+	s.server = &http.Server{Handler: mux}
+	go func() { _ = s.server.Serve(listener) }()
+}
+
+func (s *keyStoreSuite) TearDownTest() {
+	_ = s.server.Close()
+}
+
+func (s *keyStoreSuite) state() interfaces.StateInterface {
+	return interfaces.CephState{State: &clusterState{stateDir: s.stateDir}}
+}
+
+// TestKeyStoreTokenThroughNotification checks the token goes to and comes from other members
+// through notification clients, which are the only ones the token endpoint serves.
+func (s *keyStoreSuite) TestKeyStoreTokenThroughNotification() {
+	ctx := context.Background()
+
+	err := SendKeyStoreTokenToClusterMembers(ctx, s.state(), "newsecret")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "newsecret", s.token)
+
+	token, err := FetchKeyStoreToken(ctx, s.state())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "newsecret", token)
+}
+
+// TestFetchKeyStoreTokenError checks a member failing to hand out the token is reported.
+func (s *keyStoreSuite) TestFetchKeyStoreTokenError() {
+	_ = s.server.Close()
+
+	_, err := FetchKeyStoreToken(context.Background(), s.state())
+	assert.ErrorContains(s.T(), err, "failed to fetch key store token from other members")
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	microCli "github.com/canonical/microcluster/v2/client"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// operationPollInterval is how often a running operation is checked upon.
+const operationPollInterval = 2 * time.Second
+
+// GetOperations returns the operations of all cluster members.
+func GetOperations(ctx context.Context, c *microCli.Client) (types.Operations, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	ops := types.Operations{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("operations"), nil, &ops)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing operations: %w", err)
+	}
+
+	return ops, nil
+}
+
+// GetOperation returns the operation with the given ID.
+func GetOperation(ctx context.Context, c *microCli.Client, id string) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	op := types.Operation{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("operations", id), nil, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed fetching operation %s: %w", id, err)
+	}
+
+	return op, nil
+}
+
+// CancelOperation requests the member running the given operation cancels it.
+func CancelOperation(ctx context.Context, c *microCli.Client, id string) error {
+	op, err := GetOperation(ctx, c, id)
+	if err != nil {
+		return err
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	c = c.UseTarget(op.Location)
+
+	err = c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("operations", id), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed cancelling operation %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// WaitOperation polls the given operation until it is no longer running.
+// An error is returned if the operation failed or was cancelled.
+func WaitOperation(ctx context.Context, c *microCli.Client, id string) (types.Operation, error) {
+	for {
+		op, err := GetOperation(ctx, c, id)
+		if err != nil {
+			return op, err
+		}
+
+		switch op.Status {
+		case types.OperationStatusRunning:
+		case types.OperationStatusSuccess:
+			return op, nil
+		case types.OperationStatusCancelled:
+			return op, fmt.Errorf("operation %s was cancelled", id)
+		default:
+			return op, errors.New(op.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return op, ctx.Err()
+		case <-time.After(operationPollInterval):
+		}
+	}
+}
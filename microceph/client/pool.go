@@ -24,6 +24,19 @@ func PoolSetReplicationFactor(ctx context.Context, c *microCli.Client, data *typ
 	return nil
 }
 
+// CreatePool creates a new replicated pool.
+func CreatePool(ctx context.Context, c *microCli.Client, data *types.PoolPost) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("pools"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed creating pool: %w", err)
+	}
+
+	return nil
+}
+
 func GetPools(ctx context.Context, c *microCli.Client) ([]types.Pool, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
 	defer cancel()
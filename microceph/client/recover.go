@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+	"github.com/canonical/microceph/microceph/api/types"
+	microCli "github.com/canonical/microcluster/v2/client"
+)
+
+// RecoverMonStore starts rebuilding the mon store from the OSDs of the cluster.
+func RecoverMonStore(ctx context.Context, c *microCli.Client, data *types.RecoverMonStorePost) (types.Operation, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	op := types.Operation{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("recover", "mon-store"), data, &op)
+	if err != nil {
+		return op, fmt.Errorf("failed to start mon store recovery: %w", err)
+	}
+
+	return op, nil
+}
+
+// CollectMonStore asks a member to add the cluster maps held by its OSDs to the mon store.
+func CollectMonStore(ctx context.Context, c *microCli.Client, data *types.MonStoreCollectPost) (types.MonStoreCollectResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*1800)
+	defer cancel()
+
+	result := types.MonStoreCollectResult{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("recover", "mon-store", "collect"), data, &result)
+	if err != nil {
+		return result, fmt.Errorf("failed to collect cluster maps: %w", err)
+	}
+
+	return result, nil
+}
+
+// RecoverQuorum restores quorum by keeping only the monitor of the given member.
+func RecoverQuorum(ctx context.Context, c *microCli.Client, data *types.RecoverQuorumPost) (types.RecoverQuorumResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*300)
+	defer cancel()
+
+	result := types.RecoverQuorumResult{}
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("recover", "quorum"), data, &result)
+	if err != nil {
+		return result, fmt.Errorf("failed to recover quorum: %w", err)
+	}
+
+	return result, nil
+}
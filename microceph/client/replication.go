@@ -18,20 +18,18 @@ func SendReplicationRequest(ctx context.Context, c *microCli.Client, data types.
 	defer cancel()
 
 	// If no API object provided, create API request to the root endpoint.
-	if len(data.GetAPIObjectId()) == 0 {
-		// uses replication/$workload endpoint
-		err = c.Query(
-			queryCtx, data.GetAPIRequestType(), types.ExtendedPathPrefix,
-			api.NewURL().Path("ops", "replication", string(data.GetWorkloadType())),
-			data, &resp,
-		)
-	} else {
+	// uses replication/$workload endpoint
+	path := api.NewURL().Path("ops", "replication", string(data.GetWorkloadType()))
+	if len(data.GetAPIObjectId()) != 0 {
 		// Other requests use replication/$workload/$resource endpoint
-		err = c.Query(
-			queryCtx, data.GetAPIRequestType(), types.ExtendedPathPrefix,
-			api.NewURL().Path("ops", "replication", string(data.GetWorkloadType()), data.GetAPIObjectId()),
-			data, &resp,
-		)
+		path = api.NewURL().Path("ops", "replication", string(data.GetWorkloadType()), data.GetAPIObjectId())
+	}
+
+	if data.GetAPIRequestType() == "PUT" {
+		// Promotion, demotion and configuration run as operations.
+		resp, err = sendReplicationOperation(queryCtx, c, data, path)
+	} else {
+		err = c.Query(queryCtx, data.GetAPIRequestType(), types.ExtendedPathPrefix, path, data, &resp)
 	}
 	if err != nil {
 		return "", fmt.Errorf("failed to process %s request for %s: %w", data.GetWorkloadRequestType(), data.GetWorkloadType(), err)
@@ -39,3 +37,31 @@ func SendReplicationRequest(ctx context.Context, c *microCli.Client, data types.
 
 	return resp, nil
 }
+
+// sendReplicationOperation sends a long-running replication request. Members with the
+// async_operations extension run it as an operation which is waited for, others reply once done.
+func sendReplicationOperation(ctx context.Context, c *microCli.Client, data types.ReplicationRequest, path *api.URL) (string, error) {
+	async, err := HasExtension(ctx, c, ExtensionAsyncOperations)
+	if err != nil {
+		return "", err
+	}
+
+	if !async {
+		var resp string
+		err = c.Query(ctx, data.GetAPIRequestType(), types.ExtendedPathPrefix, path, data, &resp)
+		return resp, err
+	}
+
+	op := types.Operation{}
+	err = c.Query(ctx, data.GetAPIRequestType(), types.ExtendedPathPrefix, path.WithQuery("async", "1"), data, &op)
+	if err != nil {
+		return "", err
+	}
+
+	op, err = WaitOperation(ctx, c, op.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return op.Result, nil
+}
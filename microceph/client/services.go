@@ -29,15 +29,49 @@ func GetServices(ctx context.Context, c *client.Client) (types.Services, error)
 	return services, nil
 }
 
+// GetServicePolicy returns the desired service counts of the cluster.
+func GetServicePolicy(ctx context.Context, c *client.Client) (types.ServicePolicy, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	policy := types.ServicePolicy{}
+
+	err := c.Query(queryCtx, "GET", types.ExtendedPathPrefix, api.NewURL().Path("services", "policy"), nil, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("failed fetching service policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// SetServicePolicy sets the desired service counts of the cluster.
+func SetServicePolicy(ctx context.Context, c *client.Client, data *types.ServicePolicy) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("services", "policy"), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed setting service policy: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteService requests MicroCeph deconfigures a service on a given target node.
-func DeleteService(ctx context.Context, c *client.Client, target string, service string) error {
+// Forcing skips the checks that the cluster stays available without the service.
+func DeleteService(ctx context.Context, c *client.Client, target string, service string, force bool) error {
 	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
 	defer cancel()
 
 	// Send this request to target.
 	c = c.UseTarget(target)
 
-	err := c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, api.NewURL().Path("services", service), nil, nil)
+	url := api.NewURL().Path("services", service)
+	if force {
+		url = url.WithQuery("force", "1")
+	}
+
+	err := c.Query(queryCtx, "DELETE", types.ExtendedPathPrefix, url, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed disabling service %s: %w", service, err)
 	}
@@ -53,7 +87,20 @@ func SendServicePlacementReq(ctx context.Context, c *client.Client, data *types.
 	// Send this request to target.
 	c = c.UseTarget(target)
 
-	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("services", data.Name), data, nil)
+	op := types.Operation{}
+	data.Async = true
+
+	err := c.Query(queryCtx, "PUT", types.ExtendedPathPrefix, api.NewURL().Path("services", data.Name), data, &op)
+	if err != nil {
+		return fmt.Errorf("failed placing service %s: %w", data.Name, err)
+	}
+
+	// Members without the async_operations extension are done once they reply.
+	if !data.Wait || op.ID == "" {
+		return nil
+	}
+
+	_, err = WaitOperation(queryCtx, c, op.ID)
 	if err != nil {
 		return fmt.Errorf("failed placing service %s: %w", data.Name, err)
 	}
@@ -76,6 +123,34 @@ func RestartService(ctx context.Context, c *client.Client, data *types.Services)
 	return nil
 }
 
+// StopService stops services on a member, without disabling them.
+func StopService(ctx context.Context, c *client.Client, data *types.Services) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("services", "stop"), data, nil)
+	if err != nil {
+		url := c.URL()
+		return fmt.Errorf("failed Forwarding To: %s: %w", url.String(), err)
+	}
+
+	return nil
+}
+
+// StartService starts services on a member.
+func StartService(ctx context.Context, c *client.Client, data *types.Services) error {
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*120)
+	defer cancel()
+
+	err := c.Query(queryCtx, "POST", types.ExtendedPathPrefix, api.NewURL().Path("services", "start"), data, nil)
+	if err != nil {
+		url := c.URL()
+		return fmt.Errorf("failed Forwarding To: %s: %w", url.String(), err)
+	}
+
+	return nil
+}
+
 // Sends the desired list of services to be restarted on every other member of the cluster.
 func SendRestartRequestToClusterMembers(ctx context.Context, s state.State, services []string) error {
 	// Populate the restart request data.
@@ -13,7 +13,8 @@ type ClientInterface interface {
 	GetClusterMembers(*microCli.Client) ([]string, error)
 	GetDisks(*microCli.Client) (types.Disks, error)
 	GetServices(*microCli.Client) (types.Services, error)
-	DeleteService(*microCli.Client, string, string) error
+	GetStretchMode(*microCli.Client) (types.StretchMode, error)
+	DeleteService(*microCli.Client, string, string, bool) error
 	DeleteClusterMember(*microCli.Client, string, bool) error
 }
 
@@ -45,9 +46,14 @@ func (c ClientImpl) GetServices(cli *microCli.Client) (types.Services, error) {
 	return GetServices(context.Background(), cli)
 }
 
+// GetStretchMode wraps the GetStretchMode function
+func (c ClientImpl) GetStretchMode(cli *microCli.Client) (types.StretchMode, error) {
+	return GetStretchMode(context.Background(), cli)
+}
+
 // DeleteService wraps the DeleteService function
-func (c ClientImpl) DeleteService(cli *microCli.Client, target string, service string) error {
-	return DeleteService(context.Background(), cli, target, service)
+func (c ClientImpl) DeleteService(cli *microCli.Client, target string, service string, force bool) error {
+	return DeleteService(context.Background(), cli, target, service, force)
 }
 
 // DeleteClusterMember wraps the DeleteClusterMember function
@@ -18,6 +18,10 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterAddCmd := cmdClusterAdd{common: c.common, cluster: c}
 	cmd.AddCommand(clusterAddCmd.Command())
 
+	// Adopt
+	clusterAdoptCmd := cmdClusterAdopt{common: c.common, cluster: c}
+	cmd.AddCommand(clusterAdoptCmd.Command())
+
 	// Bootstrap
 	clusterBootstrapCmd := cmdClusterBootstrap{common: c.common, cluster: c}
 	cmd.AddCommand(clusterBootstrapCmd.Command())
@@ -34,6 +38,50 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterRemoveCmd := cmdClusterRemove{common: c.common, cluster: c}
 	cmd.AddCommand(clusterRemoveCmd.Command())
 
+	// Set location
+	clusterSetLocationCmd := cmdClusterSetLocation{common: c.common, cluster: c}
+	cmd.AddCommand(clusterSetLocationCmd.Command())
+
+	// Stretch Subcommand
+	clusterStretchCmd := cmdClusterStretch{common: c.common, cluster: c}
+	cmd.AddCommand(clusterStretchCmd.Command())
+
+	// Restart
+	clusterRestartCmd := cmdClusterRestart{common: c.common, cluster: c}
+	cmd.AddCommand(clusterRestartCmd.Command())
+
+	// Upgrade
+	clusterUpgradeCmd := cmdClusterUpgrade{common: c.common, cluster: c}
+	cmd.AddCommand(clusterUpgradeCmd.Command())
+
+	// Upgrade Check
+	clusterUpgradeCheckCmd := cmdClusterUpgradeCheck{common: c.common, cluster: c}
+	cmd.AddCommand(clusterUpgradeCheckCmd.Command())
+
+	// Backup
+	clusterBackupCmd := cmdClusterBackup{common: c.common, cluster: c}
+	cmd.AddCommand(clusterBackupCmd.Command())
+
+	// Restore
+	clusterRestoreCmd := cmdClusterRestore{common: c.common, cluster: c}
+	cmd.AddCommand(clusterRestoreCmd.Command())
+
+	// Shutdown
+	clusterShutdownCmd := cmdClusterShutdown{common: c.common, cluster: c}
+	cmd.AddCommand(clusterShutdownCmd.Command())
+
+	// Startup
+	clusterStartupCmd := cmdClusterStartup{common: c.common, cluster: c}
+	cmd.AddCommand(clusterStartupCmd.Command())
+
+	// Network Subcommand
+	clusterNetworkCmd := cmdClusterNetwork{common: c.common, cluster: c}
+	cmd.AddCommand(clusterNetworkCmd.Command())
+
+	// Service Policy Subcommand
+	clusterServicePolicyCmd := cmdClusterServicePolicy{common: c.common, cluster: c}
+	cmd.AddCommand(clusterServicePolicyCmd.Command())
+
 	// SQL
 	clusterSQLCmd := cmdClusterSQL{common: c.common, cluster: c}
 	cmd.AddCommand(clusterSQLCmd.Command())
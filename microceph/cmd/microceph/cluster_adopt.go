@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+)
+
+type cmdClusterAdopt struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagMicroCephIp  string
+	flagFsid         string
+	flagMonHosts     string
+	flagAdminKeyFile string
+	flagPubNet       string
+	flagClusterNet   string
+}
+
+func (c *cmdClusterAdopt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adopt --fsid <fsid> --mon-hosts <addresses> --admin-key-file <path>",
+		Short: "Sets up a new cluster taking over an existing Ceph cluster",
+		Long: `Sets up a new cluster taking over an existing Ceph cluster.
+Instead of bootstrapping a new monitor, MicroCeph connects to the monitors of
+the existing cluster. Its OSDs can then be taken over with "microceph disk
+adopt", and its control plane by enabling MicroCeph monitors and managers,
+which join the existing quorum, before retiring the previous ones.
+The key of the client.admin user is read from the given file, or from
+standard input with "--admin-key-file -", so that it doesn't show up in the
+process list or the shell history.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagMicroCephIp, "microceph-ip", "", "Network address microceph daemon binds to.")
+	cmd.Flags().StringVar(&c.flagFsid, "fsid", "", "Fsid of the Ceph cluster to adopt.")
+	cmd.Flags().StringVar(&c.flagMonHosts, "mon-hosts", "", "Comma separated addresses of the monitors of the Ceph cluster to adopt.")
+	cmd.Flags().StringVar(&c.flagAdminKeyFile, "admin-key-file", "", "File holding the key of the client.admin user of the Ceph cluster to adopt, - for stdin.")
+	cmd.Flags().StringVar(&c.flagPubNet, "public-network", "", "Public network Ceph daemons bind to.")
+	cmd.Flags().StringVar(&c.flagClusterNet, "cluster-network", "", "Cluster network Ceph daemons bind to.")
+	return cmd
+}
+
+func (c *cmdClusterAdopt) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	if c.flagFsid == "" || c.flagMonHosts == "" || c.flagAdminKeyFile == "" {
+		return fmt.Errorf("--fsid, --mon-hosts and --admin-key-file are required")
+	}
+
+	adminKey, err := readAdminKey(cmd, c.flagAdminKeyFile)
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("unable to configure MicroCeph: %w", err)
+	}
+
+	// Get system hostname.
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve system hostname: %w", err)
+	}
+
+	address := c.flagMicroCephIp
+	if address == "" {
+		// Get system address for microcluster bootstrap.
+		address = util.NetworkInterfaceAddress()
+	}
+	address = util.CanonicalNetworkAddress(address, constants.BootstrapPortConst)
+
+	data := common.BootstrapConfig{
+		PublicNet:     c.flagPubNet,
+		ClusterNet:    c.flagClusterNet,
+		AdoptFsid:     c.flagFsid,
+		AdoptMonHosts: c.flagMonHosts,
+		AdoptAdminKey: adminKey,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	err = m.Ready(ctx)
+	if err != nil {
+		return fmt.Errorf("fault while waiting for App readiness: %w", err)
+	}
+
+	return m.NewCluster(ctx, hostname, address, common.EncodeBootstrapConfig(data))
+}
+
+// readAdminKey reads the admin key from the given file, or from stdin for "-".
+func readAdminKey(cmd *cobra.Command, path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read admin key: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("no admin key found in %s", path)
+	}
+
+	return key, nil
+}
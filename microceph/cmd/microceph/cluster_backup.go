@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterBackup struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagJSON bool
+}
+
+func (c *cmdClusterBackup) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup <path>",
+		Short: "Write an archive of the control plane of this member",
+		Long: "Write an archive of the control plane of this member: the cluster database, the\n" +
+			"conf directory, the monmap, the store of the local monitor and the references\n" +
+			"to the keys of encrypted OSDs. The keys themselves aren't part of the archive.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdClusterBackup) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid backup path %q: %w", args[0], err)
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := client.BackupCluster(context.Background(), cli, &types.BackupPost{Path: path})
+	if err != nil {
+		return err
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("Backup of %s written to %s (ceph %s, %d files)\n", manifest.Member, path, manifest.Version.Version, len(manifest.Files))
+	for _, warning := range manifest.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/canonical/lxd/lxd/util"
@@ -22,6 +23,15 @@ type cmdClusterBootstrap struct {
 	flagMonIp       string
 	flagPubNet      string
 	flagClusterNet  string
+
+	flagFsid               string
+	flagPoolDefaultSize    int
+	flagPoolDefaultMinSize int
+	flagFailureDomain      string
+	flagMsgr2Only          bool
+	flagSecureMode         bool
+	flagCephConfig         []string
+	flagClientConfig       []string
 }
 
 func (c *cmdClusterBootstrap) Command() *cobra.Command {
@@ -35,6 +45,14 @@ func (c *cmdClusterBootstrap) Command() *cobra.Command {
 	cmd.Flags().StringVar(&c.flagMonIp, "mon-ip", "", "Public address for bootstrapping ceph mon service.")
 	cmd.Flags().StringVar(&c.flagPubNet, "public-network", "", "Public network Ceph daemons bind to.")
 	cmd.Flags().StringVar(&c.flagClusterNet, "cluster-network", "", "Cluster network Ceph daemons bind to.")
+	cmd.Flags().StringVar(&c.flagFsid, "fsid", "", "Fsid of the new Ceph cluster, generated if not given.")
+	cmd.Flags().IntVar(&c.flagPoolDefaultSize, "pool-default-size", 0, "Initial osd_pool_default_size.")
+	cmd.Flags().IntVar(&c.flagPoolDefaultMinSize, "pool-default-min-size", 0, "Initial osd_pool_default_min_size.")
+	cmd.Flags().StringVar(&c.flagFailureDomain, "failure-domain", "", "Initial failure domain of the automatic crush rule, osd or host.")
+	cmd.Flags().BoolVar(&c.flagMsgr2Only, "msgr2-only", false, "Only bind Ceph daemons to the msgr2 protocol.")
+	cmd.Flags().BoolVar(&c.flagSecureMode, "secure-mode", false, "Encrypt all msgr2 connections.")
+	cmd.Flags().StringArrayVar(&c.flagCephConfig, "ceph-config", nil, "Initial ceph config, as [<who>/]<key>=<value>, may be repeated.")
+	cmd.Flags().StringArrayVar(&c.flagClientConfig, "client-config", nil, "Initial client config for all hosts, as <key>=<value>, may be repeated.")
 	return cmd
 }
 
@@ -63,9 +81,39 @@ func (c *cmdClusterBootstrap) Run(cmd *cobra.Command, args []string) error {
 
 	// Set parameter data for Ceph bootstrap.
 	data := common.BootstrapConfig{
-		MonIp:      c.flagMonIp,
-		PublicNet:  c.flagPubNet,
-		ClusterNet: c.flagClusterNet,
+		MonIp:              c.flagMonIp,
+		PublicNet:          c.flagPubNet,
+		ClusterNet:         c.flagClusterNet,
+		Fsid:               c.flagFsid,
+		PoolDefaultSize:    c.flagPoolDefaultSize,
+		PoolDefaultMinSize: c.flagPoolDefaultMinSize,
+		FailureDomain:      c.flagFailureDomain,
+		Msgr2Only:          c.flagMsgr2Only,
+		SecureMode:         c.flagSecureMode,
+		ClientConfig:       map[string]string{},
+	}
+
+	for _, arg := range c.flagCephConfig {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return fmt.Errorf("invalid ceph config %q, expected [<who>/]<key>=<value>", arg)
+		}
+
+		item, err := common.NewCephConfigItem(key, value)
+		if err != nil {
+			return err
+		}
+
+		data.CephConfig = append(data.CephConfig, item)
+	}
+
+	for _, arg := range c.flagClientConfig {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return fmt.Errorf("invalid client config %q, expected <key>=<value>", arg)
+		}
+
+		data.ClientConfig[key] = value
 	}
 
 	err = preCheckBootstrapConfig(data)
@@ -97,5 +145,9 @@ func preCheckBootstrapConfig(data common.BootstrapConfig) error {
 		}
 	}
 
+	if data.PoolDefaultSize != 0 && data.PoolDefaultMinSize > data.PoolDefaultSize {
+		return fmt.Errorf("pool-default-min-size %d is larger than pool-default-size %d", data.PoolDefaultMinSize, data.PoolDefaultSize)
+	}
+
 	return nil
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
 
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/common"
 	"github.com/canonical/microceph/microceph/constants"
 )
 
@@ -17,7 +19,8 @@ type cmdClusterJoin struct {
 	common  *CmdControl
 	cluster *cmdCluster
 
-	flagMicroCephIp string
+	flagMicroCephIp   string
+	flagCrushLocation string
 }
 
 func (c *cmdClusterJoin) Command() *cobra.Command {
@@ -28,6 +31,7 @@ func (c *cmdClusterJoin) Command() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&c.flagMicroCephIp, "microceph-ip", "", "Network address microceph daemon binds to.")
+	cmd.Flags().StringVar(&c.flagCrushLocation, "crush-location", "", "CRUSH location of this node, e.g. rack=r1,row=a.")
 	return cmd
 }
 
@@ -36,6 +40,12 @@ func (c *cmdClusterJoin) Run(cmd *cobra.Command, args []string) error {
 		return cmd.Help()
 	}
 
+	// Catch typos before joining, the location is only applied once the node is a member.
+	_, err := ceph.ParseCrushLocation(c.flagCrushLocation)
+	if err != nil {
+		return err
+	}
+
 	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
 	if err != nil {
 		return fmt.Errorf("unable to configure MicroCluster: %w", err)
@@ -58,5 +68,6 @@ func (c *cmdClusterJoin) Run(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
 	defer cancel()
 
-	return m.JoinCluster(ctx, hostname, address, token, nil)
+	data := common.JoinConfig{CrushLocation: c.flagCrushLocation}
+	return m.JoinCluster(ctx, hostname, address, token, common.EncodeJoinConfig(data))
 }
@@ -56,11 +56,12 @@ func (c *cmdClusterMigrate) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Disable auto services on src node
+	// Disable auto services on src node. Their replacements were just placed on the
+	// destination, which the availability checks may not see yet, e.g. a mon still joining.
 	for _, service := range autoServices {
 		req.Name = service
 		logger.Infof("Disabling %s on %s", service, args[0])
-		err = client.DeleteService(context.Background(), cli, args[0], service)
+		err = client.DeleteService(context.Background(), cli, args[0], service, true)
 		if err != nil {
 			logger.Errorf("Failed to disable %s on %s, bailing: %v", service, args[0], err)
 			return err
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdClusterNetwork struct {
+	common  *CmdControl
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterNetwork) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Manage the networks of the cluster",
+	}
+
+	// Migrate
+	clusterNetworkMigrateCmd := cmdClusterNetworkMigrate{common: c.common, cluster: c.cluster, clusterNetwork: c}
+	cmd.AddCommand(clusterNetworkMigrateCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterNetworkMigrate struct {
+	common         *CmdControl
+	cluster        *cmdCluster
+	clusterNetwork *cmdClusterNetwork
+
+	flagPublicNetwork string
+	flagRollback      bool
+	flagNoWait        bool
+}
+
+func (c *cmdClusterNetworkMigrate) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate --public-network <cidr>",
+		Short: "Move the cluster to a new public network",
+		Long: "Move the cluster to a new public network.\n" +
+			"Every member needs an address on the new network. The monitors move to their\n" +
+			"new address one at a time, each rejoining the quorum before the next one moves.\n" +
+			"ceph.conf is then updated on every member and the MGR, MDS, RGW and OSD services\n" +
+			"are restarted, one host at a time.\n" +
+			"An interrupted migration is resumed by running it again with the same network, or\n" +
+			"rolled back with --rollback, which moves the monitors back to their previous address.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagPublicNetwork, "public-network", "", "Public network to move the cluster to")
+	cmd.Flags().BoolVar(&c.flagRollback, "rollback", false, "Move the cluster back to its previous public network after an interrupted migration")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the migration has been started")
+
+	return cmd
+}
+
+func (c *cmdClusterNetworkMigrate) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	if c.flagRollback {
+		if c.flagPublicNetwork != "" {
+			return fmt.Errorf("--public-network and --rollback can't be used together")
+		}
+	} else {
+		if c.flagPublicNetwork == "" {
+			return fmt.Errorf("--public-network is required")
+		}
+
+		_, _, err := net.ParseCIDR(c.flagPublicNetwork)
+		if err != nil {
+			return fmt.Errorf("invalid public network %q: %w", c.flagPublicNetwork, err)
+		}
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.MigrateNetwork(context.Background(), cli, &types.NetworkMigratePost{PublicNetwork: c.flagPublicNetwork, Rollback: c.flagRollback})
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Network migration running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+
+	renderErr := renderNetworkMigrateResult(op.Result)
+	if renderErr != nil {
+		return renderErr
+	}
+
+	return err
+}
+
+// renderNetworkMigrateResult prints the addresses the monitors moved to, and the warnings of the migration.
+func renderNetworkMigrateResult(result string) error {
+	if len(result) == 0 {
+		return nil
+	}
+
+	migration := types.NetworkMigrateResult{}
+	err := json.Unmarshal([]byte(result), &migration)
+	if err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	data := make([][]string, len(migration.Monitors))
+	for i, mon := range migration.Monitors {
+		data[i] = []string{mon.Name, mon.Previous, mon.Address}
+	}
+
+	if len(data) > 0 {
+		header := []string{"MONITOR", "PREVIOUS ADDRESS", "ADDRESS"}
+		err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, migration.Monitors)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, warning := range migration.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	return nil
+}
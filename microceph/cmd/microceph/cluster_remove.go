@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
 )
 
 type cmdClusterRemove struct {
@@ -12,16 +17,25 @@ type cmdClusterRemove struct {
 	cluster *cmdCluster
 
 	flagForce bool
+	flagLost  bool
+	flagYes   bool
 }
 
 func (c *cmdClusterRemove) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "remove <NAME>",
 		Short: "Removes a server from the cluster",
-		RunE:  c.Run,
+		Long: `Removes a server from the cluster.
+
+With --lost the server is treated as permanently gone: its OSDs are purged from
+Ceph, its monitor is removed from the monmap and its disk and service records
+are deleted before the server is forcibly removed from the cluster.`,
+		RunE: c.Run,
 	}
 
 	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, "Forcibly remove the cluster member")
+	cmd.Flags().BoolVar(&c.flagLost, "lost", false, "Clean up after a cluster member that is permanently lost")
+	cmd.Flags().BoolVarP(&c.flagYes, "yes", "y", false, "Don't ask for confirmation when removing a lost member")
 
 	return cmd
 }
@@ -41,5 +55,63 @@ func (c *cmdClusterRemove) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return cli.DeleteClusterMember(context.Background(), args[0], c.flagForce)
+	if !c.flagLost {
+		return cli.DeleteClusterMember(context.Background(), args[0], c.flagForce)
+	}
+
+	lost, err := client.GetLostMember(context.Background(), cli, args[0])
+	if err != nil {
+		return err
+	}
+
+	if !c.flagYes {
+		fmt.Print(lostMemberImpact(lost))
+
+		confirmed, err := c.common.Asker.AskBool("Do you want to continue? (yes/no) [default=no]: ", "no")
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			return fmt.Errorf("aborted removal of %s", lost.Name)
+		}
+	}
+
+	err = client.RemoveLostMember(context.Background(), cli, lost.Name)
+	if err != nil {
+		return err
+	}
+
+	// The lost member can't take part in its removal.
+	return cli.DeleteClusterMember(context.Background(), lost.Name, true)
+}
+
+// lostMemberImpact explains what removing a lost member does to the cluster.
+func lostMemberImpact(lost types.LostMember) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Removing %s as lost is irreversible.\n", lost.Name)
+
+	if len(lost.OSDs) > 0 {
+		osds := make([]string, 0, len(lost.OSDs))
+		for _, osd := range lost.OSDs {
+			osds = append(osds, fmt.Sprintf("osd.%d", osd))
+		}
+
+		fmt.Fprintf(&b, "The following OSDs will be purged: %s.\n", strings.Join(osds, ", "))
+		b.WriteString("Data they held is only recovered from replicas on other members; placement\n")
+		b.WriteString("groups stay degraded until recovery completes, and data without another\n")
+		b.WriteString("copy is lost.\n")
+	}
+
+	if len(lost.Services) > 0 {
+		fmt.Fprintf(&b, "The following services will be removed: %s.\n", strings.Join(lost.Services, ", "))
+	}
+
+	if lost.FailureDomainDowngrade {
+		fmt.Fprintf(&b, "Fewer than 3 %ss with OSDs remain, the automatic crush rule will be\n", lost.FailureDomain)
+		fmt.Fprintf(&b, "downgraded from '%s' to '%s' level: replicas may share a %s afterwards.\n", lost.FailureDomain, lost.FailureDomainTarget, lost.FailureDomain)
+	}
+
+	return b.String()
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterRestart struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagNoWait bool
+}
+
+func (c *cmdClusterRestart) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart <mon|mgr|osd|mds|rgw>",
+		Short: "Restart a service across the cluster, one host at a time",
+		Long: "Restart a service across the cluster, one host at a time.\n" +
+			"Before moving on to the next host, the restarted daemons need to be back, the\n" +
+			"cluster healthy and all PGs active+clean. noout is set while OSDs restart.\n" +
+			"The restart is aborted on the first host which fails or doesn't recover.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the restart has been started")
+
+	return cmd
+}
+
+func (c *cmdClusterRestart) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.RollingRestart(context.Background(), cli, &types.RollingRestartPost{Service: args[0]})
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Restart running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+
+	results := types.RollingRestartResults{}
+	if len(op.Result) > 0 {
+		jsonErr := json.Unmarshal([]byte(op.Result), &results)
+		if jsonErr != nil {
+			return fmt.Errorf("failed to parse restart results: %w", jsonErr)
+		}
+	}
+
+	data := make([][]string, len(results))
+	for i, result := range results {
+		data[i] = []string{result.Host, result.Status, result.Error}
+	}
+
+	if len(data) > 0 {
+		header := []string{"HOST", "STATUS", "ERROR"}
+		renderErr := lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, results)
+		if renderErr != nil {
+			return renderErr
+		}
+	}
+
+	return err
+}
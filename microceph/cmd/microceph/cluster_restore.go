@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterRestore struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagSkipMonStore  bool
+	flagForce         bool
+	flagClusterConfig bool
+	flagDryRun        bool
+	flagYes           bool
+	flagJSON          bool
+}
+
+func (c *cmdClusterRestore) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <path>",
+		Short: "Rebuild the control plane of this member from a backup archive",
+		Long: "Rebuild the control plane of this member from an archive written by 'microceph cluster backup'\n" +
+			"on it. The archive is checked against its manifest and the installed Ceph version first.\n" +
+			"The changes are listed and confirmed before anything is written.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagSkipMonStore, "skip-mon-store", false, "Keep the current store of the local monitor")
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Restore a backup of another cluster than the one of this member")
+	cmd.Flags().BoolVar(&c.flagClusterConfig, "cluster-config", false, "Also restore the cluster wide configuration, remotes and global client configs")
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "List the changes without making them")
+	cmd.Flags().BoolVar(&c.flagYes, "yes", false, "Restore without asking for confirmation")
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdClusterRestore) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid backup path %q: %w", args[0], err)
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.RestorePost{
+		Path:          path,
+		SkipMonStore:  c.flagSkipMonStore,
+		Force:         c.flagForce,
+		ClusterConfig: c.flagClusterConfig,
+		DryRun:        true,
+	}
+
+	result, err := client.RestoreCluster(context.Background(), cli, req)
+	if err != nil {
+		return err
+	}
+
+	if !c.flagDryRun {
+		if !c.flagYes {
+			printRestoreResult(result, "Restoring")
+
+			confirmed, err := c.common.Asker.AskBool("Do you want to continue? (yes/no) [default=no]: ", "no")
+			if err != nil {
+				return err
+			}
+
+			if !confirmed {
+				return fmt.Errorf("aborted restore of %s", result.Manifest.Member)
+			}
+		}
+
+		req.DryRun = false
+		result, err = client.RestoreCluster(context.Background(), cli, req)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if c.flagDryRun {
+		printRestoreResult(result, "Would restore")
+	} else {
+		printRestoreResult(result, "Restored")
+	}
+
+	return nil
+}
+
+// printRestoreResult prints the changes of a restore and its warnings.
+func printRestoreResult(result types.RestoreResult, action string) {
+	fmt.Printf("%s %s from backup taken %s (ceph %s)\n", action, result.Manifest.Member, result.Manifest.CreatedAt.Format("2006-01-02 15:04:05"), result.Manifest.Version.Version)
+	if len(result.Restored) == 0 {
+		fmt.Println("  no changes")
+	}
+
+	for _, restored := range result.Restored {
+		fmt.Printf("  %s\n", restored)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
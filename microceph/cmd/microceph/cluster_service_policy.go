@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdClusterServicePolicy struct {
+	common  *CmdControl
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterServicePolicy) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service-policy",
+		Short: "Manage the desired number of mon, mgr and mds services",
+	}
+
+	// Get
+	clusterServicePolicyGetCmd := cmdClusterServicePolicyGet{common: c.common, cluster: c.cluster, clusterServicePolicy: c}
+	cmd.AddCommand(clusterServicePolicyGetCmd.Command())
+
+	// Set
+	clusterServicePolicySetCmd := cmdClusterServicePolicySet{common: c.common, cluster: c.cluster, clusterServicePolicy: c}
+	cmd.AddCommand(clusterServicePolicySetCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterServicePolicyGet struct {
+	common               *CmdControl
+	cluster              *cmdCluster
+	clusterServicePolicy *cmdClusterServicePolicy
+}
+
+func (c *cmdClusterServicePolicyGet) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the desired number of mon, mgr and mds services and when offline ones are replaced",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdClusterServicePolicyGet) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	policy, err := client.GetServicePolicy(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	count := func(n int) string {
+		if n == 0 {
+			return "unmanaged"
+		}
+		return fmt.Sprintf("%d", n)
+	}
+
+	data := [][]string{
+		{"mon", count(policy.Mon)},
+		{"mgr", count(policy.Mgr)},
+		{"mds", count(policy.Mds)},
+	}
+
+	header := []string{"SERVICE", "COUNT"}
+	err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, policy)
+	if err != nil {
+		return err
+	}
+
+	replaceAfter := policy.ReplaceAfter
+	if replaceAfter == "" {
+		replaceAfter = "never"
+	}
+
+	fmt.Printf("Services of offline nodes are replaced after: %s\n", replaceAfter)
+	return nil
+}
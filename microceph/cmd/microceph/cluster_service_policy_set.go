@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterServicePolicySet struct {
+	common               *CmdControl
+	cluster              *cmdCluster
+	clusterServicePolicy *cmdClusterServicePolicy
+
+	flagMon          int
+	flagMgr          int
+	flagMds          int
+	flagReplaceAfter string
+}
+
+func (c *cmdClusterServicePolicySet) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set [--mon <COUNT>] [--mgr <COUNT>] [--mds <COUNT>] [--replace-after <DURATION>]",
+		Short: "Set the desired number of mon, mgr and mds services",
+		Long: "Set the desired number of mon, mgr and mds services.\n" +
+			"MicroCeph adds services on online nodes when some are removed or lost, or\n" +
+			"when their node is offline for longer than the replace-after duration, and\n" +
+			"removes extra ones, spreading them over crush locations when available.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().IntVar(&c.flagMon, "mon", 0, "Desired number of mon services (3, 5 or 7)")
+	cmd.Flags().IntVar(&c.flagMgr, "mgr", 0, "Desired number of mgr services")
+	cmd.Flags().IntVar(&c.flagMds, "mds", 0, "Desired number of mds services")
+	cmd.Flags().StringVar(&c.flagReplaceAfter, "replace-after", "", "Replace services of nodes offline for longer than this, e.g. 30m, 0 to never replace them")
+
+	return cmd
+}
+
+func (c *cmdClusterServicePolicySet) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	if c.flagMon == 0 && c.flagMgr == 0 && c.flagMds == 0 && c.flagReplaceAfter == "" {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.ServicePolicy{
+		Mon:          c.flagMon,
+		Mgr:          c.flagMgr,
+		Mds:          c.flagMds,
+		ReplaceAfter: c.flagReplaceAfter,
+	}
+
+	return client.SetServicePolicy(context.Background(), cli, req)
+}
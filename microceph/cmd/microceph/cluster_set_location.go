@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterSetLocation struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagRackFailureDomain bool
+}
+
+func (c *cmdClusterSetLocation) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-location <NODE> <LOCATION>",
+		Short: "Sets the CRUSH location of a node, e.g. rack=r1,row=a",
+		Long: "Sets the CRUSH location of a node, e.g. rack=r1,row=a.\n" +
+			"An empty location clears it. Once OSDs are spread over at least 3 racks,\n" +
+			"MicroCeph adds a rack crush rule, which is switched to with --rack-failure-domain.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagRackFailureDomain, "rack-failure-domain", false, "Switch to the rack failure domain, OSDs need to be spread over at least 3 racks")
+	return cmd
+}
+
+func (c *cmdClusterSetLocation) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Help()
+	}
+
+	_, err := ceph.ParseCrushLocation(args[1])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := types.CrushLocationPut{Location: args[1], RackFailureDomain: c.flagRackFailureDomain}
+	result, err := client.SetCrushLocation(context.Background(), cli, args[0], req)
+	if err != nil {
+		return err
+	}
+
+	if result.RackFailureDomainReady && result.FailureDomain != "rack" {
+		fmt.Println("OSDs are spread over at least 3 racks, switch to the rack failure domain with --rack-failure-domain.")
+		fmt.Println("Switching moves data so that replicas end up in different racks.")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterShutdown struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagForce  bool
+	flagNoWait bool
+}
+
+func (c *cmdClusterShutdown) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shutdown",
+		Short: "Shut the cluster down ahead of a power-down",
+		Long: "Shut the cluster down ahead of a power-down.\n" +
+			"Clients need to be stopped beforehand. The noout, norebalance and nobackfill\n" +
+			"flags are set, then RGW, MDS, OSD, MGR and MON services are stopped on every\n" +
+			"member, in that order. Use \"microceph cluster startup\" once powered up again.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Shut down even if members are offline or the cluster is shut down already")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the shutdown has been started")
+
+	return cmd
+}
+
+func (c *cmdClusterShutdown) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.ShutdownCluster(context.Background(), cli, &types.ShutdownPost{Force: c.flagForce})
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Shutdown running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+
+	renderErr := renderPowerResults(op.Result)
+	if renderErr != nil {
+		return renderErr
+	}
+
+	return err
+}
+
+// renderPowerResults prints the services stopped or started on each member by a shutdown or
+// startup operation.
+func renderPowerResults(result string) error {
+	results := types.PowerResults{}
+	if len(result) > 0 {
+		err := json.Unmarshal([]byte(result), &results)
+		if err != nil {
+			return fmt.Errorf("failed to parse results: %w", err)
+		}
+	}
+
+	data := make([][]string, len(results))
+	for i, result := range results {
+		data[i] = []string{result.Host, result.Service, result.Status, result.Error}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	header := []string{"HOST", "SERVICE", "STATUS", "ERROR"}
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, results)
+}
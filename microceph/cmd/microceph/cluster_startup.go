@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterStartup struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagForce  bool
+	flagNoWait bool
+}
+
+func (c *cmdClusterStartup) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "startup",
+		Short: "Bring the cluster back after a shutdown",
+		Long: "Bring the cluster back after a shutdown.\n" +
+			"MON, MGR, OSD, MDS and RGW services are started on every member, in that\n" +
+			"order. Once all OSDs are up, the flags set by the shutdown are unset.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Start services even if the cluster wasn't shut down")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the startup has been started")
+
+	return cmd
+}
+
+func (c *cmdClusterStartup) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.StartupCluster(context.Background(), cli, &types.ShutdownPost{Force: c.flagForce})
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Startup running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+
+	renderErr := renderPowerResults(op.Result)
+	if renderErr != nil {
+		return renderErr
+	}
+
+	return err
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdClusterStretch struct {
+	common  *CmdControl
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterStretch) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stretch",
+		Short: "Manage stretch mode across two sites",
+	}
+
+	// Enable
+	clusterStretchEnableCmd := cmdClusterStretchEnable{common: c.common, cluster: c.cluster, clusterStretch: c}
+	cmd.AddCommand(clusterStretchEnableCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterStretchEnable struct {
+	common         *CmdControl
+	cluster        *cmdCluster
+	clusterStretch *cmdClusterStretch
+
+	flagSiteA      string
+	flagSiteB      string
+	flagSiteAName  string
+	flagSiteBName  string
+	flagTiebreaker string
+}
+
+func (c *cmdClusterStretchEnable) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enable --site-a <NODES> --site-b <NODES> --tiebreaker <NODE>",
+		Short: "Enable stretch mode across two sites with a tiebreaker monitor",
+		Long: "Enable stretch mode across two sites with a tiebreaker monitor.\n" +
+			"The nodes of each site are placed in a datacenter bucket of the site's name.\n" +
+			"All OSDs need to be within a site, each site needs at least 2 mon services\n" +
+			"and the tiebreaker node, outside of both sites, needs to run a mon service.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagSiteA, "site-a", "", "Comma separated nodes of the first site")
+	cmd.Flags().StringVar(&c.flagSiteB, "site-b", "", "Comma separated nodes of the second site")
+	cmd.Flags().StringVar(&c.flagSiteAName, "site-a-name", "site-a", "Name of the first site")
+	cmd.Flags().StringVar(&c.flagSiteBName, "site-b-name", "site-b", "Name of the second site")
+	cmd.Flags().StringVar(&c.flagTiebreaker, "tiebreaker", "", "Node running the tiebreaker mon")
+	cmd.MarkFlagRequired("site-a")
+	cmd.MarkFlagRequired("site-b")
+	cmd.MarkFlagRequired("tiebreaker")
+
+	return cmd
+}
+
+func (c *cmdClusterStretchEnable) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	siteA := strings.Split(c.flagSiteA, ",")
+	siteB := strings.Split(c.flagSiteB, ",")
+	for _, node := range siteA {
+		if slices.Contains(siteB, node) {
+			return fmt.Errorf("node %s can't be in both sites", node)
+		}
+	}
+
+	if slices.Contains(siteA, c.flagTiebreaker) || slices.Contains(siteB, c.flagTiebreaker) {
+		return fmt.Errorf("tiebreaker %s must not be within a site", c.flagTiebreaker)
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	locations, err := client.GetCrushLocations(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	// Place the nodes of each site in its datacenter bucket, keeping the rest of their location.
+	sites := map[string][]string{c.flagSiteAName: siteA, c.flagSiteBName: siteB}
+	for _, site := range []string{c.flagSiteAName, c.flagSiteBName} {
+		for _, node := range sites[site] {
+			location, err := ceph.ParseCrushLocation(locations[node])
+			if err != nil {
+				return fmt.Errorf("invalid crush location of %s: %w", node, err)
+			}
+
+			location["datacenter"] = site
+			fmt.Printf("Placing %s in site %s\n", node, site)
+			_, err = client.SetCrushLocation(context.Background(), cli, node, types.CrushLocationPut{Location: ceph.FormatCrushLocation(location)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	req := &types.StretchModePost{
+		SiteA:      c.flagSiteAName,
+		SiteB:      c.flagSiteBName,
+		Tiebreaker: c.flagTiebreaker,
+	}
+
+	return client.EnableStretchMode(context.Background(), cli, req)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterUpgrade struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagPause  bool
+	flagResume bool
+	flagNoWait bool
+}
+
+func (c *cmdClusterUpgrade) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [--pause|--resume]",
+		Short: "Upgrade the Ceph daemons of the cluster to the installed version",
+		Long: "Upgrade the Ceph daemons of the cluster to the installed version.\n" +
+			"Refresh the snap on all members first. The daemons are then restarted\n" +
+			"service by service, in mon, mgr, osd, mds and rgw order, one host at a time.\n" +
+			"The cluster needs to recover after each host before moving on.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagPause, "pause", false, "Pause the running upgrade once the current host is done")
+	cmd.Flags().BoolVar(&c.flagResume, "resume", false, "Resume a paused upgrade")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the upgrade has been started")
+	cmd.MarkFlagsMutuallyExclusive("pause", "resume")
+
+	return cmd
+}
+
+func (c *cmdClusterUpgrade) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.UpgradePost{Action: types.UpgradeActionStart}
+	switch {
+	case c.flagPause:
+		req.Action = types.UpgradeActionPause
+	case c.flagResume:
+		req.Action = types.UpgradeActionResume
+	}
+
+	op, err := client.UpgradeCluster(context.Background(), cli, req)
+	if err != nil {
+		return err
+	}
+
+	if req.Action == types.UpgradeActionPause {
+		fmt.Println("Upgrade will pause once the current host is done")
+		return nil
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Upgrade running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+	if err != nil {
+		return fmt.Errorf("upgrade failed: %w", err)
+	}
+
+	fmt.Printf("Upgrade %s\n", op.Result)
+	return nil
+}
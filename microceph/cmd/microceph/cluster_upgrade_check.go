@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdClusterUpgradeCheck struct {
+	common  *CmdControl
+	cluster *cmdCluster
+
+	flagJSON bool
+}
+
+func (c *cmdClusterUpgradeCheck) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade-check",
+		Short: "Check the cluster is ready for an upgrade",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdClusterUpgradeCheck) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	results, err := client.GetUpgradeCheck(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == types.CheckStatusFail {
+			failed++
+		}
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+	} else {
+		data := make([][]string, len(results))
+		for i, result := range results {
+			data[i] = []string{result.Description, result.Status, result.Message}
+		}
+
+		header := []string{"CHECK", "STATUS", "MESSAGE"}
+		err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, results)
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed, the cluster isn't ready for an upgrade", failed)
+	}
+
+	return nil
+}
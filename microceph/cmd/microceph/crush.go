@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdCrush struct {
+	common *CmdControl
+}
+
+func (c *cmdCrush) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crush",
+		Short: "Manage the CRUSH map",
+	}
+
+	// Rule Subcommand
+	crushRuleCmd := cmdCrushRule{common: c.common, crush: c}
+	cmd.AddCommand(crushRuleCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdCrushRule struct {
+	common *CmdControl
+	crush  *cmdCrush
+}
+
+func (c *cmdCrushRule) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rule",
+		Short: "Manage CRUSH rules",
+	}
+
+	// List
+	crushRuleListCmd := cmdCrushRuleList{common: c.common, crush: c.crush, crushRule: c}
+	cmd.AddCommand(crushRuleListCmd.Command())
+
+	// Create
+	crushRuleCreateCmd := cmdCrushRuleCreate{common: c.common, crush: c.crush, crushRule: c}
+	cmd.AddCommand(crushRuleCreateCmd.Command())
+
+	// Delete
+	crushRuleDeleteCmd := cmdCrushRuleDelete{common: c.common, crush: c.crush, crushRule: c}
+	cmd.AddCommand(crushRuleDeleteCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdCrushRuleCreate struct {
+	common    *CmdControl
+	crush     *cmdCrush
+	crushRule *cmdCrushRule
+
+	flagType          string
+	flagRoot          string
+	flagFailureDomain string
+	flagDeviceClass   string
+	flagK             int64
+	flagM             int64
+}
+
+func (c *cmdCrushRuleCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <NAME>",
+		Short: "Create a replicated or erasure CRUSH rule",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagType, "type", "replicated", "Rule type, replicated or erasure")
+	cmd.Flags().StringVar(&c.flagRoot, "root", "default", "CRUSH root to place data under")
+	cmd.Flags().StringVar(&c.flagFailureDomain, "failure-domain", "host", "Bucket type to spread data over, e.g. osd, host or rack")
+	cmd.Flags().StringVar(&c.flagDeviceClass, "device-class", "", "Place data on OSDs of this device class only")
+	cmd.Flags().Int64Var(&c.flagK, "k", 0, "Number of data chunks of erasure rules (default 2)")
+	cmd.Flags().Int64Var(&c.flagM, "m", 0, "Number of coding chunks of erasure rules (default 1)")
+
+	return cmd
+}
+
+func (c *cmdCrushRuleCreate) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("Unable to configure MicroCeph: %w", err)
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.CrushRulePost{
+		Name:          args[0],
+		Type:          c.flagType,
+		Root:          c.flagRoot,
+		FailureDomain: c.flagFailureDomain,
+		DeviceClass:   c.flagDeviceClass,
+		K:             c.flagK,
+		M:             c.flagM,
+	}
+
+	return client.CreateCrushRule(context.Background(), cli, req)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdCrushRuleDelete struct {
+	common    *CmdControl
+	crush     *cmdCrush
+	crushRule *cmdCrushRule
+}
+
+func (c *cmdCrushRuleDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete <NAME>",
+		Short: "Delete a CRUSH rule not used by any pool",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdCrushRuleDelete) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("Unable to configure MicroCeph: %w", err)
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	return client.DeleteCrushRule(context.Background(), cli, args[0])
+}
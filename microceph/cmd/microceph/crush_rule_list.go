@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdCrushRuleList struct {
+	common    *CmdControl
+	crush     *cmdCrush
+	crushRule *cmdCrushRule
+}
+
+func (c *cmdCrushRuleList) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List CRUSH rules and the pools using them",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdCrushRuleList) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return fmt.Errorf("Unable to configure MicroCeph: %w", err)
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	rules, err := client.GetCrushRules(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, len(rules))
+	for i, rule := range rules {
+		data[i] = []string{fmt.Sprintf("%d", rule.ID), rule.Name, rule.Type, rule.Root, rule.FailureDomain, rule.DeviceClass, strings.Join(rule.Pools, ",")}
+	}
+
+	header := []string{"ID", "NAME", "TYPE", "ROOT", "FAILURE DOMAIN", "CLASS", "POOLS"}
+	err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, rules)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -11,12 +11,21 @@ type cmdDisable struct {
 func (c *cmdDisable) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "disable",
-		Short: "Disables a feature on the cluster",
+		Short: "Disables a feature or service on the cluster",
 	}
 
 	// Disable RGW
 	disableRGWCmd := cmdDisableRGW{common: c.common}
+	disableMonCmd := cmdDisableMON{common: c.common}
+	disableMgrCmd := cmdDisableMGR{common: c.common}
+	disableMdsCmd := cmdDisableMDS{common: c.common}
+	disableRbdMirrorCmd := cmdDisableRBDMirror{common: c.common}
+
 	cmd.AddCommand(disableRGWCmd.Command())
+	cmd.AddCommand(disableMonCmd.Command())
+	cmd.AddCommand(disableMgrCmd.Command())
+	cmd.AddCommand(disableMdsCmd.Command())
+	cmd.AddCommand(disableRbdMirrorCmd.Command())
 
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
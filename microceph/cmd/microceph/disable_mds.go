@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDisableMDS struct {
+	common     *CmdControl
+	flagTarget string
+}
+
+func (c *cmdDisableMDS) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mds [--target <server>]",
+		Short: "Disable the MDS service on the --target server (default: this server)",
+		RunE:  c.Run,
+	}
+	cmd.PersistentFlags().StringVar(&c.flagTarget, "target", "", "Server hostname (default: this server)")
+	return cmd
+}
+
+// Run handles the disable mds command.
+func (c *cmdDisableMDS) Run(cmd *cobra.Command, args []string) error {
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteService(context.Background(), cli, c.flagTarget, "mds", false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDisableMGR struct {
+	common     *CmdControl
+	flagTarget string
+}
+
+func (c *cmdDisableMGR) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mgr [--target <server>]",
+		Short: "Disable the MGR service on the --target server (default: this server)",
+		RunE:  c.Run,
+	}
+	cmd.PersistentFlags().StringVar(&c.flagTarget, "target", "", "Server hostname (default: this server)")
+	return cmd
+}
+
+// Run handles the disable mgr command.
+func (c *cmdDisableMGR) Run(cmd *cobra.Command, args []string) error {
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteService(context.Background(), cli, c.flagTarget, "mgr", false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDisableMON struct {
+	common     *CmdControl
+	flagTarget string
+}
+
+func (c *cmdDisableMON) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mon [--target <server>]",
+		Short: "Disable the MON service on the --target server (default: this server)",
+		RunE:  c.Run,
+	}
+	cmd.PersistentFlags().StringVar(&c.flagTarget, "target", "", "Server hostname (default: this server)")
+	return cmd
+}
+
+// Run handles the disable mon command.
+func (c *cmdDisableMON) Run(cmd *cobra.Command, args []string) error {
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteService(context.Background(), cli, c.flagTarget, "mon", false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -37,7 +37,7 @@ func (c *cmdDisableRGW) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = client.DeleteService(context.Background(), cli, c.flagTarget, "rgw")
+	err = client.DeleteService(context.Background(), cli, c.flagTarget, "rgw", false)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/spf13/cobra"
 )
 
@@ -26,9 +29,59 @@ func (c *cmdDisk) Command() *cobra.Command {
 	diskRemoveCmd := cmdDiskRemove{common: c.common, disk: c}
 	cmd.AddCommand(diskRemoveCmd.Command())
 
+	// Adopt
+	diskAdoptCmd := cmdDiskAdopt{common: c.common, disk: c}
+	cmd.AddCommand(diskAdoptCmd.Command())
+
+	// Drain
+	diskDrainCmd := cmdDiskDrain{common: c.common, disk: c}
+	cmd.AddCommand(diskDrainCmd.Command())
+
+	// Status
+	diskStatusCmd := cmdDiskStatus{common: c.common, disk: c}
+	cmd.AddCommand(diskStatusCmd.Command())
+
+	// Resize
+	diskResizeCmd := cmdDiskResize{common: c.common, disk: c}
+	cmd.AddCommand(diskResizeCmd.Command())
+
+	// Encrypt
+	diskEncryptCmd := cmdDiskEncrypt{common: c.common, disk: c}
+	cmd.AddCommand(diskEncryptCmd.Command())
+
+	// Rekey
+	diskRekeyCmd := cmdDiskRekey{common: c.common, disk: c}
+	cmd.AddCommand(diskRekeyCmd.Command())
+
+	// Unlock
+	diskUnlockCmd := cmdDiskUnlock{common: c.common, disk: c}
+	cmd.AddCommand(diskUnlockCmd.Command())
+
+	// Keystore
+	diskKeyStoreCmd := cmdDiskKeyStore{common: c.common, disk: c}
+	cmd.AddCommand(diskKeyStoreCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
 
 	return cmd
 }
+
+// parseOSDArg parses an OSD given either as $id or osd.$id.
+func parseOSDArg(arg string) (int64, error) {
+	// parse as int
+	osd, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		// check arg is of osd.$id form
+		if len(arg) < 4 || arg[:4] != "osd." {
+			return 0, fmt.Errorf("error: osd input must be either in the form $id or osd.$id, got %v", arg)
+		}
+		osd, err = strconv.ParseInt(arg[4:], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error: osd input must be either in the form $id or osd.$id: got %v", arg)
+		}
+	}
+
+	return osd, nil
+}
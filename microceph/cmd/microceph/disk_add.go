@@ -28,6 +28,7 @@ type cmdDiskAdd struct {
 	dbEncrypt      bool
 	dbWipe         bool
 	flagAllDevices bool
+	deviceClass    string
 }
 
 func (c *cmdDiskAdd) Command() *cobra.Command {
@@ -39,13 +40,19 @@ The command takes arguments which is either one or more paths to block devices s
 
 For block devices, add a space separated list of paths, e.g. "/dev/sdb /dev/sdc ...". You may also add WAL and DB devices, but doing this is mutually exclusive with adding more than one OSD block device at a time.
 
-The specification for loop files is of the form loop,<size>,<nr>
+The specification for loop files is of the form loop,<size>,<nr>[,<dir>]
 
 size is an integer with M, G, or T suffixes for megabytes, gigabytes, or terabytes.
 nr is the number of file-backed loop OSDs to create.
+dir is an optional absolute path of an existing directory to place the backing files in,
+by default they are kept in the OSD data directory.
 For instance, a spec of loop,8G,3 will create 3 file-backed loop OSDs of 8GB each.
 
-Note that loop files can't be used with encryption nor WAL/DB devices.`,
+Note that loop files can't be used with encryption nor WAL/DB devices.
+
+The crush device class (e.g. hdd or ssd) of block devices is detected, use --device-class
+to override it. Crush rules for the class are set up automatically so that pools can be
+pinned to it, see "microceph pool create --device-class".`,
 		RunE: c.Run,
 	}
 
@@ -58,6 +65,7 @@ Note that loop files can't be used with encryption nor WAL/DB devices.`,
 	cmd.PersistentFlags().StringVar(&c.dbDevice, "db-device", "", "The device used for the DB")
 	cmd.PersistentFlags().BoolVar(&c.dbWipe, "db-wipe", false, "Wipe the DB device prior to use")
 	cmd.PersistentFlags().BoolVar(&c.dbEncrypt, "db-encrypt", false, "Encrypt the DB device prior to use")
+	cmd.PersistentFlags().StringVar(&c.deviceClass, "device-class", "", "The crush device class of the disks, detected if not given")
 
 	return cmd
 }
@@ -117,6 +125,7 @@ func (c *cmdDiskAdd) Run(cmd *cobra.Command, args []string) error {
 	// required request params.
 	req.Wipe = c.flagWipe
 	req.Encrypt = c.flagEncrypt
+	req.DeviceClass = c.deviceClass
 	failures, err := client.AddDisk(context.Background(), cli, &req)
 	if err != nil {
 		return err
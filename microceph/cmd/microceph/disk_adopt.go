@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskAdopt struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagAll  bool
+	flagList bool
+	flagJSON bool
+}
+
+func (c *cmdDiskAdopt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adopt [<osd-id>...] | --all | --list",
+		Short: "Take over existing Ceph disks (OSDs) of this host",
+		Long: `Take over existing Ceph disks (OSDs) of this host.
+BlueStore OSDs deployed by other tools, e.g. cephadm or ceph-volume (LVM or
+raw), are discovered through their device labels. Adopted OSDs keep their id,
+fsid and key; their data is left untouched. The daemons previously running the
+OSDs must be stopped first.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagAll, "all", false, "Adopt all OSDs found on this host")
+	cmd.Flags().BoolVar(&c.flagList, "list", false, "List the OSDs found on this host")
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdDiskAdopt) Run(cmd *cobra.Command, args []string) error {
+	modes := 0
+	for _, set := range []bool{len(args) > 0, c.flagAll, c.flagList} {
+		if set {
+			modes++
+		}
+	}
+
+	if modes != 1 {
+		return cmd.Help()
+	}
+
+	osds := []int64{}
+	for _, arg := range args {
+		osd, err := parseOSDArg(arg)
+		if err != nil {
+			return err
+		}
+
+		osds = append(osds, osd)
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	var disks types.AdoptableDisks
+	if c.flagList {
+		disks, err = client.GetAdoptableDisks(context.Background(), cli)
+	} else {
+		disks, err = client.AdoptDisks(context.Background(), cli, osds)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(disks)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if !c.flagList && len(disks) == 0 {
+		fmt.Println("No OSDs left to adopt")
+		return nil
+	}
+
+	data := make([][]string, len(disks))
+	for i, disk := range disks {
+		up := "down"
+		if disk.Up {
+			up = "up"
+		}
+
+		data[i] = []string{
+			fmt.Sprintf("%d", disk.OSD),
+			disk.Path,
+			disk.DBPath,
+			disk.WALPath,
+			up,
+			fmt.Sprintf("%t", disk.Adopted),
+		}
+	}
+
+	header := []string{"OSD", "PATH", "DB", "WAL", "UP", "ADOPTED"}
+	sort.Sort(lxdCmd.SortColumnsNaturally(data))
+
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, disks)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskDrain struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagHost string
+	flagWait bool
+}
+
+func (c *cmdDiskDrain) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drain <osd-id> | --host <name>",
+		Short: "Migrate data off Ceph disks (OSDs) ahead of their removal",
+		Long: `Migrate data off Ceph disks (OSDs) ahead of their removal.
+The OSDs are reweighted to zero so that Ceph moves their placement groups
+to other OSDs. Use "microceph disk status" to follow the migration; once an
+OSD reports as drained it can be removed with "microceph disk remove".`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagHost, "host", "", "Drain all OSDs located on the given host")
+	cmd.Flags().BoolVar(&c.flagWait, "wait", false, "Wait until all OSDs are drained")
+
+	return cmd
+}
+
+func (c *cmdDiskDrain) Run(cmd *cobra.Command, args []string) error {
+	if (len(args) == 1) == (len(c.flagHost) > 0) {
+		return cmd.Help()
+	}
+
+	var osd int64
+	var err error
+	if len(args) == 1 {
+		osd, err = parseOSDArg(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	osds, err := client.DrainDisks(context.Background(), cli, osd, c.flagHost)
+	if err != nil {
+		return err
+	}
+
+	if !c.flagWait {
+		fmt.Println("Drain started, use \"microceph disk status\" to follow its progress")
+		return nil
+	}
+
+	return c.wait(cli, osds)
+}
+
+// wait follows the given OSDs until all of them are drained.
+func (c *cmdDiskDrain) wait(cli *microCli.Client, osds []int64) error {
+	last := map[int64]int64{}
+
+	for {
+		status, err := client.GetDiskStatus(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		drained := 0
+		for _, osd := range osds {
+			for _, disk := range status {
+				if disk.OSD != osd {
+					continue
+				}
+
+				pgs, seen := last[osd]
+				if !seen || pgs != disk.PGs {
+					fmt.Printf("osd.%d: %s, %d PGs remaining\n", osd, disk.Status, disk.PGs)
+					last[osd] = disk.PGs
+				}
+
+				if disk.Status == ceph.DrainStatusDrained {
+					drained++
+				}
+			}
+		}
+
+		if drained == len(osds) {
+			return nil
+		}
+
+		time.Sleep(10 * time.Second)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskEncrypt struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagHost   string
+	flagNoWait bool
+	flagStatus bool
+}
+
+func (c *cmdDiskEncrypt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encrypt <osd-id> | --host <name>",
+		Short: "Convert plaintext Ceph disks (OSDs) into encrypted ones",
+		Long: `Convert plaintext Ceph disks (OSDs) into encrypted ones.
+Each OSD is drained, re-created with encryption on the same device and the
+cluster is given time to recover before the next OSD is converted.
+Progress is recorded in the cluster database; an interrupted or failed
+conversion is resumed by running the command again.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagHost, "host", "", "Convert all OSDs located on the given host")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the conversion has been started")
+	cmd.Flags().BoolVar(&c.flagStatus, "status", false, "Show the progress of all conversions")
+
+	return cmd
+}
+
+func (c *cmdDiskEncrypt) Run(cmd *cobra.Command, args []string) error {
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	if c.flagStatus {
+		if len(args) != 0 || len(c.flagHost) > 0 {
+			return cmd.Help()
+		}
+
+		return c.showStatus(cli)
+	}
+
+	if (len(args) == 1) == (len(c.flagHost) > 0) {
+		return cmd.Help()
+	}
+
+	var osd int64
+	if len(args) == 1 {
+		osd, err = parseOSDArg(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	osds, err := client.EncryptDisks(context.Background(), cli, osd, c.flagHost)
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Println("Encryption started, use \"microceph disk encrypt --status\" to follow its progress")
+		return nil
+	}
+
+	return c.wait(cli, osds)
+}
+
+// wait follows the conversion of the given OSDs until all are done or one failed.
+func (c *cmdDiskEncrypt) wait(cli *microCli.Client, osds []int64) error {
+	last := map[int64]string{}
+
+	for {
+		encryptions, err := client.GetDiskEncryptions(context.Background(), cli)
+		if err != nil {
+			return err
+		}
+
+		done := 0
+		for _, osd := range osds {
+			for _, enc := range encryptions {
+				if enc.OSD != osd {
+					continue
+				}
+
+				if len(enc.Error) > 0 {
+					return fmt.Errorf("encryption of osd.%d failed while %s: %s", osd, enc.Status, enc.Error)
+				}
+
+				if last[osd] != enc.Status {
+					fmt.Printf("osd.%d: %s\n", osd, enc.Status)
+					last[osd] = enc.Status
+				}
+
+				if enc.Status == ceph.EncryptStatusDone {
+					done++
+				}
+			}
+		}
+
+		if done == len(osds) {
+			return nil
+		}
+
+		time.Sleep(10 * time.Second)
+	}
+}
+
+func (c *cmdDiskEncrypt) showStatus(cli *microCli.Client) error {
+	encryptions, err := client.GetDiskEncryptions(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, len(encryptions))
+	for i, enc := range encryptions {
+		data[i] = []string{fmt.Sprintf("%d", enc.OSD), enc.Location, enc.Status, enc.Error}
+	}
+
+	header := []string{"OSD", "LOCATION", "STATUS", "ERROR"}
+	sort.Sort(lxdCmd.SortColumnsNaturally(data))
+
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, encryptions)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskKeyStore struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+type cmdDiskKeyStoreSet struct {
+	common   *CmdControl
+	keystore *cmdDiskKeyStore
+
+	flagURL   string
+	flagToken string
+}
+
+type cmdDiskKeyStoreGet struct {
+	common   *CmdControl
+	keystore *cmdDiskKeyStore
+}
+
+func (c *cmdDiskKeyStore) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keystore",
+		Short: "Manage the key store holding the keys of encrypted disks",
+	}
+
+	// set
+	setCmd := cmdDiskKeyStoreSet{common: c.common, keystore: c}
+	cmd.AddCommand(setCmd.Command())
+
+	// get
+	getCmd := cmdDiskKeyStoreGet{common: c.common, keystore: c}
+	cmd.AddCommand(getCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
+
+func (c *cmdDiskKeyStoreSet) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <backend> [--url <url>] [--token <token>]",
+		Short: "Set the cluster wide key store backend (config-key or http)",
+		Long: `Set the cluster wide key store backend for encrypted disks.
+    config-key - keys are kept in the Ceph config-key store (default).
+    http       - keys are kept in an external Vault KV style secret store,
+                 reachable at --url and authenticated with --token.
+Keys of existing encrypted disks are migrated to the new backend.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagURL, "url", "", "URL of the key store (http backend)")
+	cmd.Flags().StringVar(&c.flagToken, "token", "", "Access token for the key store (http backend)")
+
+	return cmd
+}
+
+func (c *cmdDiskKeyStoreSet) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.KeyStoreConfig{
+		Backend: args[0],
+		URL:     c.flagURL,
+		Token:   c.flagToken,
+	}
+
+	return client.KeyStoreSet(context.Background(), cli, req)
+}
+
+func (c *cmdDiskKeyStoreGet) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the cluster wide key store backend",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskKeyStoreGet) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	config, err := client.KeyStoreGet(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("backend: %s\n", config.Backend)
+	if len(config.URL) > 0 {
+		fmt.Printf("url: %s\n", config.URL)
+	}
+
+	return nil
+}
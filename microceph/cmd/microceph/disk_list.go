@@ -16,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
 	"github.com/canonical/microceph/microceph/client"
 	"github.com/canonical/microceph/microceph/common"
 	"github.com/canonical/microceph/microceph/constants"
@@ -31,7 +32,7 @@ type cmdDiskList struct {
 func (c *cmdDiskList) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
-		Short: "List disks configured in MicroCeph and available unpartitioned disks on this system.",
+		Short: "List disks configured in MicroCeph and the disks, partitions and logical volumes available on this system.",
 		RunE:  c.Run,
 	}
 
@@ -41,16 +42,25 @@ func (c *cmdDiskList) Command() *cobra.Command {
 }
 
 type Disk struct {
-	Model string
-	Size  string
-	Type  string
-	Path  string
+	Model  string
+	Size   string
+	Type   string
+	Path   string
+	Reason string `json:",omitempty"`
+}
+
+// ConfiguredDisk holds the state and health of a disk configured in MicroCeph.
+type ConfiguredDisk struct {
+	types.DiskStatus
+	Health        string `json:"health"`
+	HealthDetails string `json:"health_details"`
 }
 
 // Structure for marshalling to json.
 type DiskListOutput struct {
-	ConfiguredDisks types.Disks
-	AvailableDisks  []Disk
+	ConfiguredDisks  []ConfiguredDisk
+	AvailableDisks   []Disk
+	UnavailableDisks []Disk
 }
 
 func (c *cmdDiskList) Run(cmd *cobra.Command, args []string) error {
@@ -65,19 +75,19 @@ func (c *cmdDiskList) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// List configured disks.
-	configuredDisks, err := client.GetDisks(context.Background(), cli)
+	configuredDisks, err := getConfiguredDisks(cli)
 	if err != nil {
-		return fmt.Errorf("internal error: unable to fetch configured disks: %w", err)
+		return err
 	}
 
-	// List unpartitioned disks.
-	availableDisks, err := getUnpartitionedDisks(cli)
+	// List local disks, with the reason for those that can't be used.
+	availableDisks, unavailableDisks, err := getLocalDisks(cli)
 	if err != nil {
-		return fmt.Errorf("internal error: unable to fetch unpartitoned disks: %w", err)
+		return fmt.Errorf("internal error: unable to fetch local disks: %w", err)
 	}
 
 	if c.hostOnly {
-		fcg := types.Disks{}
+		fcg := []ConfiguredDisk{}
 
 		// Get system hostname.
 		hostname, err := os.Hostname()
@@ -95,24 +105,94 @@ func (c *cmdDiskList) Run(cmd *cobra.Command, args []string) error {
 		configuredDisks = fcg
 	}
 
+	addDiskHealth(cli, configuredDisks)
+
 	if c.json {
-		return outputJson(configuredDisks, availableDisks)
+		return outputJson(configuredDisks, availableDisks, unavailableDisks)
+	}
+
+	return outputFormattedTable(configuredDisks, availableDisks, unavailableDisks)
+}
+
+// getConfiguredDisks fetches the disks configured in MicroCeph along with their state in Ceph.
+// If Ceph can't be queried, the disks are listed with an unknown state.
+func getConfiguredDisks(cli *microCli.Client) ([]ConfiguredDisk, error) {
+	status, err := client.GetDiskStatus(context.Background(), cli)
+	if err != nil {
+		logger.Warnf("Unable to fetch disk state from Ceph: %v", err)
+
+		disks, err := client.GetDisks(context.Background(), cli)
+		if err != nil {
+			return nil, fmt.Errorf("internal error: unable to fetch configured disks: %w", err)
+		}
+
+		status = make(types.DiskStatuses, 0, len(disks))
+		for _, disk := range disks {
+			status = append(status, types.DiskStatus{OSD: disk.OSD, Location: disk.Location, Path: disk.Path, Status: "unknown"})
+		}
+	}
+
+	ret := make([]ConfiguredDisk, 0, len(status))
+	for _, disk := range status {
+		ret = append(ret, ConfiguredDisk{DiskStatus: disk, Health: ceph.DiskHealthUnknown})
 	}
 
-	return outputFormattedTable(configuredDisks, availableDisks)
+	return ret, nil
 }
 
-func outputFormattedTable(configuredDisks types.Disks, availableDisks []Disk) error {
+// addDiskHealth fills in the health of the configured disks, as reported by the members hosting them.
+func addDiskHealth(cli *microCli.Client, disks []ConfiguredDisk) {
+	health := map[int64]types.DiskHealth{}
+	queried := map[string]bool{}
+	for _, disk := range disks {
+		if queried[disk.Location] {
+			continue
+		}
+
+		queried[disk.Location] = true
+		reports, err := client.GetDiskHealth(context.Background(), cli, disk.Location)
+		if err != nil {
+			logger.Warnf("Unable to fetch disk health: %v", err)
+			continue
+		}
+
+		for _, report := range reports {
+			health[report.OSD] = report
+		}
+	}
+
+	for i := range disks {
+		report, ok := health[disks[i].OSD]
+		if !ok {
+			continue
+		}
+
+		disks[i].Health = report.Health
+		disks[i].HealthDetails = report.Details
+	}
+}
+
+func outputFormattedTable(configuredDisks []ConfiguredDisk, availableDisks []Disk, unavailableDisks []Disk) error {
 	var err error
 
 	if len(configuredDisks) > 0 {
 		// Print configured disks.
 		cData := make([][]string, len(configuredDisks))
 		for i, cDisk := range configuredDisks {
-			cData[i] = []string{fmt.Sprintf("%d", cDisk.OSD), cDisk.Location, cDisk.Path}
+			cData[i] = []string{
+				fmt.Sprintf("%d", cDisk.OSD),
+				cDisk.Location,
+				cDisk.Path,
+				diskState(cDisk.DiskStatus),
+				cDisk.DeviceClass,
+				fmt.Sprintf("%.4f", cDisk.Weight),
+				fmt.Sprintf("%.2f%%", cDisk.Utilization),
+				fmt.Sprintf("%d", cDisk.PGs),
+				cDisk.Health,
+			}
 		}
 
-		header := []string{"OSD", "LOCATION", "PATH"}
+		header := []string{"OSD", "LOCATION", "PATH", "STATE", "CLASS", "WEIGHT", "USAGE", "PGS", "HEALTH"}
 		sort.Sort(lxdCmd.SortColumnsNaturally(cData))
 
 		fmt.Println("Disks configured in MicroCeph:")
@@ -132,22 +212,59 @@ func outputFormattedTable(configuredDisks types.Disks, availableDisks []Disk) er
 		header := []string{"MODEL", "CAPACITY", "TYPE", "PATH"}
 		sort.Sort(lxdCmd.SortColumnsNaturally(aData))
 
-		fmt.Println("\nAvailable unpartitioned disks on this system:")
+		fmt.Println("\nAvailable disks, partitions and logical volumes on this system:")
 		err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, aData, aData)
 		if err != nil {
 			return err
 		}
 	}
 
+	if len(unavailableDisks) > 0 {
+		// Print disks which can't be used, and why.
+		uData := make([][]string, len(unavailableDisks))
+		for i, uDisk := range unavailableDisks {
+			uData[i] = []string{uDisk.Model, uDisk.Size, uDisk.Type, uDisk.Path, uDisk.Reason}
+		}
+
+		header := []string{"MODEL", "CAPACITY", "TYPE", "PATH", "REASON"}
+		sort.Sort(lxdCmd.SortColumnsNaturally(uData))
+
+		fmt.Println("\nUnavailable disks on this system:")
+		err = lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, uData, uData)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// diskState renders the up/in state of an OSD, e.g. "up,in".
+func diskState(disk types.DiskStatus) string {
+	if disk.Status == "unknown" {
+		return "unknown"
+	}
+
+	up := "down"
+	if disk.Up {
+		up = "up"
+	}
+
+	in := "out"
+	if disk.In {
+		in = "in"
+	}
+
+	return fmt.Sprintf("%s,%s", up, in)
+}
+
 // outputJson prints the json output to stdout.
-func outputJson(configuredDisks types.Disks, availableDisks []Disk) error {
+func outputJson(configuredDisks []ConfiguredDisk, availableDisks []Disk, unavailableDisks []Disk) error {
 	var err error
 	output := DiskListOutput{
-		ConfiguredDisks: configuredDisks,
-		AvailableDisks:  availableDisks,
+		ConfiguredDisks:  configuredDisks,
+		AvailableDisks:   availableDisks,
+		UnavailableDisks: unavailableDisks,
 	}
 
 	opStr, err := json.Marshal(output)
@@ -159,95 +276,206 @@ func outputJson(configuredDisks types.Disks, availableDisks []Disk) error {
 	return nil
 }
 
-// getUnpartitionedDisks fetches the list of available resources
+// Types of the local devices which aren't whole disks.
+const (
+	diskTypePartition = "partition"
+	diskTypeLVM       = "lvm"
+)
+
+// getUnpartitionedDisks fetches the list of local whole disks which can be used as OSDs.
 func getUnpartitionedDisks(cli *microCli.Client) ([]Disk, error) {
+	available, _, err := getLocalDisks(cli)
+	if err != nil {
+		return nil, err
+	}
+
+	// Partitions and logical volumes are only used when asked for by path.
+	disks := []Disk{}
+	for _, disk := range available {
+		if disk.Type == diskTypePartition || disk.Type == diskTypeLVM {
+			continue
+		}
+
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+// getLocalDisks fetches the local disks, split into those which can be used as OSDs and those which can't.
+func getLocalDisks(cli *microCli.Client) ([]Disk, []Disk, error) {
 	// List configured disks.
 	disks, err := client.GetDisks(context.Background(), cli)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: unable to fetch configured disks: %w", err)
+		return nil, nil, fmt.Errorf("internal error: unable to fetch configured disks: %w", err)
 	}
 
 	// List physical disks.
 	resources, err := client.GetResources(context.Background(), cli)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: unable to fetch available disks: %w", err)
-	}
-
-	data, err := filterLocalDisks(resources, disks)
-	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("internal error: unable to fetch available disks: %w", err)
 	}
 
-	return data, nil
+	return checkLocalDisks(resources, disks)
 }
 
-// filterLocalDisks filters out the disks that are in use or otherwise not suitable for OSDs.
-func filterLocalDisks(resources *api.ResourcesStorage, disks types.Disks) ([]Disk, error) {
+// checkLocalDisks sorts out the disks, partitions and logical volumes that are in use or
+// otherwise not suitable for OSDs, recording the reason for each of them.
+func checkLocalDisks(resources *api.ResourcesStorage, disks types.Disks) ([]Disk, []Disk, error) {
 	var err error
 	// Get local hostname.
 	hostname, err := os.Hostname()
 	if err != nil {
-		return nil, fmt.Errorf("internal error: unable to fetch Hostname: %w", err)
+		return nil, nil, fmt.Errorf("internal error: unable to fetch Hostname: %w", err)
 	}
 
-	// Prepare the table.
-	data := []Disk{}
-	for _, disk := range resources.Disks {
-		if len(disk.Partitions) > 0 {
-			continue
+	// Prepare the tables.
+	available := []Disk{}
+	unavailable := []Disk{}
+	record := func(entry Disk) {
+		if entry.Reason != "" {
+			unavailable = append(unavailable, entry)
+			return
 		}
 
-		if len(disk.DeviceID) == 0 {
-			continue
+		available = append(available, entry)
+	}
+
+	for _, disk := range resources.Disks {
+		entry := Disk{
+			Model: disk.Model,
+			Size:  units.GetByteSizeStringIEC(int64(disk.Size), 2),
+			Type:  disk.Type,
+			Path:  fmt.Sprintf("/dev/%s", disk.ID),
 		}
 
-		// Minimum size set to 2GB i.e. 2*1024*1024*1024
-		if disk.Size < constants.MinOSDSize {
-			logger.Debugf("Ignoring device %s, size less than 2GB", disk.DeviceID)
-			continue
+		entry.Reason, err = diskUnavailableReason(disk, disks, hostname)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		devicePath := fmt.Sprintf("%s%s", constants.DevicePathPrefix, disk.DeviceID)
+		if entry.Reason == "" {
+			entry.Path = fmt.Sprintf("%s%s", constants.DevicePathPrefix, disk.DeviceID)
+		}
+		record(entry)
+
+		for _, part := range disk.Partitions {
+			entry := Disk{
+				Model: disk.Model,
+				Size:  units.GetByteSizeStringIEC(int64(part.Size), 2),
+				Type:  diskTypePartition,
+				Path:  fmt.Sprintf("/dev/%s", part.ID),
+			}
 
-		found := false
-		// check if disk already employed as an OSD.
-		for _, entry := range disks {
-			if entry.Location != hostname {
-				continue
+			stablePath, reason, err := partitionUnavailableReason(entry.Path, part.Size, disks, hostname)
+			if err != nil {
+				return nil, nil, err
 			}
 
-			if entry.Path == devicePath {
-				found = true
-				break
+			entry.Reason = reason
+			if reason == "" {
+				entry.Path = stablePath
 			}
+			record(entry)
 		}
+	}
 
-		if found {
-			continue
-		}
+	volumes, err := common.ListLogicalVolumes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("internal error: unable to list logical volumes: %w", err)
+	}
 
-		// check if disk is mounted or already employed as a journal or db
-		mounted, err := common.IsMounted(devicePath)
+	for _, volume := range volumes {
+		size, err := common.GetDeviceSize(volume)
 		if err != nil {
-			return nil, fmt.Errorf("internal error: unable to check if disk is mounted: %w", err)
+			return nil, nil, fmt.Errorf("internal error: unable to fetch size of %s: %w", volume, err)
 		}
-		if mounted {
-			continue
+
+		entry := Disk{
+			Size: units.GetByteSizeStringIEC(int64(size), 2),
+			Type: diskTypeLVM,
+			Path: volume,
 		}
-		isCephDev, err := common.IsCephDevice(devicePath)
+
+		entry.Reason, err = deviceUnavailableReason(volume, size, disks, hostname)
 		if err != nil {
-			return nil, fmt.Errorf("internal error checking if disk is ceph device: %w", err)
+			return nil, nil, err
 		}
-		if isCephDev {
+		record(entry)
+	}
+
+	return available, unavailable, nil
+}
+
+// diskUnavailableReason returns why a disk can't be used as an OSD, or an empty string if it can.
+func diskUnavailableReason(disk api.ResourcesStorageDisk, disks types.Disks, hostname string) (string, error) {
+	if len(disk.Partitions) > 0 {
+		return "has partitions", nil
+	}
+
+	if len(disk.DeviceID) == 0 {
+		return "no stable device path", nil
+	}
+
+	devicePath := fmt.Sprintf("%s%s", constants.DevicePathPrefix, disk.DeviceID)
+
+	return deviceUnavailableReason(devicePath, disk.Size, disks, hostname)
+}
+
+// partitionUnavailableReason returns the stable path of a partition along with why it can't
+// be used as an OSD, or an empty reason if it can. Partitions are named by their UUID.
+func partitionUnavailableReason(path string, size uint64, disks types.Disks, hostname string) (string, string, error) {
+	partUUIDPath, err := common.GetPartUUIDPath(path)
+	if err != nil {
+		return "", "", fmt.Errorf("internal error looking up partition UUID of %s: %w", path, err)
+	}
+
+	if partUUIDPath == "" {
+		return path, "no partition UUID", nil
+	}
+
+	reason, err := deviceUnavailableReason(partUUIDPath, size, disks, hostname)
+	if err != nil {
+		return "", "", err
+	}
+
+	return partUUIDPath, reason, nil
+}
+
+// deviceUnavailableReason runs the checks 'disk add' runs on a device, returning why it can't
+// be used as an OSD, or an empty string if it can.
+func deviceUnavailableReason(devicePath string, size uint64, disks types.Disks, hostname string) (string, error) {
+	// Minimum size set to 2GB i.e. 2*1024*1024*1024
+	if size < constants.MinOSDSize {
+		logger.Debugf("Ignoring device %s, size less than 2GB", devicePath)
+		return "smaller than 2GiB", nil
+	}
+
+	// check if device already employed as an OSD.
+	for _, entry := range disks {
+		if entry.Location != hostname {
 			continue
 		}
 
-		data = append(data, Disk{
-			Model: disk.Model,
-			Size:  units.GetByteSizeStringIEC(int64(disk.Size), 2),
-			Type:  disk.Type,
-			Path:  devicePath,
-		})
+		if entry.Path == devicePath {
+			return fmt.Sprintf("in use by osd.%d", entry.OSD), nil
+		}
 	}
-	return data, nil
+
+	// check if device is mounted, used as swap or claimed by another device
+	err := common.CheckDeviceAvailable(devicePath)
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	// check if device is already employed as a journal or db
+	isCephDev, err := common.IsCephDevice(devicePath)
+	if err != nil {
+		return "", fmt.Errorf("internal error checking if disk is ceph device: %w", err)
+	}
+	if isCephDev {
+		return "in use by Ceph", nil
+	}
+
+	return "", nil
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskRekey struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+func (c *cmdDiskRekey) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rekey <osd-id>",
+		Short: "Rotate the encryption keys of an encrypted Ceph disk (OSD)",
+		Long: `Rotate the encryption keys of an encrypted Ceph disk (OSD).
+A new key is added to each encrypted device of the OSD (data, WAL and DB),
+recorded in the configured key store, and the previous key is then removed.`,
+		RunE: c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskRekey) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOSDArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.RekeyDisk(context.Background(), cli, osd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rotated encryption keys of osd.%d\n", osd)
+	return nil
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
@@ -20,6 +19,7 @@ type cmdDiskRemove struct {
 	flagConfirmDowngrade       bool
 	flagProhibitCrushScaledown bool
 	flagTimeout                int64
+	flagNoWait                 bool
 }
 
 func (c *cmdDiskRemove) Command() *cobra.Command {
@@ -33,6 +33,7 @@ func (c *cmdDiskRemove) Command() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&c.flagBypassSafety, "bypass-safety-checks", false, "Bypass safety checks")
 	cmd.PersistentFlags().BoolVar(&c.flagConfirmDowngrade, "confirm-failure-domain-downgrade", false, "Confirm failure domain downgrade if required")
 	cmd.PersistentFlags().BoolVar(&c.flagProhibitCrushScaledown, "prohibit-crush-scaledown", false, "Remove OSD without scaling down the crush failure domain")
+	cmd.PersistentFlags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the removal has been started")
 
 	return cmd
 }
@@ -52,17 +53,9 @@ func (c *cmdDiskRemove) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// parse as int
-	osd, err := strconv.ParseInt(args[0], 10, 64)
+	osd, err := parseOSDArg(args[0])
 	if err != nil {
-		// check arg is of osd.$id form
-		if len(args[0]) < 4 || args[0][:4] != "osd." {
-			return fmt.Errorf("error: osd input must be either in the form $id or osd.$id, got %v", args[0])
-		}
-		osd, err = strconv.ParseInt(args[0][4:], 10, 64)
-		if err != nil {
-			return fmt.Errorf("error: osd input must be either in the form $id or osd.$id: got %v", args[0])
-		}
+		return err
 	}
 
 	if c.flagConfirmDowngrade && c.flagProhibitCrushScaledown {
@@ -78,10 +71,25 @@ func (c *cmdDiskRemove) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Removing osd.%d, timeout %ds\n", osd, req.Timeout)
-	err = client.RemoveDisk(context.Background(), cli, req)
+	op, err := client.RemoveDisk(context.Background(), cli, req)
 	if err != nil {
 		return err
 	}
 
+	// Members without the async_operations extension remove the disk before replying.
+	if op.ID == "" {
+		return nil
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Removal running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	_, err = client.WaitOperation(context.Background(), cli, op.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove disk: %w", err)
+	}
+
 	return nil
 }
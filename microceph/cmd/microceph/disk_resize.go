@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskResize struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+func (c *cmdDiskResize) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resize <osd-id> <new-size>",
+		Short: "Grow a loop file backed Ceph disk (OSD)",
+		Long: `Grow a loop file backed Ceph disk (OSD).
+The backing file is grown to the new size, an integer with M, G, or T suffixes
+for megabytes, gigabytes, or terabytes, and BlueStore is expanded into the new
+space. The OSD is briefly stopped while doing so.`,
+		RunE: c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskResize) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOSDArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.ResizeDisk(context.Background(), cli, osd, args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Resized osd.%d to %s\n", osd, args[1])
+	return nil
+}
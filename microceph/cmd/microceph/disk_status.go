@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/lxd/shared/units"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskStatus struct {
+	common *CmdControl
+	disk   *cmdDisk
+
+	flagJSON bool
+}
+
+func (c *cmdDiskStatus) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show weight, placement groups and drain state of Ceph disks (OSDs)",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdDiskStatus) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	status, err := client.GetDiskStatus(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+		return nil
+	}
+
+	data := make([][]string, len(status))
+	for i, disk := range status {
+		up := "down"
+		if disk.Up {
+			up = "up"
+		}
+
+		data[i] = []string{
+			fmt.Sprintf("%d", disk.OSD),
+			disk.Location,
+			up,
+			fmt.Sprintf("%.4f", disk.Weight),
+			fmt.Sprintf("%d", disk.PGs),
+			units.GetByteSizeStringIEC(int64(disk.UsedBytes), 2),
+			disk.Status,
+		}
+	}
+
+	header := []string{"OSD", "LOCATION", "UP", "WEIGHT", "PGS", "USED", "STATUS"}
+	sort.Sort(lxdCmd.SortColumnsNaturally(data))
+
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, status)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdDiskUnlock struct {
+	common *CmdControl
+	disk   *cmdDisk
+}
+
+func (c *cmdDiskUnlock) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unlock <osd-id>",
+		Short:  "Open the encrypted devices of a local Ceph disk (OSD)",
+		Hidden: true,
+		RunE:   c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdDiskUnlock) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	osd, err := parseOSDArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	return client.UnlockDisk(context.Background(), cli, osd)
+}
@@ -19,19 +19,32 @@ import (
 
 type cmdInit struct {
 	common *CmdControl
+
+	flagPreseed bool
+	flagFile    string
 }
 
 func (c *cmdInit) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Interactive configuration of MicroCeph",
-		RunE:  c.Run,
+		Long: "Interactive configuration of MicroCeph.\n" +
+			"With --preseed, the configuration is read as YAML or JSON from stdin, or from\n" +
+			"the file given with --file, and the outcome is printed as JSON.",
+		RunE: c.Run,
 	}
 
+	cmd.Flags().BoolVar(&c.flagPreseed, "preseed", false, "Read the configuration from stdin instead of asking for it")
+	cmd.Flags().StringVar(&c.flagFile, "file", "", "Read the preseed configuration from this file")
+
 	return cmd
 }
 
 func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
+	if c.flagPreseed || c.flagFile != "" {
+		return c.RunPreseed(cmd)
+	}
+
 	// Connect to the daemon.
 	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
 	if err != nil {
@@ -194,5 +207,5 @@ func printLocalDisks(cli *microCli.Client) error {
 		return fmt.Errorf("internal error: unable to fetch unpartitioned disks: %w", err)
 	}
 
-	return outputFormattedTable(nil, availableDisks)
+	return outputFormattedTable(nil, availableDisks, nil)
 }
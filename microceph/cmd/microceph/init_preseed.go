@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/lxd/util"
+	"github.com/canonical/lxd/shared/api"
+	microCli "github.com/canonical/microcluster/v2/client"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/ceph"
+	"github.com/canonical/microceph/microceph/client"
+	"github.com/canonical/microceph/microceph/common"
+	"github.com/canonical/microceph/microceph/constants"
+)
+
+// RunPreseed sets up MicroCeph from a preseed, then prints the outcome as JSON.
+func (c *cmdInit) RunPreseed(cmd *cobra.Command) error {
+	var data []byte
+	var err error
+	if c.flagFile != "" {
+		data, err = os.ReadFile(c.flagFile)
+	} else {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read preseed: %w", err)
+	}
+
+	preseed, err := common.ParsePreseed(data)
+	if err != nil {
+		return err
+	}
+
+	// Catch typos before joining, the location is only applied once the node is a member.
+	_, err = ceph.ParseCrushLocation(preseed.CrushLocation)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.applyPreseed(preseed)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	output, jsonErr := json.Marshal(result)
+	if jsonErr != nil {
+		return fmt.Errorf("failed to encode result: %w", jsonErr)
+	}
+
+	fmt.Println(string(output))
+
+	return err
+}
+
+// applyPreseed bootstraps or joins a cluster as the preseed asks, then issues join tokens, adds
+// disks and enables services. Adding disks and enabling services carries on past failures.
+func (c *cmdInit) applyPreseed(preseed types.Preseed) (types.InitResult, error) {
+	result := types.InitResult{
+		Mode:     "existing",
+		Tokens:   map[string]string{},
+		Disks:    []types.DiskAddReport{},
+		Services: []types.InitServiceResult{},
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return result, err
+	}
+
+	lc, err := m.LocalClient()
+	if err != nil {
+		return result, err
+	}
+
+	// Check if already initialized.
+	_, err = lc.GetClusterMembers(context.Background())
+	isUninitialized := err != nil && api.StatusErrorCheck(err, http.StatusServiceUnavailable)
+	if err != nil && !isUninitialized {
+		return result, err
+	}
+
+	wantsCluster := preseed.Bootstrap || preseed.Token != ""
+	if wantsCluster && !isUninitialized {
+		return result, fmt.Errorf("MicroCeph has already been initialized")
+	}
+
+	if !wantsCluster && isUninitialized {
+		return result, fmt.Errorf("MicroCeph isn't initialized, the preseed needs to bootstrap or join a cluster")
+	}
+
+	if wantsCluster {
+		result.Name = preseed.Name
+		if result.Name == "" {
+			result.Name, err = os.Hostname()
+			if err != nil {
+				return result, fmt.Errorf("failed to retrieve system hostname: %w", err)
+			}
+		}
+
+		address := preseed.MicroCephIp
+		if address == "" {
+			address = util.NetworkInterfaceAddress()
+		}
+		result.Address = util.CanonicalNetworkAddress(address, constants.BootstrapPortConst)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+		defer cancel()
+
+		err = m.Ready(ctx)
+		if err != nil {
+			return result, fmt.Errorf("fault while waiting for App readiness: %w", err)
+		}
+
+		if preseed.Bootstrap {
+			result.Mode = "bootstrap"
+			var data common.BootstrapConfig
+			data, err = common.PreseedBootstrapConfig(preseed)
+			if err != nil {
+				return result, err
+			}
+
+			err = m.NewCluster(ctx, result.Name, result.Address, common.EncodeBootstrapConfig(data))
+		} else {
+			result.Mode = "join"
+			data := common.JoinConfig{CrushLocation: preseed.CrushLocation}
+
+			err = m.JoinCluster(ctx, result.Name, result.Address, preseed.Token, common.EncodeJoinConfig(data))
+		}
+
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, name := range preseed.Tokens {
+		token, err := m.NewJoinToken(context.Background(), name, 3*time.Hour)
+		if err != nil {
+			return result, fmt.Errorf("failed to issue a join token for %s: %w", name, err)
+		}
+
+		result.Tokens[name] = token
+	}
+
+	failures := 0
+	for _, disk := range preseed.Disks {
+		reports := addPreseedDisk(lc, disk)
+		for _, report := range reports {
+			if report.Report != "Success" {
+				failures++
+			}
+		}
+
+		result.Disks = append(result.Disks, reports...)
+	}
+
+	for _, service := range preseed.Services {
+		serviceResult := types.InitServiceResult{Name: service.Name}
+		err := enablePreseedService(lc, service)
+		if err != nil {
+			serviceResult.Error = err.Error()
+			failures++
+		}
+
+		result.Services = append(result.Services, serviceResult)
+	}
+
+	if failures > 0 {
+		return result, fmt.Errorf("failed adding %d disks or services", failures)
+	}
+
+	return result, nil
+}
+
+// addPreseedDisk adds a disk, or the loop files of a loop spec, and reports how it went.
+func addPreseedDisk(lc *microCli.Client, disk types.PreseedDisk) []types.DiskAddReport {
+	req := &types.DisksPost{
+		Path:        []string{disk.Path},
+		Wipe:        disk.Wipe,
+		Encrypt:     disk.Encrypt,
+		DeviceClass: disk.DeviceClass,
+	}
+
+	if !strings.HasPrefix(disk.Path, constants.LoopSpecId) {
+		if disk.WALDevice != "" {
+			req.WALDev = &disk.WALDevice
+			req.WALWipe = disk.WALWipe
+			req.WALEncrypt = disk.WALEncrypt
+		}
+
+		if disk.DBDevice != "" {
+			req.DBDev = &disk.DBDevice
+			req.DBWipe = disk.DBWipe
+			req.DBEncrypt = disk.DBEncrypt
+		}
+	}
+
+	response, err := client.AddDisk(context.Background(), lc, req)
+	if err != nil {
+		return []types.DiskAddReport{{Path: disk.Path, Report: "Failure", Error: err.Error()}}
+	}
+
+	if response.ValidationError != "" {
+		return []types.DiskAddReport{{Path: disk.Path, Report: "Failure", Error: response.ValidationError}}
+	}
+
+	return response.Reports
+}
+
+// enablePreseedService enables a service on this member and waits for it to be up.
+func enablePreseedService(lc *microCli.Client, service types.PreseedService) error {
+	req := &types.EnableService{
+		Name: service.Name,
+		Wait: true,
+	}
+
+	if service.Name == "rgw" {
+		sslPort := service.SSLPort
+		if sslPort == 0 {
+			sslPort = 443
+		}
+
+		payload, err := json.Marshal(ceph.RgwServicePlacement{Port: service.Port, SSLPort: sslPort, SSLCertificate: service.SSLCertificate, SSLPrivateKey: service.SSLPrivateKey})
+		if err != nil {
+			return err
+		}
+
+		req.Payload = string(payload)
+	}
+
+	return client.SendServicePlacementReq(context.Background(), lc, req, "")
+}
@@ -61,6 +61,9 @@ func main() {
 	var cmdCluster = cmdCluster{common: &commonCmd}
 	app.AddCommand(cmdCluster.Command())
 
+	var cmdRecover = cmdRecover{common: &commonCmd}
+	app.AddCommand(cmdRecover.Command())
+
 	var cmdRemote = cmdRemote{common: &commonCmd}
 	app.AddCommand(cmdRemote.Command())
 
@@ -77,9 +80,15 @@ func main() {
 	var cmdPool = cmdPool{common: &commonCmd}
 	app.AddCommand(cmdPool.Command())
 
+	var cmdCrush = cmdCrush{common: &commonCmd}
+	app.AddCommand(cmdCrush.Command())
+
 	var cmdLog = cmdLog{common: &commonCmd}
 	app.AddCommand(cmdLog.Command())
 
+	var cmdOperation = cmdOperation{common: &commonCmd}
+	app.AddCommand(cmdOperation.Command())
+
 	app.InitDefaultHelpCmd()
 
 	err := app.Execute()
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+type cmdOperation struct {
+	common *CmdControl
+}
+
+func (c *cmdOperation) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "operation",
+		Short: "Manage long-running MicroCeph operations",
+	}
+
+	// List
+	operationListCmd := cmdOperationList{common: c.common, operation: c}
+	cmd.AddCommand(operationListCmd.Command())
+
+	// Show
+	operationShowCmd := cmdOperationShow{common: c.common, operation: c}
+	cmd.AddCommand(operationShowCmd.Command())
+
+	// Wait
+	operationWaitCmd := cmdOperationWait{common: c.common, operation: c}
+	cmd.AddCommand(operationWaitCmd.Command())
+
+	// Cancel
+	operationCancelCmd := cmdOperationCancel{common: c.common, operation: c}
+	cmd.AddCommand(operationCancelCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
+
+// printOperation prints the details of an operation.
+func printOperation(op types.Operation) {
+	fmt.Printf("ID:       %s\n", op.ID)
+	fmt.Printf("Type:     %s\n", op.Type)
+	fmt.Printf("Location: %s\n", op.Location)
+	fmt.Printf("Status:   %s\n", op.Status)
+	fmt.Printf("Progress: %s\n", op.Progress)
+	fmt.Printf("Created:  %s\n", op.CreatedAt.Local().Format(time.DateTime))
+	fmt.Printf("Updated:  %s\n", op.UpdatedAt.Local().Format(time.DateTime))
+
+	if len(op.Result) > 0 {
+		fmt.Printf("Result:   %s\n", op.Result)
+	}
+
+	if len(op.Error) > 0 {
+		fmt.Printf("Error:    %s\n", op.Error)
+	}
+}
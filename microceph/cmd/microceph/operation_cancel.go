@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdOperationCancel struct {
+	common    *CmdControl
+	operation *cmdOperation
+}
+
+func (c *cmdOperationCancel) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel <id>",
+		Short: "Cancel a running operation",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdOperationCancel) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	return client.CancelOperation(context.Background(), cli, args[0])
+}
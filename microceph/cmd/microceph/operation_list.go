@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	lxdCmd "github.com/canonical/lxd/shared/cmd"
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdOperationList struct {
+	common    *CmdControl
+	operation *cmdOperation
+
+	flagJSON bool
+}
+
+func (c *cmdOperationList) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List operations of all cluster members",
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagJSON, "json", false, "Provide output as Json encoded string.")
+
+	return cmd
+}
+
+func (c *cmdOperationList) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	ops, err := client.GetOperations(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
+	if c.flagJSON {
+		out, err := json.Marshal(ops)
+		if err != nil {
+			return fmt.Errorf("internal error: unable to encode json output: %w", err)
+		}
+
+		fmt.Println(string(out))
+		return nil
+	}
+
+	// Show the most recent operations last.
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].CreatedAt.Before(ops[j].CreatedAt) })
+
+	data := make([][]string, len(ops))
+	for i, op := range ops {
+		data[i] = []string{op.ID, op.Type, op.Location, op.Status, op.CreatedAt.Local().Format(time.DateTime)}
+	}
+
+	header := []string{"ID", "TYPE", "LOCATION", "STATUS", "CREATED"}
+
+	return lxdCmd.RenderTable(lxdCmd.TableFormatTable, header, data, ops)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdOperationShow struct {
+	common    *CmdControl
+	operation *cmdOperation
+}
+
+func (c *cmdOperationShow) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the details of an operation",
+		RunE:  c.Run,
+	}
+
+	return cmd
+}
+
+func (c *cmdOperationShow) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.GetOperation(context.Background(), cli, args[0])
+	if err != nil {
+		return err
+	}
+
+	printOperation(op)
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdOperationWait struct {
+	common    *CmdControl
+	operation *cmdOperation
+
+	flagTimeout int64
+}
+
+func (c *cmdOperationWait) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wait <id>",
+		Short: "Wait for an operation to finish",
+		Long: `Wait for an operation to finish.
+The command fails if the operation failed or was cancelled.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().Int64Var(&c.flagTimeout, "timeout", 0, "Give up waiting after the given number of seconds (0 waits indefinitely)")
+
+	return cmd
+}
+
+func (c *cmdOperationWait) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if c.flagTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.flagTimeout)*time.Second)
+		defer cancel()
+	}
+
+	op, err := client.WaitOperation(ctx, cli, args[0])
+	if err != nil {
+		return err
+	}
+
+	printOperation(op)
+
+	return nil
+}
@@ -64,6 +64,56 @@ func (c *cmdPoolSetRF) Run(cmd *cobra.Command, args []string) error {
 	return client.PoolSetReplicationFactor(context.Background(), cli, req)
 }
 
+type cmdPoolCreate struct {
+	common      *CmdControl
+	size        int64
+	deviceClass string
+	application string
+}
+
+func (c *cmdPoolCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <POOL>",
+		Short: "Create a replicated pool",
+		Long: `Create a replicated pool named <POOL>.
+    With --device-class, the pool is placed on the OSDs of the given device
+    class only, e.g. ssd, using the crush rule for the class and the current
+    failure domain. The rule is kept in step as the failure domain changes.`,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().Int64Var(&c.size, "size", 0, "Pool size, the cluster default if not given")
+	cmd.Flags().StringVar(&c.deviceClass, "device-class", "", "Place the pool on OSDs of this device class only")
+	cmd.Flags().StringVar(&c.application, "application", "", "Application to enable on the pool, e.g. rbd")
+
+	return cmd
+}
+
+func (c *cmdPoolCreate) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	req := &types.PoolPost{
+		Name:        args[0],
+		Size:        c.size,
+		DeviceClass: c.deviceClass,
+		Application: c.application,
+	}
+
+	return client.CreatePool(context.Background(), cli, req)
+}
+
 type cmdPoolList struct {
 	common *CmdControl
 }
@@ -117,6 +167,10 @@ func (c *cmdPool) Command() *cobra.Command {
 		Short: "Manage microceph pools",
 	}
 
+	// create.
+	poolCreateCmd := cmdPoolCreate{common: c.common}
+	cmd.AddCommand(poolCreateCmd.Command())
+
 	// set-rf.
 	poolSetRFCmd := cmdPoolSetRF{common: c.common, poolRF: c}
 	cmd.AddCommand(poolSetRFCmd.Command())
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type cmdRecover struct {
+	common *CmdControl
+}
+
+func (c *cmdRecover) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "Recovers the cluster after the loss of its monitors",
+	}
+
+	recoverMonStoreCmd := cmdRecoverMonStore{common: c.common}
+	recoverQuorumCmd := cmdRecoverQuorum{common: c.common}
+
+	cmd.AddCommand(recoverMonStoreCmd.Command())
+	cmd.AddCommand(recoverQuorumCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
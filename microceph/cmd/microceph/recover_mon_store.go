@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdRecoverMonStore struct {
+	common *CmdControl
+
+	flagForce  bool
+	flagNoWait bool
+}
+
+func (c *cmdRecoverMonStore) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mon-store",
+		Short: "Rebuild the mon store from the OSDs after all monitors are lost",
+		Long: "Rebuild the mon store from the cluster maps held by the OSDs of every member, after all\n" +
+			"monitors are lost. The OSDs are stopped while their maps are collected. The monitor of\n" +
+			"this member is recreated from the rebuilt store and becomes the only monitor of the cluster.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Recover even if monitors respond or members are offline")
+	cmd.Flags().BoolVar(&c.flagNoWait, "no-wait", false, "Return once the recovery has been started")
+
+	return cmd
+}
+
+func (c *cmdRecoverMonStore) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	op, err := client.RecoverMonStore(context.Background(), cli, &types.RecoverMonStorePost{Force: c.flagForce})
+	if err != nil {
+		return err
+	}
+
+	if c.flagNoWait {
+		fmt.Printf("Recovery running as operation %s, use \"microceph operation show %s\" to follow its progress\n", op.ID, op.ID)
+		return nil
+	}
+
+	op, err = client.WaitOperation(context.Background(), cli, op.ID)
+
+	result := types.RecoverMonStoreResult{}
+	if len(op.Result) > 0 {
+		jsonErr := json.Unmarshal([]byte(op.Result), &result)
+		if jsonErr != nil {
+			return fmt.Errorf("failed to parse recovery result: %w", jsonErr)
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Mon store rebuilt from the OSDs of %d members, mon.%s is the only monitor\n", len(result.Members), result.Mon)
+	return nil
+}
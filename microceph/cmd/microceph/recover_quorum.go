@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/microcluster"
+	"github.com/spf13/cobra"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/client"
+)
+
+type cmdRecoverQuorum struct {
+	common *CmdControl
+
+	flagKeep   string
+	flagRemove []string
+	flagForce  bool
+}
+
+func (c *cmdRecoverQuorum) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quorum --keep <node>",
+		Short: "Restore quorum after a majority of monitors is lost",
+		Long: "Restore quorum after a majority of monitors is lost. The monitors of nodes which aren't\n" +
+			"online, or the ones given with --remove, are removed from the monmap of the kept node,\n" +
+			"which is injected back before the monitor is restarted. The removed monitors are dropped\n" +
+			"from the cluster database and the configuration of every member is updated.",
+		RunE: c.Run,
+	}
+
+	cmd.Flags().StringVar(&c.flagKeep, "keep", "", "Node whose monitor survived")
+	cmd.Flags().StringSliceVar(&c.flagRemove, "remove", nil, "Nodes whose monitors are removed, instead of the ones of offline nodes")
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, "Recover even if monitors are in quorum")
+	cmd.MarkFlagRequired("keep")
+
+	return cmd
+}
+
+func (c *cmdRecoverQuorum) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Help()
+	}
+
+	m, err := microcluster.App(microcluster.Args{StateDir: c.common.FlagStateDir})
+	if err != nil {
+		return err
+	}
+
+	cli, err := m.LocalClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.RecoverQuorum(context.Background(), cli, &types.RecoverQuorumPost{Keep: c.flagKeep, Remove: c.flagRemove, Force: c.flagForce})
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	fmt.Printf("Removed monitors %s from the monmap of mon.%s\n", strings.Join(result.Removed, ", "), result.Mon)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/canonical/microcluster/v2/microcluster"
 	"github.com/spf13/cobra"
 
+	"github.com/canonical/microceph/microceph/api/types"
 	"github.com/canonical/microceph/microceph/client"
 )
 
@@ -55,6 +56,12 @@ func (c *cmdStatus) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Get the state of the cluster upgrade.
+	upgrade, err := client.GetUpgradeStatus(context.Background(), cli)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("MicroCeph deployment summary:")
 
 	for _, server := range clusterMembers {
@@ -88,5 +95,15 @@ func (c *cmdStatus) Run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Disks: %d\n", diskCount)
 	}
 
+	if upgrade.State != "" {
+		fmt.Printf("Upgrade to ceph %s: %s\n", upgrade.Target, upgrade.State)
+		if upgrade.Step != "" && upgrade.State != types.UpgradeStateCompleted {
+			fmt.Printf("  Step: %s\n", upgrade.Step)
+		}
+		if upgrade.Error != "" && upgrade.State == types.UpgradeStateFailed {
+			fmt.Printf("  Error: %s\n", upgrade.Error)
+		}
+	}
+
 	return nil
 }
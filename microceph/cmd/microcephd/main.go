@@ -71,13 +71,19 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 	h.PostBootstrap = func(ctx context.Context, s state.State, initConfig map[string]string) error {
 		data := common.BootstrapConfig{}
 		interf := interfaces.CephState{State: s}
-		common.DecodeBootstrapConfig(initConfig, &data)
+		err := common.DecodeBootstrapConfig(initConfig, &data)
+		if err != nil {
+			return err
+		}
+
 		return ceph.Bootstrap(ctx, interf, data)
 	}
 
 	h.PostJoin = func(ctx context.Context, s state.State, initConfig map[string]string) error {
+		data := common.JoinConfig{}
 		interf := interfaces.CephState{State: s}
-		return ceph.Join(ctx, interf)
+		common.DecodeJoinConfig(initConfig, &data)
+		return ceph.Join(ctx, interf, data)
 	}
 
 	h.OnStart = func(ctx context.Context, s state.State) error {
@@ -93,7 +99,7 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 		Verbose:          c.global.flagLogVerbose,
 		Debug:            c.global.flagLogDebug,
 		ExtensionsSchema: database.SchemaExtensions,
-		APIExtensions:    nil,
+		APIExtensions:    api.Extensions(),
 		Hooks:            h,
 		ExtensionServers: api.Servers,
 	}
@@ -1,21 +1,150 @@
 package common
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type BootstrapConfig struct {
 	MonIp      string
 	PublicNet  string
 	ClusterNet string
+
+	// Control of an existing Ceph cluster is taken over instead of creating one if set.
+	AdoptFsid     string
+	AdoptMonHosts string // comma separated
+	AdoptAdminKey string
+
+	// Initial settings of a new cluster, applied before its first OSDs start.
+	Fsid               string
+	PoolDefaultSize    int
+	PoolDefaultMinSize int
+	FailureDomain      string // osd or host
+	Msgr2Only          bool
+	SecureMode         bool
+	CephConfig         []CephConfigItem
+	ClientConfig       map[string]string
+}
+
+// CephConfigItem is a "ceph config set <who> <key> <value>" applied at bootstrap.
+type CephConfigItem struct {
+	Who   string `json:"who"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NewCephConfigItem makes a config item out of a key of the form [<who>/]<key>, who being
+// global unless given.
+func NewCephConfigItem(key string, value string) (CephConfigItem, error) {
+	item := CephConfigItem{Who: "global", Key: key, Value: value}
+
+	who, name, found := strings.Cut(key, "/")
+	if found {
+		item.Who = who
+		item.Key = name
+	}
+
+	if item.Who == "" || item.Key == "" {
+		return item, fmt.Errorf("invalid ceph config key %q, expected [<who>/]<key>", key)
+	}
+
+	return item, nil
 }
 
 func EncodeBootstrapConfig(data BootstrapConfig) map[string]string {
-	return map[string]string{
-		"MonIp":      data.MonIp,
-		"PublicNet":  data.PublicNet,
-		"ClusterNet": data.ClusterNet,
+	ret := map[string]string{
+		"MonIp":         data.MonIp,
+		"PublicNet":     data.PublicNet,
+		"ClusterNet":    data.ClusterNet,
+		"AdoptFsid":     data.AdoptFsid,
+		"AdoptMonHosts": data.AdoptMonHosts,
+		"AdoptAdminKey": data.AdoptAdminKey,
+		"Fsid":          data.Fsid,
+		"FailureDomain": data.FailureDomain,
+		"Msgr2Only":     strconv.FormatBool(data.Msgr2Only),
+		"SecureMode":    strconv.FormatBool(data.SecureMode),
+	}
+
+	if data.PoolDefaultSize != 0 {
+		ret["PoolDefaultSize"] = strconv.Itoa(data.PoolDefaultSize)
+	}
+
+	if data.PoolDefaultMinSize != 0 {
+		ret["PoolDefaultMinSize"] = strconv.Itoa(data.PoolDefaultMinSize)
+	}
+
+	// Marshalling these can't fail.
+	if len(data.CephConfig) > 0 {
+		value, _ := json.Marshal(data.CephConfig)
+		ret["CephConfig"] = string(value)
+	}
+
+	if len(data.ClientConfig) > 0 {
+		value, _ := json.Marshal(data.ClientConfig)
+		ret["ClientConfig"] = string(value)
 	}
+
+	return ret
 }
 
-func DecodeBootstrapConfig(input map[string]string, data *BootstrapConfig) {
+func DecodeBootstrapConfig(input map[string]string, data *BootstrapConfig) error {
+	var err error
+
 	data.MonIp = input["MonIp"]
 	data.PublicNet = input["PublicNet"]
 	data.ClusterNet = input["ClusterNet"]
+	data.AdoptFsid = input["AdoptFsid"]
+	data.AdoptMonHosts = input["AdoptMonHosts"]
+	data.AdoptAdminKey = input["AdoptAdminKey"]
+	data.Fsid = input["Fsid"]
+	data.FailureDomain = input["FailureDomain"]
+	data.Msgr2Only = input["Msgr2Only"] == "true"
+	data.SecureMode = input["SecureMode"] == "true"
+
+	if input["PoolDefaultSize"] != "" {
+		data.PoolDefaultSize, err = strconv.Atoi(input["PoolDefaultSize"])
+		if err != nil {
+			return fmt.Errorf("invalid pool default size: %w", err)
+		}
+	}
+
+	if input["PoolDefaultMinSize"] != "" {
+		data.PoolDefaultMinSize, err = strconv.Atoi(input["PoolDefaultMinSize"])
+		if err != nil {
+			return fmt.Errorf("invalid pool default min size: %w", err)
+		}
+	}
+
+	if input["CephConfig"] != "" {
+		err = json.Unmarshal([]byte(input["CephConfig"]), &data.CephConfig)
+		if err != nil {
+			return fmt.Errorf("invalid initial ceph config: %w", err)
+		}
+	}
+
+	if input["ClientConfig"] != "" {
+		err = json.Unmarshal([]byte(input["ClientConfig"]), &data.ClientConfig)
+		if err != nil {
+			return fmt.Errorf("invalid initial client config: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// JoinConfig holds the settings passed along when a member joins the cluster.
+type JoinConfig struct {
+	CrushLocation string
+}
+
+func EncodeJoinConfig(data JoinConfig) map[string]string {
+	return map[string]string{
+		"CrushLocation": data.CrushLocation,
+	}
+}
+
+func DecodeJoinConfig(input map[string]string, data *JoinConfig) {
+	data.CrushLocation = input["CrushLocation"]
 }
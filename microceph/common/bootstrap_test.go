@@ -0,0 +1,56 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type BootstrapConfigTestSuite struct {
+	tests.BaseSuite
+}
+
+func TestBootstrapConfig(t *testing.T) {
+	suite.Run(t, new(BootstrapConfigTestSuite))
+}
+
+func (s *BootstrapConfigTestSuite) TestEncodeDecodeBootstrapConfig() {
+	data := BootstrapConfig{
+		MonIp:              "10.0.0.1",
+		PublicNet:          "10.0.0.0/24",
+		Fsid:               "4ebf4a4b-8f4e-4a3c-9c3e-0c1a2b3c4d5e",
+		PoolDefaultSize:    2,
+		PoolDefaultMinSize: 1,
+		FailureDomain:      "host",
+		Msgr2Only:          true,
+		SecureMode:         true,
+		CephConfig:         []CephConfigItem{{Who: "osd", Key: "osd_memory_target", Value: "4G"}},
+		ClientConfig:       map[string]string{"rbd_cache": "true"},
+	}
+
+	decoded := BootstrapConfig{}
+	s.NoError(DecodeBootstrapConfig(EncodeBootstrapConfig(data), &decoded))
+	s.Equal(data, decoded)
+
+	// Settings left out stay unset.
+	decoded = BootstrapConfig{}
+	s.NoError(DecodeBootstrapConfig(EncodeBootstrapConfig(BootstrapConfig{MonIp: "10.0.0.1"}), &decoded))
+	s.Equal(BootstrapConfig{MonIp: "10.0.0.1"}, decoded)
+
+	s.ErrorContains(DecodeBootstrapConfig(map[string]string{"PoolDefaultSize": "two"}, &decoded), "invalid pool default size")
+}
+
+func (s *BootstrapConfigTestSuite) TestNewCephConfigItem() {
+	item, err := NewCephConfigItem("mon_max_pg_per_osd", "500")
+	s.NoError(err)
+	s.Equal(CephConfigItem{Who: "global", Key: "mon_max_pg_per_osd", Value: "500"}, item)
+
+	item, err = NewCephConfigItem("client.rgw/rgw_enable_usage_log", "true")
+	s.NoError(err)
+	s.Equal(CephConfigItem{Who: "client.rgw", Key: "rgw_enable_usage_log", Value: "true"}, item)
+
+	_, err = NewCephConfigItem("osd/", "1")
+	s.ErrorContains(err, "invalid ceph config key")
+}
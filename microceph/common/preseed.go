@@ -0,0 +1,197 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/constants"
+)
+
+// preseedServices are the services which can be enabled by a preseed.
+var preseedServices = []string{"mon", "mgr", "mds", "rgw", "rbd-mirror"}
+
+// PreseedBootstrapConfig returns the settings a preseed bootstraps a cluster with.
+func PreseedBootstrapConfig(preseed types.Preseed) (BootstrapConfig, error) {
+	data := BootstrapConfig{
+		MonIp:              preseed.MonIp,
+		PublicNet:          preseed.PublicNetwork,
+		ClusterNet:         preseed.ClusterNetwork,
+		Fsid:               preseed.Fsid,
+		PoolDefaultSize:    preseed.PoolDefaultSize,
+		PoolDefaultMinSize: preseed.PoolDefaultMinSize,
+		FailureDomain:      preseed.FailureDomain,
+		Msgr2Only:          preseed.Msgr2Only,
+		SecureMode:         preseed.SecureMode,
+		ClientConfig:       preseed.ClientConfig,
+	}
+
+	keys := []string{}
+	for key := range preseed.CephConfig {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		item, err := NewCephConfigItem(key, preseed.CephConfig[key])
+		if err != nil {
+			return data, err
+		}
+
+		data.CephConfig = append(data.CephConfig, item)
+	}
+
+	return data, nil
+}
+
+// ParsePreseed decodes a preseed given as YAML or JSON and validates it. Unknown keys are
+// refused, to catch typos.
+func ParsePreseed(data []byte) (types.Preseed, error) {
+	preseed := types.Preseed{}
+
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&preseed)
+		if err != nil {
+			return preseed, fmt.Errorf("failed to parse preseed: %w", err)
+		}
+	} else if len(data) > 0 {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err := decoder.Decode(&preseed)
+		if err != nil {
+			return preseed, fmt.Errorf("failed to parse preseed: %w", err)
+		}
+	}
+
+	err := ValidatePreseed(preseed)
+	if err != nil {
+		return preseed, err
+	}
+
+	return preseed, nil
+}
+
+// ValidatePreseed checks the settings of a preseed are consistent with each other.
+func ValidatePreseed(preseed types.Preseed) error {
+	if preseed.Bootstrap && preseed.Token != "" {
+		return fmt.Errorf("bootstrap and token are mutually exclusive")
+	}
+
+	if !preseed.Bootstrap {
+		switch {
+		case preseed.MonIp != "":
+			return fmt.Errorf("mon-ip only applies when bootstrapping")
+		case preseed.PublicNetwork != "":
+			return fmt.Errorf("public-network only applies when bootstrapping")
+		case preseed.ClusterNetwork != "":
+			return fmt.Errorf("cluster-network only applies when bootstrapping")
+		}
+	}
+
+	initial := preseed.Fsid != "" || preseed.PoolDefaultSize != 0 || preseed.PoolDefaultMinSize != 0 || preseed.FailureDomain != "" ||
+		preseed.Msgr2Only || preseed.SecureMode || len(preseed.CephConfig) > 0 || len(preseed.ClientConfig) > 0
+	if !preseed.Bootstrap && initial {
+		return fmt.Errorf("initial cluster settings only apply when bootstrapping")
+	}
+
+	for key := range preseed.CephConfig {
+		_, err := NewCephConfigItem(key, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	if preseed.Token != "" && len(preseed.Tokens) > 0 {
+		return fmt.Errorf("tokens can't be issued when joining")
+	}
+
+	if !preseed.Bootstrap && preseed.Token == "" {
+		if preseed.Name != "" || preseed.MicroCephIp != "" {
+			return fmt.Errorf("name and microceph-ip only apply when bootstrapping or joining")
+		}
+	}
+
+	if preseed.CrushLocation != "" && preseed.Token == "" {
+		return fmt.Errorf("crush-location only applies when joining")
+	}
+
+	if preseed.MicroCephIp != "" && net.ParseIP(preseed.MicroCephIp) == nil {
+		return fmt.Errorf("invalid microceph-ip %q", preseed.MicroCephIp)
+	}
+
+	if preseed.MonIp != "" && net.ParseIP(preseed.MonIp) == nil {
+		return fmt.Errorf("invalid mon-ip %q", preseed.MonIp)
+	}
+
+	if preseed.PublicNetwork != "" {
+		_, _, err := net.ParseCIDR(preseed.PublicNetwork)
+		if err != nil {
+			return fmt.Errorf("invalid public-network: %w", err)
+		}
+	}
+
+	if preseed.ClusterNetwork != "" {
+		_, _, err := net.ParseCIDR(preseed.ClusterNetwork)
+		if err != nil {
+			return fmt.Errorf("invalid cluster-network: %w", err)
+		}
+	}
+
+	if preseed.MonIp != "" && preseed.PublicNetwork != "" && !Network.IsIpOnSubnet(preseed.MonIp, preseed.PublicNetwork) {
+		return fmt.Errorf("provided mon-ip %s is not available on provided public network %s", preseed.MonIp, preseed.PublicNetwork)
+	}
+
+	for _, disk := range preseed.Disks {
+		err := validatePreseedDisk(disk)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, service := range preseed.Services {
+		err := validatePreseedService(service)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePreseedDisk(disk types.PreseedDisk) error {
+	if disk.Path == "" {
+		return fmt.Errorf("disks need a path")
+	}
+
+	if !strings.HasPrefix(disk.Path, constants.LoopSpecId) {
+		return nil
+	}
+
+	if disk.Encrypt || disk.WALDevice != "" || disk.DBDevice != "" {
+		return fmt.Errorf("loop spec %s can't be used with encryption nor WAL/DB devices", disk.Path)
+	}
+
+	return nil
+}
+
+func validatePreseedService(service types.PreseedService) error {
+	if !slices.Contains(preseedServices, service.Name) {
+		return fmt.Errorf("service %q can't be enabled, use one of %s", service.Name, strings.Join(preseedServices, ", "))
+	}
+
+	rgwOnly := service.Port != 0 || service.SSLPort != 0 || service.SSLCertificate != "" || service.SSLPrivateKey != ""
+	if service.Name != "rgw" && rgwOnly {
+		return fmt.Errorf("ports and SSL settings only apply to the rgw service")
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/canonical/microceph/microceph/api/types"
+	"github.com/canonical/microceph/microceph/tests"
+)
+
+type PreseedTestSuite struct {
+	tests.BaseSuite
+}
+
+func TestPreseed(t *testing.T) {
+	suite.Run(t, new(PreseedTestSuite))
+}
+
+func (s *PreseedTestSuite) TestParsePreseedYaml() {
+	data := `
+bootstrap: true
+microceph-ip: 10.0.0.1
+mon-ip: 10.0.0.1
+public-network: 10.0.0.0/24
+cluster-network: 10.1.0.0/24
+tokens: [node2, node3]
+disks:
+  - path: /dev/sdb
+    wipe: true
+    encrypt: true
+    db-device: /dev/nvme0n1p1
+  - path: loop,4G,3
+services:
+  - name: rgw
+    port: 8080
+  - name: mds
+`
+	preseed, err := ParsePreseed([]byte(data))
+	s.NoError(err)
+	s.True(preseed.Bootstrap)
+	s.Equal("10.0.0.0/24", preseed.PublicNetwork)
+	s.Equal([]string{"node2", "node3"}, preseed.Tokens)
+	s.Equal(types.PreseedDisk{Path: "/dev/sdb", Wipe: true, Encrypt: true, DBDevice: "/dev/nvme0n1p1"}, preseed.Disks[0])
+	s.Equal("loop,4G,3", preseed.Disks[1].Path)
+	s.Equal(types.PreseedService{Name: "rgw", Port: 8080}, preseed.Services[0])
+}
+
+func (s *PreseedTestSuite) TestParsePreseedJson() {
+	data := "{\n\t\"token\": \"abc\",\n\t\"crush-location\": \"rack=r1\",\n\t\"disks\": [{\"path\": \"/dev/sdc\"}]\n}"
+
+	preseed, err := ParsePreseed([]byte(data))
+	s.NoError(err)
+	s.Equal("abc", preseed.Token)
+	s.Equal("rack=r1", preseed.CrushLocation)
+	s.Equal("/dev/sdc", preseed.Disks[0].Path)
+}
+
+func (s *PreseedTestSuite) TestParsePreseedUnknownKey() {
+	_, err := ParsePreseed([]byte("bootstrap: true\nmon_ip: 10.0.0.1\n"))
+	s.ErrorContains(err, "field mon_ip not found")
+
+	_, err = ParsePreseed([]byte(`{"bootstrap": true, "monip": "10.0.0.1"}`))
+	s.ErrorContains(err, "unknown field")
+}
+
+func (s *PreseedTestSuite) TestValidatePreseed() {
+	cases := map[string]types.Preseed{
+		"bootstrap and token are mutually exclusive":   {Bootstrap: true, Token: "abc"},
+		"mon-ip only applies when bootstrapping":       {Token: "abc", MonIp: "10.0.0.1"},
+		"tokens can't be issued when joining":          {Token: "abc", Tokens: []string{"node2"}},
+		"name and microceph-ip only apply":             {Name: "node1"},
+		"crush-location only applies when joining":     {Bootstrap: true, CrushLocation: "rack=r1"},
+		"invalid public-network":                       {Bootstrap: true, PublicNetwork: "10.0.0.0"},
+		"is not available on provided public network":  {Bootstrap: true, MonIp: "10.2.0.1", PublicNetwork: "10.0.0.0/24"},
+		"disks need a path":                            {Disks: []types.PreseedDisk{{Wipe: true}}},
+		"can't be used with encryption nor WAL/DB":     {Disks: []types.PreseedDisk{{Path: "loop,4G,1", Encrypt: true}}},
+		"service \"osd\" can't be enabled":             {Services: []types.PreseedService{{Name: "osd"}}},
+		"ports and SSL settings only apply to the rgw": {Services: []types.PreseedService{{Name: "mds", Port: 80}}},
+		"initial cluster settings only apply":          {Token: "abc", PoolDefaultSize: 2},
+		"invalid ceph config key":                      {Bootstrap: true, CephConfig: map[string]string{"osd/": "1"}},
+	}
+
+	for msg, preseed := range cases {
+		s.ErrorContains(ValidatePreseed(preseed), msg)
+	}
+
+	s.NoError(ValidatePreseed(types.Preseed{Disks: []types.PreseedDisk{{Path: "/dev/sdb"}}}))
+}
+
+func (s *PreseedTestSuite) TestPreseedBootstrapConfig() {
+	data := `
+bootstrap: true
+mon-ip: 10.0.0.1
+fsid: 4ebf4a4b-8f4e-4a3c-9c3e-0c1a2b3c4d5e
+pool-default-size: 2
+failure-domain: host
+secure-mode: true
+ceph-config:
+  osd/osd_memory_target: 4G
+  mon_max_pg_per_osd: "500"
+client-config:
+  rbd_cache: "true"
+`
+	preseed, err := ParsePreseed([]byte(data))
+	s.NoError(err)
+
+	config, err := PreseedBootstrapConfig(preseed)
+	s.NoError(err)
+	s.Equal(BootstrapConfig{
+		MonIp:           "10.0.0.1",
+		Fsid:            "4ebf4a4b-8f4e-4a3c-9c3e-0c1a2b3c4d5e",
+		PoolDefaultSize: 2,
+		FailureDomain:   "host",
+		SecureMode:      true,
+		CephConfig: []CephConfigItem{
+			{Who: "global", Key: "mon_max_pg_per_osd", Value: "500"},
+			{Who: "osd", Key: "osd_memory_target", Value: "4G"},
+		},
+		ClientConfig: map[string]string{"rbd_cache": "true"},
+	}, config)
+}
@@ -2,10 +2,12 @@ package common
 
 import (
 	"bufio"
+	"fmt"
 	"github.com/canonical/lxd/shared/logger"
 	"github.com/canonical/microceph/microceph/constants"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -30,7 +32,7 @@ func IsMounted(device string) (bool, error) {
 		// device mountpoint fstype options dump pass
 		// --> split the line into parts and check if the first part matches
 		parts := strings.Fields(scanner.Text())
-		if len(parts) > 0 && parts[0] == resolvedPath {
+		if len(parts) > 0 && isSameDevice(parts[0], resolvedPath) {
 			return true, nil
 		}
 	}
@@ -83,3 +85,323 @@ func IsCephDevice(device string) (bool, error) {
 	logger.Debugf("device %s is not used as WAL or DB device for any OSD", device)
 	return false, nil
 }
+
+// isSameDevice checks if a device path, as listed in /proc, refers to the given resolved device.
+// Device mapper devices are listed by their /dev/mapper symlinks, so those are resolved too.
+func isSameDevice(listed string, resolvedPath string) bool {
+	if listed == resolvedPath {
+		return true
+	}
+
+	if !strings.HasPrefix(listed, "/dev/") {
+		return false
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(constants.GetPathConst().RootFs, listed))
+	if err != nil {
+		return false
+	}
+
+	return resolved == resolvedPath
+}
+
+// IsSwap checks if a device is in use as swap space.
+func IsSwap(device string) (bool, error) {
+	resolvedPath, err := filepath.EvalSymlinks(filepath.Join(constants.GetPathConst().RootFs, device))
+	if err != nil {
+		return false, err
+	}
+	file, err := os.Open(filepath.Join(constants.GetPathConst().ProcPath, "swaps"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Each line in /proc/swaps after the header is of the format:
+		// filename type size used priority
+		parts := strings.Fields(scanner.Text())
+		if len(parts) > 0 && isSameDevice(parts[0], resolvedPath) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// sysBlockPath returns the sysfs directory of a block device.
+func sysBlockPath(device string) (string, error) {
+	resolvedPath, err := filepath.EvalSymlinks(filepath.Join(constants.GetPathConst().RootFs, device))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(constants.GetPathConst().SysPath, "class", "block", filepath.Base(resolvedPath)), nil
+}
+
+// HasHolders checks if a device is claimed by another block device, e.g. an LVM volume
+// group, a RAID array or a dm-crypt mapping.
+func HasHolders(device string) (bool, error) {
+	sysPath, err := sysBlockPath(device)
+	if err != nil {
+		return false, err
+	}
+	holders, err := os.ReadDir(filepath.Join(sysPath, "holders"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(holders) > 0, nil
+}
+
+// diskSysPath returns the sysfs directory of a disk. Partitions resolve to the disk holding them.
+func diskSysPath(device string) (string, error) {
+	sysPath, err := sysBlockPath(device)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = os.Stat(filepath.Join(sysPath, "partition"))
+	if err != nil {
+		return sysPath, nil
+	}
+
+	// Partitions live in the sysfs directory of their disk.
+	resolvedPath, err := filepath.EvalSymlinks(sysPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(resolvedPath), nil
+}
+
+// GetDeviceState returns the state the kernel reports for a disk, e.g. "running" for SCSI
+// or "live" for NVMe devices. Partitions report the state of the disk holding them. An
+// empty string is returned for devices without a state, e.g. device mapper targets.
+func GetDeviceState(device string) (string, error) {
+	sysPath, err := diskSysPath(device)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := os.ReadFile(filepath.Join(sysPath, "device", "state"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(state)), nil
+}
+
+// GetDeviceClass returns the device class Ceph detects for a device: "hdd" for rotational
+// devices and "ssd" otherwise. An empty string is returned if the kernel doesn't say.
+func GetDeviceClass(device string) (string, error) {
+	sysPath, err := diskSysPath(device)
+	if err != nil {
+		return "", err
+	}
+
+	rotational, err := os.ReadFile(filepath.Join(sysPath, "queue", "rotational"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if strings.TrimSpace(string(rotational)) == "1" {
+		return "hdd", nil
+	}
+	return "ssd", nil
+}
+
+// GetDeviceSize returns the size of a block device in bytes, as reported by the kernel.
+func GetDeviceSize(device string) (uint64, error) {
+	sysPath, err := sysBlockPath(device)
+	if err != nil {
+		return 0, err
+	}
+
+	// The kernel counts 512 byte sectors, whatever the logical block size of the device.
+	sectors, err := os.ReadFile(filepath.Join(sysPath, "size"))
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := strconv.ParseUint(strings.TrimSpace(string(sectors)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size of %s: %w", device, err)
+	}
+	return size * 512, nil
+}
+
+// ListPartitions returns the device paths of the partitions of a disk.
+func ListPartitions(device string) ([]string, error) {
+	sysPath, err := sysBlockPath(device)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(sysPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	partitions := []string{}
+	for _, entry := range entries {
+		// Partitions show up as sub-directories holding a partition file.
+		_, err := os.Stat(filepath.Join(sysPath, entry.Name(), "partition"))
+		if err == nil {
+			partitions = append(partitions, filepath.Join("/dev", entry.Name()))
+		}
+	}
+	return partitions, nil
+}
+
+// CheckDeviceAvailable returns an error if a device, or any partition on it, is mounted,
+// in use as swap space or claimed by another block device.
+func CheckDeviceAvailable(device string) error {
+	partitions, err := ListPartitions(device)
+	if err != nil {
+		return fmt.Errorf("failed to list partitions of %s: %w", device, err)
+	}
+
+	for _, dev := range append([]string{device}, partitions...) {
+		mounted, err := IsMounted(dev)
+		if err != nil {
+			return fmt.Errorf("failed to check if %s is mounted: %w", dev, err)
+		}
+		if mounted {
+			return fmt.Errorf("%s is mounted", dev)
+		}
+
+		swap, err := IsSwap(dev)
+		if err != nil {
+			return fmt.Errorf("failed to check if %s is used as swap: %w", dev, err)
+		}
+		if swap {
+			return fmt.Errorf("%s is in use as swap space", dev)
+		}
+
+		held, err := HasHolders(dev)
+		if err != nil {
+			return fmt.Errorf("failed to check if %s is in use: %w", dev, err)
+		}
+		if held {
+			return fmt.Errorf("%s is in use by another device, e.g. LVM, RAID or dm-crypt", dev)
+		}
+	}
+	return nil
+}
+
+// GetPartUUIDPath returns the /dev/disk/by-partuuid path of a partition, or an
+// empty string if the device has none.
+func GetPartUUIDPath(device string) (string, error) {
+	rootFs := constants.GetPathConst().RootFs
+	resolvedPath, err := filepath.EvalSymlinks(filepath.Join(rootFs, device))
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(filepath.Join(rootFs, "dev", "disk", "by-partuuid"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	for _, entry := range entries {
+		link := filepath.Join("/dev", "disk", "by-partuuid", entry.Name())
+		resolved, err := filepath.EvalSymlinks(filepath.Join(rootFs, link))
+		if err == nil && resolved == resolvedPath {
+			return link, nil
+		}
+	}
+	return "", nil
+}
+
+// splitLVName splits a device mapper name of an LVM logical volume into its volume group
+// and logical volume names. LVM escapes dashes within either name by doubling them.
+func splitLVName(name string) (string, string, bool) {
+	for i := 0; i < len(name); i++ {
+		if name[i] != '-' {
+			continue
+		}
+		if i+1 < len(name) && name[i+1] == '-' {
+			// escaped dash, skip both
+			i++
+			continue
+		}
+		vg := strings.ReplaceAll(name[:i], "--", "-")
+		lv := strings.ReplaceAll(name[i+1:], "--", "-")
+		return vg, lv, vg != "" && lv != ""
+	}
+	return "", "", false
+}
+
+// GetLVPath returns the /dev/<vg>/<lv> path of an LVM logical volume, or an empty
+// string if the device is not one.
+func GetLVPath(device string) (string, error) {
+	sysPath, err := sysBlockPath(device)
+	if err != nil {
+		return "", err
+	}
+	uuid, err := os.ReadFile(filepath.Join(sysPath, "dm", "uuid"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !strings.HasPrefix(string(uuid), "LVM-") {
+		return "", nil
+	}
+	name, err := os.ReadFile(filepath.Join(sysPath, "dm", "name"))
+	if err != nil {
+		return "", err
+	}
+
+	dmName := strings.TrimSpace(string(name))
+	vg, lv, ok := splitLVName(dmName)
+	if ok {
+		lvPath := filepath.Join("/dev", vg, lv)
+		_, err = os.Stat(filepath.Join(constants.GetPathConst().RootFs, lvPath))
+		if err == nil {
+			return lvPath, nil
+		}
+	}
+	// Fall back to the device mapper name, which is just as stable.
+	return filepath.Join("/dev", "mapper", dmName), nil
+}
+
+// ListLogicalVolumes returns the paths of all active LVM logical volumes of the system.
+func ListLogicalVolumes() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(constants.GetPathConst().SysPath, "class", "block"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	volumes := []string{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "dm-") {
+			continue
+		}
+		lvPath, err := GetLVPath(filepath.Join("/dev", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if lvPath != "" {
+			volumes = append(volumes, lvPath)
+		}
+	}
+	return volumes, nil
+}
@@ -60,6 +60,192 @@ func (s *StorageDeviceTestSuite) TestIsMounted() {
 
 }
 
+// addSysBlock creates a sysfs like directory for the given block device name.
+func (s *StorageDeviceTestSuite) addSysBlock(name string) string {
+	sysPath := filepath.Join(s.Tmp, "sys", "class", "block", name)
+	os.MkdirAll(filepath.Join(sysPath, "holders"), 0775)
+	return sysPath
+}
+
+func (s *StorageDeviceTestSuite) TestIsSwap() {
+	os.Create(filepath.Join(s.Tmp, "dev", "sdd"))
+	swaps := "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n"
+	swaps += filepath.Join(s.Tmp, "dev", "sdd") + "\tpartition\t1048572\t0\t-2\n"
+	_ = os.WriteFile(filepath.Join(s.Tmp, "proc", "swaps"), []byte(swaps), 0644)
+
+	swap, err := IsSwap("/dev/sdd")
+	s.NoError(err)
+	s.True(swap, "The device should be in use as swap")
+
+	swap, err = IsSwap("/dev/sdc")
+	s.NoError(err)
+	s.False(swap, "The device should not be in use as swap")
+}
+
+func (s *StorageDeviceTestSuite) TestCheckDeviceAvailable() {
+	// sdc holds a partition sdc1 which is claimed by a device mapper device.
+	os.Create(filepath.Join(s.Tmp, "dev", "sdc1"))
+	sysPath := s.addSysBlock("sdc")
+	os.MkdirAll(filepath.Join(sysPath, "sdc1"), 0775)
+	os.Create(filepath.Join(sysPath, "sdc1", "partition"))
+	partPath := s.addSysBlock("sdc1")
+
+	partitions, err := ListPartitions("/dev/sdc")
+	s.NoError(err)
+	s.Equal([]string{"/dev/sdc1"}, partitions)
+
+	s.NoError(CheckDeviceAvailable("/dev/sdc"))
+
+	os.Create(filepath.Join(partPath, "holders", "dm-0"))
+	s.ErrorContains(CheckDeviceAvailable("/dev/sdc"), "/dev/sdc1 is in use by another device")
+	s.ErrorContains(CheckDeviceAvailable("/dev/sdc1"), "/dev/sdc1 is in use by another device")
+
+	// sdb is mounted as per the mounts file.
+	s.ErrorContains(CheckDeviceAvailable("/dev/sdb"), "/dev/sdb is mounted")
+}
+
+func (s *StorageDeviceTestSuite) TestGetDeviceState() {
+	sysPath := s.addSysBlock("sdc")
+	os.MkdirAll(filepath.Join(sysPath, "device"), 0775)
+	_ = os.WriteFile(filepath.Join(sysPath, "device", "state"), []byte("running\n"), 0644)
+
+	// Partitions link into the sysfs directory of their disk.
+	os.Create(filepath.Join(s.Tmp, "dev", "sdc1"))
+	os.MkdirAll(filepath.Join(sysPath, "sdc1"), 0775)
+	os.Create(filepath.Join(sysPath, "sdc1", "partition"))
+	os.Symlink(filepath.Join(sysPath, "sdc1"), filepath.Join(s.Tmp, "sys", "class", "block", "sdc1"))
+
+	state, err := GetDeviceState("/dev/sdc")
+	s.NoError(err)
+	s.Equal("running", state)
+
+	state, err = GetDeviceState("/dev/sdc1")
+	s.NoError(err)
+	s.Equal("running", state)
+
+	// sdb has no state, as is the case for device mapper targets.
+	s.addSysBlock("sdb")
+	state, err = GetDeviceState("/dev/sdb")
+	s.NoError(err)
+	s.Equal("", state)
+}
+
+func (s *StorageDeviceTestSuite) TestGetDeviceClass() {
+	sysPath := s.addSysBlock("sdc")
+	os.MkdirAll(filepath.Join(sysPath, "queue"), 0775)
+	_ = os.WriteFile(filepath.Join(sysPath, "queue", "rotational"), []byte("1\n"), 0644)
+
+	// Partitions take the class of their disk.
+	os.Create(filepath.Join(s.Tmp, "dev", "sdc1"))
+	os.MkdirAll(filepath.Join(sysPath, "sdc1"), 0775)
+	os.Create(filepath.Join(sysPath, "sdc1", "partition"))
+	os.Symlink(filepath.Join(sysPath, "sdc1"), filepath.Join(s.Tmp, "sys", "class", "block", "sdc1"))
+
+	class, err := GetDeviceClass("/dev/sdc1")
+	s.NoError(err)
+	s.Equal("hdd", class)
+
+	_ = os.WriteFile(filepath.Join(sysPath, "queue", "rotational"), []byte("0\n"), 0644)
+	class, err = GetDeviceClass("/dev/sdc")
+	s.NoError(err)
+	s.Equal("ssd", class)
+
+	s.addSysBlock("sdb")
+	class, err = GetDeviceClass("/dev/sdb")
+	s.NoError(err)
+	s.Equal("", class)
+}
+
+func (s *StorageDeviceTestSuite) TestGetPartUUIDPath() {
+	os.Create(filepath.Join(s.Tmp, "dev", "sdc1"))
+	byPartUUID := filepath.Join(s.Tmp, "dev", "disk", "by-partuuid")
+	os.MkdirAll(byPartUUID, 0775)
+	os.Symlink(filepath.Join(s.Tmp, "dev", "sdc1"), filepath.Join(byPartUUID, "0f3d0a6e-01"))
+
+	path, err := GetPartUUIDPath("/dev/sdc1")
+	s.NoError(err)
+	s.Equal("/dev/disk/by-partuuid/0f3d0a6e-01", path)
+
+	path, err = GetPartUUIDPath("/dev/sdb")
+	s.NoError(err)
+	s.Equal("", path)
+}
+
+func (s *StorageDeviceTestSuite) TestGetLVPath() {
+	os.Create(filepath.Join(s.Tmp, "dev", "dm-0"))
+	sysPath := s.addSysBlock("dm-0")
+	os.MkdirAll(filepath.Join(sysPath, "dm"), 0775)
+	_ = os.WriteFile(filepath.Join(sysPath, "dm", "uuid"), []byte("LVM-abc\n"), 0644)
+	_ = os.WriteFile(filepath.Join(sysPath, "dm", "name"), []byte("ceph--vg-osd--0\n"), 0644)
+
+	// No /dev/<vg>/<lv> link, fall back to the device mapper name.
+	path, err := GetLVPath("/dev/dm-0")
+	s.NoError(err)
+	s.Equal("/dev/mapper/ceph--vg-osd--0", path)
+
+	os.MkdirAll(filepath.Join(s.Tmp, "dev", "ceph-vg"), 0775)
+	os.Symlink(filepath.Join(s.Tmp, "dev", "dm-0"), filepath.Join(s.Tmp, "dev", "ceph-vg", "osd-0"))
+	path, err = GetLVPath("/dev/dm-0")
+	s.NoError(err)
+	s.Equal("/dev/ceph-vg/osd-0", path)
+
+	// Other device mapper devices, e.g. dm-crypt, are no logical volumes.
+	_ = os.WriteFile(filepath.Join(sysPath, "dm", "uuid"), []byte("CRYPT-LUKS2-abc\n"), 0644)
+	path, err = GetLVPath("/dev/dm-0")
+	s.NoError(err)
+	s.Equal("", path)
+
+	// Neither are plain disks.
+	path, err = GetLVPath("/dev/sdc")
+	s.NoError(err)
+	s.Equal("", path)
+}
+
+func (s *StorageDeviceTestSuite) TestListLogicalVolumes() {
+	for _, dm := range []string{"dm-0", "dm-1"} {
+		os.Create(filepath.Join(s.Tmp, "dev", dm))
+		os.MkdirAll(filepath.Join(s.addSysBlock(dm), "dm"), 0775)
+	}
+	_ = os.WriteFile(filepath.Join(s.Tmp, "sys", "class", "block", "dm-0", "dm", "uuid"), []byte("LVM-abc\n"), 0644)
+	_ = os.WriteFile(filepath.Join(s.Tmp, "sys", "class", "block", "dm-0", "dm", "name"), []byte("vg0-osd\n"), 0644)
+	_ = os.WriteFile(filepath.Join(s.Tmp, "sys", "class", "block", "dm-1", "dm", "uuid"), []byte("CRYPT-LUKS2-abc\n"), 0644)
+	s.addSysBlock("sdc")
+
+	volumes, err := ListLogicalVolumes()
+	s.NoError(err)
+	s.Equal([]string{"/dev/mapper/vg0-osd"}, volumes)
+}
+
+func (s *StorageDeviceTestSuite) TestGetDeviceSize() {
+	os.Create(filepath.Join(s.Tmp, "dev", "dm-0"))
+	_ = os.WriteFile(filepath.Join(s.addSysBlock("dm-0"), "size"), []byte("8388608\n"), 0644)
+
+	size, err := GetDeviceSize("/dev/dm-0")
+	s.NoError(err)
+	s.Equal(uint64(4*1024*1024*1024), size)
+
+	_, err = GetDeviceSize("/dev/sdc")
+	s.Error(err)
+}
+
+func (s *StorageDeviceTestSuite) TestSplitLVName() {
+	vg, lv, ok := splitLVName("ubuntu--vg-ubuntu--lv")
+	s.True(ok)
+	s.Equal("ubuntu-vg", vg)
+	s.Equal("ubuntu-lv", lv)
+
+	vg, lv, ok = splitLVName("vg0-data")
+	s.True(ok)
+	s.Equal("vg0", vg)
+	s.Equal("data", lv)
+
+	_, _, ok = splitLVName("luksosd-1")
+	s.True(ok)
+
+	_, _, ok = splitLVName("nodash")
+	s.False(ok)
+}
+
 func TestStorageDeviceSuite(t *testing.T) {
 	suite.Run(t, new(StorageDeviceTestSuite))
 }
@@ -39,6 +39,7 @@ type PathConst struct {
 	LogPath      string
 	RootFs       string
 	ProcPath     string
+	SysPath      string
 	SSLFilesPath string
 }
 
@@ -52,6 +53,7 @@ var GetPathConst = func() PathConst {
 		LogPath:      filepath.Join(os.Getenv("SNAP_COMMON"), "logs"),
 		RootFs:       filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/"),
 		ProcPath:     filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/proc"),
+		SysPath:      filepath.Join(os.Getenv("TEST_ROOT_PATH"), "/sys"),
 		SSLFilesPath: filepath.Join(os.Getenv("SNAP_COMMON"), "/"),
 	}
 }
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/state"
+)
+
+// crushLocationPrefix prefixes the config keys holding the crush locations of members.
+const crushLocationPrefix = "crush_location."
+
+// CrushLocationKey returns the config key holding the crush location of a member.
+func CrushLocationKey(member string) string {
+	return crushLocationPrefix + member
+}
+
+// CrushLocationQueryInterface is for querying the crush locations of members. Introduced for mocking.
+type CrushLocationQueryInterface interface {
+	List(ctx context.Context, s state.State) (map[string]string, error)
+}
+
+type CrushLocationQueryImpl struct{}
+
+// List returns the crush locations recorded for members, keyed by member name.
+func (c CrushLocationQueryImpl) List(ctx context.Context, s state.State) (map[string]string, error) {
+	locations := map[string]string{}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items, err := GetConfigItems(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch config items: %w", err)
+		}
+
+		for _, item := range items {
+			member, ok := strings.CutPrefix(item.Key, crushLocationPrefix)
+			if ok {
+				locations[member] = item.Value
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// Singleton for the CrushLocationQueryImpl, to be mocked in unit testing
+var CrushLocationQuery CrushLocationQueryInterface = CrushLocationQueryImpl{}
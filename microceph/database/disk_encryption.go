@@ -0,0 +1,35 @@
+package database
+
+//go:generate -command mapper lxd-generate db mapper -t disk_encryption.mapper.go
+//go:generate mapper reset
+//
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption objects table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption objects-by-Member table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption objects-by-OSD table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption id table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption create table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption delete-by-OSD table=disk_encryption
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption update table=disk_encryption
+//
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption GetMany table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption GetOne table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption ID table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption Exists table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption Create table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption DeleteOne-by-OSD table=disk_encryption
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e DiskEncryption Update table=disk_encryption
+
+// DiskEncryption tracks the conversion of a plaintext OSD into an encrypted one.
+type DiskEncryption struct {
+	ID     int
+	Member string `db:"join=core_cluster_members.name&joinon=disk_encryption.member_id"`
+	OSD    int64  `db:"primary=yes"`
+	Status string
+	Error  string
+}
+
+// DiskEncryptionFilter is a required struct for use with lxd-generate. It is used for filtering fields on database fetches.
+type DiskEncryptionFilter struct {
+	Member *string
+	OSD    *int64
+}
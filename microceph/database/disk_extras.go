@@ -258,3 +258,60 @@ func (o OSDQueryImpl) UpdatePath(ctx context.Context, s state.State, osd int64,
 
 // Singleton for the OSDQueryImpl, to be mocked in unit testing
 var OSDQuery OSDQueryInterface = OSDQueryImpl{}
+
+var diskCreateWithID = cluster.RegisterStmt(`
+INSERT INTO disks (id, member_id, path)
+  VALUES (?, (SELECT core_cluster_members.id FROM core_cluster_members WHERE core_cluster_members.name = ?), ?)
+`)
+
+var diskSequenceCreate = cluster.RegisterStmt(`
+INSERT INTO sqlite_sequence (name, seq)
+SELECT 'disks', 0
+WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'disks')
+`)
+
+var diskSequenceReserve = cluster.RegisterStmt(`
+UPDATE sqlite_sequence
+SET seq = ?
+WHERE name = 'disks' AND seq < ?
+`)
+
+// CreateDiskWithID records a disk of an existing OSD, keeping the OSD id assigned by Ceph.
+func CreateDiskWithID(ctx context.Context, tx *sql.Tx, osd int64, member string, path string) error {
+	sqlStmt, err := cluster.Stmt(tx, diskCreateWithID)
+	if err != nil {
+		return fmt.Errorf("Failed to get \"diskCreateWithID\" prepared statement: %w", err)
+	}
+
+	_, err = sqlStmt.Exec(osd, member, path)
+	if err != nil {
+		return fmt.Errorf("Failed to create \"disks\" entry: %w", err)
+	}
+
+	return nil
+}
+
+// ReserveDiskIDs makes sure newly recorded disks get OSD ids above the given one.
+func ReserveDiskIDs(ctx context.Context, tx *sql.Tx, osd int64) error {
+	sqlStmt, err := cluster.Stmt(tx, diskSequenceCreate)
+	if err != nil {
+		return fmt.Errorf("Failed to get \"diskSequenceCreate\" prepared statement: %w", err)
+	}
+
+	_, err = sqlStmt.Exec()
+	if err != nil {
+		return fmt.Errorf("Failed to create disk id sequence: %w", err)
+	}
+
+	sqlStmt, err = cluster.Stmt(tx, diskSequenceReserve)
+	if err != nil {
+		return fmt.Errorf("Failed to get \"diskSequenceReserve\" prepared statement: %w", err)
+	}
+
+	_, err = sqlStmt.Exec(osd, osd)
+	if err != nil {
+		return fmt.Errorf("Failed to reserve disk ids: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+)
+
+//go:generate -command mapper lxd-generate db mapper -t operation.mapper.go
+//go:generate mapper reset
+//
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation objects table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation objects-by-UUID table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation objects-by-Member table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation objects-by-Member-and-Status table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation id table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation create table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation delete-by-UUID table=operations
+//go:generate mapper stmt -d github.com/canonical/microcluster/v2/cluster -e Operation update table=operations
+//
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation GetMany table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation GetOne table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation ID table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation Exists table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation Create table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation DeleteOne-by-UUID table=operations
+//go:generate mapper method -i -d github.com/canonical/microcluster/v2/cluster -e Operation Update table=operations
+
+// Operation tracks a long-running request executed in the background by a cluster member.
+type Operation struct {
+	ID        int
+	UUID      string `db:"primary=yes"`
+	Member    string `db:"join=core_cluster_members.name&joinon=operations.member_id"`
+	Type      string
+	Status    string
+	Progress  string
+	Result    string
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OperationFilter is a required struct for use with lxd-generate. It is used for filtering fields on database fetches.
+type OperationFilter struct {
+	UUID   *string
+	Member *string
+	Status *string
+}
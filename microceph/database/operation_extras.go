@@ -0,0 +1,18 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DeleteOperationsBefore removes the records of operations that finished before the given time.
+func DeleteOperationsBefore(ctx context.Context, tx *sql.Tx, running string, before time.Time) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM operations WHERE status != ? AND updated_at < ?", running, before)
+	if err != nil {
+		return fmt.Errorf("failed to delete finished operations: %w", err)
+	}
+
+	return nil
+}
@@ -18,6 +18,8 @@ var SchemaExtensions = []schema.Update{
 	schemaUpdate3,
 	schemaUpdate4,
 	schemaUpdate5,
+	schemaUpdate6,
+	schemaUpdate7,
 }
 
 // getClusterTableName returns the name of the table that holds the record of cluster members from sqlite_master.
@@ -186,3 +188,45 @@ CREATE TABLE remote (
 
 	return err
 }
+
+// schemaUpdate6 adds the disk encryption table tracking plaintext to encrypted OSD conversions.
+func schemaUpdate6(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE disk_encryption (
+  id                            INTEGER  PRIMARY KEY AUTOINCREMENT NOT NULL,
+  member_id                     INTEGER  NOT  NULL,
+  osd                           INTEGER  NOT  NULL,
+  status                        TEXT     NOT  NULL,
+  error                         TEXT     NOT  NULL,
+  FOREIGN KEY (member_id) REFERENCES "core_cluster_members" (id) ON DELETE CASCADE,
+  FOREIGN KEY (osd) REFERENCES "disks" (id) ON DELETE CASCADE,
+  UNIQUE(osd)
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
+
+// schemaUpdate7 adds the operations table tracking long-running background requests.
+func schemaUpdate7(ctx context.Context, tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE operations (
+  id                            INTEGER  PRIMARY KEY AUTOINCREMENT NOT NULL,
+  uuid                          TEXT     NOT  NULL,
+  member_id                     INTEGER  NOT  NULL,
+  type                          TEXT     NOT  NULL,
+  status                        TEXT     NOT  NULL,
+  progress                      TEXT     NOT  NULL,
+  result                        TEXT     NOT  NULL,
+  error                         TEXT     NOT  NULL,
+  created_at                    DATETIME NOT  NULL,
+  updated_at                    DATETIME NOT  NULL,
+  FOREIGN KEY (member_id) REFERENCES "core_cluster_members" (id) ON DELETE CASCADE,
+  UNIQUE(uuid)
+);
+  `
+	_, err := tx.ExecContext(ctx, stmt)
+
+	return err
+}
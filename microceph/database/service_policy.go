@@ -0,0 +1,13 @@
+package database
+
+// serviceCountPrefix prefixes the config keys holding the desired service counts.
+const serviceCountPrefix = "service_count."
+
+// ServiceReplaceAfterKey is the config key holding how long a service on an offline member is
+// waited for before it gets replaced.
+const ServiceReplaceAfterKey = "service_replace_after"
+
+// ServiceCountKey returns the config key holding the desired count of a service.
+func ServiceCountKey(service string) string {
+	return serviceCountPrefix + service
+}
@@ -0,0 +1,8 @@
+package database
+
+// Config keys holding the state of a cluster shutdown.
+const (
+	ShutdownStateKey  = "shutdown.state"
+	ShutdownFlagsKey  = "shutdown.flags"
+	ShutdownMemberKey = "shutdown.member"
+)
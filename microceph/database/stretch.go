@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/microcluster/v2/state"
+
+	"github.com/canonical/microceph/microceph/api/types"
+)
+
+// Config keys recording the stretch mode of the cluster.
+const (
+	StretchTiebreakerKey = "stretch.tiebreaker"
+	StretchSitesKey      = "stretch.sites"
+)
+
+// StretchQueryInterface is for querying the stretch mode of the cluster. Introduced for mocking.
+type StretchQueryInterface interface {
+	Get(ctx context.Context, s state.State) (types.StretchMode, error)
+}
+
+type StretchQueryImpl struct{}
+
+// Get returns the recorded stretch mode, with the site names but without their members.
+func (c StretchQueryImpl) Get(ctx context.Context, s state.State) (types.StretchMode, error) {
+	stretch := types.StretchMode{Sites: []types.StretchSite{}}
+
+	err := s.Database().Transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		items, err := GetConfigItems(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch config items: %w", err)
+		}
+
+		for _, item := range items {
+			switch item.Key {
+			case StretchTiebreakerKey:
+				stretch.Tiebreaker = item.Value
+			case StretchSitesKey:
+				for _, site := range strings.Split(item.Value, ",") {
+					stretch.Sites = append(stretch.Sites, types.StretchSite{Name: site, Members: []string{}})
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return stretch, err
+	}
+
+	stretch.Enabled = stretch.Tiebreaker != ""
+	return stretch, nil
+}
+
+// Singleton for the StretchQueryImpl, to be mocked in unit testing
+var StretchQuery StretchQueryInterface = StretchQueryImpl{}
@@ -0,0 +1,10 @@
+package database
+
+// Config keys holding the state of a cluster upgrade.
+const (
+	UpgradeStateKey  = "upgrade.state"
+	UpgradeTargetKey = "upgrade.target"
+	UpgradeMemberKey = "upgrade.member"
+	UpgradeStepKey   = "upgrade.step"
+	UpgradeErrorKey  = "upgrade.error"
+)
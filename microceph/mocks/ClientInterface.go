@@ -29,13 +29,13 @@ func (_m *ClientInterface) DeleteClusterMember(_a0 *client.Client, _a1 string, _
 	return r0
 }
 
-// DeleteService provides a mock function with given fields: _a0, _a1, _a2
-func (_m *ClientInterface) DeleteService(_a0 *client.Client, _a1 string, _a2 string) error {
-	ret := _m.Called(_a0, _a1, _a2)
+// DeleteService provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *ClientInterface) DeleteService(_a0 *client.Client, _a1 string, _a2 string, _a3 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*client.Client, string, string) error); ok {
-		r0 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(*client.Client, string, string, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -121,6 +121,30 @@ func (_m *ClientInterface) GetServices(_a0 *client.Client) (types.Services, erro
 	return r0, r1
 }
 
+// GetStretchMode provides a mock function with given fields: _a0
+func (_m *ClientInterface) GetStretchMode(_a0 *client.Client) (types.StretchMode, error) {
+	ret := _m.Called(_a0)
+
+	var r0 types.StretchMode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*client.Client) (types.StretchMode, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(*client.Client) types.StretchMode); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(types.StretchMode)
+	}
+
+	if rf, ok := ret.Get(1).(func(*client.Client) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewClientInterface creates a new instance of ClientInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewClientInterface(t interface {